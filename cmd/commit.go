@@ -0,0 +1,129 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output/storage"
+	"github.com/pkg/errors"
+)
+
+var (
+	commitDocs bool
+	pushDocs   bool
+)
+
+// git run git command and return the output
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), fmt.Sprintf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String())))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// checkoutCommitBranch switch to the branch to commit the documents to, creating it from HEAD if it does not exist
+func checkoutCommitBranch(cm config.Commit) error {
+	if cm.Branch == "" {
+		return nil
+	}
+	current, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+	if current == cm.Branch {
+		return nil
+	}
+	exists, err := branchExists(cm.Branch)
+	if err != nil {
+		return err
+	}
+	if exists {
+		_, err = git("switch", cm.Branch)
+		return err
+	}
+	_, err = git("switch", "-c", cm.Branch)
+	return err
+}
+
+// branchExists return whether the local branch exists. The branch is not confused with files or remote branches of the same name.
+func branchExists(branch string) (bool, error) {
+	_, err := git("rev-parse", "--verify", "--quiet", fmt.Sprintf("refs/heads/%s", branch))
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, err
+}
+
+// commitDocuments commit the documents of the targets, and push the commit if configured. Nothing is committed when the documents are unchanged.
+func commitDocuments(cm config.Commit, targets []*config.Config) error {
+	paths := []string{}
+	for _, c := range targets {
+		if !storage.IsRemote(c.DocPath) {
+			paths = append(paths, c.DocPath)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := git(append([]string{"add", "-A", "--"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	if _, err := git(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		fmt.Println("documents are up to date, nothing to commit")
+		return nil
+	}
+	msg, err := cm.RenderMessage(targets, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = git(append([]string{"commit", "-q", "-m", msg, "--"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	rev, err := git("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("committed %s\n", rev)
+	if !cm.Push {
+		return nil
+	}
+	_, err = git("push", "-q", cm.RemoteName(), "HEAD")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pushed to %s\n", cm.RemoteName())
+	return nil
+}
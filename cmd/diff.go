@@ -22,10 +22,16 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
-	"github.com/k1LoW/tbls/db"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/datasource"
 	"github.com/k1LoW/tbls/output/md"
+	"github.com/k1LoW/tbls/output/notify"
+	"github.com/k1LoW/tbls/output/storage"
+	"github.com/k1LoW/tbls/schema"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -34,54 +40,233 @@ import (
 var diffCmd = &cobra.Command{
 	Use:   "diff [DSN] [DOCUMENT_PATH]",
 	Short: "diff database and document",
-	Long:  `'tbls diff' shows the difference between database schema and generated document.`,
+	Long: `'tbls diff' shows the difference between database schema and generated document.
+
+Differences are shown as unified diffs (colored on a terminal) with --format text,
+a markdown summary with collapsible sections per document file with --format markdown,
+and GitHub Actions annotations with the summary with --format github.
+
+The data of tables (row counts, statistics, sample rows, ...) is neither collected nor compared,
+so that changes of the data alone are not reported as differences.
+
+Exit status is 0 if the document is up to date, 1 if differences are found and 2 if an error occurs.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 2 {
-			return errors.WithStack(errors.New("requires two args"))
+		if len(args) > 2 {
+			return errors.WithStack(errors.New("accepts at most two args"))
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		dsn := args[0]
-		targetPath := args[1]
-		s, err := db.Analyze(dsn)
+		switch diffFormat {
+		case "text", "markdown", "github":
+		default:
+			printError(errors.WithStack(fmt.Errorf("unsupported diff output format '%s'", diffFormat)))
+			exit(2)
+		}
+		color, err := diffColorEnabled(diffColor)
 		if err != nil {
 			printError(err)
-			os.Exit(1)
+			exit(2)
 		}
-
-		if additionalDataPath != "" {
-			err = s.LoadAdditionalData(additionalDataPath)
+		targets, err := loadConfig(cmd, args)
+		if err != nil {
+			printError(err)
+			exit(2)
+		}
+		hasDiff := false
+		fileDiffs := []*md.FileDiff{}
+		for _, c := range targets {
+			err := c.Validate()
+			if err == nil && storage.IsRemote(c.DocPath) {
+				err = errors.WithStack(fmt.Errorf("object storage document path '%s' is not supported by 'tbls diff'", c.DocPath))
+			}
+			if err != nil {
+				printError(err)
+				exit(2)
+			}
+			ctx, cancel := commandContext(c.Timeout)
+			s, err := datasource.AnalyzeSchemaWithConfigContext(ctx, c)
+			cancel()
+			if err != nil {
+				printError(err)
+				exit(2)
+			}
+			if diffChangedOnly {
+				unchanged, err := unchangedFromDocument(s, c)
+				if err != nil {
+					printError(err)
+					exit(2)
+				}
+				if unchanged {
+					continue
+				}
+			}
+			d, err := md.DiffFiles(s, c)
 			if err != nil {
 				printError(err)
-				os.Exit(1)
+				exit(2)
+			}
+			if diffFormat == "text" {
+				err := md.OutputDiffText(os.Stdout, d, color)
+				if err != nil {
+					printError(err)
+					exit(2)
+				}
+			} else {
+				fileDiffs = append(fileDiffs, d...)
+			}
+			targetHasDiff := len(d) > 0
+			if targetHasDiff {
+				hasDiff = true
+				if c.Notify.Enabled() {
+					err := notifyDrift(s, c)
+					if err != nil {
+						printError(err)
+						exit(2)
+					}
+				}
 			}
 		}
-
-		if sort {
-			err = s.Sort()
+		switch diffFormat {
+		case "markdown":
+			err := md.OutputDiffSummary(os.Stdout, fileDiffs)
+			if err != nil {
+				printError(err)
+				exit(2)
+			}
+		case "github":
+			err := outputDiffGitHub(fileDiffs)
 			if err != nil {
 				printError(err)
-				os.Exit(1)
+				exit(2)
 			}
 		}
+		if hasDiff {
+			exit(1)
+		}
+	},
+}
+
+// diffFormat is the output format of differences
+var diffFormat string
+
+// diffColor is whether to color the text output (auto, always or never)
+var diffColor string
+
+// diffSummaryPath is the file path to append the markdown summary of differences to
+var diffSummaryPath string
 
-		diff, err := md.Diff(s, targetPath, adjust, erFormat)
+// diffChangedOnly skips rendering documents when tables are unchanged since the schema.json in the document path
+var diffChangedOnly bool
+
+// unchangedFromDocument return whether no table is added, changed or dropped since the schema.json in the document path.
+// It returns false when the schema.json does not exist.
+func unchangedFromDocument(s *schema.Schema, c *config.Config) (bool, error) {
+	base, err := ioutil.ReadFile(filepath.Join(c.DocPath, schemaJSONFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(errors.WithStack(err), "failed to read schema JSON of the document")
+	}
+	d, err := s.Drift(base)
+	if err != nil {
+		return false, err
+	}
+	return !d.HasDrift(), nil
+}
+
+// diffColorEnabled return whether to color the text output. With auto, it is colored when STDOUT is a terminal and $NO_COLOR is not set.
+func diffColorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "", "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		fi, err := os.Stdout.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, errors.WithStack(fmt.Errorf("unsupported color mode '%s' (auto, always or never)", mode))
+	}
+}
+
+// notifyDrift notify tables added, changed or dropped since the schema.json in the document path.
+// Without the schema.json, it notify the drift without the tables.
+func notifyDrift(s *schema.Schema, c *config.Config) error {
+	d := &schema.Drift{}
+	base, err := ioutil.ReadFile(filepath.Join(c.DocPath, schemaJSONFileName))
+	switch {
+	case err == nil:
+		d, err = s.Drift(base)
 		if err != nil {
-			printError(err)
-			os.Exit(2)
+			return err
 		}
-		fmt.Print(diff)
-		if diff != "" {
-			os.Exit(1)
+	case os.IsNotExist(err):
+		base = nil
+	default:
+		return errors.Wrap(errors.WithStack(err), "failed to read schema JSON of the document")
+	}
+	name := s.Name
+	if c.Title != "" {
+		name = c.Title
+	}
+	err = notify.Notify(c, name, d)
+	if err != nil {
+		return err
+	}
+	return notifyWebhooks(s, c, "diff", name, d, base)
+}
+
+// notifyWebhooks post the structured differences of tables to the custom webhooks
+func notifyWebhooks(s *schema.Schema, c *config.Config, command string, name string, d *schema.Drift, base []byte) error {
+	if len(c.Notify.Webhooks) == 0 {
+		return nil
+	}
+	changed := []*schema.TableDrift{}
+	if base != nil {
+		var err error
+		changed, err = s.TableDrifts(base)
+		if err != nil {
+			return err
 		}
-	},
+	}
+	return notify.Webhooks(c, notify.NewPayload(command, name, d, changed))
+}
+
+// outputDiffGitHub output annotations to STDOUT and append the markdown summary to --summary, $GITHUB_STEP_SUMMARY or STDOUT.
+func outputDiffGitHub(fileDiffs []*md.FileDiff) error {
+	err := md.OutputDiffGitHub(os.Stdout, fileDiffs)
+	if err != nil {
+		return err
+	}
+	path := diffSummaryPath
+	if path == "" {
+		path = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+	if path == "" {
+		return md.OutputDiffSummary(os.Stdout, fileDiffs)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	return md.OutputDiffSummary(file, fileDiffs)
 }
 
 func init() {
 	rootCmd.AddCommand(diffCmd)
 	diffCmd.Flags().BoolVarP(&sort, "sort", "", false, "sort")
-	diffCmd.Flags().StringVarP(&erFormat, "er-format", "t", "png", "ER diagrams output format [png, svg, jpg, ...]")
+	diffCmd.Flags().StringVarP(&erFormat, "er-format", "t", config.DefaultERFormat, "ER diagrams output format [png, svg, jpg, ..., dot, plantuml, mermaid]")
+	diffCmd.Flags().IntVarP(&erDistance, "er-distance", "", config.DefaultERDistance, "distance of related tables in per-table ER diagrams")
 	diffCmd.Flags().BoolVarP(&adjust, "adjust-table", "j", false, "adjust column width of table")
-	diffCmd.Flags().StringVarP(&additionalDataPath, "add", "a", "", "additional schema data path")
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "", "text", "output format [text, markdown, github]")
+	diffCmd.Flags().StringVarP(&diffColor, "color", "", "auto", "color the text output [auto, always, never]")
+	diffCmd.Flags().StringVarP(&diffSummaryPath, "summary", "", "", "file path to append the markdown summary to with --format github (default $GITHUB_STEP_SUMMARY, or STDOUT)")
+	diffCmd.Flags().BoolVarP(&diffChangedOnly, "changed-only", "", false, "skip rendering documents when no table is added, changed or dropped since the schema.json in the document path")
+	diffCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
 }
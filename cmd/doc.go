@@ -22,16 +22,27 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 
-	"github.com/k1LoW/tbls/db"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/datasource"
+	"github.com/k1LoW/tbls/output"
 	"github.com/k1LoW/tbls/output/dot"
+	"github.com/k1LoW/tbls/output/json"
 	"github.com/k1LoW/tbls/output/md"
+	"github.com/k1LoW/tbls/output/plantuml"
+	"github.com/k1LoW/tbls/output/render"
+	"github.com/k1LoW/tbls/output/storage"
+	"github.com/k1LoW/tbls/output/viewer"
 	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -39,142 +50,472 @@ import (
 // withoutER
 var withoutER bool
 
+// incremental
+var incremental bool
+
+// schemaJSON is a flag on whether to output schema.json to the document path
+var schemaJSON bool
+
+var reGraphvizVersion = regexp.MustCompile(`<!-- Generated by graphviz version [^>]*-->\r?\n`)
+
 // docCmd represents the doc command
 var docCmd = &cobra.Command{
 	Use:   "doc [DSN] [DOCUMENT_PATH]",
 	Short: "document a database",
 	Long:  `'tbls doc' analyzes a database and generate document in GitHub Friendly Markdown format.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 2 {
-			return errors.WithStack(errors.New("requires two args"))
+		if len(args) > 2 {
+			return errors.WithStack(errors.New("accepts at most two args"))
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		dsn := args[0]
-		outputPath := args[1]
-		s, err := db.Analyze(dsn)
+		targets, err := loadConfig(cmd, args)
 		if err != nil {
 			printError(err)
-			os.Exit(1)
+			exit(1)
 		}
-
-		if additionalDataPath != "" {
-			err = s.LoadAdditionalData(additionalDataPath)
+		for _, c := range targets {
+			err := c.Validate()
 			if err != nil {
 				printError(err)
-				os.Exit(1)
+				exit(1)
 			}
 		}
-
-		if sort {
-			err = s.Sort()
+		cm, err := config.TargetsCommit(targets)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		if cmd.Flags().Changed("commit") {
+			cm.Enabled = commitDocs
+		}
+		if cmd.Flags().Changed("push") {
+			cm.Push = pushDocs
+		}
+		if cm.Enabled {
+			err := checkoutCommitBranch(cm)
 			if err != nil {
 				printError(err)
-				os.Exit(1)
+				exit(1)
 			}
 		}
+		for _, c := range targets {
+			ctx, cancel := commandContext(c.Timeout)
+			err := doc(ctx, c)
+			cancel()
+			if err != nil {
+				printError(err)
+				exit(1)
+			}
+		}
+		if cm.Enabled {
+			err := commitDocuments(cm, targets)
+			if err != nil {
+				printError(err)
+				exit(1)
+			}
+		}
+	},
+}
+
+func doc(ctx context.Context, c *config.Config) error {
+	if storage.IsRemote(c.DocPath) {
+		return docToStorage(ctx, c)
+	}
+	s, err := datasource.AnalyzeWithConfigContext(ctx, c)
+	if err != nil {
+		return err
+	}
 
-		if !withoutER {
-			_, err = exec.Command("which", "dot").Output()
-			if err == nil {
-				err := withDot(s, outputPath, force)
-				if err != nil {
-					printError(err)
-					os.Exit(1)
-				}
+	// nil means all tables
+	var tables []string
+	unchanged := false
+	if incremental {
+		affected, dropped, err := incrementalTables(s, c)
+		if err != nil {
+			return err
+		}
+		if affected != nil {
+			tables = affected
+			unchanged = len(affected) == 0 && len(dropped) == 0
+			err := removeTableDocuments(c, dropped)
+			if err != nil {
+				return err
 			}
 		}
+	}
 
-		err = md.Output(s, outputPath, force, adjust, erFormat)
+	// schema.json is the base of --incremental, so it is also output with --incremental
+	outputJSON := c.Format.SchemaJSON || incremental
+	if outputJSON && !force && tables == nil && schemaJSONExists(c) {
+		return errors.New("output schema JSON file already exists")
+	}
 
+	if !c.ER.Skip && !unchanged {
+		err := outputER(ctx, s, c, force, tables)
 		if err != nil {
-			printError(err)
-			os.Exit(1)
+			return err
 		}
-	},
-}
+	}
 
-func withDot(s *schema.Schema, outputPath string, force bool) error {
-	fullPath, err := filepath.Abs(outputPath)
+	endRender := trace.Start(ctx, "render")
+	if tables == nil {
+		err = md.Output(s, c, force)
+	} else {
+		err = md.OutputTables(s, c, tables)
+	}
+	endRender()
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 
-	if !force && outputErExists(s, fullPath) {
-		return errors.New("output ER diagram files already exists")
+	if c.ER.Viewer {
+		err := outputViewer(s, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(c.Notify.Webhooks) > 0 {
+		err := notifyDocDrift(s, c)
+		if err != nil {
+			return err
+		}
 	}
 
-	dotFormatOption := fmt.Sprintf("-T%s", erFormat)
-	erFileName := fmt.Sprintf("schema.%s", erFormat)
+	if outputJSON {
+		return outputSchemaJSON(s, c)
+	}
+	return nil
+}
 
-	fmt.Printf("%s\n", filepath.Join(outputPath, erFileName))
-	tmpfile, _ := ioutil.TempFile("", "tblstmp")
-	c := exec.Command("dot", dotFormatOption, "-o", filepath.Join(fullPath, erFileName), tmpfile.Name())
-	var stderr bytes.Buffer
-	c.Stderr = &stderr
+// incrementalTables return names of tables whose documents are affected by the difference from the schema.json in the document path, and names of dropped tables.
+// Both are nil when the schema.json does not exist.
+func incrementalTables(s *schema.Schema, c *config.Config) ([]string, []string, error) {
+	base, err := ioutil.ReadFile(filepath.Join(c.DocPath, schemaJSONFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Wrap(errors.WithStack(err), "failed to read schema JSON of the document")
+	}
+	affected, err := s.AffectedTables(base, c.ER.Distance)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := s.Drift(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	return affected, d.Dropped, nil
+}
 
-	dot := new(dot.Dot)
+// removeTableDocuments remove markdown files and ER diagram files of the tables
+func removeTableDocuments(c *config.Config, tables []string) error {
+	for _, name := range tables {
+		paths := []string{
+			filepath.Join(c.DocPath, fmt.Sprintf("%s.md", name)),
+			filepath.Join(c.DocPath, filepath.FromSlash(c.ImagePath(fmt.Sprintf("%s.%s", name, c.ER.FileExt())))),
+		}
+		for _, path := range paths {
+			err := os.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
 
-	err = dot.OutputSchema(tmpfile, s)
+// notifyDocDrift post tables added, changed or dropped since the schema.json in the document path to the custom webhooks before overwriting it.
+// Nothing is posted when the schema.json does not exist or no table is changed.
+func notifyDocDrift(s *schema.Schema, c *config.Config) error {
+	base, err := ioutil.ReadFile(filepath.Join(c.DocPath, schemaJSONFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(errors.WithStack(err), "failed to read schema JSON of the document")
+	}
+	d, err := s.Drift(base)
 	if err != nil {
-		tmpfile.Close()
-		os.Remove(tmpfile.Name())
 		return err
 	}
-	err = tmpfile.Close()
+	if !d.HasDrift() {
+		return nil
+	}
+	name := s.Name
+	if c.Title != "" {
+		name = c.Title
+	}
+	return notifyWebhooks(s, c, "doc", name, d, base)
+}
+
+// docToStorage generate document into a temporary directory and upload it to the object storage URI of the document path
+func docToStorage(ctx context.Context, c *config.Config) error {
+	tempDir, err := ioutil.TempDir("", "tbls")
 	if err != nil {
-		os.Remove(tmpfile.Name())
 		return errors.WithStack(err)
 	}
-	err = c.Run()
+	defer os.RemoveAll(tempDir)
+	tc := *c
+	tc.DocPath = tempDir
+	err = doc(ctx, &tc)
 	if err != nil {
-		os.Remove(tmpfile.Name())
-		return errors.WithStack(errors.Wrap(err, stderr.String()))
+		return err
+	}
+	return storage.Upload(tempDir, c.DocPath)
+}
+
+// outputViewer output the interactive HTML ER viewer
+func outputViewer(s *schema.Schema, c *config.Config) error {
+	path := filepath.Join(c.DocPath, viewer.FileName)
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	fmt.Printf("%s\n", path)
+	return viewer.New(c).OutputSchema(file, s)
+}
+
+// schemaJSONFileName is the file name of schema JSON in the document path
+const schemaJSONFileName = "schema.json"
+
+// schemaJSONExists return whether the schema JSON exists in the document path
+func schemaJSONExists(c *config.Config) bool {
+	_, err := os.Stat(filepath.Join(c.DocPath, schemaJSONFileName))
+	return err == nil
+}
+
+// outputSchemaJSON output schema JSON. It is used as the base schema of `tbls doc --incremental`, `tbls diff --changed-only` and `tbls lint --changed-from`.
+func outputSchemaJSON(s *schema.Schema, c *config.Config) error {
+	path := filepath.Join(c.DocPath, schemaJSONFileName)
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	fmt.Printf("%s\n", path)
+	return new(json.JSON).OutputSchema(file, s)
+}
+
+// outputER output ER diagram files. mermaid diagrams are embedded in markdown documents.
+// When tables is not nil, ER diagrams of the tables, the whole schema and viewpoints are output.
+func outputER(ctx context.Context, s *schema.Schema, c *config.Config, force bool, tables []string) error {
+	if c.ER.Format == "mermaid" {
+		return nil
+	}
+	renderer, _ := c.ER.RendererURL()
+	if c.ER.IsImageFormat() && renderer == "dot" {
+		_, err := exec.Command("which", "dot").Output()
+		if err != nil {
+			return nil
+		}
+	}
+
+	fullPath, err := filepath.Abs(c.DocPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ext := c.ER.FileExt()
+	if !force && tables == nil && outputErExists(s, fullPath, c) {
+		return errors.New("output ER diagram files already exists")
+	}
+	err = os.MkdirAll(filepath.Join(fullPath, filepath.FromSlash(c.ImagePath(""))), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var cache erCache
+	if c.ER.Cache && c.ER.IsImageFormat() {
+		cache = loadERCache(fullPath)
+		defer func() {
+			if err := cache.save(fullPath); err != nil {
+				printError(err)
+			}
+		}()
+	}
+
+	o := erOutput(c)
+
+	if !c.ER.SkipSchemaDiagram(s) {
+		erFileName := filepath.FromSlash(c.ImagePath(fmt.Sprintf("schema.%s", ext)))
+		fmt.Printf("%s\n", filepath.Join(c.DocPath, erFileName))
+		err = writeER(ctx, filepath.Join(fullPath, erFileName), c, cache, func(wr io.Writer) error {
+			return o.OutputSchema(wr, s)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// viewpoints
+	vc := *c
+	vc.ER = c.ER.ViewpointER()
+	vo := erOutput(&vc)
+	for i, v := range c.Viewpoints {
+		vs := v.Schema(s)
+		if vc.ER.SkipSchemaDiagram(vs) {
+			continue
+		}
+		erFileName := filepath.FromSlash(c.ImagePath(fmt.Sprintf("%s.%s", v.FileName(i), ext)))
+		fmt.Printf("%s\n", filepath.Join(c.DocPath, erFileName))
+		err = writeER(ctx, filepath.Join(fullPath, erFileName), c, cache, func(wr io.Writer) error {
+			return vo.OutputSchema(wr, vs)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.ER.SkipTableDiagrams() {
+		return nil
 	}
-	os.Remove(tmpfile.Name())
 
 	// tables
+	only := map[string]bool{}
+	for _, name := range tables {
+		only[name] = true
+	}
+	targets := []*schema.Table{}
 	for _, t := range s.Tables {
-		erFileName := fmt.Sprintf("%s.%s", t.Name, erFormat)
+		if c.ER.IsExcluded(t) || (tables != nil && !only[t.Name]) {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	bar := trace.StartBar(ctx, "er", len(targets))
+	defer bar.Done()
+	for _, t := range targets {
+		erFileName := filepath.FromSlash(c.ImagePath(fmt.Sprintf("%s.%s", t.Name, ext)))
+		fmt.Printf("%s\n", filepath.Join(c.DocPath, erFileName))
+		err = writeER(ctx, filepath.Join(fullPath, erFileName), c, cache, func(wr io.Writer) error {
+			return o.OutputTable(wr, t)
+		})
+		if err != nil {
+			return err
+		}
+		bar.Add(1)
+	}
 
-		fmt.Printf("%s\n", filepath.Join(outputPath, erFileName))
-		tmpfile, _ := ioutil.TempFile("", "tblstmp")
-		c := exec.Command("dot", dotFormatOption, "-o", filepath.Join(fullPath, erFileName), tmpfile.Name())
-		var stderr bytes.Buffer
-		c.Stderr = &stderr
+	return nil
+}
 
-		err = dot.OutputTable(tmpfile, t)
+// erOutput return the output of ER diagram sources. Images rendered with PlantUML server are rendered from PlantUML sources.
+func erOutput(c *config.Config) output.Output {
+	renderer, _ := c.ER.RendererURL()
+	if c.ER.Format == "plantuml" || (c.ER.IsImageFormat() && renderer == "plantuml") {
+		return plantuml.New(c)
+	}
+	return dot.New(c)
+}
+
+// writeER write ER diagram file. Image formats are rendered with Graphviz `dot` command, or the server of er.renderer.
+// When cache is not nil, rendering is skipped if the image exists and its Graphviz source is unchanged.
+func writeER(ctx context.Context, path string, c *config.Config, cache erCache, fn func(io.Writer) error) error {
+	defer trace.Start(ctx, "er")()
+	if !c.ER.IsImageFormat() {
+		file, err := os.Create(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer file.Close()
+		return fn(file)
+	}
+
+	src := new(bytes.Buffer)
+	err := fn(src)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+	hash := erSourceHash(c.ER.Format, src.Bytes())
+	if cache != nil && cache[name] == hash {
+		if _, err := os.Lstat(path); err == nil {
+			return nil
+		}
+	}
+
+	if renderer, _ := c.ER.RendererURL(); renderer != "dot" {
+		b, err := render.Remote(ctx, c.ER, src.Bytes())
 		if err != nil {
-			tmpfile.Close()
-			os.Remove(tmpfile.Name())
 			return err
 		}
-		err = tmpfile.Close()
+		err = ioutil.WriteFile(path, b, 0644)
 		if err != nil {
-			os.Remove(tmpfile.Name())
 			return errors.WithStack(err)
 		}
-		err = c.Run()
+	} else {
+		err = renderDot(ctx, path, c, src.Bytes())
 		if err != nil {
-			os.Remove(tmpfile.Name())
-			return errors.WithStack(errors.Wrap(err, stderr.String()))
+			return err
+		}
+	}
+	if c.ER.Deterministic && c.ER.Format == "svg" {
+		err = stripGraphvizVersion(path)
+		if err != nil {
+			return err
 		}
-		os.Remove(tmpfile.Name())
 	}
+	if cache != nil {
+		cache[name] = hash
+	}
+	return nil
+}
 
+// renderDot render the Graphviz source to the image with Graphviz `dot` command
+func renderDot(ctx context.Context, path string, c *config.Config, src []byte) error {
+	tmpfile, err := ioutil.TempFile("", "tblstmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.Write(src)
+	if err != nil {
+		tmpfile.Close()
+		return errors.WithStack(err)
+	}
+	err = tmpfile.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cmd := exec.CommandContext(ctx, "dot", fmt.Sprintf("-T%s", c.ER.Format), "-o", path, tmpfile.Name())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return errors.WithStack(errors.Wrap(err, stderr.String()))
+	}
+	return nil
+}
+
+// stripGraphvizVersion remove the Graphviz version comment from the SVG so that upgrading Graphviz does not change the output
+func stripGraphvizVersion(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = ioutil.WriteFile(path, reGraphvizVersion.ReplaceAll(b, nil), 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
-func outputErExists(s *schema.Schema, path string) bool {
+func outputErExists(s *schema.Schema, path string, c *config.Config) bool {
+	ext := c.ER.FileExt()
 	// schema.png
-	erFileName := fmt.Sprintf("schema.%s", erFormat)
+	erFileName := filepath.FromSlash(c.ImagePath(fmt.Sprintf("schema.%s", ext)))
 	if _, err := os.Lstat(filepath.Join(path, erFileName)); err == nil {
 		return true
 	}
 	// tables
 	for _, t := range s.Tables {
-		erFileName := fmt.Sprintf("%s.%s", t.Name, erFormat)
+		erFileName := filepath.FromSlash(c.ImagePath(fmt.Sprintf("%s.%s", t.Name, ext)))
 		if _, err := os.Lstat(filepath.Join(path, erFileName)); err == nil {
 			return true
 		}
@@ -186,8 +527,13 @@ func init() {
 	rootCmd.AddCommand(docCmd)
 	docCmd.Flags().BoolVarP(&force, "force", "f", false, "force")
 	docCmd.Flags().BoolVarP(&sort, "sort", "", false, "sort")
-	docCmd.Flags().StringVarP(&erFormat, "er-format", "t", "png", "ER diagrams output format [png, svg, jpg, ...]")
+	docCmd.Flags().StringVarP(&erFormat, "er-format", "t", config.DefaultERFormat, "ER diagrams output format [png, svg, jpg, ..., dot, plantuml, mermaid]")
+	docCmd.Flags().IntVarP(&erDistance, "er-distance", "", config.DefaultERDistance, "distance of related tables in per-table ER diagrams")
 	docCmd.Flags().BoolVarP(&withoutER, "without-er", "", false, "no generate ER diagrams")
+	docCmd.Flags().BoolVarP(&incremental, "incremental", "", false, "regenerate only documents of tables changed since schema.json in the document path")
+	docCmd.Flags().BoolVarP(&schemaJSON, "schema-json", "", false, "output schema.json to the document path")
 	docCmd.Flags().BoolVarP(&adjust, "adjust-table", "j", false, "adjust column width of table")
-	docCmd.Flags().StringVarP(&additionalDataPath, "add", "a", "", "additional schema data path")
+	docCmd.Flags().BoolVarP(&commitDocs, "commit", "", false, "commit the generated documents with git")
+	docCmd.Flags().BoolVarP(&pushDocs, "push", "", false, "push the commit of the generated documents (with --commit)")
+	docCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// erCacheFileName is the file name of the ER diagram cache in the document path
+const erCacheFileName = ".tbls-er-cache.json"
+
+// erCache is the content hashes of Graphviz sources of rendered ER diagram images, keyed by image file name
+type erCache map[string]string
+
+// loadERCache load the ER diagram cache. A missing or broken cache is treated as empty.
+func loadERCache(dir string) erCache {
+	cache := erCache{}
+	b, err := ioutil.ReadFile(filepath.Join(dir, erCacheFileName))
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return erCache{}
+	}
+	return cache
+}
+
+// save write the ER diagram cache
+func (ec erCache) save(dir string) error {
+	b, err := json.MarshalIndent(ec, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, erCacheFileName), b, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// erSourceHash return the content hash of the Graphviz source rendered into the format
+func erSourceHash(format string, src []byte) string {
+	h := sha256.New()
+	h.Write([]byte(format))
+	h.Write([]byte{0})
+	h.Write(src)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,208 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/datasource"
+	"github.com/k1LoW/tbls/output/lint"
+	"github.com/k1LoW/tbls/profile"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [DSN]",
+	Short: "check database document",
+	Long:  `'tbls lint' checks database document with the rules of 'lint:' in the config file.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.WithStack(errors.New("accepts at most one arg"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := loadConfig(cmd, args)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		warns := []lint.Warn{}
+		failed := false
+		for _, c := range targets {
+			err := c.Lint.Validate()
+			if err != nil {
+				printError(err)
+				exit(1)
+			}
+			ctx, cancel := commandContext(c.Timeout)
+			s, err := datasource.AnalyzeWithConfigContext(ctx, c)
+			if err != nil {
+				printError(err)
+				exit(1)
+			}
+			ws := c.Lint.Check(s)
+			if checkIntegrity {
+				iws, err := integrityWarns(ctx, s, c)
+				if err != nil {
+					printError(err)
+					exit(1)
+				}
+				ws = append(ws, iws...)
+			}
+			cancel()
+			if changedFrom != "" {
+				ws, err = filterChangedTables(ws, s, c, changedFrom)
+				if err != nil {
+					printError(err)
+					exit(1)
+				}
+			}
+			if c.Lint.IsFailed(ws, maxWarnings) {
+				failed = true
+			}
+			for _, w := range ws {
+				warns = append(warns, lint.Warn{
+					RuleWarn: w,
+					File:     lintWarnFile(c, w),
+				})
+			}
+		}
+		err = lint.Output(os.Stdout, lintFormat, warns)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		if failed {
+			exit(1)
+		}
+	},
+}
+
+// lintFormat is the output format of lint results
+var lintFormat string
+
+// maxWarnings is the number of warnings to trigger nonzero exit code
+var maxWarnings int
+
+// lintWarnFile return the file path to annotate the warning on: the table document if exists, otherwise the config file.
+func lintWarnFile(c *config.Config, w config.RuleWarn) string {
+	if c.DocPath != "" && w.Table != "" {
+		p := filepath.Join(c.DocPath, fmt.Sprintf("%s.md", w.Table))
+		if _, err := os.Stat(p); err == nil {
+			return filepath.ToSlash(p)
+		}
+	}
+	p := configPath
+	if p == "" {
+		p = config.DefaultConfigFilePath
+	}
+	if _, err := os.Stat(p); err == nil {
+		return filepath.ToSlash(p)
+	}
+	return ""
+}
+
+// checkIntegrity is whether orphaned rows of relations not declared in the database are checked
+var checkIntegrity bool
+
+// integrityWarns return warnings of orphaned rows of additional and virtual relations found in the database
+func integrityWarns(ctx context.Context, s *schema.Schema, c *config.Config) ([]config.RuleWarn, error) {
+	db, closeDB, err := datasource.OpenContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	orphans, err := profile.CheckIntegrity(ctx, db, s, c)
+	if err != nil {
+		return nil, err
+	}
+	warns := []config.RuleWarn{}
+	for _, o := range orphans {
+		warns = append(warns, config.RuleWarn{
+			Rule:     "relationIntegrity",
+			Severity: config.SeverityError,
+			Table:    o.Relation.Table.Name,
+			Target:   o.Target(),
+			Message:  fmt.Sprintf("%s.", o),
+		})
+	}
+	return warns, nil
+}
+
+// changedFrom is the git ref of the base schema
+var changedFrom string
+
+// filterChangedTables return warnings of the tables added or modified since the schema JSON at the git ref
+func filterChangedTables(warns []config.RuleWarn, s *schema.Schema, c *config.Config, ref string) ([]config.RuleWarn, error) {
+	path := filepath.Join(c.DocPath, schemaJSONFileName)
+	if filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		path, err = filepath.Rel(wd, path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	var stderr bytes.Buffer
+	gitCmd := exec.Command("git", "show", fmt.Sprintf("%s:./%s", ref, filepath.ToSlash(path)))
+	gitCmd.Stderr = &stderr
+	base, err := gitCmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to get schema JSON at '%s': %s", ref, strings.TrimSpace(stderr.String())))
+	}
+	changed, err := s.ChangedTables(base)
+	if err != nil {
+		return nil, err
+	}
+	changedTables := map[string]bool{}
+	for _, t := range changed {
+		changedTables[t] = true
+	}
+	filtered := []config.RuleWarn{}
+	for _, w := range warns {
+		if w.Table == "" || changedTables[w.Table] {
+			filtered = append(filtered, w)
+		}
+	}
+	return filtered, nil
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "t", "text", "output format [text, json, github, sarif]")
+	lintCmd.Flags().StringVarP(&changedFrom, "changed-from", "", "", "lint only tables added or modified since the schema.json in the document path at the git ref")
+	lintCmd.Flags().BoolVarP(&checkIntegrity, "check-integrity", "", false, "check orphaned rows of additional and virtual relations in the database with anti-join queries")
+	lintCmd.Flags().IntVarP(&maxWarnings, "max-warnings", "", -1, "number of warnings to trigger nonzero exit code (per target, -1 means no limit)")
+	lintCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+}
@@ -0,0 +1,162 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/datasource"
+	"github.com/k1LoW/tbls/output/metrics"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// metricsListen is the address to serve /metrics
+var metricsListen string
+
+// metricsCmd represents the metrics command
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [DSN]",
+	Short: "output schema metrics in the Prometheus text format",
+	Long: `'tbls metrics' analyzes databases and output schema metrics (table count, comment coverage, tables without primary key, ...) in the Prometheus text format.
+
+With --listen, it serves the metrics at /metrics and analyzes databases on each scrape.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.WithStack(errors.New("accepts at most one arg"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := loadConfig(cmd, args)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		for _, c := range targets {
+			if err := c.Validate(); err != nil {
+				printError(err)
+				exit(1)
+			}
+		}
+		collector := newMetricsCollector(targets)
+		if metricsListen == "" {
+			// the timeout of each target is applied in collect
+			ctx, cancel := commandContext(0)
+			defer cancel()
+			buf := &bytes.Buffer{}
+			ok, err := collector.collect(ctx, buf)
+			if err != nil {
+				printError(err)
+				exit(1)
+			}
+			_, _ = buf.WriteTo(os.Stdout)
+			if !ok {
+				exit(1)
+			}
+			return
+		}
+		http.Handle("/metrics", collector)
+		err = http.ListenAndServe(metricsListen, nil)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+	},
+}
+
+// metricsCollector analyzes databases and keeps the time of the last successful analysis and the number of failed analyses of each target
+type metricsCollector struct {
+	mu      sync.Mutex
+	configs []*config.Config
+	targets []*metrics.Target
+}
+
+func newMetricsCollector(configs []*config.Config) *metricsCollector {
+	targets := []*metrics.Target{}
+	for _, c := range configs {
+		name := c.Name
+		if name == "" {
+			name = c.Title
+		}
+		if name == "" {
+			name = c.DocPath
+		}
+		targets = append(targets, &metrics.Target{Name: name})
+	}
+	return &metricsCollector{
+		configs: configs,
+		targets: targets,
+	}
+}
+
+// collect analyzes databases within the timeout of each target and write metrics. It returns false when the analysis of any target failed.
+func (m *metricsCollector) collect(ctx context.Context, buf *bytes.Buffer) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ok := true
+	for i, c := range m.configs {
+		t := m.targets[i]
+		start := time.Now()
+		tctx, cancel := ctx, context.CancelFunc(func() {})
+		if c.Timeout > 0 {
+			tctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		}
+		// metrics are of the schema, so the data of tables is not collected
+		s, err := datasource.AnalyzeSchemaWithConfigContext(tctx, c)
+		cancel()
+		t.Duration = time.Since(start)
+		if err != nil {
+			printError(err)
+			t.Up = false
+			t.Failures++
+			ok = false
+			continue
+		}
+		t.Schema = s
+		t.Up = true
+		t.LastSuccess = time.Now()
+	}
+	return ok, metrics.Output(buf, m.targets)
+}
+
+func (m *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	buf := &bytes.Buffer{}
+	_, err := m.collect(r.Context(), buf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.Flags().StringVarP(&metricsListen, "listen", "", "", "address to serve metrics at /metrics (e.g. :9090)")
+	metricsCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+}
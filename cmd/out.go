@@ -21,13 +21,19 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/k1LoW/tbls/db"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/datasource"
 	"github.com/k1LoW/tbls/output"
-	"github.com/k1LoW/tbls/output/dot"
-	"github.com/k1LoW/tbls/output/json"
+	_ "github.com/k1LoW/tbls/output/backstage"
+	_ "github.com/k1LoW/tbls/output/dot"
+	_ "github.com/k1LoW/tbls/output/json"
+	_ "github.com/k1LoW/tbls/output/mermaid"
+	_ "github.com/k1LoW/tbls/output/openmetadata"
+	_ "github.com/k1LoW/tbls/output/plantuml"
+	_ "github.com/k1LoW/tbls/output/yaml"
+	"github.com/k1LoW/tbls/trace"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -43,68 +49,59 @@ var outCmd = &cobra.Command{
 	Short: "analyzes a database and output",
 	Long:  `'tbls out' analyzes a database and output.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 1 {
-			return errors.WithStack(errors.New("requires two args"))
+		if len(args) > 1 {
+			return errors.WithStack(errors.New("accepts at most one arg"))
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		dsn := args[0]
-		s, err := db.Analyze(dsn)
+		targets, err := loadConfig(cmd, args)
 		if err != nil {
 			printError(err)
-			os.Exit(1)
+			exit(1)
 		}
-
-		if additionalDataPath != "" {
-			err = s.LoadAdditionalData(additionalDataPath)
-			if err != nil {
-				printError(err)
-				os.Exit(1)
-			}
+		ctx, cancel := commandContext(targets[0].Timeout)
+		defer cancel()
+		if len(targets) > 1 {
+			printError(errors.New("'tbls out' does not support multiple docs targets. specify [DSN]"))
+			exit(1)
 		}
-
-		if sort {
-			err = s.Sort()
-			if err != nil {
-				printError(err)
-				os.Exit(1)
-			}
+		s, err := datasource.AnalyzeWithConfigContext(ctx, targets[0])
+		if err != nil {
+			printError(err)
+			exit(1)
 		}
 
-		var o output.Output
-
-		switch format {
-		case "json":
-			o = new(json.JSON)
-		case "dot":
-			o = new(dot.Dot)
-		default:
-			printError(fmt.Errorf("unsupported format '%s'", format))
-			os.Exit(1)
+		o, err := output.NewContext(ctx, format, targets[0])
+		if err != nil {
+			printError(err)
+			exit(1)
 		}
 
+		endRender := trace.Start(ctx, "render")
 		if tableName == "" {
 			err = o.OutputSchema(os.Stdout, s)
 		} else {
-			t, err := s.FindTableByName(tableName)
-			if err != nil {
-				printError(err)
-				os.Exit(1)
+			t, errf := s.FindTableByName(tableName)
+			if errf != nil {
+				printError(errf)
+				exit(1)
 			}
 			err = o.OutputTable(os.Stdout, t)
 		}
+		endRender()
 
 		if err != nil {
 			printError(err)
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(outCmd)
-	outCmd.Flags().StringVarP(&additionalDataPath, "add", "a", "", "additional schema data path")
-	outCmd.Flags().StringVarP(&format, "format", "t", "json", "output format")
+	outCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+	outCmd.Flags().StringVarP(&format, "format", "t", "json", "output format [json, yaml, dot, plantuml, mermaid, backstage, mkdocs, openmetadata] or the format of the output plugin tbls-output-<format>")
 	outCmd.Flags().StringVar(&tableName, "table", "", "table name")
+	outCmd.Flags().IntVarP(&erDistance, "er-distance", "", config.DefaultERDistance, "distance of related tables (with --table)")
 }
@@ -0,0 +1,114 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/k1LoW/tbls/datasource"
+	"github.com/k1LoW/tbls/output/md"
+	"github.com/k1LoW/tbls/profile"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile [DSN] [REPORT_PATH]",
+	Short: "generate data profiling report",
+	Long:  `'tbls profile' analyzes a database and generate the Markdown report of the data profile (null ratios, cardinalities and the most frequent values) of columns with the settings of 'profile:' in the config file.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 2 {
+			return errors.WithStack(errors.New("accepts at most two args"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		dsnArgs := args
+		if len(dsnArgs) > 1 {
+			dsnArgs = args[:1]
+		}
+		targets, err := loadConfig(cmd, dsnArgs)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		if len(targets) > 1 {
+			printError(errors.New("'tbls profile' does not support multiple docs targets. specify [DSN]"))
+			exit(1)
+		}
+		c := targets[0]
+		ctx, cancel := commandContext(c.Timeout)
+		defer cancel()
+		if len(args) > 1 {
+			c.Profile.Path = args[1]
+		}
+		if cmd.Flags().Changed("table") {
+			c.Profile.Tables = profileTables
+		}
+		s, err := datasource.AnalyzeWithConfigContext(ctx, c)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		db, closeDB, err := datasource.OpenContext(ctx, c)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		reports, err := profile.Report(ctx, db, s, c)
+		closeDB()
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		path := c.ProfilePath()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			printError(errors.WithStack(err))
+			exit(1)
+		}
+		f, err := os.Create(filepath.Clean(path))
+		if err != nil {
+			printError(errors.WithStack(err))
+			exit(1)
+		}
+		err = md.OutputProfile(f, s, reports, c)
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = errors.WithStack(cerr)
+		}
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		fmt.Println(path)
+	},
+}
+
+// profileTables is the tables (glob patterns) to profile
+var profileTables []string
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.Flags().StringArrayVarP(&profileTables, "table", "t", []string{}, "table (glob pattern) to profile (can be specified multiple times)")
+	profileCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+}
@@ -0,0 +1,178 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/datasource"
+	"github.com/k1LoW/tbls/output/confluence"
+	"github.com/k1LoW/tbls/output/datahub"
+	"github.com/k1LoW/tbls/output/notion"
+	"github.com/k1LoW/tbls/output/openlineage"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// publishCmd represents the publish command
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "publish document to external services",
+	Long:  `'tbls publish' publishes document of a database to external services.`,
+}
+
+// publishConfluenceCmd represents the publish confluence command
+var publishConfluenceCmd = &cobra.Command{
+	Use:   "confluence [DSN] [DOCUMENT_PATH]",
+	Short: "publish document to Confluence",
+	Long: `'tbls publish confluence' creates or updates the page tree of a database in a Confluence space.
+
+ER diagram images generated by 'tbls doc' in DOCUMENT_PATH are attached to the pages.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 2 {
+			return errors.WithStack(errors.New("accepts at most two args"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish(cmd, args, (*config.Config).ValidateConfluence, func(c *config.Config, s *schema.Schema) error {
+			cf, err := confluence.New(c)
+			if err != nil {
+				return err
+			}
+			return cf.Publish(s)
+		})
+	},
+}
+
+// publishNotionCmd represents the publish notion command
+var publishNotionCmd = &cobra.Command{
+	Use:   "notion [DSN]",
+	Short: "publish document to Notion",
+	Long:  `'tbls publish notion' creates the page of a database and a child page per table under a Notion page, replacing the page previously published.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.WithStack(errors.New("accepts at most one arg"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish(cmd, args, (*config.Config).ValidateNotion, func(c *config.Config, s *schema.Schema) error {
+			n, err := notion.New(c)
+			if err != nil {
+				return err
+			}
+			return n.Publish(s)
+		})
+	},
+}
+
+// publishDataHubCmd represents the publish datahub command
+var publishDataHubCmd = &cobra.Command{
+	Use:   "datahub [DSN]",
+	Short: "emit metadata to DataHub",
+	Long:  `'tbls publish datahub' emits datasets of tables with descriptions, columns and foreign keys to a DataHub GMS endpoint.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.WithStack(errors.New("accepts at most one arg"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish(cmd, args, (*config.Config).ValidateDataHub, func(c *config.Config, s *schema.Schema) error {
+			d, err := datahub.New(c)
+			if err != nil {
+				return err
+			}
+			return d.Publish(s)
+		})
+	},
+}
+
+// publishOpenLineageCmd represents the publish openlineage command
+var publishOpenLineageCmd = &cobra.Command{
+	Use:   "openlineage [DSN]",
+	Short: "emit dataset events to OpenLineage",
+	Long:  `'tbls publish openlineage' emits OpenLineage dataset events with the schema facet of tables to an OpenLineage endpoint such as Marquez.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.WithStack(errors.New("accepts at most one arg"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish(cmd, args, (*config.Config).ValidateOpenLineage, func(c *config.Config, s *schema.Schema) error {
+			o, err := openlineage.New(c)
+			if err != nil {
+				return err
+			}
+			return o.Publish(s)
+		})
+	},
+}
+
+// runPublish analyze databases of targets and publish document of them
+func runPublish(cmd *cobra.Command, args []string, validate func(*config.Config) error, publish func(*config.Config, *schema.Schema) error) {
+	targets, err := loadConfig(cmd, args)
+	if err != nil {
+		printError(err)
+		exit(1)
+	}
+	for _, c := range targets {
+		err := c.Validate()
+		if err == nil {
+			err = validate(c)
+		}
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+	}
+	for _, c := range targets {
+		ctx, cancel := commandContext(c.Timeout)
+		s, err := datasource.AnalyzeWithConfigContext(ctx, c)
+		cancel()
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		err = publish(c, s)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+	publishCmd.AddCommand(publishConfluenceCmd)
+	publishConfluenceCmd.Flags().BoolVarP(&sort, "sort", "", false, "sort")
+	publishConfluenceCmd.Flags().StringVarP(&erFormat, "er-format", "t", config.DefaultERFormat, "ER diagrams output format of attached images [png, svg, jpg]")
+	publishConfluenceCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+	publishCmd.AddCommand(publishNotionCmd)
+	publishNotionCmd.Flags().BoolVarP(&sort, "sort", "", false, "sort")
+	publishNotionCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+	publishCmd.AddCommand(publishDataHubCmd)
+	publishDataHubCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+	publishCmd.AddCommand(publishOpenLineageCmd)
+	publishOpenLineageCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+}
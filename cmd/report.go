@@ -0,0 +1,81 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/k1LoW/tbls/datasource"
+	"github.com/k1LoW/tbls/output/html"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report [DSN] [REPORT_PATH]",
+	Short: "generate HTML report",
+	Long:  `'tbls report' analyzes a database and generate the HTML report with sortable tables, orphan tables, constraints and anomalies.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 2 {
+			return errors.WithStack(errors.New("accepts at most two args"))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		dsnArgs := args
+		if len(dsnArgs) > 1 {
+			dsnArgs = args[:1]
+		}
+		targets, err := loadConfig(cmd, dsnArgs)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		if len(targets) > 1 {
+			printError(errors.New("'tbls report' does not support multiple docs targets. specify [DSN]"))
+			exit(1)
+		}
+		c := targets[0]
+		ctx, cancel := commandContext(c.Timeout)
+		defer cancel()
+		if len(args) > 1 {
+			c.Report.Path = args[1]
+		}
+		s, err := datasource.AnalyzeWithConfigContext(ctx, c)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		err = html.New(c).Output(c.ReportPath(), s)
+		if err != nil {
+			printError(err)
+			exit(1)
+		}
+		fmt.Println(filepath.Join(c.ReportPath(), "index.html"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringArrayVarP(&additionalDataPaths, "add", "a", []string{}, "additional schema data path (file or directory, can be specified multiple times)")
+}
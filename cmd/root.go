@@ -21,10 +21,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/trace"
+	"github.com/k1LoW/tbls/version"
 	"github.com/spf13/cobra"
 )
 
@@ -37,17 +44,38 @@ var force bool
 // sort is a flag on whether to sort tables, columns, and more
 var sort bool
 
-// additionalDataPath is a additional data path
-var additionalDataPath string
+// additionalDataPaths is additional data paths
+var additionalDataPaths []string
 
 // erFormat is a option that ER diagram file format
 var erFormat string
 
+// erDistance is a option that distance of related tables in per-table ER diagrams
+var erDistance int
+
+// configPath is a config file path
+var configPath string
+
+// timeout is a option that timeout of the command
+var timeout time.Duration
+
+// queryTimeout is a option that timeout of each catalog query
+var queryTimeout time.Duration
+
+// wait is a option that duration to wait until the database is ready
+var wait time.Duration
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "tbls",
 	Short: "tbls is a CI-Friendly tool for document a database, written in Go.",
 	Long:  `tbls is a CI-Friendly tool for document a database, written in Go.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return startProfile()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		stopProfile()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -55,19 +83,121 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		printError(err)
-		os.Exit(1)
+		exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", fmt.Sprintf("config file path (default: %s)", config.DefaultConfigFilePath))
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "print the time spent in each phase (connect, introspect, render, er) to stderr")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "log each query (duration, row count) and connection to stderr, and print errors with stack traces")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "format of the debug log (text or json)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "do not report the progress of long phases (introspect, profile, er) to stderr")
+	rootCmd.PersistentFlags().StringVar(&profilePath, "profile", "", "write the CPU profile (pprof) to the file")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "timeout of the command (e.g. 5m). no timeout when 0")
+	rootCmd.PersistentFlags().DurationVar(&wait, "wait", 0, "wait until the database is ready, up to the duration (e.g. 60s)")
+	rootCmd.PersistentFlags().DurationVar(&queryTimeout, "query-timeout", 0, "timeout of each catalog query (e.g. 30s). no timeout when 0")
+}
+
+// loadConfig load config file and overwrite it with args and flags
+func loadConfig(cmd *cobra.Command, args []string) ([]*config.Config, error) {
+	c := config.New()
+	err := c.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	err = c.CheckRequiredVersion(version.Version)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 {
+		c.DSN = config.DSN(args[0])
+		c.Docs = nil
+	}
+	if len(args) > 1 {
+		c.DocPath = args[1]
+	}
+	targets, err := c.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range targets {
+		if cmd.Flags().Changed("add") {
+			t.AdditionalData = config.Paths(additionalDataPaths)
+		}
+		if cmd.Flags().Changed("sort") {
+			t.Format.Sort = sort
+		}
+		if cmd.Flags().Changed("adjust-table") {
+			t.Format.Adjust = adjust
+		}
+		if cmd.Flags().Changed("er-format") {
+			t.ER.Format = erFormat
+		}
+		if cmd.Flags().Changed("er-distance") {
+			t.ER.Distance = erDistance
+		}
+		if cmd.Flags().Changed("without-er") {
+			t.ER.Skip = withoutER
+		}
+		if cmd.Flags().Changed("timeout") {
+			t.Timeout = timeout
+		}
+		if cmd.Flags().Changed("query-timeout") {
+			t.QueryTimeout = queryTimeout
+		}
+		if cmd.Flags().Changed("wait") {
+			t.Connect.Wait = wait
+		}
+		if cmd.Flags().Changed("schema-json") {
+			t.Format.SchemaJSON = schemaJSON
+		}
+	}
+	return targets, nil
+}
+
+// commandContext return the context of the command. It is canceled on interrupt (Ctrl-C) or SIGTERM, and after the timeout when it is positive.
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	cancelTimeout := func() {}
+	if timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	if recorder != nil {
+		ctx = trace.WithRecorder(ctx, recorder)
+	}
+	if logger != nil {
+		ctx = trace.WithLogger(ctx, logger)
+	}
+	if progress != nil {
+		ctx = trace.WithProgress(ctx, progress)
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+		// a second interrupt terminates tbls immediately
+		signal.Stop(sig)
+	}()
+	return ctx, func() {
+		cancel()
+		cancelTimeout()
+	}
 }
 
+// printError print the error with passwords of DSNs masked
 func printError(err error) {
 	env := os.Getenv("DEBUG")
-	debug, _ := strconv.ParseBool(env)
-	if env != "" && debug {
-		fmt.Printf("%+v\n", err)
+	debugEnv, _ := strconv.ParseBool(env)
+	// errors of drivers and external commands may contain the DSN
+	if debug || (env != "" && debugEnv) {
+		fmt.Println(config.MaskCredentials(fmt.Sprintf("%+v", err)))
 	} else {
-		fmt.Println(err)
+		fmt.Println(config.MaskCredentials(err.Error()))
 	}
 }
@@ -0,0 +1,115 @@
+// Copyright © 2018 Ken'ichiro Oyama <k1lowxb@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"time"
+
+	"github.com/k1LoW/tbls/trace"
+	"github.com/pkg/errors"
+)
+
+// verbose is a flag on whether to print the time spent in each phase
+var verbose bool
+
+// profilePath is a option that path of the CPU profile
+var profilePath string
+
+// debug is a flag on whether to log each query and connection to stderr
+var debug bool
+
+// logFormat is a option that format of the debug log (text or json)
+var logFormat string
+
+// quiet is a flag on whether to suppress the progress of long phases
+var quiet bool
+
+var (
+	recorder    *trace.Recorder
+	logger      *trace.Logger
+	progress    *trace.Progress
+	startedAt   time.Time
+	profileFile *os.File
+)
+
+// startProfile start recording the time spent in each phase with --verbose, logging queries with --debug, reporting the progress unless --quiet, and CPU profiling with --profile
+func startProfile() error {
+	startedAt = time.Now()
+	if verbose {
+		recorder = trace.New()
+	}
+	if debug {
+		l, err := trace.NewLogger(os.Stderr, logFormat)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		logger = l
+	}
+	if !quiet && !debug {
+		progress = trace.NewProgress(os.Stderr, progressInterval())
+	}
+	if profilePath == "" {
+		return nil
+	}
+	f, err := os.Create(profilePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = pprof.StartCPUProfile(f)
+	if err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	profileFile = f
+	return nil
+}
+
+// progressInterval return the interval of reporting the progress. It is longer when stderr is not a terminal (e.g. CI logs).
+func progressInterval() time.Duration {
+	fi, err := os.Stderr.Stat()
+	if err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return 2 * time.Second
+	}
+	return 10 * time.Second
+}
+
+// stopProfile write the CPU profile, and print the time spent in each phase to stderr
+func stopProfile() {
+	if profileFile != nil {
+		pprof.StopCPUProfile()
+		profileFile.Close()
+		profileFile = nil
+		fmt.Fprintf(os.Stderr, "CPU profile: %s (go tool pprof %s)\n", profilePath, profilePath)
+	}
+	if recorder != nil {
+		_ = recorder.Write(os.Stderr, time.Since(startedAt))
+		recorder = nil
+	}
+}
+
+// exit stop profiling and exit with the code, so that the profile of a failed (or timed out) command is also written
+func exit(code int) {
+	stopProfile()
+	os.Exit(code)
+}
@@ -0,0 +1,15 @@
+package config
+
+// Backstage is the struct for the entity descriptor of the Backstage software catalog
+type Backstage struct {
+	Owner     string `yaml:"owner,omitempty"`
+	System    string `yaml:"system,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	URL       string `yaml:"url,omitempty"`
+}
+
+// DefaultBackstageOwner is the owner of entities when backstage.owner is not set
+const DefaultBackstageOwner = "unknown"
+
+// DefaultBackstageNamespace is the namespace of entities when backstage.namespace is not set
+const DefaultBackstageNamespace = "default"
@@ -0,0 +1,21 @@
+package config
+
+// Cache is the struct for the cache of the analyzed schema
+type Cache struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Path    string `yaml:"path,omitempty"`
+}
+
+// DefaultCachePath is the directory of the cache of the analyzed schema when cache.path is not set
+const DefaultCachePath = ".tbls-cache"
+
+// CachePath return the directory of the cache of the analyzed schema. It is empty when the cache is disabled.
+func (c *Config) CachePath() string {
+	if !c.Cache.Enabled {
+		return ""
+	}
+	if c.Cache.Path != "" {
+		return c.Cache.Path
+	}
+	return DefaultCachePath
+}
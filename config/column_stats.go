@@ -0,0 +1,21 @@
+package config
+
+// ColumnStats is the struct for statistics of columns (null ratio, distinct count, min and max) in the documents
+type ColumnStats struct {
+	// Tables is the allowlist of tables (glob patterns) to collect statistics of columns
+	Tables []string `yaml:"tables,omitempty"`
+	// SampleSize is the number of rows of each table scanned for statistics. All rows are scanned when it is zero.
+	SampleSize int `yaml:"sampleSize,omitempty"`
+	// Percentiles is the percentiles (0-100) of values of numeric and date/time columns collected in addition to min and max
+	Percentiles []int `yaml:"percentiles,omitempty"`
+}
+
+// Enabled return whether statistics of columns are collected
+func (s ColumnStats) Enabled() bool {
+	return len(s.Tables) > 0
+}
+
+// Allowed return whether statistics of columns of the table are collected
+func (s ColumnStats) Allowed(table string) bool {
+	return match(s.Tables, table)
+}
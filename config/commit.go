@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCommitMessage is the default message of the commit of the generated documents
+const DefaultCommitMessage = "Update database document"
+
+// DefaultCommitRemote is the default remote to push the commit to
+const DefaultCommitRemote = "origin"
+
+// Commit is the struct for committing the generated documents with git after `tbls doc`
+type Commit struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Branch  string `yaml:"branch,omitempty"`
+	Message string `yaml:"message,omitempty"`
+	Push    bool   `yaml:"push,omitempty"`
+	Remote  string `yaml:"remote,omitempty"`
+}
+
+// CommitMessageData is the data for the template of the commit message
+type CommitMessageData struct {
+	Targets []string
+	Date    string
+}
+
+// RenderMessage return the commit message rendered from the template with the names (or document paths) of the targets
+func (cm Commit) RenderMessage(targets []*Config, now time.Time) (string, error) {
+	msg := cm.Message
+	if msg == "" {
+		msg = DefaultCommitMessage
+	}
+	tmpl, err := template.New("message").Parse(msg)
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), "invalid commit.message")
+	}
+	data := CommitMessageData{
+		Targets: []string{},
+		Date:    now.Format("2006-01-02"),
+	}
+	for _, t := range targets {
+		name := t.Name
+		if name == "" {
+			name = t.DocPath
+		}
+		data.Targets = append(data.Targets, name)
+	}
+	buf := new(bytes.Buffer)
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), "invalid commit.message")
+	}
+	return buf.String(), nil
+}
+
+// TargetsCommit return the commit settings of the targets.
+// The documents of all targets are committed together, so the settings must not differ between targets.
+func TargetsCommit(targets []*Config) (Commit, error) {
+	cm := targets[0].Commit
+	for _, c := range targets[1:] {
+		if c.Commit != cm {
+			return cm, errors.WithStack(fmt.Errorf("%s: commit settings must be the same for all docs targets", c.label()))
+		}
+	}
+	return cm, nil
+}
+
+// RemoteName return the remote to push the commit to
+func (cm Commit) RemoteName() string {
+	if cm.Remote == "" {
+		return DefaultCommitRemote
+	}
+	return cm.Remote
+}
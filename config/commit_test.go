@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommitRenderMessage(t *testing.T) {
+	now := time.Date(2020, 4, 1, 9, 0, 0, 0, time.UTC)
+	targets := []*Config{&Config{Name: "app", DocPath: "doc/app"}, &Config{DocPath: "doc/log"}}
+	tests := []struct {
+		message string
+		want    string
+		wantErr bool
+	}{
+		{"", DefaultCommitMessage, false},
+		{"docs: update {{ join .Targets }}", "", true},
+		{"docs: update {{ range $i, $t := .Targets }}{{ if $i }}, {{ end }}{{ $t }}{{ end }} ({{ .Date }})", "docs: update app, doc/log (2020-04-01)", false},
+		{"{{ .Unknown }}", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Commit{Message: tt.message}.RenderMessage(targets, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: actual %v\nwant error %v", tt.message, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestTargetsCommit(t *testing.T) {
+	tests := []struct {
+		commits []Commit
+		wantErr bool
+	}{
+		{[]Commit{Commit{Enabled: true}}, false},
+		{[]Commit{Commit{Enabled: true, Branch: "dbdoc"}, Commit{Enabled: true, Branch: "dbdoc"}}, false},
+		{[]Commit{Commit{Enabled: true, Branch: "dbdoc"}, Commit{Enabled: true, Branch: "docs"}}, true},
+		{[]Commit{Commit{}, Commit{Push: true}}, true},
+	}
+	for i, tt := range tests {
+		targets := []*Config{}
+		for _, cm := range tt.commits {
+			targets = append(targets, &Config{Commit: cm})
+		}
+		got, err := TargetsCommit(targets)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%d: actual %v\nwant error %v", i, err, tt.wantErr)
+		}
+		if err == nil && got != tt.commits[0] {
+			t.Errorf("%d: actual %v\nwant %v", i, got, tt.commits[0])
+		}
+	}
+}
@@ -0,0 +1,1061 @@
+package config
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultConfigFilePath is the default config file path
+const DefaultConfigFilePath = ".tbls.yml"
+
+// DefaultERFormat is the default ER diagram file format
+const DefaultERFormat = "png"
+
+// DefaultERDistance is the default distance of related tables in per-table ER diagrams
+const DefaultERDistance = 1
+
+// DefaultKrokiURL is the default URL of Kroki server for `er.renderer: kroki`
+const DefaultKrokiURL = "https://kroki.io"
+
+// DefaultPlantUMLServerURL is the default URL of PlantUML server for `er.renderer: plantuml`
+const DefaultPlantUMLServerURL = "https://www.plantuml.com/plantuml"
+
+// DefaultERFont is the default font of ER diagrams
+const DefaultERFont = "Arial"
+
+// DefaultERFontSize is the default font size of ER nodes
+const DefaultERFontSize = 14
+
+// DefaultERRankdir is the default direction of ER diagram layout
+const DefaultERRankdir = "TB"
+
+// DefaultERAdditionalRelationColor is the default line color of additional relations in ER diagrams
+const DefaultERAdditionalRelationColor = "#3C78D8"
+
+// DefaultERThemes is the builtin color themes of ER diagrams
+var DefaultERThemes = map[string]ERTheme{
+	"light": ERTheme{
+		Header:  "#EFEFEF",
+		Type:    "#666666",
+		Comment: "#333333",
+		Cluster: "#999999",
+	},
+	"dark": ERTheme{
+		Background: "#1E1E1E",
+		Node:       "#2D2D2D",
+		Header:     "#3C3C3C",
+		Text:       "#E0E0E0",
+		Type:       "#A0A0A0",
+		Comment:    "#C0C0C0",
+		Border:     "#808080",
+		Edge:       "#C0C0C0",
+		Cluster:    "#808080",
+	},
+}
+
+// DefaultERPalette is the default palette of ER node header colors for `colorBy: schema`
+var DefaultERPalette = []string{"#DAE8FC", "#D5E8D4", "#FFE6CC", "#F8CECC", "#E1D5E7", "#FFF2CC", "#B1DDF0", "#D0CEE2"}
+
+// DefaultLogicalNameDelimiter is the default delimiter between logical name and description in comment
+const DefaultLogicalNameDelimiter = "|"
+
+// Config is the struct for tbls config
+type Config struct {
+	Name                   string                 `yaml:"name,omitempty"`
+	RequiredVersion        string                 `yaml:"requiredVersion,omitempty"`
+	DSN                    DSN                    `yaml:"dsn"`
+	DocPath                string                 `yaml:"docPath"`
+	IAMAuth                IAMAuth                `yaml:"iamAuth,omitempty"`
+	SSHTunnel              SSHTunnel              `yaml:"sshTunnel,omitempty"`
+	TLS                    TLS                    `yaml:"tls,omitempty"`
+	Connect                Connect                `yaml:"connect,omitempty"`
+	AdditionalData         Paths                  `yaml:"additionalData,omitempty"`
+	Dbt                    Dbt                    `yaml:"dbt,omitempty"`
+	IncludeSystemSchemas   bool                   `yaml:"includeSystemSchemas,omitempty"`
+	Concurrency            int                    `yaml:"concurrency,omitempty"`
+	Timeout                time.Duration          `yaml:"timeout,omitempty"`
+	QueryTimeout           time.Duration          `yaml:"queryTimeout,omitempty"`
+	Cache                  Cache                  `yaml:"cache,omitempty"`
+	Include                []string               `yaml:"include,omitempty"`
+	Exclude                []string               `yaml:"exclude,omitempty"`
+	HideColumns            []string               `yaml:"hideColumns,omitempty"`
+	Samples                Samples                `yaml:"samples,omitempty"`
+	RowCount               string                 `yaml:"rowCount,omitempty"`
+	ColumnStats            ColumnStats            `yaml:"columnStats,omitempty"`
+	EnumValues             EnumValues             `yaml:"enumValues,omitempty"`
+	Storage                Storage                `yaml:"storage,omitempty"`
+	Freshness              Freshness              `yaml:"freshness,omitempty"`
+	DetectVirtualRelations DetectVirtualRelations `yaml:"detectVirtualRelations,omitempty"`
+	DetectSensitivity      DetectSensitivity      `yaml:"detectSensitivity,omitempty"`
+	LogicalName            LogicalName            `yaml:"logicalName"`
+	Dict                   Dict                   `yaml:"dict,omitempty"`
+	Title                  string                 `yaml:"title,omitempty"`
+	Tables                 []Table                `yaml:"tables,omitempty"`
+	Labels                 []schema.Label         `yaml:"labels,omitempty"`
+	Viewpoints             []Viewpoint            `yaml:"viewpoints,omitempty"`
+	Format                 Format                 `yaml:"format"`
+	ER                     ER                     `yaml:"er"`
+	Templates              Templates              `yaml:"templates,omitempty"`
+	Link                   Link                   `yaml:"link,omitempty"`
+	Lint                   Lint                   `yaml:"lint,omitempty"`
+	Publish                Publish                `yaml:"publish,omitempty"`
+	Notify                 Notify                 `yaml:"notify,omitempty"`
+	Commit                 Commit                 `yaml:"commit,omitempty"`
+	Backstage              Backstage              `yaml:"backstage,omitempty"`
+	OpenMetadata           OpenMetadata           `yaml:"openMetadata,omitempty"`
+	Report                 Report                 `yaml:"report,omitempty"`
+	Profile                Profile                `yaml:"profile,omitempty"`
+	Docs                   []yaml.MapSlice        `yaml:"docs,omitempty"`
+}
+
+// Format is the struct for document format
+type Format struct {
+	Adjust          bool       `yaml:"adjust"`
+	Sort            bool       `yaml:"sort"`
+	KeepColumnOrder bool       `yaml:"keepColumnOrder"`
+	NormalizeTypes  bool       `yaml:"normalizeTypes,omitempty"`
+	SchemaJSON      bool       `yaml:"schemaJSON,omitempty"`
+	IndexPages      IndexPages `yaml:"indexPages,omitempty"`
+	Wiki            string     `yaml:"wiki,omitempty"`
+}
+
+// IndexPages is the struct for splitting the table list of the index into multiple pages
+type IndexPages struct {
+	// Threshold is the number of tables above which the table list is split. The table list is not split when it is 0.
+	Threshold int `yaml:"threshold,omitempty"`
+	// By is how tables are grouped into pages: alphabet (first letter of the table name, default), schema or label
+	By string `yaml:"by,omitempty"`
+}
+
+// Enabled return whether the table list of the index of the number of tables is split into pages
+func (p IndexPages) Enabled(tables int) bool {
+	return p.Threshold > 0 && tables > p.Threshold
+}
+
+// ER is the struct for ER diagram config
+type ER struct {
+	Skip                    bool     `yaml:"skip"`
+	SkipSchema              bool     `yaml:"skipSchema"`
+	SkipTables              bool     `yaml:"skipTables"`
+	SchemaMaxTables         int      `yaml:"schemaMaxTables"`
+	SchemaMaxRelations      int      `yaml:"schemaMaxRelations"`
+	Oversize                string   `yaml:"oversize"`
+	Format                  string   `yaml:"format"`
+	Comment                 bool     `yaml:"comment"`
+	CommentMaxLength        int      `yaml:"commentMaxLength"`
+	Distance                int      `yaml:"distance"`
+	Font                    string   `yaml:"font"`
+	FontSize                int      `yaml:"fontSize"`
+	Rankdir                 string   `yaml:"rankdir"`
+	DPI                     int      `yaml:"dpi"`
+	Columns                 string   `yaml:"columns"`
+	ColorBy                 string   `yaml:"colorBy"`
+	Palette                 []string `yaml:"palette"`
+	ClusterBy               string   `yaml:"clusterBy"`
+	Seeds                   []string `yaml:"seeds"`
+	SeedDistance            int      `yaml:"seedDistance"`
+	DetectCardinality       bool     `yaml:"detectCardinality"`
+	Deterministic           bool     `yaml:"deterministic"`
+	AdditionalRelationColor string   `yaml:"additionalRelationColor"`
+	Legend                  bool     `yaml:"legend"`
+	Splines                 string   `yaml:"splines"`
+	Overlap                 string   `yaml:"overlap"`
+	Nodesep                 float64  `yaml:"nodesep"`
+	Ranksep                 float64  `yaml:"ranksep"`
+	Links                   bool     `yaml:"links"`
+	Exclude                 []string `yaml:"exclude"`
+	Viewer                  bool     `yaml:"viewer"`
+	Theme                   string   `yaml:"theme"`
+	Colors                  ERTheme  `yaml:"colors"`
+	Cache                   bool     `yaml:"cache"`
+	EdgeLabel               string   `yaml:"edgeLabel"`
+	KeyIcons                bool     `yaml:"keyIcons"`
+	Renderer                string   `yaml:"renderer"`
+}
+
+// ERCluster is the group of tables rendered as a cluster in ER diagrams
+type ERCluster struct {
+	Name   string
+	Tables []*schema.Table
+}
+
+// ERTheme is the struct for colors of ER diagrams. Empty colors are left to the renderer defaults.
+type ERTheme struct {
+	Background string `yaml:"background"`
+	Node       string `yaml:"node"`
+	Header     string `yaml:"header"`
+	Text       string `yaml:"text"`
+	Type       string `yaml:"type"`
+	Comment    string `yaml:"comment"`
+	Border     string `yaml:"border"`
+	Edge       string `yaml:"edge"`
+	Cluster    string `yaml:"cluster"`
+}
+
+// ERLegendColor is the header color and the schema or label name it stands for in the ER legend
+type ERLegendColor struct {
+	Name  string
+	Color string
+}
+
+// ERLegendKey is the key marker of columns and the key type it stands for in the ER legend
+type ERLegendKey struct {
+	Key         string
+	Description string
+}
+
+// Dbt is the struct for merging the dbt manifest into the schema
+type Dbt struct {
+	Manifest string `yaml:"manifest,omitempty"`
+}
+
+// Link is the struct for links between generated documents
+type Link struct {
+	Style   string `yaml:"style,omitempty"`
+	BaseURL string `yaml:"baseUrl,omitempty"`
+}
+
+// Templates is the struct for user-provided template file paths
+type Templates struct {
+	MD   MDTemplates `yaml:"md,omitempty"`
+	Dot  ERTemplates `yaml:"dot,omitempty"`
+	PUML ERTemplates `yaml:"puml,omitempty"`
+}
+
+// MDTemplates is the struct for markdown template file paths
+type MDTemplates struct {
+	Index     string `yaml:"index,omitempty"`
+	IndexPage string `yaml:"indexPage,omitempty"`
+	Table     string `yaml:"table,omitempty"`
+	Viewpoint string `yaml:"viewpoint,omitempty"`
+	Profile   string `yaml:"profile,omitempty"`
+}
+
+// ERTemplates is the struct for ER diagram template file paths
+type ERTemplates struct {
+	Schema string `yaml:"schema,omitempty"`
+	Table  string `yaml:"table,omitempty"`
+}
+
+// Viewpoint is the struct for a named subset of tables documented with its own page and ER diagram
+type Viewpoint struct {
+	Name   string   `yaml:"name"`
+	Desc   string   `yaml:"desc,omitempty"`
+	Tables []string `yaml:"tables,omitempty"`
+	Labels []string `yaml:"labels,omitempty"`
+}
+
+// FileName return the base file name of the viewpoint document and ER diagram
+func (v Viewpoint) FileName(i int) string {
+	return fmt.Sprintf("viewpoint-%d", i)
+}
+
+// Schema return the schema consisting of tables matching tables patterns or labels, and relations between them
+func (v Viewpoint) Schema(s *schema.Schema) *schema.Schema {
+	encountered := map[*schema.Table]bool{}
+	tables := []*schema.Table{}
+	for _, t := range s.Tables {
+		if match(v.Tables, t.Name) || v.hasLabel(t) {
+			encountered[t] = true
+			tables = append(tables, t)
+		}
+	}
+	relations := []*schema.Relation{}
+	for _, r := range s.Relations {
+		if encountered[r.Table] && encountered[r.ParentTable] {
+			relations = append(relations, r)
+		}
+	}
+	d := *s
+	d.Tables = tables
+	d.Relations = relations
+	return &d
+}
+
+func (v Viewpoint) hasLabel(t *schema.Table) bool {
+	for _, l := range t.Labels {
+		for _, name := range v.Labels {
+			if l.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Table is the struct for per-table document config
+type Table struct {
+	Name            string   `yaml:"name"`
+	Alias           string   `yaml:"alias,omitempty"`
+	DescriptionFile string   `yaml:"descriptionFile,omitempty"`
+	Snippets        []string `yaml:"snippets,omitempty"`
+}
+
+// FindTable find per-table document config by table name
+func (c *Config) FindTable(name string) (Table, bool) {
+	for _, t := range c.Tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// TableTitle return the display name of the table
+func (c *Config) TableTitle(name string) string {
+	if t, ok := c.FindTable(name); ok && t.Alias != "" {
+		return fmt.Sprintf("%s (%s)", t.Alias, name)
+	}
+	return name
+}
+
+// TableLink return the link to the table document according to link config
+func (c *Config) TableLink(name string) string {
+	if c.Link.Style == "noext" || c.Format.Wiki != "" {
+		return c.FileLink(name)
+	}
+	return c.FileLink(fmt.Sprintf("%s.md", name))
+}
+
+// IndexFileName return the file name of the index document. Wikis use their home page.
+func (c *Config) IndexFileName() string {
+	switch c.Format.Wiki {
+	case "gitlab":
+		return "home.md"
+	case "bitbucket", "github":
+		return "Home.md"
+	}
+	return "README.md"
+}
+
+// SidebarFileName return the file name of the sidebar of the wiki. Empty if the wiki has no sidebar.
+func (c *Config) SidebarFileName() string {
+	switch c.Format.Wiki {
+	case "gitlab":
+		return "_sidebar.md"
+	case "github":
+		return "_Sidebar.md"
+	}
+	return ""
+}
+
+// ImagePath return the slash-separated path of the ER diagram image in the document path. GitLab wiki stores attachments in uploads/.
+func (c *Config) ImagePath(file string) string {
+	if c.Format.Wiki == "gitlab" {
+		return path.Join("uploads", file)
+	}
+	return file
+}
+
+// FileLink return the link to the file in the document directory according to link config
+func (c *Config) FileLink(file string) string {
+	if c.Link.BaseURL == "" {
+		return file
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(c.Link.BaseURL, "/"), file)
+}
+
+// Paths is the list of file or directory paths. In yaml, it can be a string or a list of strings.
+type Paths []string
+
+// UnmarshalYAML unmarshal a string or a list of strings
+func (p *Paths) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		if path == "" {
+			*p = nil
+		} else {
+			*p = Paths{path}
+		}
+		return nil
+	}
+	var paths []string
+	if err := unmarshal(&paths); err != nil {
+		return err
+	}
+	*p = Paths(paths)
+	return nil
+}
+
+// Dict is the dictionary for localizing output strings
+type Dict map[string]string
+
+// Lookup return the translated text of the output string. If not found, return the string as it is.
+func (d Dict) Lookup(k string) string {
+	if v, ok := d[k]; ok {
+		return v
+	}
+	return k
+}
+
+// LogicalName is the struct for logical name (taken from comment) config
+type LogicalName struct {
+	Delimiter string `yaml:"delimiter"`
+	ShowInER  bool   `yaml:"showInER"`
+	Replace   bool   `yaml:"replace"`
+}
+
+// DetectVirtualRelations is the struct for detecting relations from naming conventions
+type DetectVirtualRelations struct {
+	Enabled  bool                         `yaml:"enabled"`
+	Strategy string                       `yaml:"strategy,omitempty"`
+	Rules    []schema.VirtualRelationRule `yaml:"rules,omitempty"`
+}
+
+// IsImageFormat return whether the ER diagram format is an image format rendered with Graphviz
+func (e ER) IsImageFormat() bool {
+	switch e.Format {
+	case "dot", "plantuml", "mermaid":
+		return false
+	}
+	return true
+}
+
+// RendererURL return the renderer of ER diagram images (dot, kroki or plantuml) and the URL of the server.
+// er.renderer is `dot` (default, Graphviz command), `kroki`, `plantuml`, or the renderer with the URL such as `kroki:https://kroki.example.com`.
+func (e ER) RendererURL() (string, string) {
+	kind := e.Renderer
+	u := ""
+	if i := strings.Index(e.Renderer, ":"); i > 0 {
+		kind = e.Renderer[:i]
+		u = e.Renderer[i+1:]
+	}
+	switch {
+	case kind == "":
+		kind = "dot"
+	case kind == "kroki" && u == "":
+		u = DefaultKrokiURL
+	case kind == "plantuml" && u == "":
+		u = DefaultPlantUMLServerURL
+	}
+	return kind, u
+}
+
+// SkipSchemaDiagram return whether to skip the ER diagram of the whole schema
+func (e ER) SkipSchemaDiagram(s *schema.Schema) bool {
+	if e.Skip || e.SkipSchema {
+		return true
+	}
+	return e.IsOversized(s) && (e.Oversize == "" || e.Oversize == "skip")
+}
+
+// IsOversized return whether the ER diagram of the whole schema exceeds schemaMaxTables or schemaMaxRelations
+func (e ER) IsOversized(s *schema.Schema) bool {
+	d := e.SchemaDiagram(s)
+	if e.SchemaMaxTables > 0 && len(d.Tables) > e.SchemaMaxTables {
+		return true
+	}
+	return e.SchemaMaxRelations > 0 && len(d.Relations) > e.SchemaMaxRelations
+}
+
+// SchemaDiagramER return the ER config for the ER diagram of the whole schema.
+// When the diagram is oversized and oversize is keys or none, columns of ER nodes are reduced.
+func (e ER) SchemaDiagramER(s *schema.Schema) ER {
+	if !e.IsOversized(s) {
+		return e
+	}
+	switch e.Oversize {
+	case "keys", "none":
+		e.Columns = e.Oversize
+	}
+	return e
+}
+
+// NodeComment return the comment shown in ER nodes, truncated to commentMaxLength characters
+func (e ER) NodeComment(text string) string {
+	if e.CommentMaxLength <= 0 {
+		return text
+	}
+	r := []rune(text)
+	if len(r) <= e.CommentMaxLength {
+		return text
+	}
+	return fmt.Sprintf("%s...", string(r[:e.CommentMaxLength]))
+}
+
+// SchemaDiagram return the schema rendered in the ER diagram of the whole schema.
+// Tables matching exclude are removed. When seeds is set, only the seed tables and tables within seedDistance hops from them are included.
+// When deterministic is set, tables and relations are ordered by name.
+func (e ER) SchemaDiagram(s *schema.Schema) *schema.Schema {
+	if len(e.Seeds) == 0 && len(e.Exclude) == 0 && !e.Deterministic {
+		return s
+	}
+	encountered := map[*schema.Table]bool{}
+	for _, t := range s.Tables {
+		if len(e.Seeds) == 0 {
+			encountered[t] = true
+			continue
+		}
+		if !match(e.Seeds, t.Name) {
+			continue
+		}
+		encountered[t] = true
+		for _, rt := range s.RelatedTables(t, e.SeedDistance) {
+			encountered[rt] = true
+		}
+	}
+	tables := []*schema.Table{}
+	for _, t := range s.Tables {
+		if encountered[t] && !e.IsExcluded(t) {
+			tables = append(tables, t)
+		}
+	}
+	relations := []*schema.Relation{}
+	for _, r := range s.Relations {
+		if encountered[r.Table] && encountered[r.ParentTable] && !e.IsExcluded(r.Table) && !e.IsExcluded(r.ParentTable) {
+			relations = append(relations, r)
+		}
+	}
+	d := *s
+	d.Tables = tables
+	d.Relations = relations
+	if e.Deterministic {
+		d.Tables, d.Relations = sortDiagram(tables, relations)
+	}
+	return &d
+}
+
+// TableDiagram return the related tables and relations rendered in the ER diagram of the table.
+// Tables matching exclude are removed.
+func (e ER) TableDiagram(t *schema.Table) ([]*schema.Table, []*schema.Relation) {
+	tables, relations := t.CollectTablesAndRelations(e.Distance)
+	if len(e.Exclude) > 0 {
+		ft := []*schema.Table{}
+		for _, rt := range tables {
+			if !e.IsExcluded(rt) {
+				ft = append(ft, rt)
+			}
+		}
+		fr := []*schema.Relation{}
+		for _, r := range relations {
+			if !e.IsExcluded(r.Table) && !e.IsExcluded(r.ParentTable) {
+				fr = append(fr, r)
+			}
+		}
+		tables, relations = ft, fr
+	}
+	if e.Deterministic {
+		return sortDiagram(tables, relations)
+	}
+	return tables, relations
+}
+
+// RelationLabel return the label of the ER edge of the relation according to edgeLabel (def, name or none).
+// name falls back to def for additional relations and relations without constraint name.
+func (e ER) RelationLabel(r *schema.Relation) string {
+	switch e.EdgeLabel {
+	case "none":
+		return ""
+	case "name":
+		if n := r.ConstraintName(); n != "" {
+			return n
+		}
+	}
+	return r.Def
+}
+
+// IsExcluded return whether the table is excluded from ER diagrams
+func (e ER) IsExcluded(t *schema.Table) bool {
+	return match(e.Exclude, t.Name)
+}
+
+// SkipTableDiagram return whether to skip the ER diagram of the table
+func (e ER) SkipTableDiagram(t *schema.Table) bool {
+	return e.SkipTableDiagrams() || e.IsExcluded(t)
+}
+
+// sortDiagram return copies of tables and relations ordered by name
+func sortDiagram(tables []*schema.Table, relations []*schema.Relation) ([]*schema.Table, []*schema.Relation) {
+	st := make([]*schema.Table, len(tables))
+	copy(st, tables)
+	sort.SliceStable(st, func(i, j int) bool {
+		return st[i].Name < st[j].Name
+	})
+	sr := make([]*schema.Relation, len(relations))
+	copy(sr, relations)
+	sort.SliceStable(sr, func(i, j int) bool {
+		return relationKey(sr[i]) < relationKey(sr[j])
+	})
+	return st, sr
+}
+
+func relationKey(r *schema.Relation) string {
+	columns := []string{}
+	for _, c := range r.Columns {
+		columns = append(columns, c.Name)
+	}
+	parentColumns := []string{}
+	for _, c := range r.ParentColumns {
+		parentColumns = append(parentColumns, c.Name)
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s", r.Table.Name, strings.Join(columns, ","), r.ParentTable.Name, strings.Join(parentColumns, ","))
+}
+
+// ViewpointER return the ER config for viewpoint diagrams. seeds are ignored because viewpoints select tables by themselves.
+func (e ER) ViewpointER() ER {
+	e.Seeds = nil
+	return e
+}
+
+// SkipTableDiagrams return whether to skip per-table ER diagrams
+func (e ER) SkipTableDiagrams() bool {
+	return e.Skip || e.SkipTables
+}
+
+// ShowColumn return whether the column is shown in ER nodes according to the columns mode (all, keys or none)
+func (e ER) ShowColumn(t *schema.Table, c *schema.Column) bool {
+	if c.Hidden {
+		return false
+	}
+	switch e.Columns {
+	case "none":
+		return false
+	case "keys":
+		return t.IsKeyColumn(c)
+	}
+	return true
+}
+
+// ColumnKeys return the key types (PK, FK, UK or IDX) marked on the column in ER nodes. Empty unless keyIcons is set.
+func (e ER) ColumnKeys(t *schema.Table, c *schema.Column) []string {
+	if !e.KeyIcons {
+		return []string{}
+	}
+	return t.ColumnKeys(c)
+}
+
+// TableColor return the header color of the table node according to colorBy (label, schema or none).
+// Empty string means the default color.
+func (e ER) TableColor(t *schema.Table) string {
+	_, c := e.tableColorKey(t)
+	return c
+}
+
+// ThemeColors return colors of the theme (default light) overridden by colors
+func (e ER) ThemeColors() ERTheme {
+	name := e.Theme
+	if name == "" {
+		name = "light"
+	}
+	t := DefaultERThemes[name]
+	o := e.Colors
+	for _, c := range []struct {
+		dst *string
+		src string
+	}{
+		{&t.Background, o.Background},
+		{&t.Node, o.Node},
+		{&t.Header, o.Header},
+		{&t.Text, o.Text},
+		{&t.Type, o.Type},
+		{&t.Comment, o.Comment},
+		{&t.Border, o.Border},
+		{&t.Edge, o.Edge},
+		{&t.Cluster, o.Cluster},
+	} {
+		if c.src != "" {
+			*c.dst = c.src
+		}
+	}
+	return t
+}
+
+// LegendColors return header colors used by the tables and the schemas or labels they stand for
+func (e ER) LegendColors(tables []*schema.Table, more ...*schema.Table) []*ERLegendColor {
+	colors := []*ERLegendColor{}
+	encountered := map[string]bool{}
+	for _, t := range append(more, tables...) {
+		name, c := e.tableColorKey(t)
+		if c == "" || encountered[name] {
+			continue
+		}
+		encountered[name] = true
+		colors = append(colors, &ERLegendColor{Name: name, Color: c})
+	}
+	return colors
+}
+
+// LegendKeys return key markers of columns and the key types they stand for. Empty unless keyIcons is set.
+func (e ER) LegendKeys() []*ERLegendKey {
+	if !e.KeyIcons {
+		return []*ERLegendKey{}
+	}
+	return []*ERLegendKey{
+		&ERLegendKey{Key: schema.KeyPrimary, Description: "Primary key"},
+		&ERLegendKey{Key: schema.KeyForeign, Description: "Foreign key"},
+		&ERLegendKey{Key: schema.KeyUnique, Description: "Unique key"},
+		&ERLegendKey{Key: schema.KeyIndex, Description: "Indexed"},
+	}
+}
+
+// tableColorKey return the schema or label name which decides the header color of the table, and the color
+func (e ER) tableColorKey(t *schema.Table) (string, string) {
+	switch e.ColorBy {
+	case "none":
+		return "", ""
+	case "schema":
+		i := strings.LastIndex(t.Name, ".")
+		if i < 0 {
+			return "", ""
+		}
+		palette := e.Palette
+		if len(palette) == 0 {
+			palette = DefaultERPalette
+		}
+		return t.Name[:i], paletteColor(palette, t.Name[:i])
+	}
+	for _, l := range t.Labels {
+		if l.Color != "" {
+			return l.Name, l.Color
+		}
+	}
+	if len(t.Labels) > 0 && len(e.Palette) > 0 {
+		return t.Labels[0].Name, paletteColor(e.Palette, t.Labels[0].Name)
+	}
+	return "", ""
+}
+
+// Clusters return groups of tables according to clusterBy (schema or label). Tables without schema or label are not clustered.
+func (e ER) Clusters(tables []*schema.Table) []*ERCluster {
+	clusters := []*ERCluster{}
+	if e.ClusterBy != "schema" && e.ClusterBy != "label" {
+		return clusters
+	}
+	index := map[string]*ERCluster{}
+	for _, t := range tables {
+		name := ""
+		switch e.ClusterBy {
+		case "schema":
+			if i := strings.LastIndex(t.Name, "."); i >= 0 {
+				name = t.Name[:i]
+			}
+		case "label":
+			if len(t.Labels) > 0 {
+				name = t.Labels[0].Name
+			}
+		}
+		if name == "" {
+			continue
+		}
+		cl, ok := index[name]
+		if !ok {
+			cl = &ERCluster{Name: name}
+			index[name] = cl
+			clusters = append(clusters, cl)
+		}
+		cl.Tables = append(cl.Tables, t)
+	}
+	return clusters
+}
+
+// paletteColor return the color of the palette assigned to the name
+func paletteColor(palette []string, name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// FileExt return the file extension of ER diagram files. mermaid is embedded in documents.
+func (e ER) FileExt() string {
+	switch e.Format {
+	case "plantuml":
+		return "puml"
+	case "mermaid":
+		return ""
+	}
+	return e.Format
+}
+
+// New return *Config
+func New() *Config {
+	return &Config{
+		ER: ER{
+			Format:                  DefaultERFormat,
+			Distance:                DefaultERDistance,
+			SeedDistance:            DefaultERDistance,
+			Font:                    DefaultERFont,
+			FontSize:                DefaultERFontSize,
+			AdditionalRelationColor: DefaultERAdditionalRelationColor,
+			Rankdir:                 DefaultERRankdir,
+		},
+		LogicalName: LogicalName{
+			Delimiter: DefaultLogicalNameDelimiter,
+		},
+	}
+}
+
+// Load load config file. If path is empty, load DefaultConfigFilePath when it exists.
+func (c *Config) Load(path string) error {
+	if path == "" {
+		if _, err := os.Lstat(DefaultConfigFilePath); err != nil {
+			return nil
+		}
+		path = DefaultConfigFilePath
+	}
+	fullPath, err := filepath.Abs(path)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to load config file")
+	}
+	buf, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to load config file")
+	}
+	err = c.loadBytes(buf, fullPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to load config file")
+	}
+	return nil
+}
+
+// LoadBytes load config from yaml buffer. Unknown keys are reported as errors.
+// Relative paths in `lint.extends:` are resolved from the current directory.
+func (c *Config) LoadBytes(buf []byte) error {
+	return c.loadBytes(buf, "")
+}
+
+// loadBytes load config from yaml buffer of the config file path
+func (c *Config) loadBytes(buf []byte, path string) error {
+	err := yaml.UnmarshalStrict(buf, c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(c.Lint.Extends) > 0 {
+		err := c.Lint.resolveExtends(buf, path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Targets return documentation targets.
+// Each `docs:` entry inherits the top-level settings and overrides them with its own.
+func (c *Config) Targets() ([]*Config, error) {
+	if len(c.Docs) == 0 {
+		return []*Config{c}, nil
+	}
+	base := *c
+	base.Docs = nil
+	baseBuf, err := yaml.Marshal(&base)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	targets := []*Config{}
+	for i, d := range c.Docs {
+		t := &Config{}
+		err := yaml.Unmarshal(baseBuf, t)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buf, err := yaml.Marshal(d)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		err = yaml.UnmarshalStrict(buf, t)
+		if err != nil {
+			return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid docs[%d]", i))
+		}
+		if t.Docs != nil {
+			return nil, errors.WithStack(fmt.Errorf("invalid docs[%d]: nested docs are not supported", i))
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Validate validate config as a documentation target
+func (c *Config) Validate() error {
+	if c.DSN == "" {
+		return errors.WithStack(fmt.Errorf("%s: DSN is required", c.label()))
+	}
+	if c.DocPath == "" {
+		return errors.WithStack(fmt.Errorf("%s: document path is required", c.label()))
+	}
+	if c.Concurrency < 0 {
+		return errors.WithStack(fmt.Errorf("%s: concurrency must not be negative", c.label()))
+	}
+	if c.Connect.Retries < 0 || c.Connect.Backoff < 0 || c.Connect.Wait < 0 {
+		return errors.WithStack(fmt.Errorf("%s: connect retries, backoff and wait must not be negative", c.label()))
+	}
+	if c.Format.IndexPages.Threshold < 0 {
+		return errors.WithStack(fmt.Errorf("%s: format.indexPages.threshold must not be negative", c.label()))
+	}
+	switch c.Format.IndexPages.By {
+	case "", "alphabet", "schema", "label":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported format.indexPages.by '%s'", c.label(), c.Format.IndexPages.By))
+	}
+	if c.Samples.Rows < 0 {
+		return errors.WithStack(fmt.Errorf("%s: samples.rows must not be negative", c.label()))
+	}
+	if c.Samples.Rows > 0 && len(c.Samples.Tables) == 0 {
+		return errors.WithStack(fmt.Errorf("%s: samples.tables is required to select sample rows", c.label()))
+	}
+	if c.ColumnStats.SampleSize < 0 {
+		return errors.WithStack(fmt.Errorf("%s: columnStats.sampleSize must not be negative", c.label()))
+	}
+	for _, p := range c.ColumnStats.Percentiles {
+		if p < 0 || p > 100 {
+			return errors.WithStack(fmt.Errorf("%s: columnStats.percentiles must be between 0 and 100", c.label()))
+		}
+	}
+	if c.EnumValues.Threshold < 0 || c.EnumValues.SampleSize < 0 {
+		return errors.WithStack(fmt.Errorf("%s: enumValues.threshold and enumValues.sampleSize must not be negative", c.label()))
+	}
+	if c.Storage.Top < 0 {
+		return errors.WithStack(fmt.Errorf("%s: storage.top must not be negative", c.label()))
+	}
+	if c.Profile.TopK < 0 || c.Profile.SampleSize < 0 {
+		return errors.WithStack(fmt.Errorf("%s: profile.topK and profile.sampleSize must not be negative", c.label()))
+	}
+	for _, m := range c.Profile.Metrics {
+		switch m {
+		case ProfileNulls, ProfileCardinality, ProfileTopK:
+		default:
+			return errors.WithStack(fmt.Errorf("%s: unsupported profile metric '%s' (nulls, cardinality or topk)", c.label(), m))
+		}
+	}
+	if err := c.DetectSensitivity.validate(); err != nil {
+		return errors.Wrap(err, c.label())
+	}
+	switch c.RowCount {
+	case "", RowCountExact, RowCountEstimate:
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported rowCount '%s' (exact or estimate)", c.label(), c.RowCount))
+	}
+	if c.Timeout < 0 || c.QueryTimeout < 0 {
+		return errors.WithStack(fmt.Errorf("%s: timeout must not be negative", c.label()))
+	}
+	for i, v := range c.Viewpoints {
+		if v.Name == "" {
+			return errors.WithStack(fmt.Errorf("%s: viewpoints[%d]: name is required", c.label(), i))
+		}
+	}
+	switch c.ER.Columns {
+	case "", "all", "keys", "none":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER columns mode '%s'", c.label(), c.ER.Columns))
+	}
+	switch c.ER.EdgeLabel {
+	case "", "def", "name", "none":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER edgeLabel '%s' (def, name or none)", c.label(), c.ER.EdgeLabel))
+	}
+	switch c.ER.Oversize {
+	case "", "skip", "keys", "none":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER oversize mode '%s'", c.label(), c.ER.Oversize))
+	}
+	if _, ok := DefaultERThemes[c.ER.Theme]; c.ER.Theme != "" && !ok {
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER theme '%s' (light or dark)", c.label(), c.ER.Theme))
+	}
+	switch c.ER.ColorBy {
+	case "", "label", "schema", "none":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER colorBy '%s' (label, schema or none)", c.label(), c.ER.ColorBy))
+	}
+	switch c.ER.ClusterBy {
+	case "", "schema", "label":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER clusterBy '%s' (schema or label)", c.label(), c.ER.ClusterBy))
+	}
+	switch c.ER.Rankdir {
+	case "TB", "LR", "BT", "RL":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER rankdir '%s' (TB, LR, BT or RL)", c.label(), c.ER.Rankdir))
+	}
+	switch c.ER.Splines {
+	case "", "none", "line", "polyline", "curved", "ortho", "spline":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER splines '%s' (none, line, polyline, curved, ortho or spline)", c.label(), c.ER.Splines))
+	}
+	switch renderer, _ := c.ER.RendererURL(); renderer {
+	case "dot":
+	case "kroki":
+		switch c.ER.Format {
+		case "png", "svg", "jpg", "jpeg", "pdf":
+		default:
+			if c.ER.IsImageFormat() {
+				return errors.WithStack(fmt.Errorf("%s: ER renderer kroki does not support format '%s' (png, svg, jpg or pdf)", c.label(), c.ER.Format))
+			}
+		}
+	case "plantuml":
+		switch c.ER.Format {
+		case "png", "svg":
+		default:
+			if c.ER.IsImageFormat() {
+				return errors.WithStack(fmt.Errorf("%s: ER renderer plantuml does not support format '%s' (png or svg)", c.label(), c.ER.Format))
+			}
+		}
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported ER renderer '%s' (dot, kroki or plantuml)", c.label(), c.ER.Renderer))
+	}
+	if c.ER.Nodesep < 0 || c.ER.Ranksep < 0 {
+		return errors.WithStack(fmt.Errorf("%s: ER nodesep and ranksep must not be negative", c.label()))
+	}
+	if c.ER.FontSize <= 0 || c.ER.DPI < 0 {
+		return errors.WithStack(fmt.Errorf("%s: ER fontSize must be positive and dpi must not be negative", c.label()))
+	}
+	if c.ER.Distance < 0 {
+		return errors.WithStack(fmt.Errorf("%s: ER distance must not be negative", c.label()))
+	}
+	if c.ER.CommentMaxLength < 0 {
+		return errors.WithStack(fmt.Errorf("%s: ER commentMaxLength must not be negative", c.label()))
+	}
+	if c.ER.SeedDistance < 0 {
+		return errors.WithStack(fmt.Errorf("%s: ER seedDistance must not be negative", c.label()))
+	}
+	switch c.Format.Wiki {
+	case "", "gitlab", "bitbucket", "github":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported wiki '%s' (gitlab, bitbucket or github)", c.label(), c.Format.Wiki))
+	}
+	switch c.Link.Style {
+	case "", "relative", "noext":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported link style '%s'", c.label(), c.Link.Style))
+	}
+	switch c.IAMAuth.Provider {
+	case "", "aws", "gcp":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported IAM auth provider '%s' (aws or gcp)", c.label(), c.IAMAuth.Provider))
+	}
+	switch c.TLS.Mode {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		return errors.WithStack(fmt.Errorf("%s: unsupported TLS mode '%s' (disable, require, verify-ca or verify-full)", c.label(), c.TLS.Mode))
+	}
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		return errors.WithStack(fmt.Errorf("%s: TLS cert and key must be set together", c.label()))
+	}
+	if c.TLS.Mode == "disable" && (c.TLS.CA != "" || c.TLS.Cert != "" || c.TLS.ServerName != "") {
+		return errors.WithStack(fmt.Errorf("%s: TLS ca, cert and serverName can not be set with mode 'disable'", c.label()))
+	}
+	for i, w := range c.Notify.Webhooks {
+		if w.URL == "" {
+			return errors.WithStack(fmt.Errorf("%s: notify.webhooks[%d]: url is required", c.label(), i))
+		}
+	}
+	return nil
+}
+
+func (c *Config) label() string {
+	if c.Name != "" {
+		return fmt.Sprintf("target '%s'", c.Name)
+	}
+	if c.DocPath != "" {
+		return fmt.Sprintf("target '%s'", c.DocPath)
+	}
+	return "target"
+}
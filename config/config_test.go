@@ -0,0 +1,1289 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestLoad(t *testing.T) {
+	c := New()
+	err := c.Load(filepath.Join(testdataDir(), "config_test_tbls.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "pg://root:pgpass@localhost:55432/testdb?sslmode=disable"
+	actual := string(c.DSN)
+	if actual != expected {
+		t.Errorf("actual %v\nwant %v", actual, expected)
+	}
+	if !c.Format.Adjust {
+		t.Errorf("actual %v\nwant %v", c.Format.Adjust, true)
+	}
+	expected2 := "svg"
+	actual2 := c.ER.Format
+	if actual2 != expected2 {
+		t.Errorf("actual %v\nwant %v", actual2, expected2)
+	}
+}
+
+func TestLoadBytesAdditionalData(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Paths
+	}{
+		{"additionalData: a.yml", Paths{"a.yml"}},
+		{"additionalData:\n  - a.yml\n  - additional_data", Paths{"a.yml", "additional_data"}},
+	}
+	for _, tt := range tests {
+		c := New()
+		err := c.LoadBytes([]byte(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.AdditionalData) != len(tt.want) {
+			t.Fatalf("actual %v\nwant %v", c.AdditionalData, tt.want)
+		}
+		for i := range tt.want {
+			if c.AdditionalData[i] != tt.want[i] {
+				t.Errorf("actual %v\nwant %v", c.AdditionalData, tt.want)
+			}
+		}
+	}
+}
+
+func TestLoadBytesTimeout(t *testing.T) {
+	c := New()
+	err := c.LoadBytes([]byte("timeout: 5m\nqueryTimeout: 30s\ndocs:\n  - dsn: my://root:mypass@localhost:33306/testdb\n    docPath: dbdoc\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := c.Targets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].Timeout != 5*time.Minute {
+		t.Errorf("actual %v\nwant %v", targets[0].Timeout, 5*time.Minute)
+	}
+	if targets[0].QueryTimeout != 30*time.Second {
+		t.Errorf("actual %v\nwant %v", targets[0].QueryTimeout, 30*time.Second)
+	}
+
+	targets[0].QueryTimeout = -time.Second
+	if err := targets[0].Validate(); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestLoadBytesUnknownKey(t *testing.T) {
+	c := New()
+	err := c.LoadBytes([]byte("dsn: my://root:mypass@localhost:33306/testdb\ndocPath: dbdoc\nformat:\n  ajust: true\n"))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	expected := "line 4: field ajust not found"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("actual %v\nwant %v", err.Error(), expected)
+	}
+}
+
+func TestLoadBytesLintExtends(t *testing.T) {
+	ts := httptest.NewServer(http.FileServer(http.Dir(testdataDir())))
+	defer ts.Close()
+
+	for _, p := range []string{filepath.Join(testdataDir(), "config_test_lint_preset.yml"), fmt.Sprintf("%s/config_test_lint_preset.yml", ts.URL)} {
+		c := New()
+		err := c.LoadBytes([]byte(fmt.Sprintf("lint:\n  extends: %s\n  requireColumnComment:\n    severity: error\n  columnCount:\n    max: 30\n", p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.Lint.RequireTableComment.Enabled || len(c.Lint.RequireTableComment.Exclude) != 1 {
+			t.Errorf("actual %v\nwant %v", c.Lint.RequireTableComment, "inherited from the base preset")
+		}
+		if !c.Lint.RequireColumnComment.Enabled || c.Lint.RequireColumnComment.Severity != "error" {
+			t.Errorf("actual %v\nwant %v", c.Lint.RequireColumnComment, "severity overridden")
+		}
+		if !c.Lint.ColumnCount.Enabled || c.Lint.ColumnCount.Max != 30 {
+			t.Errorf("actual %v\nwant %v", c.Lint.ColumnCount, "max overridden")
+		}
+	}
+}
+
+func TestLoadLintExtends(t *testing.T) {
+	c := New()
+	err := c.Load(filepath.Join(testdataDir(), "config_test_lint_extends.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Lint.RequireTableComment.Enabled || len(c.Lint.RequireTableComment.Exclude) != 1 {
+		t.Errorf("actual %v\nwant %v", c.Lint.RequireTableComment, "inherited from the base preset")
+	}
+	if !c.Lint.ColumnCount.Enabled || c.Lint.ColumnCount.Max != 50 {
+		t.Errorf("actual %v\nwant %v", c.Lint.ColumnCount, "inherited from the preset")
+	}
+}
+
+func TestLoadBytesLintExtendsError(t *testing.T) {
+	tests := []string{
+		"lint:\n  extends: testdata/not_found.yml\n",
+		"lint:\n  extends: ../testdata/config_test_tbls.yml\n",
+		"lint:\n  extends: ../testdata/config_test_lint_preset_circular.yml\n",
+	}
+	for _, in := range tests {
+		c := New()
+		err := c.LoadBytes([]byte(in))
+		if err == nil {
+			t.Errorf("%s: actual %v\nwant %v", in, err, "error")
+		}
+	}
+}
+
+func TestERSkipDiagrams(t *testing.T) {
+	tests := []struct {
+		er             ER
+		tableCount     int
+		wantSkipSchema bool
+		wantSkipTables bool
+	}{
+		{ER{}, 10, false, false},
+		{ER{Skip: true}, 10, true, true},
+		{ER{SkipSchema: true}, 10, true, false},
+		{ER{SkipTables: true}, 10, false, true},
+		{ER{SchemaMaxTables: 10}, 10, false, false},
+		{ER{SchemaMaxTables: 10}, 11, true, false},
+		{ER{SchemaMaxTables: 10, Oversize: "skip"}, 11, true, false},
+		{ER{SchemaMaxTables: 10, Oversize: "keys"}, 11, false, false},
+		{ER{SchemaMaxTables: 10, Oversize: "none"}, 11, false, false},
+		{ER{SchemaMaxTables: 10, Seeds: []string{"t1"}}, 11, false, false},
+	}
+	for _, tt := range tests {
+		s := &schema.Schema{}
+		for i := 0; i < tt.tableCount; i++ {
+			s.Tables = append(s.Tables, &schema.Table{Name: fmt.Sprintf("t%d", i)})
+		}
+		if got := tt.er.SkipSchemaDiagram(s); got != tt.wantSkipSchema {
+			t.Errorf("%+v: actual %v\nwant %v", tt.er, got, tt.wantSkipSchema)
+		}
+		if got := tt.er.SkipTableDiagrams(); got != tt.wantSkipTables {
+			t.Errorf("%+v: actual %v\nwant %v", tt.er, got, tt.wantSkipTables)
+		}
+	}
+}
+
+func TestERSchemaDiagramER(t *testing.T) {
+	s := &schema.Schema{Name: "testschema"}
+	for _, n := range []string{"a", "b", "c"} {
+		s.Tables = append(s.Tables, &schema.Table{Name: n})
+	}
+	s.Relations = []*schema.Relation{
+		&schema.Relation{Table: s.Tables[1], ParentTable: s.Tables[0]},
+		&schema.Relation{Table: s.Tables[2], ParentTable: s.Tables[0]},
+	}
+	tests := []struct {
+		er          ER
+		wantColumns string
+	}{
+		{ER{Oversize: "none"}, ""},
+		{ER{SchemaMaxTables: 3, Oversize: "none"}, ""},
+		{ER{SchemaMaxTables: 2, Oversize: "none"}, "none"},
+		{ER{SchemaMaxRelations: 2, Oversize: "keys"}, ""},
+		{ER{SchemaMaxRelations: 1, Oversize: "keys"}, "keys"},
+		{ER{SchemaMaxRelations: 1, Oversize: "skip"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.er.SchemaDiagramER(s).Columns; got != tt.wantColumns {
+			t.Errorf("%+v: actual %v\nwant %v", tt.er, got, tt.wantColumns)
+		}
+	}
+}
+
+func TestValidateERDistance(t *testing.T) {
+	tests := []struct {
+		distance int
+		wantErr  bool
+	}{
+		{0, false},
+		{2, false},
+		{-1, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.ER.Distance = tt.distance
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%d: actual %v\nwant %v", tt.distance, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateERColumns(t *testing.T) {
+	tests := []struct {
+		columns string
+		wantErr bool
+	}{
+		{"", false},
+		{"all", false},
+		{"keys", false},
+		{"none", false},
+		{"pk", true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.ER.Columns = tt.columns
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: actual %v\nwant %v", tt.columns, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateIndexPages(t *testing.T) {
+	tests := []struct {
+		pages   IndexPages
+		wantErr bool
+	}{
+		{IndexPages{}, false},
+		{IndexPages{Threshold: 500, By: "alphabet"}, false},
+		{IndexPages{Threshold: 500, By: "schema"}, false},
+		{IndexPages{Threshold: 500, By: "label"}, false},
+		{IndexPages{Threshold: 500, By: "size"}, true},
+		{IndexPages{Threshold: -1}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.Format.IndexPages = tt.pages
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.pages, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateSamples(t *testing.T) {
+	tests := []struct {
+		samples Samples
+		wantErr bool
+	}{
+		{Samples{}, false},
+		{Samples{Rows: 3, Tables: []string{"users"}}, false},
+		{Samples{Rows: 3}, true},
+		{Samples{Rows: -1, Tables: []string{"users"}}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.Samples = tt.samples
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.samples, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateColumnStats(t *testing.T) {
+	tests := []struct {
+		stats   ColumnStats
+		wantErr bool
+	}{
+		{ColumnStats{}, false},
+		{ColumnStats{Tables: []string{"*"}, SampleSize: 10000}, false},
+		{ColumnStats{Tables: []string{"*"}, SampleSize: -1}, true},
+		{ColumnStats{Tables: []string{"*"}, Percentiles: []int{0, 50, 100}}, false},
+		{ColumnStats{Tables: []string{"*"}, Percentiles: []int{101}}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.ColumnStats = tt.stats
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.stats, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateEnumValues(t *testing.T) {
+	tests := []struct {
+		enumValues EnumValues
+		wantErr    bool
+	}{
+		{EnumValues{}, false},
+		{EnumValues{Tables: []string{"*"}, Threshold: 20, SampleSize: 10000}, false},
+		{EnumValues{Tables: []string{"*"}, Threshold: -1}, true},
+		{EnumValues{Tables: []string{"*"}, SampleSize: -1}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.EnumValues = tt.enumValues
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.enumValues, err, tt.wantErr)
+		}
+	}
+	if got := (EnumValues{}).Max(); got != DefaultEnumValuesThreshold {
+		t.Errorf("actual %v\nwant %v", got, DefaultEnumValuesThreshold)
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		storage Storage
+		wantErr bool
+	}{
+		{Storage{}, false},
+		{Storage{Enabled: true, Top: 20}, false},
+		{Storage{Enabled: true, Top: -1}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.Storage = tt.storage
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.storage, err, tt.wantErr)
+		}
+	}
+	if got := (Storage{}).N(); got != DefaultStorageTop {
+		t.Errorf("actual %v\nwant %v", got, DefaultStorageTop)
+	}
+}
+
+func TestFreshnessColumn(t *testing.T) {
+	f := Freshness{Columns: []string{"orders.paid_at", "updated_at", "created_at"}}
+	tests := []struct {
+		table   *schema.Table
+		want    string
+		wantErr bool
+	}{
+		{&schema.Table{Name: "users", Columns: []*schema.Column{{Name: "created_at"}, {Name: "updated_at"}}}, "updated_at", false},
+		{&schema.Table{Name: "orders", Columns: []*schema.Column{{Name: "updated_at"}, {Name: "paid_at"}}}, "paid_at", false},
+		{&schema.Table{Name: "logs", Columns: []*schema.Column{{Name: "created_at"}}}, "created_at", false},
+		{&schema.Table{Name: "tags", Columns: []*schema.Column{{Name: "name"}}}, "", true},
+	}
+	for _, tt := range tests {
+		got, ok := f.Column(tt.table)
+		if ok == tt.wantErr {
+			t.Errorf("%s: actual %v\nwant %v", tt.table.Name, ok, !tt.wantErr)
+			continue
+		}
+		if ok && got.Name != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.table.Name, got.Name, tt.want)
+		}
+	}
+}
+
+func TestValidateRowCount(t *testing.T) {
+	tests := []struct {
+		rowCount string
+		wantErr  bool
+	}{
+		{"", false},
+		{RowCountExact, false},
+		{RowCountEstimate, false},
+		{"approx", true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.RowCount = tt.rowCount
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.rowCount, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		wantErr bool
+	}{
+		{Profile{}, false},
+		{Profile{Metrics: []string{ProfileNulls, ProfileTopK}, TopK: 10, SampleSize: 1000}, false},
+		{Profile{Metrics: []string{"histogram"}}, true},
+		{Profile{TopK: -1}, true},
+		{Profile{SampleSize: -1}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.Profile = tt.profile
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.profile, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProfile(t *testing.T) {
+	c := New()
+	c.DocPath = "dbdoc"
+	if got, want := c.ProfilePath(), filepath.Join("dbdoc", DefaultProfileFile); got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+	if !c.Profile.Allowed("users") || !c.Profile.Enabled(ProfileTopK) || c.Profile.K() != DefaultProfileTopK {
+		t.Errorf("actual %v\nwant %v", c.Profile, "all tables and metrics")
+	}
+	c.Profile = Profile{Path: "profile/data.md", Tables: []string{"public.*"}, Metrics: []string{ProfileNulls}, TopK: 3}
+	if got, want := c.ProfilePath(), "profile/data.md"; got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+	if c.Profile.Allowed("users") || !c.Profile.Allowed("public.users") {
+		t.Errorf("actual %v\nwant %v", c.Profile.Tables, "public.* only")
+	}
+	if !c.Profile.Enabled(ProfileNulls) || c.Profile.Enabled(ProfileTopK) || c.Profile.K() != 3 {
+		t.Errorf("actual %v\nwant %v", c.Profile, "nulls only")
+	}
+}
+
+func TestValidateDetectSensitivity(t *testing.T) {
+	tests := []struct {
+		detect  DetectSensitivity
+		wantErr bool
+	}{
+		{DetectSensitivity{}, false},
+		{DetectSensitivity{Enabled: true, Strategy: "none", SampleRows: 100, Detectors: []schema.SensitivityDetector{{Name: "card", Value: `^[0-9]{16}$`}}}, false},
+		{DetectSensitivity{Enabled: true, Strategy: "strict"}, true},
+		{DetectSensitivity{Enabled: true, SampleRows: -1}, true},
+		{DetectSensitivity{Enabled: true, Detectors: []schema.SensitivityDetector{{Name: "card", Value: "("}}}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.DetectSensitivity = tt.detect
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", tt.detect, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSensitivityDetectors(t *testing.T) {
+	custom := schema.SensitivityDetector{Name: "card", Value: `^[0-9]{16}$`}
+	tests := []struct {
+		strategy string
+		want     int
+	}{
+		{"", len(schema.DefaultSensitivityDetectors) + 1},
+		{"none", 1},
+	}
+	for _, tt := range tests {
+		d := DetectSensitivity{Enabled: true, Strategy: tt.strategy, Detectors: []schema.SensitivityDetector{custom}}
+		got := d.SensitivityDetectors()
+		if len(got) != tt.want || got[len(got)-1].Name != custom.Name {
+			t.Errorf("%s: actual %v\nwant %v", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestSamplesMask(t *testing.T) {
+	s := Samples{
+		Rows:   3,
+		Tables: []string{"users", "public.*"},
+		Masks: []SampleMask{
+			{Columns: []string{"*email*"}},
+			{Columns: []string{"users.password"}, Replacement: "(secret)"},
+		},
+	}
+	tests := []struct {
+		table       string
+		column      string
+		allowed     bool
+		replacement string
+		masked      bool
+	}{
+		{"users", "id", true, "", false},
+		{"users", "email", true, DefaultSampleMaskReplacement, true},
+		{"users", "password", true, "(secret)", true},
+		{"posts", "password", false, "", false},
+		{"public.accounts", "backup_email", true, DefaultSampleMaskReplacement, true},
+	}
+	for _, tt := range tests {
+		if got := s.Allowed(tt.table); got != tt.allowed {
+			t.Errorf("%s: actual %v\nwant %v", tt.table, got, tt.allowed)
+		}
+		replacement, masked := s.Mask(tt.table, tt.column)
+		if replacement != tt.replacement || masked != tt.masked {
+			t.Errorf("%s.%s: actual %v %v\nwant %v %v", tt.table, tt.column, replacement, masked, tt.replacement, tt.masked)
+		}
+	}
+}
+
+func TestValidateERLayout(t *testing.T) {
+	tests := []struct {
+		rankdir  string
+		fontSize int
+		dpi      int
+		wantErr  bool
+	}{
+		{"TB", 14, 0, false},
+		{"LR", 10, 300, false},
+		{"XY", 14, 0, true},
+		{"", 14, 0, true},
+		{"TB", 0, 0, true},
+		{"TB", 14, -1, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.ER.Rankdir = tt.rankdir
+		c.ER.FontSize = tt.fontSize
+		c.ER.DPI = tt.dpi
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant %v", tt, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateEREdgeRouting(t *testing.T) {
+	tests := []struct {
+		splines string
+		nodesep float64
+		ranksep float64
+		wantErr bool
+	}{
+		{"", 0, 0, false},
+		{"ortho", 0.5, 1.2, false},
+		{"polyline", 0, 0, false},
+		{"zigzag", 0, 0, true},
+		{"ortho", -0.1, 0, true},
+		{"ortho", 0, -1, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.ER.Splines = tt.splines
+		c.ER.Nodesep = tt.nodesep
+		c.ER.Ranksep = tt.ranksep
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant %v", tt, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateERRenderer(t *testing.T) {
+	tests := []struct {
+		renderer string
+		format   string
+		wantErr  bool
+	}{
+		{"", "png", false},
+		{"dot", "gif", false},
+		{"kroki", "svg", false},
+		{"kroki:https://kroki.example.com", "pdf", false},
+		{"kroki", "gif", true},
+		{"plantuml", "png", false},
+		{"plantuml", "jpg", true},
+		{"plantuml", "plantuml", false},
+		{"mermaid", "png", true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.ER.Renderer = tt.renderer
+		c.ER.Format = tt.format
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant %v", tt, err, tt.wantErr)
+		}
+	}
+}
+
+func TestERRendererURL(t *testing.T) {
+	tests := []struct {
+		renderer string
+		want     string
+		wantURL  string
+	}{
+		{"", "dot", ""},
+		{"kroki", "kroki", DefaultKrokiURL},
+		{"kroki:http://localhost:8000", "kroki", "http://localhost:8000"},
+		{"plantuml", "plantuml", DefaultPlantUMLServerURL},
+	}
+	for _, tt := range tests {
+		got, gotURL := (ER{Renderer: tt.renderer}).RendererURL()
+		if got != tt.want || gotURL != tt.wantURL {
+			t.Errorf("actual %v %v\nwant %v %v", got, gotURL, tt.want, tt.wantURL)
+		}
+	}
+}
+
+func TestWikiLayout(t *testing.T) {
+	tests := []struct {
+		wiki    string
+		index   string
+		sidebar string
+		image   string
+		link    string
+	}{
+		{"", "README.md", "", "schema.png", "users.md"},
+		{"gitlab", "home.md", "_sidebar.md", "uploads/schema.png", "users"},
+		{"bitbucket", "Home.md", "", "schema.png", "users"},
+		{"github", "Home.md", "_Sidebar.md", "schema.png", "users"},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.Format.Wiki = tt.wiki
+		if got := c.IndexFileName(); got != tt.index {
+			t.Errorf("%s: actual %v\nwant %v", tt.wiki, got, tt.index)
+		}
+		if got := c.SidebarFileName(); got != tt.sidebar {
+			t.Errorf("%s: actual %v\nwant %v", tt.wiki, got, tt.sidebar)
+		}
+		if got := c.ImagePath("schema.png"); got != tt.image {
+			t.Errorf("%s: actual %v\nwant %v", tt.wiki, got, tt.image)
+		}
+		if got := c.TableLink("users"); got != tt.link {
+			t.Errorf("%s: actual %v\nwant %v", tt.wiki, got, tt.link)
+		}
+	}
+}
+
+func TestERTableColor(t *testing.T) {
+	red := &schema.Label{Name: "red", Color: "#FF0000"}
+	plain := &schema.Label{Name: "plain"}
+	palette := []string{"#000001"}
+	tests := []struct {
+		er    ER
+		table *schema.Table
+		want  string
+	}{
+		{ER{}, &schema.Table{Name: "users"}, ""},
+		{ER{}, &schema.Table{Name: "users", Labels: []*schema.Label{red}}, "#FF0000"},
+		{ER{}, &schema.Table{Name: "users", Labels: []*schema.Label{plain}}, ""},
+		{ER{Palette: palette}, &schema.Table{Name: "users", Labels: []*schema.Label{plain}}, "#000001"},
+		{ER{ColorBy: "none"}, &schema.Table{Name: "users", Labels: []*schema.Label{red}}, ""},
+		{ER{ColorBy: "schema"}, &schema.Table{Name: "users", Labels: []*schema.Label{red}}, ""},
+		{ER{ColorBy: "schema", Palette: palette}, &schema.Table{Name: "billing.invoices"}, "#000001"},
+	}
+	for i, tt := range tests {
+		got := tt.er.TableColor(tt.table)
+		if got != tt.want {
+			t.Errorf("%d: actual %v\nwant %v", i, got, tt.want)
+		}
+	}
+
+	er := ER{ColorBy: "schema"}
+	a := er.TableColor(&schema.Table{Name: "billing.invoices"})
+	b := er.TableColor(&schema.Table{Name: "billing.payments"})
+	if a == "" || a != b {
+		t.Errorf("actual %v\nwant %v", b, a)
+	}
+}
+
+func TestERThemeColors(t *testing.T) {
+	tests := []struct {
+		er             ER
+		wantBackground string
+		wantHeader     string
+	}{
+		{ER{}, "", "#EFEFEF"},
+		{ER{Theme: "light"}, "", "#EFEFEF"},
+		{ER{Theme: "dark"}, "#1E1E1E", "#3C3C3C"},
+		{ER{Colors: ERTheme{Background: "transparent"}}, "transparent", "#EFEFEF"},
+		{ER{Theme: "dark", Colors: ERTheme{Header: "#000000"}}, "#1E1E1E", "#000000"},
+	}
+	for _, tt := range tests {
+		got := tt.er.ThemeColors()
+		if got.Background != tt.wantBackground {
+			t.Errorf("%+v: actual %v\nwant %v", tt.er, got.Background, tt.wantBackground)
+		}
+		if got.Header != tt.wantHeader {
+			t.Errorf("%+v: actual %v\nwant %v", tt.er, got.Header, tt.wantHeader)
+		}
+	}
+	if DefaultERThemes["light"].Background != "" {
+		t.Errorf("actual %v\nwant %v", DefaultERThemes["light"].Background, "")
+	}
+}
+
+func TestERRelationLabel(t *testing.T) {
+	def := "FOREIGN KEY (user_id) REFERENCES users (id)"
+	posts := &schema.Table{
+		Name: "posts",
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{Name: "posts_user_id_fk", Type: "FOREIGN KEY", Def: def},
+		},
+	}
+	fk := &schema.Relation{Table: posts, Def: def}
+	additional := &schema.Relation{Table: posts, Def: "Additional Relation", IsAdditional: true}
+	tests := []struct {
+		edgeLabel string
+		relation  *schema.Relation
+		want      string
+	}{
+		{"", fk, def},
+		{"def", fk, def},
+		{"name", fk, "posts_user_id_fk"},
+		{"name", additional, "Additional Relation"},
+		{"none", fk, ""},
+	}
+	for _, tt := range tests {
+		if got := (ER{EdgeLabel: tt.edgeLabel}).RelationLabel(tt.relation); got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.edgeLabel, got, tt.want)
+		}
+	}
+}
+
+func TestERLegendColors(t *testing.T) {
+	red := &schema.Label{Name: "red", Color: "#FF0000"}
+	plain := &schema.Label{Name: "plain"}
+	tables := []*schema.Table{
+		&schema.Table{Name: "users", Labels: []*schema.Label{red}},
+		&schema.Table{Name: "billing.invoices", Labels: []*schema.Label{plain}},
+		&schema.Table{Name: "billing.payments", Labels: []*schema.Label{red}},
+		&schema.Table{Name: "logs"},
+	}
+	tests := []struct {
+		er   ER
+		want string
+	}{
+		{ER{}, "red:#FF0000"},
+		{ER{ColorBy: "label", Palette: []string{"#000001"}}, "red:#FF0000,plain:#000001"},
+		{ER{ColorBy: "schema", Palette: []string{"#000001"}}, "billing:#000001"},
+		{ER{ColorBy: "none"}, ""},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, l := range tt.er.LegendColors(tables) {
+			got = append(got, fmt.Sprintf("%s:%s", l.Name, l.Color))
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%+v: actual %v\nwant %v", tt.er, strings.Join(got, ","), tt.want)
+		}
+	}
+}
+
+func TestERClusters(t *testing.T) {
+	core := &schema.Label{Name: "core"}
+	tables := []*schema.Table{
+		&schema.Table{Name: "users", Labels: []*schema.Label{core}},
+		&schema.Table{Name: "billing.invoices"},
+		&schema.Table{Name: "billing.payments", Labels: []*schema.Label{core}},
+		&schema.Table{Name: "logs"},
+	}
+	tests := []struct {
+		clusterBy string
+		want      map[string]int
+	}{
+		{"", map[string]int{}},
+		{"schema", map[string]int{"billing": 2}},
+		{"label", map[string]int{"core": 2}},
+	}
+	for _, tt := range tests {
+		got := map[string]int{}
+		for _, cl := range (ER{ClusterBy: tt.clusterBy}).Clusters(tables) {
+			got[cl.Name] = len(cl.Tables)
+		}
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) {
+			t.Errorf("%s: actual %v\nwant %v", tt.clusterBy, got, tt.want)
+		}
+	}
+}
+
+func TestERNodeComment(t *testing.T) {
+	tests := []struct {
+		maxLength int
+		text      string
+		want      string
+	}{
+		{0, "table comment", "table comment"},
+		{5, "table comment", "table..."},
+		{13, "table comment", "table comment"},
+		{2, "テーブル", "テー..."},
+	}
+	for _, tt := range tests {
+		got := (ER{CommentMaxLength: tt.maxLength}).NodeComment(tt.text)
+		if got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestERSchemaDiagram(t *testing.T) {
+	s := &schema.Schema{Name: "testschema"}
+	for _, n := range []string{"a", "b", "c", "d", "e"} {
+		s.Tables = append(s.Tables, &schema.Table{Name: n, Columns: []*schema.Column{&schema.Column{Name: "id"}}})
+	}
+	// a <- b <- c <- d, e is isolated
+	for i := 1; i < 4; i++ {
+		child := s.Tables[i]
+		parent := s.Tables[i-1]
+		r := &schema.Relation{
+			Table:         child,
+			Columns:       []*schema.Column{child.Columns[0]},
+			ParentTable:   parent,
+			ParentColumns: []*schema.Column{parent.Columns[0]},
+		}
+		child.Columns[0].ParentRelations = append(child.Columns[0].ParentRelations, r)
+		parent.Columns[0].ChildRelations = append(parent.Columns[0].ChildRelations, r)
+		s.Relations = append(s.Relations, r)
+	}
+	tests := []struct {
+		seeds         []string
+		distance      int
+		exclude       []string
+		wantTables    int
+		wantRelations int
+	}{
+		{[]string{}, 1, []string{}, 5, 3},
+		{[]string{"a"}, 0, []string{}, 1, 0},
+		{[]string{"a"}, 1, []string{}, 2, 1},
+		{[]string{"b"}, 1, []string{}, 3, 2},
+		{[]string{"a", "e"}, 2, []string{}, 4, 2},
+		{[]string{"x*"}, 1, []string{}, 0, 0},
+		{[]string{}, 1, []string{"b"}, 4, 1},
+		{[]string{"a"}, 1, []string{"b"}, 1, 0},
+		{[]string{}, 1, []string{"[a-c]"}, 2, 0},
+	}
+	for _, tt := range tests {
+		got := (ER{Seeds: tt.seeds, SeedDistance: tt.distance, Exclude: tt.exclude}).SchemaDiagram(s)
+		if len(got.Tables) != tt.wantTables {
+			t.Errorf("%v: actual %v\nwant %v", tt.seeds, len(got.Tables), tt.wantTables)
+		}
+		if len(got.Relations) != tt.wantRelations {
+			t.Errorf("%v: actual %v\nwant %v", tt.seeds, len(got.Relations), tt.wantRelations)
+		}
+	}
+	if len(s.Tables) != 5 {
+		t.Errorf("actual %v\nwant %v", len(s.Tables), 5)
+	}
+	tables, relations := (ER{Distance: 1, Exclude: []string{"c"}}).TableDiagram(s.Tables[1])
+	if len(tables) != 1 || tables[0].Name != "a" {
+		t.Errorf("actual %v\nwant %v", tables, "[a]")
+	}
+	if len(relations) != 1 {
+		t.Errorf("actual %v\nwant %v", len(relations), 1)
+	}
+	if got := (ER{Exclude: []string{"c"}}).SkipTableDiagram(s.Tables[2]); !got {
+		t.Errorf("actual %v\nwant %v", got, true)
+	}
+}
+
+func TestERDeterministic(t *testing.T) {
+	s := &schema.Schema{Name: "testschema"}
+	for _, n := range []string{"c", "a", "b"} {
+		s.Tables = append(s.Tables, &schema.Table{Name: n, Columns: []*schema.Column{&schema.Column{Name: "id"}}})
+	}
+	// c -> a, b -> a
+	for _, child := range []*schema.Table{s.Tables[0], s.Tables[2]} {
+		parent := s.Tables[1]
+		r := &schema.Relation{
+			Table:         child,
+			Columns:       []*schema.Column{child.Columns[0]},
+			ParentTable:   parent,
+			ParentColumns: []*schema.Column{parent.Columns[0]},
+		}
+		child.Columns[0].ParentRelations = append(child.Columns[0].ParentRelations, r)
+		parent.Columns[0].ChildRelations = append(parent.Columns[0].ChildRelations, r)
+		s.Relations = append(s.Relations, r)
+	}
+	tests := []struct {
+		deterministic bool
+		wantTables    string
+		wantRelations string
+		wantRelated   string
+	}{
+		{false, "c,a,b", "c,b", "c,b"},
+		{true, "a,b,c", "b,c", "b,c"},
+	}
+	for _, tt := range tests {
+		e := ER{Distance: 1, Deterministic: tt.deterministic}
+		d := e.SchemaDiagram(s)
+		tables := []string{}
+		for _, t := range d.Tables {
+			tables = append(tables, t.Name)
+		}
+		if got := strings.Join(tables, ","); got != tt.wantTables {
+			t.Errorf("actual %v\nwant %v", got, tt.wantTables)
+		}
+		relations := []string{}
+		for _, r := range d.Relations {
+			relations = append(relations, r.Table.Name)
+		}
+		if got := strings.Join(relations, ","); got != tt.wantRelations {
+			t.Errorf("actual %v\nwant %v", got, tt.wantRelations)
+		}
+		related, _ := e.TableDiagram(s.Tables[1])
+		tables = []string{}
+		for _, t := range related {
+			tables = append(tables, t.Name)
+		}
+		if got := strings.Join(tables, ","); got != tt.wantRelated {
+			t.Errorf("actual %v\nwant %v", got, tt.wantRelated)
+		}
+	}
+	if s.Tables[0].Name != "c" {
+		t.Errorf("actual %v\nwant %v", s.Tables[0].Name, "c")
+	}
+}
+
+var targetsTests = []struct {
+	name     string
+	dsn      string
+	docPath  string
+	erFormat string
+	sort     bool
+}{
+	{"users", "my://root:mypass@localhost:33306/users", "dbdoc/users", "svg", true},
+	{"billing", "pg://root:pgpass@localhost:55432/billing?sslmode=disable", "dbdoc/billing", "png", true},
+}
+
+func TestTargets(t *testing.T) {
+	c := New()
+	err := c.Load(filepath.Join(testdataDir(), "config_test_tbls_docs.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := c.Targets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != len(targetsTests) {
+		t.Fatalf("actual %v\nwant %v", len(targets), len(targetsTests))
+	}
+	for i, tt := range targetsTests {
+		target := targets[i]
+		if target.Name != tt.name {
+			t.Errorf("actual %v\nwant %v", target.Name, tt.name)
+		}
+		if string(target.DSN) != tt.dsn {
+			t.Errorf("actual %v\nwant %v", target.DSN, tt.dsn)
+		}
+		if target.DocPath != tt.docPath {
+			t.Errorf("actual %v\nwant %v", target.DocPath, tt.docPath)
+		}
+		if target.ER.Format != tt.erFormat {
+			t.Errorf("actual %v\nwant %v", target.ER.Format, tt.erFormat)
+		}
+		if target.Format.Sort != tt.sort {
+			t.Errorf("actual %v\nwant %v", target.Format.Sort, tt.sort)
+		}
+		if err := target.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+var requiredVersionTests = []struct {
+	requiredVersion string
+	wantErr         bool
+}{
+	{"", false},
+	{">= 1.5", false},
+	{">= 1.5 < 2", false},
+	{">=1.5.1, <2.0", false},
+	{"1.5.1", false},
+	{"> 1.5.1", true},
+	{">= 1.40 < 2", true},
+	{"!= 1.5.1", true},
+	{">= x", true},
+}
+
+func TestCheckRequiredVersion(t *testing.T) {
+	for _, tt := range requiredVersionTests {
+		c := New()
+		c.RequiredVersion = tt.requiredVersion
+		err := c.CheckRequiredVersion("1.5.1")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: actual %v\nwant error %v", tt.requiredVersion, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMaskCredentials(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"pg://root:pgpass@localhost:55432/testdb?sslmode=disable", "pg://root:xxxxx@localhost:55432/testdb?sslmode=disable"},
+		{"my://root:p@ss@localhost:33306/testdb", "my://root:xxxxx@localhost:33306/testdb"},
+		{"my://root@localhost:33306/testdb", "my://root@localhost:33306/testdb"},
+		{"sq:///path/to/testdb.sqlite3", "sq:///path/to/testdb.sqlite3"},
+		{"ms://localhost/testdb?user id=sa&password=secret&encrypt=true", "ms://localhost/testdb?user id=sa&password=xxxxx&encrypt=true"},
+		{`parse "pg://root:pgpass@local host/testdb": invalid character " " in host name`, `parse "pg://root:xxxxx@local host/testdb": invalid character " " in host name`},
+		{"pg://dbuser:${ssm:///tbls/password}@localhost:5432/testdb", "pg://dbuser:${ssm:///tbls/password}@localhost:5432/testdb"},
+		{"env://TBLS_DSN", "env://TBLS_DSN"},
+	}
+	for _, tt := range tests {
+		if got := MaskCredentials(tt.in); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestDSN_String(t *testing.T) {
+	dsn := DSN("pg://root:pgpass@localhost:55432/testdb")
+	want := "pg://root:xxxxx@localhost:55432/testdb"
+	if got := fmt.Sprintf("%s", dsn); got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+	if got := fmt.Sprintf("%v", fmt.Errorf("failed to connect %s", dsn)); got != "failed to connect "+want {
+		t.Errorf("actual %v\nwant %v", got, "failed to connect "+want)
+	}
+}
+
+func TestResolveDSN(t *testing.T) {
+	os.Setenv("TBLS_TEST_DSN", "my://root:mypass@localhost:33306/testdb")
+	defer os.Unsetenv("TBLS_TEST_DSN")
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		switch {
+		case name == "vault":
+			return []byte("pg://vault:pass@localhost:55432/testdb\n"), nil
+		case name == "aws" && args[0] == "ssm":
+			return []byte(fmt.Sprintf("%s\n", args[3])), nil
+		case name == "gcloud":
+			return []byte(fmt.Sprintf(`{"password":"p@ss/%s"}`, strings.Join(args[3:], " "))), nil
+		}
+		return []byte(`{"dsn":"my://aws:pass@localhost:33306/testdb"}`), nil
+	}
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"pg://root:pgpass@localhost:55432/testdb?sslmode=disable", "pg://root:pgpass@localhost:55432/testdb?sslmode=disable"},
+		{"env://TBLS_TEST_DSN", "my://root:mypass@localhost:33306/testdb"},
+		{fmt.Sprintf("envfile://%s#TBLS_DSN", filepath.Join(testdataDir(), "config_test.env")), "pg://root:pgpass@localhost:55432/testdb?sslmode=disable"},
+		{"vault://secret/tbls", "pg://vault:pass@localhost:55432/testdb"},
+		{"awssecrets://tbls#dsn", "my://aws:pass@localhost:33306/testdb"},
+		{"ssm:///tbls/dsn", "/tbls/dsn"},
+		{"gcpsecrets://tbls#password", "p@ss/latest --secret=tbls"},
+		{"gcpsecrets://projects/myproject/secrets/tbls/versions/3#password", "p@ss/3 --secret=tbls --project=myproject"},
+		{"pg://dbuser:${gcpsecrets://tbls#password}@${ssm:///tbls/host}:5432/testdb", "pg://dbuser:p%40ss%2Flatest%20--secret=tbls@%2Ftbls%2Fhost:5432/testdb"},
+		{"pg://dbuser:pa${ss}@localhost/testdb", "pg://dbuser:pa${ss}@localhost/testdb"},
+		{"pg://dbuser:${TBLS_PASSWORD}@localhost/testdb", "pg://dbuser:${TBLS_PASSWORD}@localhost/testdb"},
+		{"pg://dbuser:pa$${env://TBLS_TEST_DSN}@${ssm:///tbls/host}/testdb", "pg://dbuser:pa${env://TBLS_TEST_DSN}@%2Ftbls%2Fhost/testdb"},
+	}
+	for _, tt := range tests {
+		actual, err := ResolveDSN(tt.dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != tt.want {
+			t.Errorf("actual %v\nwant %v", actual, tt.want)
+		}
+	}
+	for _, dsn := range []string{"pg://dbuser:${env://TBLS_TEST_UNSET}@localhost/testdb", "gcpsecrets://projects/myproject/tbls"} {
+		_, err := ResolveDSN(dsn)
+		if err == nil {
+			t.Errorf("%s: actual %v\nwant %v", dsn, err, "error")
+		}
+	}
+}
+
+func testdataDir() string {
+	wd, _ := os.Getwd()
+	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(wd), "testdata"))
+	return dir
+}
+
+func TestViewpointSchema(t *testing.T) {
+	s := &schema.Schema{Name: "testschema"}
+	for _, n := range []string{"users", "user_options", "posts", "comments"} {
+		s.Tables = append(s.Tables, &schema.Table{Name: n})
+	}
+	s.Tables[3].Labels = []*schema.Label{&schema.Label{Name: "blog"}}
+	s.Relations = []*schema.Relation{
+		&schema.Relation{Table: s.Tables[1], ParentTable: s.Tables[0]},
+		&schema.Relation{Table: s.Tables[2], ParentTable: s.Tables[0]},
+		&schema.Relation{Table: s.Tables[3], ParentTable: s.Tables[2]},
+	}
+	tests := []struct {
+		viewpoint     Viewpoint
+		wantTables    []string
+		wantRelations int
+	}{
+		{Viewpoint{Name: "user", Tables: []string{"user*"}}, []string{"users", "user_options"}, 1},
+		{Viewpoint{Name: "blog", Tables: []string{"posts"}, Labels: []string{"blog"}}, []string{"posts", "comments"}, 1},
+		{Viewpoint{Name: "all", Tables: []string{"*"}}, []string{"users", "user_options", "posts", "comments"}, 3},
+		{Viewpoint{Name: "none"}, []string{}, 0},
+	}
+	for _, tt := range tests {
+		got := tt.viewpoint.Schema(s)
+		names := []string{}
+		for _, t := range got.Tables {
+			names = append(names, t.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.wantTables, ",") {
+			t.Errorf("%s: actual %v\nwant %v", tt.viewpoint.Name, names, tt.wantTables)
+		}
+		if len(got.Relations) != tt.wantRelations {
+			t.Errorf("%s: actual %v\nwant %v", tt.viewpoint.Name, len(got.Relations), tt.wantRelations)
+		}
+	}
+	if len(s.Tables) != 4 {
+		t.Errorf("actual %v\nwant %v", len(s.Tables), 4)
+	}
+	if got := (ER{Seeds: []string{"users"}}).ViewpointER().Seeds; got != nil {
+		t.Errorf("actual %v\nwant %v", got, nil)
+	}
+}
+
+func TestValidateConfluence(t *testing.T) {
+	tests := []struct {
+		confluence Confluence
+		wantErr    bool
+	}{
+		{Confluence{URL: "https://example.atlassian.net/wiki", Space: "DOC", Token: "env://CONFLUENCE_TOKEN"}, false},
+		{Confluence{URL: "https://example.atlassian.net/wiki", Token: "env://CONFLUENCE_TOKEN"}, true},
+		{Confluence{URL: "https://example.atlassian.net/wiki", Space: "DOC"}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.Publish.Confluence = tt.confluence
+		err := c.ValidateConfluence()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant error %v", tt.confluence, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateNotion(t *testing.T) {
+	tests := []struct {
+		notion  Notion
+		wantErr bool
+	}{
+		{Notion{ParentID: "0123456789abcdef", Token: "env://NOTION_TOKEN"}, false},
+		{Notion{Token: "env://NOTION_TOKEN"}, true},
+		{Notion{ParentID: "0123456789abcdef"}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.Publish.Notion = tt.notion
+		err := c.ValidateNotion()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant error %v", tt.notion, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDataHub(t *testing.T) {
+	c := New()
+	if err := c.ValidateDataHub(); err == nil {
+		t.Errorf("actual %v\nwant error", err)
+	}
+	c.Publish.DataHub.URL = "http://localhost:8080"
+	if err := c.ValidateDataHub(); err != nil {
+		t.Errorf("actual %v\nwant %v", err, nil)
+	}
+}
+
+func TestValidateOpenLineage(t *testing.T) {
+	c := New()
+	if err := c.ValidateOpenLineage(); err == nil {
+		t.Errorf("actual %v\nwant error", err)
+	}
+	c.Publish.OpenLineage.URL = "http://localhost:5000"
+	if err := c.ValidateOpenLineage(); err != nil {
+		t.Errorf("actual %v\nwant %v", err, nil)
+	}
+}
+
+func TestValidateNotifyWebhooks(t *testing.T) {
+	tests := []struct {
+		webhooks []CustomWebhook
+		wantErr  bool
+	}{
+		{nil, false},
+		{[]CustomWebhook{CustomWebhook{URL: "https://example.com/hook"}}, false},
+		{[]CustomWebhook{CustomWebhook{Headers: map[string]string{"Authorization": "env://TOKEN"}}}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "my://root:mypass@localhost:33306/testdb"
+		c.DocPath = "dbdoc"
+		c.Notify.Webhooks = tt.webhooks
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant %v", tt, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateIAMAuth(t *testing.T) {
+	tests := []struct {
+		provider string
+		wantErr  bool
+	}{
+		{"", false},
+		{"aws", false},
+		{"gcp", false},
+		{"azure", true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "pg://dbuser@localhost:55432/testdb"
+		c.DocPath = "dbdoc"
+		c.IAMAuth.Provider = tt.provider
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant %v", tt, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		tls     TLS
+		wantErr bool
+	}{
+		{TLS{}, false},
+		{TLS{Mode: "verify-full", CA: "ca.pem", Cert: "client.pem", Key: "client-key.pem", ServerName: "db.internal"}, false},
+		{TLS{Mode: "disable"}, false},
+		{TLS{Mode: "prefer"}, true},
+		{TLS{Cert: "client.pem"}, true},
+		{TLS{Mode: "disable", CA: "ca.pem"}, true},
+	}
+	for _, tt := range tests {
+		c := New()
+		c.DSN = "pg://dbuser@localhost:55432/testdb"
+		c.DocPath = "dbdoc"
+		c.TLS = tt.tls
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: actual %v\nwant %v", tt, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTLS_SSLMode(t *testing.T) {
+	tests := []struct {
+		tls  TLS
+		want string
+	}{
+		{TLS{Cert: "client.pem", Key: "client-key.pem"}, "require"},
+		{TLS{CA: "ca.pem"}, "verify-full"},
+		{TLS{Mode: "verify-ca", CA: "ca.pem"}, "verify-ca"},
+	}
+	for _, tt := range tests {
+		if got := tt.tls.SSLMode(); got != tt.want {
+			t.Errorf("%+v: actual %v\nwant %v", tt.tls, got, tt.want)
+		}
+	}
+}
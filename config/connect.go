@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// Connect is the struct for retries of connecting to the database
+type Connect struct {
+	// Retries is the number of retries when connecting to the database fails
+	Retries int `yaml:"retries,omitempty"`
+	// Backoff is the interval before the first retry. It doubles on each retry.
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+	// Wait is the duration to keep retrying until the database is ready, regardless of Retries
+	Wait time.Duration `yaml:"wait,omitempty"`
+}
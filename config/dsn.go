@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultVaultField is the default field name of the DSN stored in Vault
+const DefaultVaultField = "dsn"
+
+// maskedCredential replaces passwords in DSNs (same as url.URL.Redacted)
+const maskedCredential = "xxxxx"
+
+var (
+	reDSNPassword      = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^:@/?#\s"']*:)[^/?#\s"']*@`)
+	reDSNPasswordParam = regexp.MustCompile(`(?i)([?&;](?:password|passwd|pwd|pass|secret|token)=)[^&;\s"']*`)
+)
+
+// DSN is the data source name of the database, or the reference to it (e.g. env://DATABASE_URL).
+// String() masks passwords, so it is safe to be printed in logs and errors.
+type DSN string
+
+// String return the DSN with passwords masked
+func (d DSN) String() string {
+	return MaskCredentials(string(d))
+}
+
+// MaskCredentials mask passwords of DSNs in the text (`user:password@` of URLs and `password=` of query strings), e.g. in error messages of drivers.
+func MaskCredentials(text string) string {
+	text = reDSNPassword.ReplaceAllString(text, fmt.Sprintf("${1}%s@", maskedCredential))
+	return reDSNPasswordParam.ReplaceAllString(text, fmt.Sprintf("${1}%s", maskedCredential))
+}
+
+// runCommand run external command (vault, aws) and return stdout
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// ResolveDSN resolve DSN reference at runtime.
+//
+//	env://NAME                   value of the environment variable NAME
+//	envfile://path/to/.env#KEY   value of KEY in the environment file
+//	dsnfile://path/to/file       content of the file
+//	vault://secret/path#field    field of the Vault secret (using `vault` command)
+//	awssecrets://secret-id#key   AWS Secrets Manager secret or key of JSON secret (using `aws` command)
+//	ssm:///parameter/name        AWS Systems Manager Parameter Store parameter (using `aws` command)
+//	gcpsecrets://secret#key      latest version of GCP Secret Manager secret or key of JSON secret (using `gcloud` command).
+//	                             projects/PROJECT/secrets/SECRET/versions/VERSION is also available
+//
+// References of the schemes above in `${...}` in DSN are resolved and URL-escaped as DSN components, e.g. pg://user:${ssm:///db/password}@host/db.
+// `$${...}` is the literal `${...}`, and `${...}` without the schemes above is left as it is.
+// Other DSN is returned as it is.
+func ResolveDSN(dsn string) (string, error) {
+	if reDSNComponent.MatchString(dsn) {
+		return resolveDSNComponents(dsn)
+	}
+	i := strings.Index(dsn, "://")
+	if i < 0 {
+		return dsn, nil
+	}
+	scheme := dsn[:i]
+	ref := dsn[i+3:]
+	key := ""
+	if j := strings.LastIndex(ref, "#"); j >= 0 {
+		key = ref[j+1:]
+		ref = ref[:j]
+	}
+
+	switch scheme {
+	case "env":
+		v := os.Getenv(ref)
+		if v == "" {
+			return "", errors.WithStack(fmt.Errorf("environment variable '%s' is not set", ref))
+		}
+		return v, nil
+	case "envfile":
+		if key == "" {
+			return "", errors.WithStack(fmt.Errorf("invalid DSN reference '%s': key is required", dsn))
+		}
+		return lookupEnvFile(ref, key)
+	case "dsnfile":
+		buf, err := ioutil.ReadFile(ref)
+		if err != nil {
+			return "", errors.Wrap(errors.WithStack(err), "failed to read DSN file")
+		}
+		return strings.TrimSpace(string(buf)), nil
+	case "vault":
+		if key == "" {
+			key = DefaultVaultField
+		}
+		out, err := runCommand("vault", "kv", "get", fmt.Sprintf("-field=%s", key), ref)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get DSN from Vault")
+		}
+		return strings.TrimSpace(string(out)), nil
+	case "awssecrets":
+		out, err := runCommand("aws", "secretsmanager", "get-secret-value", "--secret-id", ref, "--query", "SecretString", "--output", "text")
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get DSN from AWS Secrets Manager")
+		}
+		return secretValue(strings.TrimSpace(string(out)), ref, key)
+	case "ssm":
+		out, err := runCommand("aws", "ssm", "get-parameter", "--name", ref, "--with-decryption", "--query", "Parameter.Value", "--output", "text")
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get DSN from AWS Systems Manager Parameter Store")
+		}
+		return strings.TrimSpace(string(out)), nil
+	case "gcpsecrets":
+		args, err := gcpSecretArgs(ref)
+		if err != nil {
+			return "", err
+		}
+		out, err := runCommand("gcloud", args...)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get DSN from GCP Secret Manager")
+		}
+		return secretValue(strings.TrimSpace(string(out)), ref, key)
+	}
+	return dsn, nil
+}
+
+// reDSNComponent matches references of DSN components `${scheme://...}`, and escaped ones `$${scheme://...}`
+var reDSNComponent = regexp.MustCompile(`(\$?)\$\{((?:env|envfile|dsnfile|vault|awssecrets|ssm|gcpsecrets)://[^}]+)\}`)
+
+// resolveDSNComponents resolve references in `${...}` and replace them with URL-escaped values
+func resolveDSNComponents(dsn string) (string, error) {
+	var rerr error
+	resolved := reDSNComponent.ReplaceAllStringFunc(dsn, func(m string) string {
+		if rerr != nil {
+			return m
+		}
+		sm := reDSNComponent.FindStringSubmatch(m)
+		if sm[1] != "" {
+			// escaped
+			return m[1:]
+		}
+		v, err := ResolveDSN(sm[2])
+		if err != nil {
+			rerr = err
+			return m
+		}
+		return strings.TrimPrefix(url.UserPassword("", v).String(), ":")
+	})
+	if rerr != nil {
+		return "", rerr
+	}
+	return resolved, nil
+}
+
+// gcpSecretArgs return arguments of `gcloud` command to access the secret version
+func gcpSecretArgs(ref string) ([]string, error) {
+	project := ""
+	secret := ref
+	version := "latest"
+	if strings.HasPrefix(ref, "projects/") {
+		parts := strings.Split(ref, "/")
+		if (len(parts) != 4 && len(parts) != 6) || parts[2] != "secrets" || (len(parts) == 6 && parts[4] != "versions") {
+			return nil, errors.WithStack(fmt.Errorf("invalid GCP Secret Manager secret '%s'", ref))
+		}
+		project = parts[1]
+		secret = parts[3]
+		if len(parts) == 6 {
+			version = parts[5]
+		}
+	}
+	args := []string{"secrets", "versions", "access", version, fmt.Sprintf("--secret=%s", secret)}
+	if project != "" {
+		args = append(args, fmt.Sprintf("--project=%s", project))
+	}
+	return args, nil
+}
+
+// secretValue return the secret, or the value of the key when the secret is JSON and the key is specified
+func secretValue(secret string, ref string, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	values := map[string]interface{}{}
+	err := json.Unmarshal([]byte(secret), &values)
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to parse secret '%s' as JSON", ref))
+	}
+	v, ok := values[key]
+	if !ok {
+		return "", errors.WithStack(fmt.Errorf("key '%s' not found in secret '%s'", key, ref))
+	}
+	return fmt.Sprintf("%v", v), nil
+}
+
+func lookupEnvFile(path string, key string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), "failed to read environment file")
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != key {
+			continue
+		}
+		v := strings.TrimSpace(kv[1])
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		return v, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return "", errors.WithStack(fmt.Errorf("key '%s' not found in environment file '%s'", key, path))
+}
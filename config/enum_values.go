@@ -0,0 +1,32 @@
+package config
+
+// DefaultEnumValuesThreshold is the max number of distinct values listed when enumValues.threshold is not set
+const DefaultEnumValuesThreshold = 10
+
+// EnumValues is the struct for listing distinct values of low-cardinality columns (de facto enums) in the documents
+type EnumValues struct {
+	// Tables is the allowlist of tables (glob patterns) to list values of columns
+	Tables []string `yaml:"tables,omitempty"`
+	// Threshold is the max number of distinct values of columns whose values are listed
+	Threshold int `yaml:"threshold,omitempty"`
+	// SampleSize is the number of rows of each table scanned for values. All rows are scanned when it is zero.
+	SampleSize int `yaml:"sampleSize,omitempty"`
+}
+
+// Enabled return whether values of columns are listed
+func (e EnumValues) Enabled() bool {
+	return len(e.Tables) > 0
+}
+
+// Allowed return whether values of columns of the table are listed
+func (e EnumValues) Allowed(table string) bool {
+	return match(e.Tables, table)
+}
+
+// Max return the max number of distinct values of columns whose values are listed
+func (e EnumValues) Max() int {
+	if e.Threshold > 0 {
+		return e.Threshold
+	}
+	return DefaultEnumValuesThreshold
+}
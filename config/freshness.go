@@ -0,0 +1,26 @@
+package config
+
+import "github.com/k1LoW/tbls/schema"
+
+// Freshness is the struct for the last written time (MAX of the timestamp column) of tables in the documents
+type Freshness struct {
+	// Columns is glob patterns of timestamp column names or `table.column`. The column matching the first pattern is used.
+	Columns []string `yaml:"columns,omitempty"`
+}
+
+// Enabled return whether the last written time of tables is collected
+func (f Freshness) Enabled() bool {
+	return len(f.Columns) > 0
+}
+
+// Column return the timestamp column of the table, and whether the table has it
+func (f Freshness) Column(t *schema.Table) (*schema.Column, bool) {
+	for _, p := range f.Columns {
+		for _, c := range t.Columns {
+			if match([]string{p}, c.Name) || match([]string{p}, t.Name+"."+c.Name) {
+				return c, true
+			}
+		}
+	}
+	return nil, false
+}
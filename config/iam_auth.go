@@ -0,0 +1,16 @@
+package config
+
+// IAMAuth is the struct for the database authentication with the token of cloud IAM instead of the password
+//
+//	aws: AWS RDS IAM authentication token (using `aws rds generate-db-auth-token`)
+//	gcp: Cloud SQL IAM database authentication with the access token (using `gcloud auth print-access-token`)
+type IAMAuth struct {
+	Provider string `yaml:"provider"`
+	Region   string `yaml:"region,omitempty"`
+	Profile  string `yaml:"profile,omitempty"`
+}
+
+// Enabled return whether the IAM authentication is configured
+func (a IAMAuth) Enabled() bool {
+	return a.Provider != ""
+}
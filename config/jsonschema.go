@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// jsonSchemaTypes are the schemas of types unmarshaled in their own way
+var jsonSchemaTypes = map[reflect.Type]map[string]interface{}{
+	reflect.TypeOf(Paths{}): {
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	},
+	// durations are strings like "30s" or nanoseconds
+	reflect.TypeOf(time.Duration(0)): {"type": []string{"string", "integer"}},
+	// entries of docs are unmarshaled into Config
+	reflect.TypeOf(yaml.MapSlice{}): {"$ref": "#/definitions/Config"},
+}
+
+// JSONSchema return the JSON Schema of the config file generated from the fields of Config.
+// Unknown keys are not allowed in the same way as loading the config file.
+func JSONSchema() ([]byte, error) {
+	return jsonSchema(reflect.TypeOf(Config{}), "tbls config")
+}
+
+// AdditionalDataJSONSchema return the JSON Schema of the additional data file generated from the fields of schema.AdditionalData.
+// Unknown keys are not allowed in the same way as loading the additional data file.
+func AdditionalDataJSONSchema() ([]byte, error) {
+	return jsonSchema(reflect.TypeOf(schema.AdditionalData{}), "tbls additional data")
+}
+
+func jsonSchema(t reflect.Type, title string) ([]byte, error) {
+	g := &jsonSchemaGenerator{definitions: map[string]interface{}{}, types: map[string]reflect.Type{}}
+	root, err := g.schema(t)
+	if err != nil {
+		return nil, err
+	}
+	s := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       title,
+		"$ref":        root["$ref"],
+		"definitions": g.definitions,
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(b, '\n'), nil
+}
+
+type jsonSchemaGenerator struct {
+	definitions map[string]interface{}
+	types       map[string]reflect.Type
+}
+
+// schema return the schema of the type. Structs are defined in definitions and referred by $ref.
+func (g *jsonSchemaGenerator) schema(t reflect.Type) (map[string]interface{}, error) {
+	if s, ok := jsonSchemaTypes[t]; ok {
+		return s, nil
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, errors.WithStack(fmt.Errorf("unsupported key type of map in config: %s", t))
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		ref := map[string]interface{}{"$ref": fmt.Sprintf("#/definitions/%s", t.Name())}
+		if d, ok := g.types[t.Name()]; ok {
+			if d != t {
+				return nil, errors.WithStack(fmt.Errorf("duplicate type name in config: %s and %s", d, t))
+			}
+			return ref, nil
+		}
+		g.types[t.Name()] = t
+		properties := map[string]interface{}{}
+		if err := g.properties(t, properties); err != nil {
+			return nil, err
+		}
+		g.definitions[t.Name()] = map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		return ref, nil
+	default:
+		return nil, errors.WithStack(fmt.Errorf("unsupported type in config: %s", t))
+	}
+}
+
+// properties add the properties of the fields of the struct in the way of yaml.v2
+func (g *jsonSchemaGenerator) properties(t reflect.Type, properties map[string]interface{}) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		inline := false
+		for _, o := range tag[1:] {
+			if o == "inline" {
+				inline = true
+			}
+		}
+		if inline {
+			if err := g.properties(f.Type, properties); err != nil {
+				return err
+			}
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		s, err := g.schema(f.Type)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("%s.%s", t.Name(), f.Name))
+		}
+		properties[name] = s
+	}
+	return nil
+}
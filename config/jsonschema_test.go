@@ -0,0 +1,250 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/schema"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var updateJSONSchema = flag.Bool("update-json-schema", false, "update tbls.schema.json and tbls.additional_data.schema.json")
+
+func TestJSONSchemaFile(t *testing.T) {
+	tests := []struct {
+		file     string
+		generate func() ([]byte, error)
+	}{
+		{"tbls.schema.json", JSONSchema},
+		{"tbls.additional_data.schema.json", AdditionalDataJSONSchema},
+	}
+	for _, tt := range tests {
+		got, err := tt.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(testdataDir(), "..", tt.file)
+		if *updateJSONSchema {
+			if err := ioutil.WriteFile(path, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s is out of date. Run `make jsonschema` to update it.", tt.file)
+		}
+	}
+}
+
+func TestJSONSchemaSamples(t *testing.T) {
+	s := loadTestJSONSchema(t, JSONSchema)
+	paths, err := filepath.Glob(filepath.Join(testdataDir(), "config_test_tbls*.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no sample configs")
+	}
+	for _, path := range paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New()
+		if err := c.LoadBytes(buf); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if errs := validateYAML(t, s, buf); len(errs) > 0 {
+			t.Errorf("%s: %v", filepath.Base(path), errs)
+		}
+	}
+}
+
+func TestAdditionalDataJSONSchemaSamples(t *testing.T) {
+	s := loadTestJSONSchema(t, AdditionalDataJSONSchema)
+	paths := []string{}
+	for _, pattern := range []string{"*additional_data.yml", "schema_test_additional_data_dir/*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(testdataDir(), pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no sample additional data")
+	}
+	for _, path := range paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data schema.AdditionalData
+		if err := yaml.UnmarshalStrict(buf, &data); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if errs := validateYAML(t, s, buf); len(errs) > 0 {
+			t.Errorf("%s: %v", filepath.Base(path), errs)
+		}
+	}
+}
+
+func TestAdditionalDataJSONSchemaInvalid(t *testing.T) {
+	s := loadTestJSONSchema(t, AdditionalDataJSONSchema)
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"relations:\n  - table: posts\n    columns: [user_id]\n    parentTable: users\n    parentColums: [id]\n", []string{"/relations/0: unknown key 'parentColums'"}},
+		{"relations:\n  - table: posts\n    columns: user_id\n", []string{"/relations/0/columns: want array"}},
+		{"comments:\n  - table: users\n    columnComments:\n      id: [a]\n", []string{"/comments/0/columnComments/id: want string"}},
+		{"tables:\n  - name: payments\n    virtual: yes\n    columns:\n      - name: id\n        typ: int\n", []string{"/tables/0/columns/0: unknown key 'typ'"}},
+		{"defaultSchema: public\ncomments:\n  - table: users\n    tableComment: Users\n", nil},
+	}
+	for _, tt := range tests {
+		got := validateYAML(t, s, []byte(tt.in))
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) {
+			t.Errorf("%q: actual %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJSONSchemaInvalid(t *testing.T) {
+	s := loadTestJSONSchema(t, JSONSchema)
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"er:\n  fomat: svg\n", []string{"/er: unknown key 'fomat'"}},
+		{"format:\n  adjust: yes please\n", []string{"/format/adjust: want boolean"}},
+		{"include: users\n", []string{"/include: want array"}},
+		{"docs:\n  - docPath: a\n    dsn: b\n  - docPth: c\n", []string{"/docs/1: unknown key 'docPth'"}},
+		{"lint:\n  extends: preset.yml\n", nil},
+		{"timeout: 30s\nqueryTimeout: 1000000000\n", nil},
+	}
+	for _, tt := range tests {
+		got := validateYAML(t, s, []byte(tt.in))
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) {
+			t.Errorf("%q: actual %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func loadTestJSONSchema(t *testing.T, generate func() ([]byte, error)) map[string]interface{} {
+	t.Helper()
+	b, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := map[string]interface{}{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// validateYAML validate the YAML with the subset of JSON Schema generated by JSONSchema
+func validateYAML(t *testing.T, root map[string]interface{}, buf []byte) []string {
+	t.Helper()
+	var v interface{}
+	if err := yaml.Unmarshal(buf, &v); err != nil {
+		t.Fatal(err)
+	}
+	return validateJSONSchema(root, root, v, "")
+}
+
+func validateJSONSchema(root, s map[string]interface{}, v interface{}, path string) []string {
+	if ref, ok := s["$ref"].(string); ok {
+		d := root["definitions"].(map[string]interface{})[strings.TrimPrefix(ref, "#/definitions/")]
+		return validateJSONSchema(root, d.(map[string]interface{}), v, path)
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		for _, o := range oneOf {
+			if len(validateJSONSchema(root, o.(map[string]interface{}), v, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: want one of %v", path, oneOf)}
+	}
+	if typ, ok := s["type"]; ok {
+		types := []string{}
+		switch tt := typ.(type) {
+		case string:
+			types = append(types, tt)
+		case []interface{}:
+			for _, ty := range tt {
+				types = append(types, ty.(string))
+			}
+		}
+		matched := false
+		for _, ty := range types {
+			if jsonSchemaTypeOf(v) == ty || (ty == "number" && jsonSchemaTypeOf(v) == "integer") {
+				matched = true
+			}
+		}
+		if !matched {
+			return []string{fmt.Sprintf("%s: want %s", path, strings.Join(types, " or "))}
+		}
+	}
+	errs := []string{}
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		properties, _ := s["properties"].(map[string]interface{})
+		keys := []string{}
+		for k := range vv {
+			keys = append(keys, fmt.Sprintf("%v", k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			var ps interface{}
+			if p, ok := properties[k]; ok {
+				ps = p
+			} else {
+				ps = s["additionalProperties"]
+			}
+			switch pss := ps.(type) {
+			case bool:
+				if !pss {
+					errs = append(errs, fmt.Sprintf("%s: unknown key '%s'", path, k))
+				}
+			case map[string]interface{}:
+				errs = append(errs, validateJSONSchema(root, pss, vv[k], fmt.Sprintf("%s/%s", path, k))...)
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range vv {
+				errs = append(errs, validateJSONSchema(root, items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func jsonSchemaTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[interface{}]interface{}:
+		return "object"
+	}
+	return ""
+}
@@ -0,0 +1,1184 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// Lint is the struct for lint config
+type Lint struct {
+	Extends                Paths                  `yaml:"extends"`
+	RequireTableComment    RequireTableComment    `yaml:"requireTableComment"`
+	RequireColumnComment   RequireColumnComment   `yaml:"requireColumnComment"`
+	UnrelatedTable         UnrelatedTable         `yaml:"unrelatedTable"`
+	ColumnCount            ColumnCount            `yaml:"columnCount"`
+	RequireColumns         RequireColumns         `yaml:"requireColumns"`
+	DuplicateRelations     DuplicateRelations     `yaml:"duplicateRelations"`
+	UnindexedForeignKey    UnindexedForeignKey    `yaml:"unindexedForeignKey"`
+	NamingConvention       NamingConvention       `yaml:"namingConvention"`
+	RequirePrimaryKey      RequirePrimaryKey      `yaml:"requirePrimaryKey"`
+	ReservedWords          ReservedWords          `yaml:"reservedWords"`
+	ForeignKeyNullability  ForeignKeyNullability  `yaml:"foreignKeyNullability"`
+	ForeignKeyTypeMismatch ForeignKeyTypeMismatch `yaml:"foreignKeyTypeMismatch"`
+	RequireEnumValues      RequireEnumValues      `yaml:"requireEnumValues"`
+	MixedCharsetCollation  MixedCharsetCollation  `yaml:"mixedCharsetCollation"`
+	CustomRules            []CustomRule           `yaml:"customRules"`
+	Ignores                []LintIgnore           `yaml:"ignores"`
+	FailOn                 string                 `yaml:"failOn"`
+}
+
+// Severity levels of lint rules
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var severityLevels = map[string]int{
+	SeverityInfo:    0,
+	SeverityWarning: 1,
+	SeverityError:   2,
+}
+
+// RuleWarn is the struct for rule violation
+type RuleWarn struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Table    string `json:"table"`
+	Target   string `json:"target"`
+	Message  string `json:"message"`
+}
+
+// Rule is the interface for `tbls lint` rule
+type Rule interface {
+	Name() string
+	IsEnabled() bool
+	Level() string
+	Check(s *schema.Schema) []RuleWarn
+}
+
+// Rules return all lint rules
+func (l Lint) Rules() []Rule {
+	rules := []Rule{
+		l.RequireTableComment,
+		l.RequireColumnComment,
+		l.UnrelatedTable,
+		l.ColumnCount,
+		l.RequireColumns,
+		l.DuplicateRelations,
+		l.UnindexedForeignKey,
+		l.NamingConvention,
+		l.RequirePrimaryKey,
+		l.ReservedWords,
+		l.ForeignKeyNullability,
+		l.ForeignKeyTypeMismatch,
+		l.RequireEnumValues,
+		l.MixedCharsetCollation,
+	}
+	for _, r := range l.CustomRules {
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// Check check schema with enabled lint rules
+func (l Lint) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, r := range l.Rules() {
+		if !r.IsEnabled() {
+			continue
+		}
+		for _, w := range r.Check(s) {
+			w.Severity = r.Level()
+			warns = append(warns, w)
+		}
+	}
+	return l.applyExemptions(s, warns)
+}
+
+// Validate validate lint config
+func (l Lint) Validate() error {
+	if _, ok := severityLevels[severity(l.FailOn)]; !ok {
+		return errors.WithStack(fmt.Errorf("lint: unsupported severity '%s' of failOn", l.FailOn))
+	}
+	for _, r := range l.Rules() {
+		if _, ok := severityLevels[r.Level()]; !ok {
+			return errors.WithStack(fmt.Errorf("lint: unsupported severity '%s' of %s", r.Level(), r.Name()))
+		}
+	}
+	if l.ColumnCount.Enabled && l.ColumnCount.Max <= 0 {
+		return errors.WithStack(fmt.Errorf("lint: max of %s must be positive", l.ColumnCount.Name()))
+	}
+	for _, r := range l.CustomRules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, i := range l.Ignores {
+		if i.Rule == "" || i.Target == "" {
+			return errors.WithStack(fmt.Errorf("lint: rule and target of ignores are required"))
+		}
+	}
+	return nil
+}
+
+// IsFailed return whether the lint results fail the run.
+// The results fail when a violation has the severity of failOn or higher, or the number of warnings exceeds maxWarnings (negative maxWarnings means no limit).
+func (l Lint) IsFailed(warns []RuleWarn, maxWarnings int) bool {
+	failOn := severityLevels[severity(l.FailOn)]
+	warnCount := 0
+	for _, w := range warns {
+		if severityLevels[w.Severity] >= failOn {
+			return true
+		}
+		if w.Severity == SeverityWarning {
+			warnCount++
+		}
+	}
+	return maxWarnings >= 0 && warnCount > maxWarnings
+}
+
+// severity return severity. Default is error.
+func severity(s string) string {
+	if s == "" {
+		return SeverityError
+	}
+	return s
+}
+
+// RequireTableComment checks table comment
+type RequireTableComment struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r RequireTableComment) Name() string {
+	return "requireTableComment"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r RequireTableComment) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r RequireTableComment) Level() string {
+	return severity(r.Severity)
+}
+
+// Check table comment
+func (r RequireTableComment) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, t := range s.Tables {
+		if match(r.Exclude, t.Name) {
+			continue
+		}
+		if t.Comment == "" {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   t.Name,
+				Target:  t.Name,
+				Message: "table comment required.",
+			})
+		}
+	}
+	return warns
+}
+
+// RequireColumnComment checks column comment
+type RequireColumnComment struct {
+	Enabled        bool     `yaml:"enabled"`
+	Severity       string   `yaml:"severity"`
+	Exclude        []string `yaml:"exclude"`
+	ExcludedTables []string `yaml:"excludedTables"`
+}
+
+// Name return rule name
+func (r RequireColumnComment) Name() string {
+	return "requireColumnComment"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r RequireColumnComment) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r RequireColumnComment) Level() string {
+	return severity(r.Severity)
+}
+
+// Check column comment
+func (r RequireColumnComment) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, t := range s.Tables {
+		if match(r.ExcludedTables, t.Name) {
+			continue
+		}
+		for _, c := range t.Columns {
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			if match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			if c.Comment == "" {
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  target,
+					Message: "column comment required.",
+				})
+			}
+		}
+	}
+	return warns
+}
+
+// UnrelatedTable checks isolated table
+type UnrelatedTable struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r UnrelatedTable) Name() string {
+	return "unrelatedTable"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r UnrelatedTable) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r UnrelatedTable) Level() string {
+	return severity(r.Severity)
+}
+
+// Check table relations (including additional relations)
+func (r UnrelatedTable) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	related := map[*schema.Table]bool{}
+	for _, rel := range s.Relations {
+		related[rel.Table] = true
+		related[rel.ParentTable] = true
+	}
+	for _, t := range s.Tables {
+		if match(r.Exclude, t.Name) {
+			continue
+		}
+		if !related[t] {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   t.Name,
+				Target:  t.Name,
+				Message: "unrelated (isolated) table.",
+			})
+		}
+	}
+	return warns
+}
+
+// ColumnCount checks table column count
+type ColumnCount struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Max      int      `yaml:"max"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r ColumnCount) Name() string {
+	return "columnCount"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r ColumnCount) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r ColumnCount) Level() string {
+	return severity(r.Severity)
+}
+
+// Check table column count
+func (r ColumnCount) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, t := range s.Tables {
+		if match(r.Exclude, t.Name) {
+			continue
+		}
+		if len(t.Columns) > r.Max {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   t.Name,
+				Target:  t.Name,
+				Message: fmt.Sprintf("too many columns. [%d/%d]", len(t.Columns), r.Max),
+			})
+		}
+	}
+	return warns
+}
+
+// RequireColumns checks that tables have required columns
+type RequireColumns struct {
+	Enabled  bool             `yaml:"enabled"`
+	Severity string           `yaml:"severity"`
+	Columns  []RequiredColumn `yaml:"columns"`
+}
+
+// RequiredColumn is the struct for a column every table must have
+type RequiredColumn struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type,omitempty"`
+	Nullable *bool    `yaml:"nullable,omitempty"`
+	Exclude  []string `yaml:"exclude,omitempty"`
+}
+
+// Name return rule name
+func (r RequireColumns) Name() string {
+	return "requireColumns"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r RequireColumns) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r RequireColumns) Level() string {
+	return severity(r.Severity)
+}
+
+// Check required columns of tables. Views are not checked.
+func (r RequireColumns) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, t := range s.Tables {
+		if isView(t) {
+			continue
+		}
+		for _, rc := range r.Columns {
+			if match(rc.Exclude, t.Name) {
+				continue
+			}
+			c, err := t.FindColumnByName(rc.Name)
+			if err != nil {
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  t.Name,
+					Message: fmt.Sprintf("column '%s' required.", rc.Name),
+				})
+				continue
+			}
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			if rc.Type != "" && !strings.EqualFold(rc.Type, c.Type) {
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  target,
+					Message: fmt.Sprintf("column type should be '%s', but '%s'.", rc.Type, c.Type),
+				})
+			}
+			if rc.Nullable != nil && *rc.Nullable != c.Nullable {
+				msg := "column should be NOT NULL."
+				if *rc.Nullable {
+					msg = "column should be nullable."
+				}
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  target,
+					Message: msg,
+				})
+			}
+		}
+	}
+	return warns
+}
+
+// DuplicateRelations checks duplicate relations
+type DuplicateRelations struct {
+	Enabled  bool   `yaml:"enabled"`
+	Severity string `yaml:"severity"`
+}
+
+// Name return rule name
+func (r DuplicateRelations) Name() string {
+	return "duplicateRelations"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r DuplicateRelations) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r DuplicateRelations) Level() string {
+	return severity(r.Severity)
+}
+
+// Check duplicate relations between the same columns (including additional relations)
+func (r DuplicateRelations) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	seen := map[string]bool{}
+	// duplicates are removed from Relations when the schema is built, and kept by DuplicatedRelations
+	relations := append(append([]*schema.Relation{}, s.Relations...), s.DuplicatedRelations()...)
+	for _, rel := range relations {
+		key := relationLabel(rel)
+		if seen[key] {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   rel.Table.Name,
+				Target:  key,
+				Message: "duplicate relation.",
+			})
+			continue
+		}
+		seen[key] = true
+	}
+	return warns
+}
+
+// relationLabel return `table(columns) -> parent_table(parent_columns)`
+func relationLabel(r *schema.Relation) string {
+	columns := []string{}
+	for _, c := range r.Columns {
+		columns = append(columns, c.Name)
+	}
+	parentColumns := []string{}
+	for _, c := range r.ParentColumns {
+		parentColumns = append(parentColumns, c.Name)
+	}
+	return fmt.Sprintf("%s(%s) -> %s(%s)", r.Table.Name, strings.Join(columns, ", "), r.ParentTable.Name, strings.Join(parentColumns, ", "))
+}
+
+// UnindexedForeignKey checks that foreign key columns are indexed
+type UnindexedForeignKey struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r UnindexedForeignKey) Name() string {
+	return "unindexedForeignKey"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r UnindexedForeignKey) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r UnindexedForeignKey) Level() string {
+	return severity(r.Severity)
+}
+
+// Check that child-side columns of foreign keys are the leading columns of an index.
+// Additional relations are not checked.
+func (r UnindexedForeignKey) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, rel := range s.Relations {
+		if rel.IsAdditional || match(r.Exclude, rel.Table.Name) {
+			continue
+		}
+		columns := []string{}
+		for _, c := range rel.Columns {
+			columns = append(columns, c.Name)
+		}
+		if coveredByIndex(rel.Table, columns) {
+			continue
+		}
+		warns = append(warns, RuleWarn{
+			Rule:    r.Name(),
+			Table:   rel.Table.Name,
+			Target:  fmt.Sprintf("%s(%s)", rel.Table.Name, strings.Join(columns, ", ")),
+			Message: "foreign key columns are not indexed.",
+		})
+	}
+	return warns
+}
+
+// coveredByIndex return whether the columns are the leading columns of an index of the table (in any order)
+func coveredByIndex(t *schema.Table, columns []string) bool {
+	for _, i := range t.Indexes {
+		if len(i.Columns) < len(columns) {
+			continue
+		}
+		leading := map[string]bool{}
+		for _, c := range i.Columns[:len(columns)] {
+			leading[c] = true
+		}
+		covered := true
+		for _, c := range columns {
+			if !leading[c] {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// NamingConvention checks names of tables, columns, indexes and constraints with regexp
+type NamingConvention struct {
+	Enabled     bool       `yaml:"enabled"`
+	Severity    string     `yaml:"severity"`
+	Tables      NamingRule `yaml:"tables"`
+	Columns     NamingRule `yaml:"columns"`
+	Indexes     NamingRule `yaml:"indexes"`
+	Constraints NamingRule `yaml:"constraints"`
+}
+
+// NamingRule is the struct for the name pattern of an object type
+type NamingRule struct {
+	Pattern string   `yaml:"pattern"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r NamingConvention) Name() string {
+	return "namingConvention"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r NamingConvention) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r NamingConvention) Level() string {
+	return severity(r.Severity)
+}
+
+// Check names. Exclude of columns, indexes and constraints matches `name` or `table.name`.
+func (r NamingConvention) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	check := func(rule NamingRule, kind string, tableName string, name string) {
+		if rule.Pattern == "" {
+			return
+		}
+		target := name
+		table := name
+		if tableName != "" {
+			target = fmt.Sprintf("%s.%s", tableName, name)
+			table = tableName
+		}
+		if match(rule.Exclude, name) || match(rule.Exclude, target) {
+			return
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   table,
+				Target:  target,
+				Message: fmt.Sprintf("invalid %s name pattern '%s'.", kind, rule.Pattern),
+			})
+			return
+		}
+		if !re.MatchString(name) {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   table,
+				Target:  target,
+				Message: fmt.Sprintf("%s name should match '%s'.", kind, rule.Pattern),
+			})
+		}
+	}
+	for _, t := range s.Tables {
+		check(r.Tables, "table", "", t.Name)
+		for _, c := range t.Columns {
+			check(r.Columns, "column", t.Name, c.Name)
+		}
+		for _, i := range t.Indexes {
+			check(r.Indexes, "index", t.Name, i.Name)
+		}
+		for _, c := range t.Constraints {
+			check(r.Constraints, "constraint", t.Name, c.Name)
+		}
+	}
+	return warns
+}
+
+// RequirePrimaryKey checks that tables have a primary key
+type RequirePrimaryKey struct {
+	Enabled          bool     `yaml:"enabled"`
+	Severity         string   `yaml:"severity"`
+	AllowUniqueIndex bool     `yaml:"allowUniqueIndex"`
+	Exclude          []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r RequirePrimaryKey) Name() string {
+	return "requirePrimaryKey"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r RequirePrimaryKey) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r RequirePrimaryKey) Level() string {
+	return severity(r.Severity)
+}
+
+// Check primary key of tables. Views are not checked.
+func (r RequirePrimaryKey) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, t := range s.Tables {
+		if isView(t) || match(r.Exclude, t.Name) {
+			continue
+		}
+		if hasConstraint(t, "PRIMARY KEY") {
+			continue
+		}
+		if r.AllowUniqueIndex && hasUniqueIndex(t) {
+			continue
+		}
+		msg := "primary key required."
+		if r.AllowUniqueIndex {
+			msg = "primary key or unique index required."
+		}
+		warns = append(warns, RuleWarn{
+			Rule:    r.Name(),
+			Table:   t.Name,
+			Target:  t.Name,
+			Message: msg,
+		})
+	}
+	return warns
+}
+
+func isView(t *schema.Table) bool {
+	return strings.Contains(strings.ToUpper(t.Type), "VIEW")
+}
+
+func hasConstraint(t *schema.Table, constraintType string) bool {
+	for _, c := range t.Constraints {
+		if strings.EqualFold(c.Type, constraintType) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasUniqueIndex(t *schema.Table) bool {
+	for _, c := range t.Constraints {
+		if strings.HasPrefix(strings.ToUpper(c.Type), "UNIQUE") {
+			return true
+		}
+	}
+	for _, i := range t.Indexes {
+		if strings.Contains(strings.ToUpper(i.Def), "UNIQUE") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReservedWords checks table and column names colliding with SQL reserved words
+type ReservedWords struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Dialect  string   `yaml:"dialect"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r ReservedWords) Name() string {
+	return "reservedWords"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r ReservedWords) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r ReservedWords) Level() string {
+	return severity(r.Severity)
+}
+
+// Check table and column names. The dialect defaults to the driver of the analyzed database.
+func (r ReservedWords) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	dialect := r.Dialect
+	if dialect == "" {
+		dialect = s.Driver
+	}
+	for _, t := range s.Tables {
+		if !match(r.Exclude, t.Name) && isReservedWord(dialect, t.Name) {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   t.Name,
+				Target:  t.Name,
+				Message: "table name is a reserved word.",
+			})
+		}
+		for _, c := range t.Columns {
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			if match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			if isReservedWord(dialect, c.Name) {
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  target,
+					Message: "column name is a reserved word.",
+				})
+			}
+		}
+	}
+	return warns
+}
+
+// ForeignKeyNullability checks nullability of foreign key columns
+type ForeignKeyNullability struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Nullable bool     `yaml:"nullable"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r ForeignKeyNullability) Name() string {
+	return "foreignKeyNullability"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r ForeignKeyNullability) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r ForeignKeyNullability) Level() string {
+	return severity(r.Severity)
+}
+
+// Check that child-side columns of foreign keys are NOT NULL (or nullable when Nullable).
+// Additional relations are not checked.
+func (r ForeignKeyNullability) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, rel := range s.Relations {
+		if rel.IsAdditional {
+			continue
+		}
+		for _, c := range rel.Columns {
+			target := fmt.Sprintf("%s.%s", rel.Table.Name, c.Name)
+			if match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			if c.Nullable == r.Nullable {
+				continue
+			}
+			msg := "foreign key column should be NOT NULL."
+			if r.Nullable {
+				msg = "foreign key column should be nullable."
+			}
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   rel.Table.Name,
+				Target:  target,
+				Message: msg,
+			})
+		}
+	}
+	return warns
+}
+
+// CustomRule is the user-defined rule. It reports a violation for each table or column which satisfies the condition.
+type CustomRule struct {
+	RuleName  string   `yaml:"name"`
+	Target    string   `yaml:"target"`
+	Condition string   `yaml:"condition"`
+	Message   string   `yaml:"message"`
+	Severity  string   `yaml:"severity"`
+	Exclude   []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r CustomRule) Name() string {
+	return r.RuleName
+}
+
+// IsEnabled return Rule is enabled or not
+func (r CustomRule) IsEnabled() bool {
+	return true
+}
+
+// Level return rule severity
+func (r CustomRule) Level() string {
+	return severity(r.Severity)
+}
+
+// Validate validate custom rule config
+func (r CustomRule) Validate() error {
+	if r.RuleName == "" {
+		return errors.WithStack(errors.New("lint: name of custom rule is required"))
+	}
+	switch r.Target {
+	case "table", "column":
+	default:
+		return errors.WithStack(fmt.Errorf("lint: unsupported target '%s' of %s (table or column)", r.Target, r.RuleName))
+	}
+	if _, err := compileExpr(r.Condition); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("lint: invalid condition of %s", r.RuleName))
+	}
+	return nil
+}
+
+// Check tables or columns with the condition
+func (r CustomRule) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	e, err := compileExpr(r.Condition)
+	if err != nil {
+		return append(warns, RuleWarn{
+			Rule:    r.Name(),
+			Target:  r.Condition,
+			Message: fmt.Sprintf("invalid condition: %s", err),
+		})
+	}
+	message := r.Message
+	if message == "" {
+		message = fmt.Sprintf("condition '%s' is satisfied.", r.Condition)
+	}
+	for _, t := range s.Tables {
+		if match(r.Exclude, t.Name) {
+			continue
+		}
+		te := tableExprEnv(t)
+		if r.Target == "table" {
+			warns = append(warns, r.eval(e, map[string]interface{}{"table": te}, t.Name, t.Name, message)...)
+			continue
+		}
+		for _, c := range t.Columns {
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			if match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			warns = append(warns, r.eval(e, map[string]interface{}{"table": te, "column": columnExprEnv(c)}, t.Name, target, message)...)
+		}
+	}
+	return warns
+}
+
+func (r CustomRule) eval(e expr, env map[string]interface{}, table, target, message string) []RuleWarn {
+	ok, err := evalBool(e, env)
+	if err != nil {
+		message = fmt.Sprintf("failed to evaluate condition: %s", err)
+	} else if !ok {
+		return nil
+	}
+	return []RuleWarn{
+		RuleWarn{
+			Rule:    r.Name(),
+			Table:   table,
+			Target:  target,
+			Message: message,
+		},
+	}
+}
+
+// tableExprEnv return the table fields available in the condition of custom rules
+func tableExprEnv(t *schema.Table) map[string]interface{} {
+	columns := []interface{}{}
+	for _, c := range t.Columns {
+		columns = append(columns, columnExprEnv(c))
+	}
+	indexes := []interface{}{}
+	for _, i := range t.Indexes {
+		indexColumns := []interface{}{}
+		for _, c := range i.Columns {
+			indexColumns = append(indexColumns, c)
+		}
+		indexes = append(indexes, map[string]interface{}{
+			"Name":    i.Name,
+			"Def":     i.Def,
+			"Columns": indexColumns,
+		})
+	}
+	constraints := []interface{}{}
+	for _, c := range t.Constraints {
+		constraints = append(constraints, map[string]interface{}{
+			"Name": c.Name,
+			"Type": c.Type,
+			"Def":  c.Def,
+		})
+	}
+	triggers := []interface{}{}
+	for _, tr := range t.Triggers {
+		triggers = append(triggers, map[string]interface{}{
+			"Name": tr.Name,
+			"Def":  tr.Def,
+		})
+	}
+	return map[string]interface{}{
+		"Name":        t.Name,
+		"Type":        t.Type,
+		"Comment":     t.Comment,
+		"Def":         t.Def,
+		"Columns":     columns,
+		"Indexes":     indexes,
+		"Constraints": constraints,
+		"Triggers":    triggers,
+	}
+}
+
+// columnExprEnv return the column fields available in the condition of custom rules
+func columnExprEnv(c *schema.Column) map[string]interface{} {
+	return map[string]interface{}{
+		"Name":         c.Name,
+		"Type":         c.Type,
+		"Nullable":     c.Nullable,
+		"Default":      c.DefaultValue(),
+		"Comment":      c.Comment,
+		"IsForeignKey": len(c.ParentRelations) > 0,
+	}
+}
+
+// ForeignKeyTypeMismatch checks that types of foreign key columns match the parent columns
+type ForeignKeyTypeMismatch struct {
+	Enabled  bool     `yaml:"enabled"`
+	Severity string   `yaml:"severity"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r ForeignKeyTypeMismatch) Name() string {
+	return "foreignKeyTypeMismatch"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r ForeignKeyTypeMismatch) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r ForeignKeyTypeMismatch) Level() string {
+	return severity(r.Severity)
+}
+
+// Check types of foreign key columns and the parent columns
+func (r ForeignKeyTypeMismatch) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	for _, rel := range s.Relations {
+		for i, c := range rel.Columns {
+			if i >= len(rel.ParentColumns) {
+				break
+			}
+			target := fmt.Sprintf("%s.%s", rel.Table.Name, c.Name)
+			if match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			pc := rel.ParentColumns[i]
+			if normalizeColumnType(c.Type) == normalizeColumnType(pc.Type) {
+				continue
+			}
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   rel.Table.Name,
+				Target:  target,
+				Message: fmt.Sprintf("column type '%s' does not match the parent column %s.%s type '%s'.", c.Type, rel.ParentTable.Name, pc.Name, pc.Type),
+			})
+		}
+	}
+	return warns
+}
+
+var reIntegerDisplayWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|integer|bigint)\(\d+\)`)
+
+var columnTypeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+}
+
+// normalizeColumnType return comparable column type. Display width of MySQL integer types and aliases of integer types are ignored.
+func normalizeColumnType(t string) string {
+	t = strings.Join(strings.Fields(strings.ToLower(t)), " ")
+	t = reIntegerDisplayWidth.ReplaceAllString(t, "$1")
+	parts := strings.SplitN(t, " ", 2)
+	if a, ok := columnTypeAliases[parts[0]]; ok {
+		parts[0] = a
+	}
+	return strings.Join(parts, " ")
+}
+
+// RequireEnumValues checks that status/enum-like columns document their permitted values
+type RequireEnumValues struct {
+	Enabled       bool     `yaml:"enabled"`
+	Severity      string   `yaml:"severity"`
+	Columns       []string `yaml:"columns"`
+	ValuesPattern string   `yaml:"valuesPattern"`
+	Exclude       []string `yaml:"exclude"`
+}
+
+// DefaultEnumColumns is the default glob patterns of enum-like column names
+var DefaultEnumColumns = []string{"*_status", "*_type", "*_flag", "status", "type"}
+
+// DefaultEnumValuesPattern is the default pattern of a value definition in comments (e.g. `0: draft`, `1=published`)
+const DefaultEnumValuesPattern = `[\w-]+\s*[:=]`
+
+// Name return rule name
+func (r RequireEnumValues) Name() string {
+	return "requireEnumValues"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r RequireEnumValues) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r RequireEnumValues) Level() string {
+	return severity(r.Severity)
+}
+
+// Check comments of enum-like columns. The comment should have two or more value definitions. Columns of enum types are skipped.
+func (r RequireEnumValues) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	columns := r.Columns
+	if len(columns) == 0 {
+		columns = DefaultEnumColumns
+	}
+	pattern := r.ValuesPattern
+	if pattern == "" {
+		pattern = DefaultEnumValuesPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return append(warns, RuleWarn{
+			Rule:    r.Name(),
+			Target:  pattern,
+			Message: fmt.Sprintf("invalid values pattern '%s'.", pattern),
+		})
+	}
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			if !match(columns, c.Name) || match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			if strings.HasPrefix(strings.ToLower(c.Type), "enum") || len(re.FindAllString(c.Comment, -1)) >= 2 {
+				continue
+			}
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   t.Name,
+				Target:  target,
+				Message: "column comment should enumerate the permitted values.",
+			})
+		}
+	}
+	return warns
+}
+
+// MixedCharsetCollation checks that charsets and collations of tables and columns are the same as the default
+type MixedCharsetCollation struct {
+	Enabled   bool     `yaml:"enabled"`
+	Severity  string   `yaml:"severity"`
+	Charset   string   `yaml:"charset"`
+	Collation string   `yaml:"collation"`
+	Exclude   []string `yaml:"exclude"`
+}
+
+// Name return rule name
+func (r MixedCharsetCollation) Name() string {
+	return "mixedCharsetCollation"
+}
+
+// IsEnabled return Rule is enabled or not
+func (r MixedCharsetCollation) IsEnabled() bool {
+	return r.Enabled
+}
+
+// Level return rule severity
+func (r MixedCharsetCollation) Level() string {
+	return severity(r.Severity)
+}
+
+// Check charsets and collations. The default is the schema (database) default unless configured.
+func (r MixedCharsetCollation) Check(s *schema.Schema) []RuleWarn {
+	warns := []RuleWarn{}
+	charset := r.Charset
+	if charset == "" {
+		charset = s.Charset
+	}
+	collation := r.Collation
+	if collation == "" {
+		collation = s.Collation
+	}
+	for _, t := range s.Tables {
+		if match(r.Exclude, t.Name) {
+			continue
+		}
+		if collation != "" && t.Collation != "" && t.Collation != collation {
+			warns = append(warns, RuleWarn{
+				Rule:    r.Name(),
+				Table:   t.Name,
+				Target:  t.Name,
+				Message: fmt.Sprintf("table collation '%s' differs from the default '%s'.", t.Collation, collation),
+			})
+		}
+		for _, c := range t.Columns {
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			if match(r.Exclude, c.Name) || match(r.Exclude, target) {
+				continue
+			}
+			switch {
+			case charset != "" && c.Charset != "" && c.Charset != charset:
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  target,
+					Message: fmt.Sprintf("column charset '%s' differs from the default '%s'.", c.Charset, charset),
+				})
+			case collation != "" && c.Collation != "" && c.Collation != collation:
+				warns = append(warns, RuleWarn{
+					Rule:    r.Name(),
+					Table:   t.Name,
+					Target:  target,
+					Message: fmt.Sprintf("column collation '%s' differs from the default '%s'.", c.Collation, collation),
+				})
+			}
+		}
+	}
+	return warns
+}
+
+// match return whether the name matches one of the glob patterns
+func match(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String return the formatted rule violation
+func (w RuleWarn) String() string {
+	return fmt.Sprintf("%s: %s (%s)", w.Target, w.Message, w.Rule)
+}
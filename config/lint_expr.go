@@ -0,0 +1,489 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// expr is the compiled expression of custom lint rules.
+//
+// Supported syntax:
+//
+//	literals     "string", 'string', 123, 1.5, true, false
+//	fields       table.Name, column.Type
+//	operators    || && ! == != < <= > >= (and, or, not are also available)
+//	string ops   contains, startsWith, endsWith, matches (regexp)
+//	functions    len(x), lower(s), upper(s)
+type expr interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+// compileExpr parse expression string
+func compileExpr(src string) (expr, error) {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, errors.WithStack(fmt.Errorf("unexpected token '%s' in expression '%s'", p.peek().value, src))
+	}
+	return e, nil
+}
+
+// evalBool evaluate expression as bool
+func evalBool(e expr, env map[string]interface{}) (bool, error) {
+	v, err := e.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.WithStack(fmt.Errorf("expression result is not bool: %v", v))
+	}
+	return b, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+var wordOps = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+func tokenizeExpr(src string) ([]token, error) {
+	tokens := []token{}
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(rs) && rs[j] != r; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				sb.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				return nil, errors.WithStack(fmt.Errorf("unterminated string in expression '%s'", src))
+			}
+			tokens = append(tokens, token{tokenString, sb.String()})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, string(rs[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			word := string(rs[i:j])
+			if op, ok := wordOps[word]; ok {
+				tokens = append(tokens, token{tokenOp, op})
+			} else {
+				tokens = append(tokens, token{tokenIdent, word})
+			}
+			i = j
+		default:
+			if i+1 < len(rs) {
+				two := string(rs[i : i+2])
+				switch two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, token{tokenOp, two})
+					i += 2
+					continue
+				}
+			}
+			switch r {
+			case '!', '<', '>', '(', ')', '.', ',':
+				tokens = append(tokens, token{tokenOp, string(r)})
+				i++
+			default:
+				return nil, errors.WithStack(fmt.Errorf("unexpected character '%c' in expression '%s'", r, src))
+			}
+		}
+	}
+	return append(tokens, token{tokenEOF, ""}), nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) expect(value string) error {
+	t := p.next()
+	if t.kind != tokenOp || t.value != value {
+		return errors.WithStack(fmt.Errorf("expected '%s' but got '%s'", value, t.value))
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().value == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().value == "&&" {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+var comparisonOps = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"contains": true, "startsWith": true, "endsWith": true, "matches": true,
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if (t.kind == tokenOp || t.kind == tokenIdent) && comparisonOps[t.value] {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if t.value == "matches" {
+			lit, ok := right.(*literalExpr)
+			if !ok {
+				return nil, errors.WithStack(errors.New("right side of 'matches' must be a string literal"))
+			}
+			s, ok := lit.value.(string)
+			if !ok {
+				return nil, errors.WithStack(errors.New("right side of 'matches' must be a string literal"))
+			}
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return &matchesExpr{left: left, re: re}, nil
+		}
+		return &comparisonExpr{op: t.value, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if t := p.peek(); t.kind == tokenOp && t.value == "!" {
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{e: e}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalExpr{value: t.value}, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &literalExpr{value: f}, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return &literalExpr{value: true}, nil
+		case "false":
+			return &literalExpr{value: false}, nil
+		}
+		if n := p.peek(); n.kind == tokenOp && n.value == "(" {
+			p.next()
+			args := []expr{}
+			for !(p.peek().kind == tokenOp && p.peek().value == ")") {
+				if len(args) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				arg, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+			p.next()
+			if _, ok := exprFuncs[t.value]; !ok {
+				return nil, errors.WithStack(fmt.Errorf("unknown function '%s'", t.value))
+			}
+			return &callExpr{name: t.value, args: args}, nil
+		}
+		path := []string{t.value}
+		for p.peek().kind == tokenOp && p.peek().value == "." {
+			p.next()
+			f := p.next()
+			if f.kind != tokenIdent {
+				return nil, errors.WithStack(fmt.Errorf("expected field name but got '%s'", f.value))
+			}
+			path = append(path, f.value)
+		}
+		return &fieldExpr{path: path}, nil
+	case tokenOp:
+		if t.value == "(" {
+			e, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+	return nil, errors.WithStack(fmt.Errorf("unexpected token '%s'", t.value))
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e *literalExpr) eval(env map[string]interface{}) (interface{}, error) {
+	return e.value, nil
+}
+
+type fieldExpr struct {
+	path []string
+}
+
+func (e *fieldExpr) eval(env map[string]interface{}) (interface{}, error) {
+	var v interface{} = env
+	for _, name := range e.path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.WithStack(fmt.Errorf("'%s' is not an object", strings.Join(e.path, ".")))
+		}
+		v, ok = m[name]
+		if !ok {
+			return nil, errors.WithStack(fmt.Errorf("unknown field '%s'", strings.Join(e.path, ".")))
+		}
+	}
+	return v, nil
+}
+
+type notExpr struct {
+	e expr
+}
+
+func (e *notExpr) eval(env map[string]interface{}) (interface{}, error) {
+	b, err := evalBool(e.e, env)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+type logicalExpr struct {
+	op    string
+	left  expr
+	right expr
+}
+
+func (e *logicalExpr) eval(env map[string]interface{}) (interface{}, error) {
+	l, err := evalBool(e.left, env)
+	if err != nil {
+		return nil, err
+	}
+	if e.op == "&&" && !l {
+		return false, nil
+	}
+	if e.op == "||" && l {
+		return true, nil
+	}
+	return evalBool(e.right, env)
+}
+
+type comparisonExpr struct {
+	op    string
+	left  expr
+	right expr
+}
+
+func (e *comparisonExpr) eval(env map[string]interface{}) (interface{}, error) {
+	l, err := e.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==":
+		return reflect.DeepEqual(l, r), nil
+	case "!=":
+		return !reflect.DeepEqual(l, r), nil
+	case "contains", "startsWith", "endsWith":
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if !lok || !rok {
+			return nil, errors.WithStack(fmt.Errorf("'%s' requires strings: %v %s %v", e.op, l, e.op, r))
+		}
+		switch e.op {
+		case "contains":
+			return strings.Contains(ls, rs), nil
+		case "startsWith":
+			return strings.HasPrefix(ls, rs), nil
+		default:
+			return strings.HasSuffix(ls, rs), nil
+		}
+	}
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, errors.WithStack(fmt.Errorf("'%s' requires numbers: %v %s %v", e.op, l, e.op, r))
+	}
+	switch e.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	default:
+		return lf >= rf, nil
+	}
+}
+
+type matchesExpr struct {
+	left expr
+	re   *regexp.Regexp
+}
+
+func (e *matchesExpr) eval(env map[string]interface{}) (interface{}, error) {
+	l, err := e.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := l.(string)
+	if !ok {
+		return nil, errors.WithStack(fmt.Errorf("'matches' requires string: %v", l))
+	}
+	return e.re.MatchString(s), nil
+}
+
+var exprFuncs = map[string]func(args []interface{}) (interface{}, error){
+	"len": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.WithStack(errors.New("len() takes exactly one argument"))
+		}
+		switch v := args[0].(type) {
+		case string:
+			return float64(len(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, errors.WithStack(fmt.Errorf("len() requires string or list: %v", args[0]))
+	},
+	"lower": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.WithStack(errors.New("lower() takes exactly one argument"))
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, errors.WithStack(fmt.Errorf("lower() requires string: %v", args[0]))
+		}
+		return strings.ToLower(s), nil
+	},
+	"upper": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.WithStack(errors.New("upper() takes exactly one argument"))
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, errors.WithStack(fmt.Errorf("upper() requires string: %v", args[0]))
+		}
+		return strings.ToUpper(s), nil
+	},
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+func (e *callExpr) eval(env map[string]interface{}) (interface{}, error) {
+	args := []interface{}{}
+	for _, a := range e.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return exprFuncs[e.name](args)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestCompileExpr(t *testing.T) {
+	env := map[string]interface{}{
+		"table": map[string]interface{}{
+			"Name":    "users",
+			"Comment": "",
+			"Columns": []interface{}{"id", "name"},
+		},
+		"column": map[string]interface{}{
+			"Name":     "total_amount",
+			"Type":     "float",
+			"Nullable": true,
+		},
+	}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`column.Type == "float" && column.Name endsWith "_amount"`, true},
+		{`column.Type == 'float' && column.Name endsWith "_price"`, false},
+		{`column.Type != "float" || column.Nullable`, true},
+		{`!column.Nullable`, false},
+		{`not column.Nullable or table.Name == "users"`, true},
+		{`table.Name startsWith "user" and table.Comment == ""`, true},
+		{`column.Name contains "amount"`, true},
+		{`column.Name matches "^[a-z_]+$"`, true},
+		{`len(table.Columns) > 1`, true},
+		{`len(table.Columns) >= 3`, false},
+		{`len(column.Name) <= 12`, true},
+		{`upper(table.Name) == "USERS"`, true},
+		{`lower("ID") == "id"`, true},
+		{`(false || true) && !(1 < 0)`, true},
+		{`true == false`, false},
+	}
+	for _, tt := range tests {
+		e, err := compileExpr(tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		got, err := evalBool(e, env)
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileExprError(t *testing.T) {
+	tests := []string{
+		``,
+		`column.Name ==`,
+		`column.Name == "foo`,
+		`(column.Name == "a"`,
+		`column.Name matches column.Type`,
+		`column.Name matches "["`,
+		`unknown(column.Name)`,
+		`column.Name # 1`,
+		`column.Name == "a" column.Type`,
+	}
+	for _, src := range tests {
+		_, err := compileExpr(src)
+		if err == nil {
+			t.Errorf("%s: actual %v\nwant %v", src, err, "error")
+		}
+	}
+}
+
+func TestEvalExprError(t *testing.T) {
+	env := map[string]interface{}{
+		"column": map[string]interface{}{
+			"Name": "id",
+		},
+	}
+	tests := []string{
+		`column.Unknown == "a"`,
+		`column.Name`,
+		`column.Name > 1`,
+		`column.Name.Length == 1`,
+		`len(1) == 1`,
+	}
+	for _, src := range tests {
+		e, err := compileExpr(src)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		_, err = evalBool(e, env)
+		if err == nil {
+			t.Errorf("%s: actual %v\nwant %v", src, err, "error")
+		}
+	}
+}
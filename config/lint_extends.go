@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// lintPresetTimeout is the timeout of fetching lint presets by URL
+var lintPresetTimeout = 30 * time.Second
+
+// resolveExtends load lint presets of `extends:` and override them with the lint config in the config yaml buffer.
+// Presets are applied in order, and a preset can also have `extends:`.
+// Relative paths are resolved from the location of the config file (path), or the current directory when path is empty.
+func (l *Lint) resolveExtends(buf []byte, path string) error {
+	raw := struct {
+		Lint yaml.MapSlice `yaml:"lint"`
+	}{}
+	err := yaml.Unmarshal(buf, &raw)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	lintBuf, err := yaml.Marshal(raw.Lint)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	layers, err := lintLayers(lintBuf, path, []string{})
+	if err != nil {
+		return err
+	}
+	resolved := Lint{}
+	for _, layer := range layers {
+		err := yaml.UnmarshalStrict(layer, &resolved)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	*l = resolved
+	return nil
+}
+
+// lintLayers return lint config buffers in the order to apply.
+// Relative paths in a config file or a preset (base) are resolved from the location of it.
+func lintLayers(buf []byte, base string, chain []string) ([][]byte, error) {
+	l := struct {
+		Extends Paths `yaml:"extends"`
+	}{}
+	err := yaml.Unmarshal(buf, &l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	layers := [][]byte{}
+	for _, p := range l.Extends {
+		p, err := resolvePresetPath(p, base)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range chain {
+			if c == p {
+				return nil, errors.WithStack(fmt.Errorf("circular lint extends: %s -> %s", strings.Join(chain, " -> "), p))
+			}
+		}
+		pbuf, err := readLintPreset(p)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := lintLayers(pbuf, p, append(chain[:len(chain):len(chain)], p))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid lint preset '%s'", p))
+		}
+		layers = append(layers, sub...)
+	}
+	return append(layers, buf), nil
+}
+
+// readLintPreset read lint preset from URL or file path
+func readLintPreset(p string) ([]byte, error) {
+	if !isURL(p) {
+		buf, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, errors.Wrap(errors.WithStack(err), "failed to read lint preset")
+		}
+		return buf, nil
+	}
+	client := &http.Client{Timeout: lintPresetTimeout}
+	res, err := client.Get(p)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), "failed to fetch lint preset")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(fmt.Errorf("failed to fetch lint preset '%s': %s", p, res.Status))
+	}
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), "failed to fetch lint preset")
+	}
+	return buf, nil
+}
+
+// resolvePresetPath resolve the relative path of preset from the location of the base config file or preset
+func resolvePresetPath(p, base string) (string, error) {
+	if base == "" || isURL(p) || filepath.IsAbs(p) {
+		return p, nil
+	}
+	if !isURL(base) {
+		return filepath.Join(filepath.Dir(base), p), nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	r, err := url.Parse(filepath.ToSlash(p))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+func isURL(p string) bool {
+	return strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://")
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/k1LoW/tbls/schema"
+)
+
+// UnusedExemptionRuleName is the rule name of the warning for unused exemptions
+const UnusedExemptionRuleName = "unusedExemption"
+
+var reIgnoreAnnotation = regexp.MustCompile(`tbls:ignore=([\w*,-]+)`)
+
+// LintIgnore is the struct for the exemption of lint rule
+type LintIgnore struct {
+	Rule   string `yaml:"rule"`
+	Target string `yaml:"target"`
+	Reason string `yaml:"reason"`
+}
+
+type exemption struct {
+	rule   string
+	target string
+	table  string
+	source string
+	used   bool
+}
+
+// matches return whether the exemption suppresses the rule violation
+func (e *exemption) matches(w RuleWarn) bool {
+	if !match([]string{e.rule}, w.Rule) {
+		return false
+	}
+	return match([]string{e.target}, w.Target) || match([]string{e.target}, w.Table)
+}
+
+// exemptions return exemptions of `ignores:` and the annotation `tbls:ignore=<rule>[,<rule>...]` in table and column comments
+func (l Lint) exemptions(s *schema.Schema) []*exemption {
+	exemptions := []*exemption{}
+	for _, i := range l.Ignores {
+		exemptions = append(exemptions, &exemption{
+			rule:   i.Rule,
+			target: i.Target,
+			source: i.Target,
+		})
+	}
+	for _, t := range s.Tables {
+		for _, rule := range ignoreAnnotation(t.Comment) {
+			exemptions = append(exemptions, &exemption{
+				rule:   rule,
+				target: t.Name,
+				table:  t.Name,
+				source: t.Name,
+			})
+		}
+		for _, c := range t.Columns {
+			target := fmt.Sprintf("%s.%s", t.Name, c.Name)
+			for _, rule := range ignoreAnnotation(c.Comment) {
+				exemptions = append(exemptions, &exemption{
+					rule:   rule,
+					target: target,
+					table:  t.Name,
+					source: target,
+				})
+			}
+		}
+	}
+	return exemptions
+}
+
+// applyExemptions remove suppressed rule violations, and add warnings for unused exemptions of enabled rules
+func (l Lint) applyExemptions(s *schema.Schema, warns []RuleWarn) []RuleWarn {
+	exemptions := l.exemptions(s)
+	if len(exemptions) == 0 {
+		return warns
+	}
+	filtered := []RuleWarn{}
+	for _, w := range warns {
+		exempted := false
+		for _, e := range exemptions {
+			if e.matches(w) {
+				e.used = true
+				exempted = true
+			}
+		}
+		if !exempted {
+			filtered = append(filtered, w)
+		}
+	}
+	enabled := []string{}
+	for _, r := range l.Rules() {
+		if r.IsEnabled() {
+			enabled = append(enabled, r.Name())
+		}
+	}
+	for _, e := range exemptions {
+		if e.used {
+			continue
+		}
+		for _, name := range enabled {
+			if match([]string{e.rule}, name) {
+				filtered = append(filtered, RuleWarn{
+					Rule:     UnusedExemptionRuleName,
+					Severity: SeverityWarning,
+					Table:    e.table,
+					Target:   e.source,
+					Message:  fmt.Sprintf("exemption for '%s' is unused.", e.rule),
+				})
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func ignoreAnnotation(comment string) []string {
+	rules := []string{}
+	for _, m := range reIgnoreAnnotation.FindAllStringSubmatch(comment, -1) {
+		for _, r := range strings.Split(m[1], ",") {
+			if r != "" {
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules
+}
@@ -0,0 +1,696 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestRequireTableComment(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		exclude []string
+		want    int
+	}{
+		{true, []string{}, 2},
+		{false, []string{}, 0},
+		{true, []string{"b"}, 1},
+		{true, []string{"*"}, 0},
+	}
+	for i, tt := range tests {
+		r := RequireTableComment{
+			Enabled: tt.enabled,
+			Exclude: tt.exclude,
+		}
+		l := Lint{RequireTableComment: r}
+		warns := l.Check(newTestSchema())
+		if len(warns) != tt.want {
+			t.Errorf("TestRequireTableComment(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestRequireColumnComment(t *testing.T) {
+	tests := []struct {
+		enabled        bool
+		exclude        []string
+		excludedTables []string
+		want           int
+	}{
+		{true, []string{}, []string{}, 2},
+		{false, []string{}, []string{}, 0},
+		{true, []string{"a2"}, []string{}, 1},
+		{true, []string{"b.b2"}, []string{}, 1},
+		{true, []string{}, []string{"a"}, 1},
+		{true, []string{"*2"}, []string{}, 0},
+	}
+	for i, tt := range tests {
+		r := RequireColumnComment{
+			Enabled:        tt.enabled,
+			Exclude:        tt.exclude,
+			ExcludedTables: tt.excludedTables,
+		}
+		l := Lint{RequireColumnComment: r}
+		warns := l.Check(newTestSchema())
+		if len(warns) != tt.want {
+			t.Errorf("TestRequireColumnComment(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestUnrelatedTable(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		exclude []string
+		want    int
+	}{
+		{true, []string{}, 1},
+		{false, []string{}, 0},
+		{true, []string{"c"}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Tables = append(s.Tables, &schema.Table{
+			Name: "c",
+			Type: "BASE TABLE",
+		})
+		r := UnrelatedTable{
+			Enabled: tt.enabled,
+			Exclude: tt.exclude,
+		}
+		l := Lint{UnrelatedTable: r}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestUnrelatedTable(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestColumnCount(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		max     int
+		exclude []string
+		want    int
+	}{
+		{true, 1, []string{}, 2},
+		{false, 1, []string{}, 0},
+		{true, 2, []string{}, 0},
+		{true, 1, []string{"b"}, 1},
+	}
+	for i, tt := range tests {
+		r := ColumnCount{
+			Enabled: tt.enabled,
+			Max:     tt.max,
+			Exclude: tt.exclude,
+		}
+		l := Lint{ColumnCount: r}
+		warns := l.Check(newTestSchema())
+		if len(warns) != tt.want {
+			t.Errorf("TestColumnCount(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+	r := ColumnCount{Enabled: true, Max: 1}
+	expected := "too many columns. [2/1]"
+	actual := r.Check(newTestSchema())[0].Message
+	if actual != expected {
+		t.Errorf("actual %v\nwant %v", actual, expected)
+	}
+}
+
+func TestRequireColumns(t *testing.T) {
+	nullable := true
+	tests := []struct {
+		enabled bool
+		columns []RequiredColumn
+		want    int
+	}{
+		{true, []RequiredColumn{}, 0},
+		{true, []RequiredColumn{RequiredColumn{Name: "created"}}, 2},
+		{false, []RequiredColumn{RequiredColumn{Name: "created"}}, 0},
+		{true, []RequiredColumn{RequiredColumn{Name: "created", Exclude: []string{"a"}}}, 1},
+		{true, []RequiredColumn{RequiredColumn{Name: "a", Exclude: []string{"b"}}}, 0},
+		{true, []RequiredColumn{RequiredColumn{Name: "a", Type: "BIGINT(20)", Exclude: []string{"b"}}}, 0},
+		{true, []RequiredColumn{RequiredColumn{Name: "a", Type: "int", Exclude: []string{"b"}}}, 1},
+		{true, []RequiredColumn{RequiredColumn{Name: "a", Nullable: &nullable, Exclude: []string{"b"}}}, 1},
+	}
+	for i, tt := range tests {
+		r := RequireColumns{
+			Enabled: tt.enabled,
+			Columns: tt.columns,
+		}
+		l := Lint{RequireColumns: r}
+		warns := l.Check(newTestSchema())
+		if len(warns) != tt.want {
+			t.Errorf("TestRequireColumns(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestDuplicateRelations(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		linked  bool
+		want    int
+	}{
+		{true, false, 1},
+		{true, true, 1},
+		{false, false, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		r := s.Relations[0]
+		s.Relations = append(s.Relations, &schema.Relation{
+			Table:         r.Table,
+			Columns:       r.Columns,
+			ParentTable:   r.ParentTable,
+			ParentColumns: r.ParentColumns,
+			IsAdditional:  true,
+		})
+		if tt.linked {
+			// the duplicate is removed from Relations
+			s.LinkRelations()
+		}
+		l := Lint{DuplicateRelations: DuplicateRelations{Enabled: tt.enabled}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestDuplicateRelations(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+	s := newTestSchema()
+	expected := "b(b) -> a(a)"
+	actual := relationLabel(s.Relations[0])
+	if actual != expected {
+		t.Errorf("actual %v\nwant %v", actual, expected)
+	}
+}
+
+func TestUnindexedForeignKey(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		indexes []*schema.Index
+		exclude []string
+		want    int
+	}{
+		{true, []*schema.Index{}, []string{}, 1},
+		{false, []*schema.Index{}, []string{}, 0},
+		{true, []*schema.Index{}, []string{"b"}, 0},
+		{true, []*schema.Index{&schema.Index{Name: "b_b_idx", Columns: []string{"b"}}}, []string{}, 0},
+		{true, []*schema.Index{&schema.Index{Name: "b_b_b2_idx", Columns: []string{"b", "b2"}}}, []string{}, 0},
+		{true, []*schema.Index{&schema.Index{Name: "b_b2_b_idx", Columns: []string{"b2", "b"}}}, []string{}, 1},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		tb, _ := s.FindTableByName("b")
+		tb.Indexes = tt.indexes
+		l := Lint{UnindexedForeignKey: UnindexedForeignKey{
+			Enabled: tt.enabled,
+			Exclude: tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestUnindexedForeignKey(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestNamingConvention(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		tables  NamingRule
+		columns NamingRule
+		indexes NamingRule
+		want    int
+	}{
+		{true, NamingRule{}, NamingRule{}, NamingRule{}, 0},
+		{true, NamingRule{Pattern: "^[a-z_]+s$"}, NamingRule{}, NamingRule{}, 2},
+		{false, NamingRule{Pattern: "^[a-z_]+s$"}, NamingRule{}, NamingRule{}, 0},
+		{true, NamingRule{Pattern: "^[a-z_]+s$", Exclude: []string{"a"}}, NamingRule{}, NamingRule{}, 1},
+		{true, NamingRule{}, NamingRule{Pattern: "^[a-z]+$"}, NamingRule{}, 2},
+		{true, NamingRule{}, NamingRule{Pattern: "^[a-z]+$", Exclude: []string{"a.a2"}}, NamingRule{}, 1},
+		{true, NamingRule{}, NamingRule{}, NamingRule{Pattern: "^idx_"}, 1},
+		{true, NamingRule{}, NamingRule{}, NamingRule{Pattern: "("}, 1},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Tables[0].Indexes = []*schema.Index{&schema.Index{Name: "a_a_idx", Columns: []string{"a"}}}
+		l := Lint{NamingConvention: NamingConvention{
+			Enabled: tt.enabled,
+			Tables:  tt.tables,
+			Columns: tt.columns,
+			Indexes: tt.indexes,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestNamingConvention(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestRequirePrimaryKey(t *testing.T) {
+	tests := []struct {
+		enabled          bool
+		allowUniqueIndex bool
+		exclude          []string
+		want             int
+	}{
+		{true, false, []string{}, 1},
+		{false, false, []string{}, 0},
+		{true, false, []string{"b"}, 0},
+		{true, true, []string{}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Tables[0].Constraints = []*schema.Constraint{&schema.Constraint{Name: "PRIMARY", Type: "PRIMARY KEY", Def: "PRIMARY KEY (a)"}}
+		s.Tables[1].Indexes = []*schema.Index{&schema.Index{Name: "b_b2_key", Def: "CREATE UNIQUE INDEX b_b2_key ON b(b2)", Columns: []string{"b2"}}}
+		s.Tables = append(s.Tables, &schema.Table{Name: "v", Type: "VIEW"})
+		l := Lint{RequirePrimaryKey: RequirePrimaryKey{
+			Enabled:          tt.enabled,
+			AllowUniqueIndex: tt.allowUniqueIndex,
+			Exclude:          tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestRequirePrimaryKey(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestReservedWords(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		dialect string
+		driver  string
+		exclude []string
+		want    int
+	}{
+		{true, "", "", []string{}, 3},
+		{false, "", "", []string{}, 0},
+		{true, "", "postgres", []string{}, 3},
+		{true, "", "mysql", []string{}, 2},
+		{true, "mysql", "postgres", []string{}, 2},
+		{true, "", "", []string{"order", "order.*"}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Driver = tt.driver
+		s.Tables = append(s.Tables, &schema.Table{
+			Name: "order",
+			Columns: []*schema.Column{
+				&schema.Column{Name: "desc"},
+				&schema.Column{Name: "user"},
+			},
+		})
+		l := Lint{ReservedWords: ReservedWords{
+			Enabled: tt.enabled,
+			Dialect: tt.dialect,
+			Exclude: tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestReservedWords(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestForeignKeyNullability(t *testing.T) {
+	tests := []struct {
+		enabled        bool
+		columnNullable bool
+		nullable       bool
+		exclude        []string
+		want           int
+	}{
+		{true, true, false, []string{}, 1},
+		{true, false, false, []string{}, 0},
+		{false, true, false, []string{}, 0},
+		{true, false, true, []string{}, 1},
+		{true, true, false, []string{"b.b"}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Relations[0].Columns[0].Nullable = tt.columnNullable
+		l := Lint{ForeignKeyNullability: ForeignKeyNullability{
+			Enabled:  tt.enabled,
+			Nullable: tt.nullable,
+			Exclude:  tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestForeignKeyNullability(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     string
+	}{
+		{"", SeverityError},
+		{"error", SeverityError},
+		{"warning", SeverityWarning},
+		{"info", SeverityInfo},
+	}
+	for i, tt := range tests {
+		l := Lint{RequireTableComment: RequireTableComment{Enabled: true, Severity: tt.severity}}
+		warns := l.Check(newTestSchema())
+		for _, w := range warns {
+			if w.Severity != tt.want {
+				t.Errorf("TestSeverity(%d): actual %v\nwant %v", i, w.Severity, tt.want)
+			}
+		}
+	}
+}
+
+func TestLintValidate(t *testing.T) {
+	tests := []struct {
+		failOn   string
+		severity string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{"warning", "info", false},
+		{"fatal", "", true},
+		{"", "critical", true},
+	}
+	for i, tt := range tests {
+		l := Lint{
+			FailOn:              tt.failOn,
+			RequireTableComment: RequireTableComment{Severity: tt.severity},
+		}
+		err := l.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("TestLintValidate(%d): actual %v\nwant %v", i, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLintValidateColumnCount(t *testing.T) {
+	tests := []struct {
+		rule    ColumnCount
+		wantErr bool
+	}{
+		{ColumnCount{}, false},
+		{ColumnCount{Enabled: true, Max: 30}, false},
+		{ColumnCount{Enabled: true}, true},
+		{ColumnCount{Enabled: true, Max: -1}, true},
+	}
+	for i, tt := range tests {
+		l := Lint{ColumnCount: tt.rule}
+		err := l.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("TestLintValidateColumnCount(%d): actual %v\nwant %v", i, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIsFailed(t *testing.T) {
+	tests := []struct {
+		failOn      string
+		severities  []string
+		maxWarnings int
+		want        bool
+	}{
+		{"", []string{}, -1, false},
+		{"", []string{"error"}, -1, true},
+		{"", []string{"warning", "warning", "info"}, -1, false},
+		{"", []string{"warning", "warning", "info"}, 2, false},
+		{"", []string{"warning", "warning", "info"}, 1, true},
+		{"", []string{"info"}, 0, false},
+		{"warning", []string{"warning"}, -1, true},
+		{"warning", []string{"info"}, -1, false},
+		{"info", []string{"info"}, -1, true},
+	}
+	for i, tt := range tests {
+		warns := []RuleWarn{}
+		for _, s := range tt.severities {
+			warns = append(warns, RuleWarn{Severity: s})
+		}
+		l := Lint{FailOn: tt.failOn}
+		got := l.IsFailed(warns, tt.maxWarnings)
+		if got != tt.want {
+			t.Errorf("TestIsFailed(%d): actual %v\nwant %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	tests := []struct {
+		target    string
+		condition string
+		exclude   []string
+		want      int
+	}{
+		{"table", `table.Comment == ""`, []string{}, 2},
+		{"table", `table.Name == "a"`, []string{}, 1},
+		{"table", `table.Comment == ""`, []string{"a"}, 1},
+		{"column", `column.Name endsWith "2"`, []string{}, 2},
+		{"column", `column.Name endsWith "2"`, []string{"b.b2"}, 1},
+		{"column", `column.IsForeignKey`, []string{}, 1},
+		{"column", `table.Name == "b" && len(table.Columns) == 2`, []string{}, 2},
+	}
+	for i, tt := range tests {
+		r := CustomRule{
+			RuleName:  "custom",
+			Target:    tt.target,
+			Condition: tt.condition,
+			Exclude:   tt.exclude,
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		l := Lint{CustomRules: []CustomRule{r}}
+		warns := l.Check(newTestSchema())
+		if len(warns) != tt.want {
+			t.Errorf("TestCustomRule(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestCustomRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule    CustomRule
+		wantErr bool
+	}{
+		{CustomRule{RuleName: "custom", Target: "table", Condition: `table.Name == "a"`}, false},
+		{CustomRule{Target: "table", Condition: `table.Name == "a"`}, true},
+		{CustomRule{RuleName: "custom", Target: "index", Condition: `table.Name == "a"`}, true},
+		{CustomRule{RuleName: "custom", Target: "column", Condition: `column.Name ==`}, true},
+	}
+	for i, tt := range tests {
+		err := tt.rule.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("TestCustomRuleValidate(%d): actual %v\nwant %v", i, err, tt.wantErr)
+		}
+	}
+}
+
+func TestForeignKeyTypeMismatch(t *testing.T) {
+	tests := []struct {
+		enabled    bool
+		columnType string
+		parentType string
+		exclude    []string
+		want       int
+	}{
+		{true, "bigint(20)", "bigint(20)", []string{}, 0},
+		{false, "int", "bigint", []string{}, 0},
+		{true, "int", "bigint", []string{}, 1},
+		{true, "int(10) unsigned", "int(11)", []string{}, 1},
+		{true, "int(10) unsigned", "int(11) unsigned", []string{}, 0},
+		{true, "varchar(255)", "varchar(191)", []string{}, 1},
+		{true, "INTEGER", "serial", []string{}, 0},
+		{true, "int8", "bigserial", []string{}, 0},
+		{true, "int", "bigint", []string{"b.b"}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Relations[0].Columns[0].Type = tt.columnType
+		s.Relations[0].ParentColumns[0].Type = tt.parentType
+		l := Lint{ForeignKeyTypeMismatch: ForeignKeyTypeMismatch{
+			Enabled: tt.enabled,
+			Exclude: tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestForeignKeyTypeMismatch(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestExemptions(t *testing.T) {
+	tests := []struct {
+		tableComment  string
+		columnComment string
+		ignores       []LintIgnore
+		want          []string
+	}{
+		{"", "", []LintIgnore{}, []string{"a", "b", "b.b"}},
+		{"", "tbls:ignore=foreignKeyNullability", []LintIgnore{}, []string{"a", "b"}},
+		{"tbls:ignore=requireTableComment", "", []LintIgnore{}, []string{"b", "b.b", "a"}},
+		{"", "", []LintIgnore{{Rule: "foreignKeyNullability", Target: "b.*"}}, []string{"a", "b"}},
+		{"", "", []LintIgnore{{Rule: "*", Target: "b"}}, []string{"a"}},
+		{"", "tbls:ignore=unrelatedTable", []LintIgnore{}, []string{"a", "b", "b.b"}},
+		{"", "", []LintIgnore{{Rule: "requireTableComment", Target: "c"}}, []string{"a", "b", "b.b", "c"}},
+		{"", "tbls:ignore=requireTableComment,foreignKeyNullability", []LintIgnore{}, []string{"a", "b", "b.b"}},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Tables[0].Comment = tt.tableComment
+		s.Tables[1].Columns[0].Comment = tt.columnComment
+		l := Lint{
+			RequireTableComment:   RequireTableComment{Enabled: true},
+			ForeignKeyNullability: ForeignKeyNullability{Enabled: true, Nullable: true},
+			Ignores:               tt.ignores,
+		}
+		got := []string{}
+		for _, w := range l.Check(s) {
+			got = append(got, w.Target)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TestExemptions(%d): actual %v\nwant %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestRequireEnumValues(t *testing.T) {
+	tests := []struct {
+		enabled       bool
+		columnName    string
+		columnType    string
+		comment       string
+		columns       []string
+		valuesPattern string
+		exclude       []string
+		want          int
+	}{
+		{true, "order_status", "int", "", []string{}, "", []string{}, 1},
+		{false, "order_status", "int", "", []string{}, "", []string{}, 0},
+		{true, "order_status", "int", "status of the order", []string{}, "", []string{}, 1},
+		{true, "order_status", "int", "0: draft, 1: published", []string{}, "", []string{}, 0},
+		{true, "kind", "int", "0=a 1=b", []string{}, "", []string{}, 0},
+		{true, "entry_type", "enum('a','b')", "", []string{}, "", []string{}, 0},
+		{true, "name", "text", "", []string{}, "", []string{}, 0},
+		{true, "kind", "int", "", []string{"kind"}, "", []string{}, 1},
+		{true, "is_flag", "int", "draft / published / archived", []string{"is_*"}, `\w+ /`, []string{}, 0},
+		{true, "order_status", "int", "", []string{}, "", []string{"a.order_status"}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		c := s.Tables[0].Columns[1]
+		c.Name = tt.columnName
+		c.Type = tt.columnType
+		c.Comment = tt.comment
+		l := Lint{RequireEnumValues: RequireEnumValues{
+			Enabled:       tt.enabled,
+			Columns:       tt.columns,
+			ValuesPattern: tt.valuesPattern,
+			Exclude:       tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestRequireEnumValues(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func TestMixedCharsetCollation(t *testing.T) {
+	tests := []struct {
+		enabled         bool
+		tableCollation  string
+		columnCharset   string
+		columnCollation string
+		collation       string
+		exclude         []string
+		want            int
+	}{
+		{true, "utf8mb4_general_ci", "utf8mb4", "utf8mb4_general_ci", "", []string{}, 0},
+		{false, "latin1_swedish_ci", "latin1", "latin1_swedish_ci", "", []string{}, 0},
+		{true, "latin1_swedish_ci", "utf8mb4", "utf8mb4_general_ci", "", []string{}, 1},
+		{true, "utf8mb4_general_ci", "latin1", "latin1_swedish_ci", "", []string{}, 1},
+		{true, "utf8mb4_general_ci", "utf8mb4", "utf8mb4_bin", "", []string{}, 1},
+		{true, "utf8mb4_bin", "utf8mb4", "utf8mb4_bin", "utf8mb4_bin", []string{}, 0},
+		{true, "", "", "", "", []string{}, 0},
+		{true, "latin1_swedish_ci", "latin1", "latin1_swedish_ci", "", []string{"a"}, 0},
+		{true, "utf8mb4_general_ci", "latin1", "latin1_swedish_ci", "", []string{"a.a2"}, 0},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		s.Charset = "utf8mb4"
+		s.Collation = "utf8mb4_general_ci"
+		s.Tables[0].Collation = tt.tableCollation
+		s.Tables[0].Columns[1].Charset = tt.columnCharset
+		s.Tables[0].Columns[1].Collation = tt.columnCollation
+		l := Lint{MixedCharsetCollation: MixedCharsetCollation{
+			Enabled:   tt.enabled,
+			Collation: tt.collation,
+			Exclude:   tt.exclude,
+		}}
+		warns := l.Check(s)
+		if len(warns) != tt.want {
+			t.Errorf("TestMixedCharsetCollation(%d): actual %v\nwant %v", i, len(warns), tt.want)
+		}
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint(20)",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Type:    "text",
+		Comment: "column b",
+	}
+
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "",
+		Columns: []*schema.Column{
+			ca,
+			&schema.Column{
+				Name:    "a2",
+				Type:    "datetime",
+				Comment: "",
+			},
+		},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Comment: "",
+		Columns: []*schema.Column{
+			cb,
+			&schema.Column{
+				Name:    "b2",
+				Type:    "text",
+				Comment: "",
+			},
+		},
+	}
+	r := &schema.Relation{
+		Table:         tb,
+		Columns:       []*schema.Column{cb},
+		ParentTable:   ta,
+		ParentColumns: []*schema.Column{ca},
+	}
+	ca.ChildRelations = []*schema.Relation{r}
+	cb.ParentRelations = []*schema.Relation{r}
+
+	s := &schema.Schema{
+		Name: "testschema",
+		Tables: []*schema.Table{
+			ta,
+			tb,
+		},
+		Relations: []*schema.Relation{
+			r,
+		},
+	}
+	return s
+}
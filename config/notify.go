@@ -0,0 +1,25 @@
+package config
+
+// Notify is the struct for notifications of schema drift detected by `tbls diff`
+type Notify struct {
+	Slack    Webhook         `yaml:"slack,omitempty"`
+	Teams    Webhook         `yaml:"teams,omitempty"`
+	Webhooks []CustomWebhook `yaml:"webhooks,omitempty"`
+}
+
+// Webhook is the struct for an incoming webhook
+type Webhook struct {
+	WebhookURL string `yaml:"webhookUrl"`
+}
+
+// CustomWebhook is the struct for a webhook receiving the JSON payload of schema drift detected by `tbls diff` or `tbls doc`.
+// The URL and header values can be DSN references such as env://WEBHOOK_TOKEN.
+type CustomWebhook struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// Enabled return whether any notification is configured
+func (n Notify) Enabled() bool {
+	return n.Slack.WebhookURL != "" || n.Teams.WebhookURL != "" || len(n.Webhooks) > 0
+}
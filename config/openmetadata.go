@@ -0,0 +1,9 @@
+package config
+
+// OpenMetadata is the struct for the metadata in the ingestion format of OpenMetadata
+type OpenMetadata struct {
+	Service string `yaml:"service,omitempty"`
+}
+
+// DefaultOpenMetadataService is the name of the database service when openMetadata.service is not set
+const DefaultOpenMetadataService = "tbls"
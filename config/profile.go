@@ -0,0 +1,71 @@
+package config
+
+import "path/filepath"
+
+// Profiling metrics of `tbls profile`
+const (
+	// ProfileNulls is the ratio of NULL values
+	ProfileNulls = "nulls"
+	// ProfileCardinality is the number of distinct values
+	ProfileCardinality = "cardinality"
+	// ProfileTopK is the most frequent values and their counts
+	ProfileTopK = "topk"
+)
+
+// DefaultProfileMetrics is the profiling metrics when profile.metrics is not set
+var DefaultProfileMetrics = []string{ProfileNulls, ProfileCardinality, ProfileTopK}
+
+// DefaultProfileTopK is the number of the most frequent values when profile.topK is not set
+const DefaultProfileTopK = 5
+
+// DefaultProfileFile is the file name of the data profiling report in the document path when profile.path is not set
+const DefaultProfileFile = "profile.md"
+
+// Profile is the struct for the data profiling report of `tbls profile`
+type Profile struct {
+	// Path is the path of the report
+	Path string `yaml:"path,omitempty"`
+	// Tables is the allowlist of tables (glob patterns) to profile. All tables are profiled when it is empty.
+	Tables []string `yaml:"tables,omitempty"`
+	// Metrics is the profiling metrics (nulls, cardinality, topk)
+	Metrics []string `yaml:"metrics,omitempty"`
+	// TopK is the number of the most frequent values of each column
+	TopK int `yaml:"topK,omitempty"`
+	// SampleSize is the number of rows of each table scanned. All rows are scanned when it is zero.
+	SampleSize int `yaml:"sampleSize,omitempty"`
+}
+
+// ProfilePath return the path of the data profiling report
+func (c *Config) ProfilePath() string {
+	if c.Profile.Path != "" {
+		return c.Profile.Path
+	}
+	return filepath.Join(c.DocPath, DefaultProfileFile)
+}
+
+// Allowed return whether the table is profiled
+func (p Profile) Allowed(table string) bool {
+	return len(p.Tables) == 0 || match(p.Tables, table)
+}
+
+// Enabled return whether the metric is profiled
+func (p Profile) Enabled(metric string) bool {
+	metrics := p.Metrics
+	if len(metrics) == 0 {
+		metrics = DefaultProfileMetrics
+	}
+	for _, m := range metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// K return the number of the most frequent values of each column
+func (p Profile) K() int {
+	if p.TopK > 0 {
+		return p.TopK
+	}
+	return DefaultProfileTopK
+}
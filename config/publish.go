@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Publish is the struct for publishing documents to external services
+type Publish struct {
+	Confluence  Confluence  `yaml:"confluence,omitempty"`
+	Notion      Notion      `yaml:"notion,omitempty"`
+	DataHub     DataHub     `yaml:"datahub,omitempty"`
+	OpenLineage OpenLineage `yaml:"openlineage,omitempty"`
+}
+
+// Confluence is the struct for publishing documents to a Confluence space
+type Confluence struct {
+	URL      string `yaml:"url"`
+	Space    string `yaml:"space"`
+	ParentID string `yaml:"parentId,omitempty"`
+	User     string `yaml:"user,omitempty"`
+	Token    string `yaml:"token"`
+}
+
+// Notion is the struct for publishing documents to Notion pages
+type Notion struct {
+	URL      string `yaml:"url,omitempty"`
+	ParentID string `yaml:"parentId"`
+	Token    string `yaml:"token"`
+}
+
+// DataHub is the struct for emitting metadata to a DataHub GMS endpoint
+type DataHub struct {
+	URL      string `yaml:"url"`
+	Token    string `yaml:"token,omitempty"`
+	Platform string `yaml:"platform,omitempty"`
+	Env      string `yaml:"env,omitempty"`
+}
+
+// OpenLineage is the struct for emitting dataset events to an OpenLineage endpoint
+type OpenLineage struct {
+	URL       string `yaml:"url"`
+	Endpoint  string `yaml:"endpoint,omitempty"`
+	APIKey    string `yaml:"apiKey,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// ValidateConfluence validate config for publishing documents to Confluence
+func (c *Config) ValidateConfluence() error {
+	cf := c.Publish.Confluence
+	if cf.URL == "" || cf.Space == "" {
+		return errors.WithStack(fmt.Errorf("%s: publish.confluence.url and publish.confluence.space are required", c.label()))
+	}
+	if cf.Token == "" {
+		return errors.WithStack(fmt.Errorf("%s: publish.confluence.token is required", c.label()))
+	}
+	return nil
+}
+
+// ValidateNotion validate config for publishing documents to Notion
+func (c *Config) ValidateNotion() error {
+	n := c.Publish.Notion
+	if n.ParentID == "" || n.Token == "" {
+		return errors.WithStack(fmt.Errorf("%s: publish.notion.parentId and publish.notion.token are required", c.label()))
+	}
+	return nil
+}
+
+// ValidateDataHub validate config for emitting metadata to DataHub
+func (c *Config) ValidateDataHub() error {
+	if c.Publish.DataHub.URL == "" {
+		return errors.WithStack(fmt.Errorf("%s: publish.datahub.url is required", c.label()))
+	}
+	return nil
+}
+
+// ValidateOpenLineage validate config for emitting dataset events to OpenLineage
+func (c *Config) ValidateOpenLineage() error {
+	if c.Publish.OpenLineage.URL == "" {
+		return errors.WithStack(fmt.Errorf("%s: publish.openlineage.url is required", c.label()))
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package config
+
+// Report is the struct for the HTML report of `tbls report`
+type Report struct {
+	Path string `yaml:"path,omitempty"`
+}
+
+// DefaultReportPath is the output directory of the HTML report when report.path is not set
+const DefaultReportPath = "dbreport"
+
+// ReportPath return the output directory of the HTML report
+func (c *Config) ReportPath() string {
+	if c.Report.Path != "" {
+		return c.Report.Path
+	}
+	return DefaultReportPath
+}
@@ -0,0 +1,9 @@
+package config
+
+// Modes of row counts of tables
+const (
+	// RowCountExact counts rows of tables with COUNT(*)
+	RowCountExact = "exact"
+	// RowCountEstimate reads the estimated row counts from the statistics of the planner
+	RowCountEstimate = "estimate"
+)
@@ -0,0 +1,44 @@
+package config
+
+// DefaultSampleMaskReplacement is the replacement of masked values of sample rows when samples.masks[].replacement is not set
+const DefaultSampleMaskReplacement = "****"
+
+// Samples is the struct for sample rows of tables in the documents
+type Samples struct {
+	// Rows is the number of sample rows selected per table. Sampling is disabled when it is zero.
+	Rows int `yaml:"rows,omitempty"`
+	// Tables is the allowlist of tables (glob patterns) to select sample rows from
+	Tables []string `yaml:"tables,omitempty"`
+	// Masks replace values of matching columns in sample rows
+	Masks []SampleMask `yaml:"masks,omitempty"`
+}
+
+// SampleMask is the struct for the masking rule of sample rows
+type SampleMask struct {
+	// Columns is glob patterns of column names or `table.column`
+	Columns     []string `yaml:"columns"`
+	Replacement string   `yaml:"replacement,omitempty"`
+}
+
+// Enabled return whether sample rows are selected
+func (s Samples) Enabled() bool {
+	return s.Rows > 0 && len(s.Tables) > 0
+}
+
+// Allowed return whether sample rows of the table are selected
+func (s Samples) Allowed(table string) bool {
+	return s.Enabled() && match(s.Tables, table)
+}
+
+// Mask return the replacement of values of the column, and whether the column is masked
+func (s Samples) Mask(table, column string) (string, bool) {
+	for _, m := range s.Masks {
+		if match(m.Columns, column) || match(m.Columns, table+"."+column) {
+			if m.Replacement == "" {
+				return DefaultSampleMaskReplacement, true
+			}
+			return m.Replacement, true
+		}
+	}
+	return "", false
+}
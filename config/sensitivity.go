@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// DetectSensitivity is the struct for detecting sensitive columns (PII) and tagging them with sensitivity labels
+type DetectSensitivity struct {
+	Enabled bool `yaml:"enabled"`
+	// Strategy is `default` (builtin detectors of email, phone and national ID) or `none`
+	Strategy  string                       `yaml:"strategy,omitempty"`
+	Detectors []schema.SensitivityDetector `yaml:"detectors,omitempty"`
+	// SampleRows is the number of rows of each table whose values are matched with detectors. Values are not matched when it is zero.
+	SampleRows int `yaml:"sampleRows,omitempty"`
+}
+
+// SensitivityDetectors return the builtin detectors of the strategy followed by the detectors of the config
+func (d DetectSensitivity) SensitivityDetectors() []schema.SensitivityDetector {
+	detectors := []schema.SensitivityDetector{}
+	if d.Strategy != "none" {
+		detectors = append(detectors, schema.DefaultSensitivityDetectors...)
+	}
+	return append(detectors, d.Detectors...)
+}
+
+// validate check the strategy and the detectors
+func (d DetectSensitivity) validate() error {
+	switch d.Strategy {
+	case "", "default", "none":
+	default:
+		return errors.WithStack(fmt.Errorf("unsupported detectSensitivity.strategy '%s' (default or none)", d.Strategy))
+	}
+	if d.SampleRows < 0 {
+		return errors.New("detectSensitivity.sampleRows must not be negative")
+	}
+	for _, detector := range d.Detectors {
+		if err := detector.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package config
+
+// SSHTunnel is the struct for the SSH tunnel (local port forwarding through the bastion host) to connect to the database
+type SSHTunnel struct {
+	Host         string   `yaml:"host"`
+	Port         int      `yaml:"port,omitempty"`
+	User         string   `yaml:"user,omitempty"`
+	IdentityFile string   `yaml:"identityFile,omitempty"`
+	Options      []string `yaml:"options,omitempty"`
+}
+
+// Enabled return whether the SSH tunnel is configured
+func (t SSHTunnel) Enabled() bool {
+	return t.Host != ""
+}
@@ -0,0 +1,19 @@
+package config
+
+// DefaultStorageTop is the number of the largest tables listed in the index when storage.top is not set
+const DefaultStorageTop = 10
+
+// Storage is the struct for on-disk sizes of tables and indexes (PostgreSQL and MySQL) in the documents
+type Storage struct {
+	Enabled bool `yaml:"enabled"`
+	// Top is the number of the largest tables listed in the index
+	Top int `yaml:"top,omitempty"`
+}
+
+// N return the number of the largest tables listed in the index
+func (s Storage) N() int {
+	if s.Top > 0 {
+		return s.Top
+	}
+	return DefaultStorageTop
+}
@@ -0,0 +1,29 @@
+package config
+
+// TLS is the struct for the TLS/SSL connection to the database
+//
+//	mode: disable, require (encrypt without verification), verify-ca (verify the certificate chain) or verify-full (verify the chain and the server name)
+type TLS struct {
+	Mode       string `yaml:"mode,omitempty"`
+	CA         string `yaml:"ca,omitempty"`
+	Cert       string `yaml:"cert,omitempty"`
+	Key        string `yaml:"key,omitempty"`
+	ServerName string `yaml:"serverName,omitempty"`
+}
+
+// Enabled return whether the TLS connection is configured
+func (t TLS) Enabled() bool {
+	return t.Mode != "" || t.CA != "" || t.Cert != "" || t.Key != "" || t.ServerName != ""
+}
+
+// SSLMode return the mode of the TLS connection. It is verify-full when the CA is set, otherwise require.
+func (t TLS) SSLMode() string {
+	switch {
+	case t.Mode != "":
+		return t.Mode
+	case t.CA != "":
+		return "verify-full"
+	default:
+		return "require"
+	}
+}
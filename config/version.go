@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// CheckRequiredVersion check whether the version satisfies `requiredVersion:` (e.g. ">= 1.5 < 2")
+func (c *Config) CheckRequiredVersion(v string) error {
+	if c.RequiredVersion == "" {
+		return nil
+	}
+	ok, err := satisfiesVersion(v, c.RequiredVersion)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithStack(fmt.Errorf("the required tbls version is '%s', but running tbls version is %s", c.RequiredVersion, v))
+	}
+	return nil
+}
+
+// satisfiesVersion check version against space/comma separated constraints
+func satisfiesVersion(v string, constraints string) (bool, error) {
+	current, err := parseVersion(v)
+	if err != nil {
+		return false, err
+	}
+	tokens := strings.Fields(strings.Replace(constraints, ",", " ", -1))
+	for i := 0; i < len(tokens); i++ {
+		op := "="
+		token := tokens[i]
+		for _, o := range versionOperators {
+			if strings.HasPrefix(token, o) {
+				op = o
+				token = strings.TrimPrefix(token, o)
+				break
+			}
+		}
+		if token == "" {
+			if i+1 >= len(tokens) {
+				return false, errors.WithStack(fmt.Errorf("invalid requiredVersion '%s'", constraints))
+			}
+			i++
+			token = tokens[i]
+		}
+		required, err := parseVersion(token)
+		if err != nil {
+			return false, errors.Wrap(err, fmt.Sprintf("invalid requiredVersion '%s'", constraints))
+		}
+		cmp := compareVersion(current, required)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func parseVersion(v string) ([]int, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parsed := []int{}
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, errors.WithStack(fmt.Errorf("invalid version '%s'", v))
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed, nil
+}
+
+func compareVersion(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,280 @@
+// Package datasource is the entry point for Go programs embedding tbls to analyze a database (or load a schema JSON) into *schema.Schema
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/db"
+	"github.com/k1LoW/tbls/profile"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// jsonScheme is the scheme of the DSN of the schema JSON file
+const jsonScheme = "json://"
+
+// yamlScheme is the scheme of the DSN of the schema YAML file
+const yamlScheme = "yaml://"
+
+// Analyze analyze the database of the DSN, or load the schema JSON of `json://path/to/schema.json` (or the schema YAML of `yaml://path/to/schema.yml`).
+// DSN references (`env://`, `vault://`, `${...}`, ...) are resolved.
+func Analyze(dsn string) (*schema.Schema, error) {
+	return AnalyzeContext(context.Background(), dsn)
+}
+
+// AnalyzeContext is Analyze with the context. Analysis in progress is canceled when ctx is done.
+func AnalyzeContext(ctx context.Context, dsn string) (*schema.Schema, error) {
+	c := config.New()
+	c.DSN = config.DSN(dsn)
+	return AnalyzeWithConfigContext(ctx, c)
+}
+
+// AnalyzeJSON load the schema JSON (output of `tbls out -t json`, or schema.json of the document)
+func AnalyzeJSON(path string) (*schema.Schema, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &schema.Schema{}
+	err = json.Unmarshal(b, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse schema JSON")
+	}
+	return s, nil
+}
+
+// AnalyzeYAML load the schema YAML (output of `tbls out -t yaml`)
+func AnalyzeYAML(path string) (*schema.Schema, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &schema.Schema{}
+	err = yaml.Unmarshal(b, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse schema YAML")
+	}
+	return s, nil
+}
+
+// AnalyzeWithConfig analyze the database of the DSN of the config, and apply the config (additional data, virtual relations, table/column filters, labels, sort option, ...) to the schema in the same way as `tbls doc`.
+func AnalyzeWithConfig(c *config.Config) (*schema.Schema, error) {
+	return AnalyzeWithConfigContext(context.Background(), c)
+}
+
+// AnalyzeWithConfigContext is AnalyzeWithConfig with the context. Analysis in progress is canceled when ctx is done.
+func AnalyzeWithConfigContext(ctx context.Context, c *config.Config) (*schema.Schema, error) {
+	return analyzeWithConfig(ctx, c, c)
+}
+
+// AnalyzeSchemaWithConfigContext is AnalyzeWithConfigContext without collecting the data of tables (row counts, statistics, sample rows, ...), which change without schema changes.
+func AnalyzeSchemaWithConfigContext(ctx context.Context, c *config.Config) (*schema.Schema, error) {
+	return analyzeWithConfig(ctx, c, profile.WithoutData(c))
+}
+
+// analyzeWithConfig analyze the database of the DSN of the config, apply the config, and profile the data of tables in the way of pc
+func analyzeWithConfig(ctx context.Context, c *config.Config, pc *config.Config) (*schema.Schema, error) {
+	if c.DSN == "" {
+		return nil, errors.New("DSN is required")
+	}
+	dsn, err := config.ResolveDSN(string(c.DSN))
+	if err != nil {
+		return nil, err
+	}
+	s, err := analyzeDSN(ctx, dsn, c)
+	if err != nil {
+		return nil, err
+	}
+	endApply := trace.Start(ctx, "apply config")
+	err = applyConfig(s, c)
+	endApply()
+	if err != nil {
+		return nil, err
+	}
+	if profile.Enabled(pc) {
+		err = profileDSN(ctx, dsn, s, pc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// applyConfig apply the config (additional data, virtual relations, table/column filters, labels, sort option, ...) to the analyzed schema
+func applyConfig(s *schema.Schema, c *config.Config) error {
+	var err error
+	if c.Format.NormalizeTypes {
+		s.NormalizeTypes()
+	}
+	for _, path := range c.AdditionalData {
+		err = s.LoadAdditionalData(path)
+		if err != nil {
+			return err
+		}
+	}
+	if c.Dbt.Manifest != "" {
+		err = s.LoadDbtManifest(c.Dbt.Manifest)
+		if err != nil {
+			return err
+		}
+	}
+	if c.DetectVirtualRelations.Enabled {
+		err = s.DetectVirtualRelations(c.DetectVirtualRelations.Strategy, c.DetectVirtualRelations.Rules)
+		if err != nil {
+			return err
+		}
+	}
+	if len(c.Include) > 0 || len(c.Exclude) > 0 {
+		err = s.Filter(c.Include, c.Exclude)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.AssignLabels(c.Labels)
+	if err != nil {
+		return err
+	}
+	if c.DetectSensitivity.Enabled {
+		err = s.DetectSensitivity(c.DetectSensitivity.SensitivityDetectors())
+		if err != nil {
+			return err
+		}
+	}
+	if len(c.HideColumns) > 0 {
+		err = s.HideColumns(c.HideColumns)
+		if err != nil {
+			return err
+		}
+	}
+	if c.ER.DetectCardinality {
+		s.DetectCardinalities()
+	}
+	if c.Format.Sort {
+		err = s.SortWithOption(schema.SortOption{
+			KeepColumnOrder: c.Format.KeepColumnOrder,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analyzeDSN analyze the database of the resolved DSN with IAM authentication, the SSH tunnel and the cache of the config.
+// Databases of the DSN whose scheme has the driver plugin in PATH are analyzed by the plugin.
+func analyzeDSN(ctx context.Context, dsn string, c *config.Config) (*schema.Schema, error) {
+	if strings.HasPrefix(dsn, jsonScheme) {
+		return AnalyzeJSON(strings.TrimPrefix(dsn, jsonScheme))
+	}
+	if strings.HasPrefix(dsn, yamlScheme) {
+		return AnalyzeYAML(strings.TrimPrefix(dsn, yamlScheme))
+	}
+	dsn, closeTunnel, err := prepareDSN(dsn, c)
+	if err != nil {
+		return nil, err
+	}
+	defer closeTunnel()
+	if path, ok := findPlugin(dsn); ok {
+		return analyzePlugin(ctx, path, dsn)
+	}
+	return db.AnalyzeContext(ctx, dsn, dbOption(c))
+}
+
+// profileDSN collect the data of tables of the schema from the database of the resolved DSN in the way of the config.
+// Schema files and databases analyzed by plugins are not profiled.
+func profileDSN(ctx context.Context, dsn string, s *schema.Schema, c *config.Config) error {
+	if strings.HasPrefix(dsn, jsonScheme) || strings.HasPrefix(dsn, yamlScheme) {
+		return nil
+	}
+	if _, ok := findPlugin(dsn); ok {
+		return nil
+	}
+	conn, closeConn, err := openDSN(ctx, dsn, c)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	return profile.Run(ctx, conn, s, c)
+}
+
+// OpenContext open the database of the DSN of the config with IAM authentication and the SSH tunnel of the config, to query the data of tables.
+// The returned func closes the connection and the tunnel.
+func OpenContext(ctx context.Context, c *config.Config) (*sql.DB, func(), error) {
+	if c.DSN == "" {
+		return nil, nil, errors.New("DSN is required")
+	}
+	dsn, err := config.ResolveDSN(string(c.DSN))
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.HasPrefix(dsn, jsonScheme) || strings.HasPrefix(dsn, yamlScheme) {
+		return nil, nil, errors.New("schema files have no data to query")
+	}
+	if _, ok := findPlugin(dsn); ok {
+		return nil, nil, errors.New("databases analyzed by driver plugins can not be queried")
+	}
+	return openDSN(ctx, dsn, c)
+}
+
+// openDSN open the database of the resolved DSN. The returned func closes the connection and the tunnel.
+func openDSN(ctx context.Context, dsn string, c *config.Config) (*sql.DB, func(), error) {
+	dsn, closeTunnel, err := prepareDSN(dsn, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := db.Open(ctx, dsn, dbOption(c))
+	if err != nil {
+		closeTunnel()
+		return nil, nil, err
+	}
+	return conn, func() {
+		conn.Close()
+		closeTunnel()
+	}, nil
+}
+
+// prepareDSN apply IAM authentication and TLS, and open the SSH tunnel of the config to the resolved DSN. The returned func closes the tunnel.
+func prepareDSN(dsn string, c *config.Config) (string, func(), error) {
+	var err error
+	if c.IAMAuth.Enabled() {
+		dsn, err = db.ApplyIAMAuth(dsn, c.IAMAuth)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if c.TLS.Enabled() {
+		dsn, err = db.ApplyTLS(dsn, c.TLS)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if c.SSHTunnel.Enabled() {
+		tunnelDSN, tunnel, err := db.OpenSSHTunnel(dsn, c.SSHTunnel)
+		if err != nil {
+			return "", nil, err
+		}
+		return tunnelDSN, func() { tunnel.Close() }, nil
+	}
+	return dsn, func() {}, nil
+}
+
+// dbOption return the option of analyzing the database of the config
+func dbOption(c *config.Config) db.Option {
+	return db.Option{
+		IncludeSystemSchemas: c.IncludeSystemSchemas,
+		Concurrency:          c.Concurrency,
+		ConnectRetries:       c.Connect.Retries,
+		ConnectBackoff:       c.Connect.Backoff,
+		Wait:                 c.Connect.Wait,
+		QueryTimeout:         c.QueryTimeout,
+		CacheDir:             c.CachePath(),
+		CacheKey:             string(c.DSN),
+	}
+}
@@ -0,0 +1,143 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output/json"
+	"github.com/k1LoW/tbls/output/yaml"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+func TestAnalyze(t *testing.T) {
+	s, err := Analyze(fmt.Sprintf("sq://%s", filepath.Join(testdataDir(), "testdb.sqlite3")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "testdb.sqlite3"; s.Name != want {
+		t.Errorf("actual %v\nwant %v", s.Name, want)
+	}
+	if len(s.Tables) == 0 || len(s.Relations) == 0 {
+		t.Errorf("actual %v tables and %v relations\nwant %v", len(s.Tables), len(s.Relations), "tables and relations")
+	}
+}
+
+func TestAnalyzeContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := AnalyzeContext(ctx, fmt.Sprintf("sq://%s", filepath.Join(testdataDir(), "testdb.sqlite3")))
+	if errors.Cause(err) != context.Canceled {
+		t.Errorf("actual %v\nwant %v", err, context.Canceled)
+	}
+}
+
+func TestAnalyzeJSON(t *testing.T) {
+	s, err := Analyze(fmt.Sprintf("sq://%s", filepath.Join(testdataDir(), "testdb.sqlite3")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(bytes.Buffer)
+	err = new(json.JSON).OutputSchema(want, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, "schema.json")
+	err = ioutil.WriteFile(path, want.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Analyze(fmt.Sprintf("json://%s", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	err = new(json.JSON).OutputSchema(got, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("actual %v\nwant %v", got.String(), want.String())
+	}
+}
+
+func TestAnalyzeYAML(t *testing.T) {
+	s, err := Analyze(fmt.Sprintf("sq://%s", filepath.Join(testdataDir(), "testdb.sqlite3")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(bytes.Buffer)
+	err = new(json.JSON).OutputSchema(want, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	err = new(yaml.YAML).OutputSchema(b, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, "schema.yml")
+	err = ioutil.WriteFile(path, b.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Analyze(fmt.Sprintf("yaml://%s", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	err = new(json.JSON).OutputSchema(got, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("actual %v\nwant %v", got.String(), want.String())
+	}
+}
+
+func TestAnalyzeWithConfig(t *testing.T) {
+	c := config.New()
+	if _, err := AnalyzeWithConfig(c); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+	c.DSN = config.DSN(fmt.Sprintf("sq://%s", filepath.Join(testdataDir(), "testdb.sqlite3")))
+	c.Include = []string{"users", "posts"}
+	s, err := AnalyzeWithConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tables) != 2 {
+		t.Errorf("actual %v\nwant %v", len(s.Tables), 2)
+	}
+
+	c.Format.NormalizeTypes = true
+	s, err = AnalyzeWithConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := s.FindTableByName("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := users.Columns[0].Type, "integer"; got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func testdataDir() string {
+	wd, _ := os.Getwd()
+	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(wd), "testdata"))
+	return dir
+}
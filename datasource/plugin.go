@@ -0,0 +1,53 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
+	"github.com/pkg/errors"
+)
+
+// PluginPrefix is the prefix of the executable name of driver plugins. The driver plugin of `<scheme>://...` is `tbls-driver-<scheme>`.
+const PluginPrefix = "tbls-driver-"
+
+// findPlugin return the path of the driver plugin for the scheme of the DSN in PATH
+func findPlugin(dsn string) (string, bool) {
+	i := strings.Index(dsn, "://")
+	if i <= 0 {
+		return "", false
+	}
+	path, err := exec.LookPath(PluginPrefix + dsn[:i])
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// analyzePlugin analyze the database with the driver plugin.
+// The plugin is run with the DSN as the argument (and the environment variable TBLS_DSN), and writes the schema JSON (same as `tbls out -t json`) to stdout.
+func analyzePlugin(ctx context.Context, path, dsn string) (*schema.Schema, error) {
+	defer trace.Start(ctx, "introspect")()
+	cmd := exec.CommandContext(ctx, path, dsn)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("TBLS_DSN=%s", dsn))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to run driver plugin '%s': %s", path, config.MaskCredentials(strings.TrimSpace(stderr.String()))))
+	}
+	s := &schema.Schema{}
+	err = json.Unmarshal(stdout.Bytes(), s)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("invalid schema JSON from driver plugin '%s'", path))
+	}
+	return s, nil
+}
@@ -0,0 +1,53 @@
+package datasource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzePlugin(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	plugin := `#!/bin/sh
+if [ "$1" != "$TBLS_DSN" ]; then
+  echo "invalid DSN" >&2
+  exit 1
+fi
+case "$1" in
+  fake://broken) echo "connection refused" >&2; exit 1 ;;
+esac
+cat <<JSON
+{"name": "fakedb", "driver": "fake", "tables": [{"name": "users", "type": "collection", "comment": "", "columns": [{"name": "_id", "type": "ObjectId", "nullable": false, "default": null, "comment": ""}]}], "relations": []}
+JSON
+`
+	err := ioutil.WriteFile(filepath.Join(tempDir, PluginPrefix+"fake"), []byte(plugin), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", tempDir+string(os.PathListSeparator)+path)
+
+	s, err := Analyze("fake://localhost/fakedb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "fakedb" || s.Driver != "fake" {
+		t.Errorf("actual %v, %v\nwant %v, %v", s.Name, s.Driver, "fakedb", "fake")
+	}
+	if _, err := s.Tables[0].FindColumnByName("_id"); err != nil {
+		t.Errorf("%s", err)
+	}
+
+	_, err = Analyze("fake://broken")
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("actual %v\nwant %v", err, "connection refused")
+	}
+
+	if _, ok := findPlugin("unknown://localhost/db"); ok {
+		t.Errorf("actual %v\nwant %v", ok, false)
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/version"
+	"github.com/pkg/errors"
+)
+
+// cache is the analyzed schema cached with the checksum of the catalog
+type cache struct {
+	Version  string         `json:"version"`
+	Checksum string         `json:"checksum"`
+	Schema   *schema.Schema `json:"schema"`
+}
+
+// analyzeWithCache return the cached schema when the checksum of the catalog is unchanged, otherwise analyze the database and cache the schema
+func analyzeWithCache(ctx context.Context, driver Driver, db *sql.DB, s *schema.Schema, urlstr string, opt Option) (*schema.Schema, error) {
+	checksum, err := driver.Checksum(ctx, db, s)
+	if err != nil {
+		return s, err
+	}
+	path := cachePath(urlstr, opt)
+	if cached, ok := loadCache(path, checksum); ok {
+		return cached, nil
+	}
+	err = driver.Analyze(ctx, db, s)
+	if err != nil {
+		return s, err
+	}
+	err = saveCache(path, checksum, s)
+	if err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// cachePath return the path of the cache file of the database and the option
+func cachePath(urlstr string, opt Option) string {
+	key := opt.CacheKey
+	if key == "" {
+		key = urlstr
+	}
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%t", key, opt.IncludeSystemSchemas)))
+	return filepath.Join(opt.CacheDir, fmt.Sprintf("%x.json", h))
+}
+
+// loadCache return the cached schema when the cache file exists and its checksum and tbls version match
+func loadCache(path, checksum string) (*schema.Schema, bool) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	c := cache{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, false
+	}
+	if c.Version != version.Version || c.Checksum != checksum || c.Schema == nil {
+		return nil, false
+	}
+	return c.Schema, true
+}
+
+// saveCache write the schema to the cache file with the checksum of the catalog
+func saveCache(path, checksum string, s *schema.Schema) error {
+	b, err := json.Marshal(cache{
+		Version:  version.Version,
+		Checksum: checksum,
+		Schema:   s,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = ioutil.WriteFile(path, b, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/k1LoW/tbls/schema"
+)
+
+type fakeDriver struct {
+	checksum string
+	analyzed int
+}
+
+func (d *fakeDriver) Analyze(ctx context.Context, db *sql.DB, s *schema.Schema) error {
+	d.analyzed++
+	s.Tables = []*schema.Table{&schema.Table{Name: "users", Columns: []*schema.Column{}}}
+	s.Relations = []*schema.Relation{}
+	return nil
+}
+
+func (d *fakeDriver) Checksum(ctx context.Context, db *sql.DB, s *schema.Schema) (string, error) {
+	return d.checksum, nil
+}
+
+func TestAnalyzeWithCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tbls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opt := Option{CacheDir: dir}
+	d := &fakeDriver{checksum: "a"}
+
+	tests := []struct {
+		checksum string
+		want     int
+	}{
+		{"a", 1},
+		{"a", 1},
+		{"b", 2},
+		{"b", 2},
+	}
+	for i, tt := range tests {
+		d.checksum = tt.checksum
+		s, err := analyzeWithCache(context.Background(), d, nil, &schema.Schema{Name: "testdb"}, "sq://testdb", opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.analyzed != tt.want {
+			t.Errorf("%d: actual %v\nwant %v", i, d.analyzed, tt.want)
+		}
+		if s.Name != "testdb" || len(s.Tables) != 1 {
+			t.Errorf("%d: actual %v\nwant %v", i, s, "schema of testdb")
+		}
+	}
+}
+
+func TestCachePath(t *testing.T) {
+	opt := Option{CacheDir: "cache"}
+	if cachePath("sq://a", opt) == cachePath("sq://b", opt) {
+		t.Errorf("cache paths of different DSNs are same")
+	}
+	opt.CacheKey = "key"
+	if cachePath("sq://a", opt) != cachePath("sq://b", opt) {
+		t.Errorf("cache paths of the same key are different")
+	}
+	if cachePath("sq://a", opt) == cachePath("sq://a", Option{CacheDir: "cache", CacheKey: "key", IncludeSystemSchemas: true}) {
+		t.Errorf("cache paths of different options are same")
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultConnectBackoff is the interval before the first retry of connecting when Option.ConnectBackoff is not set
+const DefaultConnectBackoff = time.Second
+
+// maxConnectBackoff is the upper limit of the interval between retries of connecting
+const maxConnectBackoff = 30 * time.Second
+
+// connect call fn until it succeeds, up to opt.ConnectRetries retries or until opt.Wait elapses, with exponential backoff.
+func connect(ctx context.Context, opt Option, fn func() error) error {
+	backoff := opt.ConnectBackoff
+	if backoff <= 0 {
+		backoff = DefaultConnectBackoff
+	}
+	var deadline time.Time
+	if opt.Wait > 0 {
+		deadline = time.Now().Add(opt.Wait)
+	}
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errors.WithStack(err)
+		}
+		if i >= opt.ConnectRetries {
+			if deadline.IsZero() || !time.Now().Before(deadline) {
+				return errors.WithStack(err)
+			}
+			if remaining := time.Until(deadline); backoff > remaining {
+				backoff = remaining
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConnect(t *testing.T) {
+	errNotReady := errors.New("connection refused")
+	tests := []struct {
+		opt       Option
+		readyAt   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{Option{}, 0, 1, false},
+		{Option{}, 1, 1, true},
+		{Option{ConnectRetries: 3, ConnectBackoff: time.Millisecond}, 2, 3, false},
+		{Option{ConnectRetries: 2, ConnectBackoff: time.Millisecond}, 5, 3, true},
+		{Option{ConnectBackoff: time.Millisecond, Wait: time.Second}, 4, 5, false},
+	}
+	for i, tt := range tests {
+		calls := 0
+		err := connect(context.Background(), tt.opt, func() error {
+			calls++
+			if calls <= tt.readyAt {
+				return errNotReady
+			}
+			return nil
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%d: actual %v\nwant %v", i, err, tt.wantErr)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%d: actual %v\nwant %v", i, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestConnectWaitElapsed(t *testing.T) {
+	start := time.Now()
+	err := connect(context.Background(), Option{ConnectBackoff: 10 * time.Millisecond, Wait: 50 * time.Millisecond}, func() error {
+		return errors.New("connection refused")
+	})
+	if err == nil {
+		t.Error("want error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("actual %v\nwant %v", elapsed, "less than 1s")
+	}
+}
+
+func TestConnectCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := connect(ctx, Option{ConnectRetries: 100, ConnectBackoff: time.Millisecond}, func() error {
+		calls++
+		cancel()
+		return errors.New("connection refused")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("actual %v, %v\nwant %v, %v", err, calls, "error", 1)
+	}
+}
@@ -1,62 +1,129 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/k1LoW/tbls/drivers/mysql"
-	"github.com/k1LoW/tbls/drivers/postgres"
-	"github.com/k1LoW/tbls/drivers/sqlite"
+	"github.com/k1LoW/tbls/config"
 	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
 	"github.com/pkg/errors"
 	"github.com/xo/dburl"
 )
 
 // Driver is the common interface for database driver
 type Driver interface {
-	Analyze(*sql.DB, *schema.Schema) error
+	Analyze(context.Context, *sql.DB, *schema.Schema) error
+	Checksum(context.Context, *sql.DB, *schema.Schema) (string, error)
+}
+
+// Option is the struct for analyze option
+type Option struct {
+	IncludeSystemSchemas bool
+	Concurrency          int
+	// ConnectRetries is the number of retries when connecting to the database fails
+	ConnectRetries int
+	// ConnectBackoff is the interval before the first retry of connecting. It doubles on each retry.
+	ConnectBackoff time.Duration
+	// Wait is the duration to keep retrying to connect until the database is ready, regardless of ConnectRetries
+	Wait time.Duration
+	// QueryTimeout is the deadline of each catalog query. Queries have no deadline when it is zero.
+	QueryTimeout time.Duration
+	// CacheDir is the directory of the cache of the analyzed schema. The cache is disabled when it is empty.
+	CacheDir string
+	// CacheKey identifies the database in the cache. The DSN is used when it is empty.
+	CacheKey string
 }
 
 // Analyze database
 func Analyze(urlstr string) (*schema.Schema, error) {
+	return AnalyzeWithOption(urlstr, Option{})
+}
+
+// AnalyzeWithOption analyze database with option.
+// System schemas (information_schema, pg_catalog, sqlite_* tables) are excluded unless IncludeSystemSchemas.
+// Tables are analyzed by Concurrency workers (sequentially when less than 2).
+// With CacheDir, the cached schema is returned while the checksum of the catalog is unchanged.
+func AnalyzeWithOption(urlstr string, opt Option) (*schema.Schema, error) {
+	return AnalyzeContext(context.Background(), urlstr, opt)
+}
+
+// AnalyzeContext analyze database with option. Catalog queries in flight are canceled when ctx is done.
+func AnalyzeContext(ctx context.Context, urlstr string, opt Option) (*schema.Schema, error) {
 	s := &schema.Schema{}
 	u, err := dburl.Parse(urlstr)
 	if err != nil {
-		return s, errors.WithStack(err)
+		return s, maskCredentials(err)
 	}
 	splitted := strings.Split(u.Short(), "/")
 	if len(splitted) < 2 {
-		return s, errors.WithStack(fmt.Errorf("invalid DSN: parse %s -> %#v", urlstr, u))
+		return s, errors.WithStack(fmt.Errorf("invalid DSN: %s", config.DSN(urlstr)))
 	}
 
-	db, err := dburl.Open(urlstr)
-	defer db.Close()
-	if err != nil {
-		return s, errors.WithStack(err)
+	newDriver, ok := lookupDriver(u.Driver)
+	if !ok {
+		return s, errors.WithStack(fmt.Errorf("unsupported driver '%s'", u.Driver))
 	}
-	if err = db.Ping(); err != nil {
-		return s, errors.WithStack(err)
+	driver := newDriver(opt)
+	s.Driver = u.Driver
+	s.Name = splitted[1]
+	if u.Driver == "sqlite3" {
+		s.Name = splitted[len(splitted)-1]
 	}
 
-	var driver Driver
+	db, err := Open(ctx, urlstr, opt)
+	if err != nil {
+		return s, err
+	}
+	defer db.Close()
 
-	switch u.Driver {
-	case "postgres":
-		s.Name = splitted[1]
-		driver = new(postgres.Postgres)
-	case "mysql":
-		s.Name = splitted[1]
-		driver = new(mysql.Mysql)
-	case "sqlite3":
-		s.Name = splitted[len(splitted)-1]
-		driver = new(sqlite.Sqlite)
-	default:
-		return s, errors.WithStack(fmt.Errorf("unsupported driver '%s'", u.Driver))
+	defer trace.Start(ctx, "introspect")()
+	if opt.CacheDir != "" {
+		return analyzeWithCache(ctx, driver, db, s, urlstr, opt)
 	}
-	err = driver.Analyze(db, s)
+	err = driver.Analyze(ctx, db, s)
 	if err != nil {
 		return s, err
 	}
 	return s, nil
 }
+
+// Open open the database of the DSN and connect to it with the retries of the option, to query the data of tables (e.g. sample rows).
+// Sessions are read-only and only catalog queries and SELECT statements can be issued.
+func Open(ctx context.Context, urlstr string, opt Option) (*sql.DB, error) {
+	u, err := dburl.Parse(urlstr)
+	if err != nil {
+		return nil, maskCredentials(err)
+	}
+	if _, ok := lookupDriver(u.Driver); !ok {
+		return nil, errors.WithStack(fmt.Errorf("unsupported driver '%s'", u.Driver))
+	}
+	name, err := readOnlyDriverName(u.Driver)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(name, u.DSN)
+	if err != nil {
+		return nil, maskCredentials(err)
+	}
+	defer trace.Start(ctx, "connect")()
+	start := time.Now()
+	err = connect(ctx, opt, func() error {
+		return db.PingContext(ctx)
+	})
+	if err != nil {
+		trace.Debug(ctx, "connect", "dsn", config.DSN(urlstr), "duration_ms", time.Since(start), "error", config.MaskCredentials(err.Error()))
+		db.Close()
+		return nil, wrapTLSError(err)
+	}
+	trace.Debug(ctx, "connect", "dsn", config.DSN(urlstr), "duration_ms", time.Since(start))
+	return db, nil
+}
+
+// maskCredentials return the error with passwords masked, since errors of parsing the DSN contain the DSN
+func maskCredentials(err error) error {
+	return errors.WithStack(errors.New(config.MaskCredentials(err.Error())))
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"sort"
+	"sync"
+)
+
+// NewDriver create the Driver with the option
+type NewDriver func(opt Option) Driver
+
+var (
+	newDriversMu sync.RWMutex
+	newDrivers   = map[string]NewDriver{}
+)
+
+// Register register the driver of the database/sql driver name (postgres, mysql, sqlite3, ...).
+// Builtin drivers are registered unless tbls is built with the build tag to exclude them (nopostgres, nomysql, nosqlite).
+func Register(name string, f NewDriver) {
+	newDriversMu.Lock()
+	defer newDriversMu.Unlock()
+	newDrivers[name] = f
+}
+
+// Drivers return names of registered drivers in order
+func Drivers() []string {
+	newDriversMu.RLock()
+	defer newDriversMu.RUnlock()
+	names := []string{}
+	for n := range newDrivers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupDriver(name string) (NewDriver, bool) {
+	newDriversMu.RLock()
+	defer newDriversMu.RUnlock()
+	f, ok := newDrivers[name]
+	return f, ok
+}
@@ -0,0 +1,16 @@
+//go:build !nomysql
+// +build !nomysql
+
+package db
+
+import (
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/k1LoW/tbls/drivers/mysql"
+)
+
+func init() {
+	registerMySQLTLSConfig = gomysql.RegisterTLSConfig
+	Register("mysql", func(opt Option) Driver {
+		return &mysql.Mysql{Concurrency: opt.Concurrency, QueryTimeout: opt.QueryTimeout}
+	})
+}
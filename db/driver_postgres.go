@@ -0,0 +1,15 @@
+//go:build !nopostgres
+// +build !nopostgres
+
+package db
+
+import (
+	"github.com/k1LoW/tbls/drivers/postgres"
+	_ "github.com/lib/pq"
+)
+
+func init() {
+	Register("postgres", func(opt Option) Driver {
+		return &postgres.Postgres{IncludeSystemSchemas: opt.IncludeSystemSchemas, Concurrency: opt.Concurrency, QueryTimeout: opt.QueryTimeout}
+	})
+}
@@ -0,0 +1,16 @@
+//go:build !nosqlite && cgo
+// +build !nosqlite,cgo
+
+package db
+
+import (
+	"github.com/k1LoW/tbls/drivers/sqlite"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The SQLite driver (github.com/mattn/go-sqlite3) requires cgo, so it is left out of builds without cgo instead of failing on connecting
+func init() {
+	Register("sqlite3", func(opt Option) Driver {
+		return &sqlite.Sqlite{IncludeSystemSchemas: opt.IncludeSystemSchemas, Concurrency: opt.Concurrency, QueryTimeout: opt.QueryTimeout}
+	})
+}
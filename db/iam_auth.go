@@ -0,0 +1,87 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/pkg/errors"
+	"github.com/xo/dburl"
+)
+
+// runCommand run external command (aws, gcloud) and return stdout
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...) // #nosec
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// ApplyIAMAuth return the DSN with the password replaced by the token of cloud IAM.
+// TLS is required by IAM authentication, so the TLS parameters are added to the DSN if not set.
+func ApplyIAMAuth(dsn string, a config.IAMAuth) (string, error) {
+	du, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defaultPort, ok := defaultPorts[du.Driver]
+	if !ok {
+		return "", errors.WithStack(fmt.Errorf("IAM auth is not supported for driver '%s'", du.Driver))
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", errors.WithStack(errors.New("IAM auth requires the user name in DSN"))
+	}
+	user := u.User.Username()
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+
+	var out []byte
+	switch a.Provider {
+	case "aws":
+		args := []string{"rds", "generate-db-auth-token", "--hostname", u.Hostname(), "--port", port, "--username", user}
+		if a.Region != "" {
+			args = append(args, "--region", a.Region)
+		}
+		if a.Profile != "" {
+			args = append(args, "--profile", a.Profile)
+		}
+		out, err = runCommand("aws", args...)
+	case "gcp":
+		out, err = runCommand("gcloud", "auth", "print-access-token")
+	default:
+		return "", errors.WithStack(fmt.Errorf("unsupported IAM auth provider '%s' (aws or gcp)", a.Provider))
+	}
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("failed to get IAM auth token for %s", net.JoinHostPort(u.Hostname(), port)))
+	}
+	u.User = url.UserPassword(user, strings.TrimSpace(string(out)))
+
+	q := u.Query()
+	switch du.Driver {
+	case "mysql":
+		if q.Get("tls") == "" {
+			q.Set("tls", "true")
+		}
+		q.Set("allowCleartextPasswords", "true")
+	case "postgres":
+		if q.Get("sslmode") == "" {
+			q.Set("sslmode", "require")
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
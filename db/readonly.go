@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/trace"
+	"github.com/pkg/errors"
+)
+
+// readOnlySessions are the statements to make the session read-only, run on each new connection of the driver
+var readOnlySessions = map[string]string{
+	"postgres": "SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY",
+	"mysql":    "SET SESSION TRANSACTION READ ONLY",
+	"sqlite3":  "PRAGMA query_only = ON",
+}
+
+// readOnlyStatements are the leading keywords of statements tbls is allowed to issue
+var readOnlyStatements = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+	"PRAGMA":   true,
+	"VALUES":   true,
+}
+
+var (
+	readOnlyDriversMu sync.Mutex
+	readOnlyDrivers   = map[string]string{}
+)
+
+// readOnlyDriverName return the name of the database/sql driver wrapping the driver of the name to allow read-only statements only.
+// The wrapping driver is registered on the first call.
+func readOnlyDriverName(name string) (string, error) {
+	readOnlyDriversMu.Lock()
+	defer readOnlyDriversMu.Unlock()
+	if n, ok := readOnlyDrivers[name]; ok {
+		return n, nil
+	}
+	base, err := sql.Open(name, "")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	n := fmt.Sprintf("tbls-readonly-%s", name)
+	sql.Register(n, &readOnlyDriver{Driver: base.Driver(), session: readOnlySessions[name]})
+	readOnlyDrivers[name] = n
+	return n, nil
+}
+
+// readOnlyDriver is the database/sql driver to make sessions read-only and reject statements other than catalog queries and SELECT
+type readOnlyDriver struct {
+	driver.Driver
+	session string
+}
+
+// Open open the connection and make the session read-only
+func (d *readOnlyDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if d.session != "" {
+		if err := execConn(c, d.session); err != nil {
+			c.Close()
+			return nil, errors.Wrap(errors.WithStack(err), "failed to set the session read-only")
+		}
+	}
+	return &readOnlyConn{Conn: c}, nil
+}
+
+func execConn(c driver.Conn, query string) error {
+	if e, ok := c.(driver.ExecerContext); ok {
+		_, err := e.ExecContext(context.Background(), query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	stmt, err := c.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if s, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = s.ExecContext(context.Background(), nil)
+		return err
+	}
+	_, err = stmt.Exec(nil) //nolint:staticcheck
+	return err
+}
+
+// readOnlyConn is the connection to reject statements other than catalog queries and SELECT.
+// Queries are logged with their duration and row counts when the context has the logger of trace.
+type readOnlyConn struct {
+	driver.Conn
+}
+
+func (c *readOnlyConn) Prepare(query string) (driver.Stmt, error) {
+	if err := checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *readOnlyConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil || trace.LoggerFromContext(ctx) == nil {
+		return stmt, err
+	}
+	return &tracedStmt{Stmt: stmt, query: query}, nil
+}
+
+func (c *readOnlyConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	return traceRows(ctx, query, len(args), start, rows, err)
+}
+
+func (c *readOnlyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		traceQuery(ctx, query, len(args), start, -1, err)
+	}
+	return res, err
+}
+
+func (c *readOnlyConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	opts.ReadOnly = true
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *readOnlyConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *readOnlyConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *readOnlyConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// tracedStmt is the prepared statement to log queries
+type tracedStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args)) //nolint:staticcheck
+	}
+	return traceRows(ctx, s.query, len(args), start, rows, err)
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var (
+		res driver.Result
+		err error
+	)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(namedValues(args)) //nolint:staticcheck
+	}
+	traceQuery(ctx, s.query, len(args), start, -1, err)
+	return res, err
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	return values
+}
+
+// tracedRows is the rows to log the query with the row count when closed
+type tracedRows struct {
+	driver.Rows
+	ctx   context.Context
+	query string
+	args  int
+	start time.Time
+	count int
+	err   error
+	once  sync.Once
+}
+
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.count++
+	case err != io.EOF:
+		r.err = err
+	}
+	return err
+}
+
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(func() {
+		if r.err != nil {
+			traceQuery(r.ctx, r.query, r.args, r.start, r.count, r.err)
+			return
+		}
+		traceQuery(r.ctx, r.query, r.args, r.start, r.count, err)
+	})
+	return err
+}
+
+// traceRows return the rows logging the query when closed, or log the failed query
+func traceRows(ctx context.Context, query string, args int, start time.Time, rows driver.Rows, err error) (driver.Rows, error) {
+	if trace.LoggerFromContext(ctx) == nil || err == driver.ErrSkip {
+		return rows, err
+	}
+	if err != nil {
+		traceQuery(ctx, query, args, start, -1, err)
+		return rows, err
+	}
+	return &tracedRows{Rows: rows, ctx: ctx, query: query, args: args, start: start}, nil
+}
+
+// traceQuery log the query with credentials masked. rows is the row count, or negative when unknown.
+func traceQuery(ctx context.Context, query string, args int, start time.Time, rows int, err error) {
+	kv := []interface{}{"query", config.MaskCredentials(strings.Join(strings.Fields(query), " ")), "args", args, "duration_ms", time.Since(start)}
+	if rows >= 0 {
+		kv = append(kv, "rows", rows)
+	}
+	if err != nil && err != io.EOF {
+		kv = append(kv, "error", config.MaskCredentials(err.Error()))
+	}
+	trace.Debug(ctx, "query", kv...)
+}
+
+// checkReadOnly return the error unless the query is a single statement of catalog query or SELECT
+func checkReadOnly(query string) error {
+	keyword, multi := firstKeyword(query)
+	switch {
+	case multi:
+		return errors.WithStack(fmt.Errorf("tbls issues only a single read-only statement at a time: %s", abbreviateQuery(query)))
+	case !readOnlyStatements[keyword]:
+		return errors.WithStack(fmt.Errorf("tbls issues only read-only statements: %s", abbreviateQuery(query)))
+	case keyword == "PRAGMA" && strings.Contains(query, "="):
+		return errors.WithStack(fmt.Errorf("tbls does not set PRAGMA: %s", abbreviateQuery(query)))
+	}
+	return nil
+}
+
+// firstKeyword return the upper-cased first keyword of the query, skipping comments and parentheses, and whether the query has more than one statement
+func firstKeyword(query string) (string, bool) {
+	keyword := ""
+	end := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '-' && strings.HasPrefix(query[i:], "--"):
+			n := strings.IndexByte(query[i:], '\n')
+			if n < 0 {
+				return keyword, false
+			}
+			i += n
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			n := strings.Index(query[i+2:], "*/")
+			if n < 0 {
+				return keyword, false
+			}
+			i += n + 3
+		case ch == '\'' || ch == '"' || ch == '`':
+			n := strings.IndexByte(query[i+1:], ch)
+			if n < 0 {
+				return keyword, false
+			}
+			i += n + 1
+		case ch == ';':
+			end = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		case end:
+			return keyword, true
+		case keyword == "" && ch != '(':
+			j := i
+			for j < len(query) && isKeywordChar(query[j]) {
+				j++
+			}
+			if j == i {
+				return "", false
+			}
+			keyword = strings.ToUpper(query[i:j])
+			i = j - 1
+		}
+	}
+	return keyword, false
+}
+
+func isKeywordChar(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func abbreviateQuery(query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	if len(q) > 60 {
+		q = q[:60] + "..."
+	}
+	return q
+}
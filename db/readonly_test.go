@@ -0,0 +1,92 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/trace"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"SELECT * FROM users", false},
+		{"  select 1;", false},
+		{"-- comment\n/* block; */ (SELECT 1) UNION (SELECT 2)", false},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", false},
+		{"SHOW CREATE TABLE users", false},
+		{"PRAGMA index_list('users')", false},
+		{"SELECT ';' FROM users", false},
+		{"PRAGMA query_only = OFF", true},
+		{"INSERT INTO users VALUES (1)", true},
+		{"delete from users", true},
+		{"SET SESSION TRANSACTION READ WRITE", true},
+		{"SELECT 1; DROP TABLE users", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		err := checkReadOnly(tt.query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: actual %v\nwant error %v", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(ctx, "sq://"+filepath.Join(t.TempDir(), "readonly.sqlite3"), Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "CREATE TABLE users (id INTEGER)"); err == nil {
+		t.Error("CREATE TABLE should be rejected")
+	}
+	var queryOnly int
+	if err := db.QueryRowContext(ctx, "PRAGMA query_only").Scan(&queryOnly); err != nil {
+		t.Fatal(err)
+	}
+	if queryOnly != 1 {
+		t.Errorf("actual %v\nwant %v", queryOnly, 1)
+	}
+}
+
+func TestOpenTraceQueries(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := trace.NewLogger(buf, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := trace.WithLogger(context.Background(), l)
+	db, err := Open(ctx, "sq://"+filepath.Join(t.TempDir(), "trace.sqlite3"), Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.QueryContext(ctx, "SELECT 1 UNION SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if _, err := db.QueryContext(ctx, "SELECT * FROM missing"); err == nil {
+		t.Error("query of the missing table should fail")
+	}
+	for _, want := range []string{
+		" connect dsn=sq://",
+		` query query="SELECT 1 UNION SELECT 2" args=0 duration_ms=`,
+		" rows=2\n",
+		` query query="SELECT * FROM missing" args=0 duration_ms=`,
+		"error=\"no such table: missing\"",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("actual %v\nwant %v", buf.String(), want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/pkg/errors"
+	"github.com/xo/dburl"
+)
+
+// registerMySQLTLSConfig register the TLS config of the key to the MySQL driver. It is nil when tbls is built without the MySQL driver.
+var registerMySQLTLSConfig func(key string, c *tls.Config) error
+
+var mysqlTLSConfigs int64
+
+// tlsErrors are the fragments of errors of drivers when the TLS handshake fails
+var tlsErrors = []string{
+	"x509:",
+	"tls:",
+	"SSL is not enabled on the server",
+	"server does not support TLS",
+}
+
+// ApplyTLS return the DSN with the TLS parameters of the driver set from the TLS config.
+//
+//	postgres: sslmode, sslrootcert, sslcert and sslkey
+//	mysql: tls with the TLS config registered to the driver
+func ApplyTLS(dsn string, t config.TLS) (string, error) {
+	du, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", maskCredentials(err)
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", maskCredentials(err)
+	}
+	q := u.Query()
+	switch du.Driver {
+	case "postgres":
+		if t.ServerName != "" {
+			return "", errors.WithStack(errors.New("TLS serverName is not supported for driver 'postgres'"))
+		}
+		q.Set("sslmode", t.SSLMode())
+		for k, v := range map[string]string{"sslrootcert": t.CA, "sslcert": t.Cert, "sslkey": t.Key} {
+			if v != "" {
+				q.Set(k, v)
+			}
+		}
+	case "mysql":
+		if t.SSLMode() == "disable" {
+			q.Set("tls", "false")
+			break
+		}
+		if registerMySQLTLSConfig == nil {
+			return "", errors.WithStack(errors.New("TLS is not supported: tbls is built without driver 'mysql'"))
+		}
+		c, err := mysqlTLSConfig(t, u.Hostname())
+		if err != nil {
+			return "", err
+		}
+		key := fmt.Sprintf("tbls%d", atomic.AddInt64(&mysqlTLSConfigs, 1))
+		if err := registerMySQLTLSConfig(key, c); err != nil {
+			return "", errors.WithStack(err)
+		}
+		q.Set("tls", key)
+	default:
+		return "", errors.WithStack(fmt.Errorf("TLS is not supported for driver '%s'", du.Driver))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// mysqlTLSConfig return the TLS config of the MySQL driver. The server name is the host of the DSN unless set.
+func mysqlTLSConfig(t config.TLS, host string) (*tls.Config, error) {
+	c := &tls.Config{ServerName: t.ServerName}
+	if c.ServerName == "" {
+		c.ServerName = host
+	}
+	if t.CA != "" {
+		pem, err := ioutil.ReadFile(t.CA)
+		if err != nil {
+			return nil, errors.Wrap(errors.WithStack(err), "failed to read TLS ca")
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.WithStack(fmt.Errorf("no certificates in TLS ca %s", t.CA))
+		}
+	}
+	if t.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, errors.Wrap(errors.WithStack(err), "failed to load TLS cert and key")
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	switch t.SSLMode() {
+	case "require":
+		c.InsecureSkipVerify = true // #nosec
+	case "verify-ca":
+		// the certificate chain is verified without the server name
+		c.InsecureSkipVerify = true // #nosec
+		roots := c.RootCAs
+		c.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			certs := make([]*x509.Certificate, len(rawCerts))
+			for i, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				certs[i] = cert
+			}
+			if len(certs) == 0 {
+				return errors.New("no server certificate")
+			}
+			opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+			for _, cert := range certs[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := certs[0].Verify(opts)
+			return err
+		}
+	}
+	return c, nil
+}
+
+// wrapTLSError return the error with the hint of the TLS config when the TLS handshake with the database failed
+func wrapTLSError(err error) error {
+	for _, f := range tlsErrors {
+		if strings.Contains(err.Error(), f) {
+			return errors.Wrap(err, "TLS handshake with the database failed (check mode, ca, cert, key and serverName of tls:)")
+		}
+	}
+	return err
+}
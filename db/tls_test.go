@@ -0,0 +1,119 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+)
+
+func TestApplyTLS(t *testing.T) {
+	ca := writeTestCA(t)
+	tests := []struct {
+		dsn     string
+		tls     config.TLS
+		want    string
+		wantErr bool
+	}{
+		{
+			"pg://dbuser:dbpass@db.internal/testdb?sslmode=disable",
+			config.TLS{CA: "/etc/ssl/ca.pem", Cert: "/etc/ssl/client.pem", Key: "/etc/ssl/client-key.pem"},
+			"pg://dbuser:dbpass@db.internal/testdb?sslcert=%2Fetc%2Fssl%2Fclient.pem&sslkey=%2Fetc%2Fssl%2Fclient-key.pem&sslmode=verify-full&sslrootcert=%2Fetc%2Fssl%2Fca.pem",
+			false,
+		},
+		{"postgres://db.internal/testdb", config.TLS{Mode: "require"}, "postgres://db.internal/testdb?sslmode=require", false},
+		{"postgres://db.internal/testdb", config.TLS{ServerName: "db.example.com"}, "", true},
+		{"my://dbuser:dbpass@db.internal/testdb", config.TLS{Mode: "disable"}, "my://dbuser:dbpass@db.internal/testdb?tls=false", false},
+		{"my://dbuser:dbpass@db.internal/testdb", config.TLS{CA: ca, ServerName: "db.example.com"}, "my://dbuser:dbpass@db.internal/testdb?tls=tbls", false},
+		{"my://dbuser:dbpass@db.internal/testdb", config.TLS{CA: filepath.Join(t.TempDir(), "missing.pem")}, "", true},
+		{"sq:///path/to/testdb.sqlite3", config.TLS{Mode: "require"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := ApplyTLS(tt.dsn, tt.tls)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: actual %v\nwant error %v", tt.dsn, err, tt.wantErr)
+			continue
+		}
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestMySQLTLSConfig(t *testing.T) {
+	ca := writeTestCA(t)
+	tests := []struct {
+		tls            config.TLS
+		wantServerName string
+		wantSkip       bool
+		wantVerify     bool
+	}{
+		{config.TLS{CA: ca}, "db.internal", false, false},
+		{config.TLS{CA: ca, ServerName: "db.example.com"}, "db.example.com", false, false},
+		{config.TLS{Mode: "require"}, "db.internal", true, false},
+		{config.TLS{Mode: "verify-ca", CA: ca}, "db.internal", true, true},
+	}
+	for _, tt := range tests {
+		c, err := mysqlTLSConfig(tt.tls, "db.internal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ServerName != tt.wantServerName || c.InsecureSkipVerify != tt.wantSkip || (c.VerifyPeerCertificate != nil) != tt.wantVerify {
+			t.Errorf("%+v: actual %v %v %v\nwant %v %v %v", tt.tls, c.ServerName, c.InsecureSkipVerify, c.VerifyPeerCertificate != nil, tt.wantServerName, tt.wantSkip, tt.wantVerify)
+		}
+	}
+}
+
+func TestWrapTLSError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("x509: certificate signed by unknown authority"), true},
+		{errors.New("pq: SSL is not enabled on the server"), true},
+		{errors.New("dial tcp 127.0.0.1:5432: connect: connection refused"), false},
+	}
+	for _, tt := range tests {
+		got := strings.Contains(wrapTLSError(tt.err).Error(), "TLS handshake")
+		if got != tt.want {
+			t.Errorf("%v: actual %v\nwant %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// writeTestCA write the self-signed CA certificate and return the path
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tbls test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/pkg/errors"
+	"github.com/xo/dburl"
+)
+
+// tunnelTimeout is the time to wait for the SSH tunnel to be ready
+var tunnelTimeout = 30 * time.Second
+
+// defaultPorts is the default port of the database server of the driver
+var defaultPorts = map[string]string{
+	"postgres": "5432",
+	"mysql":    "3306",
+}
+
+// Tunnel is the SSH tunnel opened with the `ssh` command
+type Tunnel struct {
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// OpenSSHTunnel open the SSH tunnel forwarding a local port to the database server of the DSN through the bastion host,
+// and return the DSN to connect to the database through the tunnel.
+// Authentication is done by the `ssh` command, so the identity file, ssh-agent and ~/.ssh/config are available.
+func OpenSSHTunnel(dsn string, t config.SSHTunnel) (string, *Tunnel, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	localPort := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	remote, tunnelDSN, err := forwardDSN(dsn, localPort)
+	if err != nil {
+		return "", nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh", sshArgs(t, localPort, remote)...) // #nosec
+	cmd.Stderr = &stderr
+	err = cmd.Start()
+	if err != nil {
+		return "", nil, errors.Wrap(errors.WithStack(err), "failed to start ssh")
+	}
+	tunnel := &Tunnel{cmd: cmd, done: make(chan error, 1)}
+	go func() {
+		tunnel.done <- cmd.Wait()
+	}()
+
+	deadline := time.Now().Add(tunnelTimeout)
+	for {
+		select {
+		case err := <-tunnel.done:
+			return "", nil, errors.WithStack(fmt.Errorf("failed to open SSH tunnel to %s through %s: %v: %s", remote, t.Host, err, strings.TrimSpace(stderr.String())))
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", localPort), time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return tunnelDSN, tunnel, nil
+		}
+		if time.Now().After(deadline) {
+			_ = tunnel.Close()
+			return "", nil, errors.WithStack(fmt.Errorf("timeout opening SSH tunnel to %s through %s: %s", remote, t.Host, strings.TrimSpace(stderr.String())))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Close close the SSH tunnel
+func (t *Tunnel) Close() error {
+	if t.cmd.Process == nil {
+		return nil
+	}
+	_ = t.cmd.Process.Kill()
+	<-t.done
+	return nil
+}
+
+// forwardDSN return the address of the database server of the DSN, and the DSN with the host replaced by the local forwarded port
+func forwardDSN(dsn string, localPort int) (string, string, error) {
+	du, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	defaultPort, ok := defaultPorts[du.Driver]
+	if !ok {
+		return "", "", errors.WithStack(fmt.Errorf("SSH tunnel is not supported for driver '%s'", du.Driver))
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return "", "", errors.WithStack(fmt.Errorf("SSH tunnel requires the host of the database in DSN"))
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	u.Host = net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	return net.JoinHostPort(host, port), u.String(), nil
+}
+
+// sshArgs return arguments of the `ssh` command for local port forwarding
+func sshArgs(t config.SSHTunnel, localPort int, remote string) []string {
+	args := []string{"-N", "-o", "ExitOnForwardFailure=yes", "-o", "BatchMode=yes"}
+	if t.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(t.Port))
+	}
+	if t.User != "" {
+		args = append(args, "-l", t.User)
+	}
+	if t.IdentityFile != "" {
+		args = append(args, "-i", t.IdentityFile)
+	}
+	for _, o := range t.Options {
+		args = append(args, "-o", o)
+	}
+	return append(args, "-L", fmt.Sprintf("127.0.0.1:%d:%s", localPort, remote), t.Host)
+}
@@ -0,0 +1,73 @@
+// Package drivers is the common functions of database drivers
+package drivers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/tbls/trace"
+)
+
+// WithQueryTimeout return the context of a catalog query. The query is canceled after timeout when timeout is positive.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Parallel call fn for each index in [0, n) with up to concurrency goroutines, and return the first error.
+// fn is called sequentially when concurrency is less than 2.
+func Parallel(n int, concurrency int, fn func(i int) error) error {
+	if concurrency < 2 {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	indexes := make(chan int)
+	done := make(chan struct{})
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						close(done)
+					})
+				}
+			}
+		}()
+	}
+L:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-done:
+			break L
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return firstErr
+}
+
+// ParallelProgress call fn like Parallel, and report the progress of the phase to the progress of the context
+func ParallelProgress(ctx context.Context, phase string, n int, concurrency int, fn func(i int) error) error {
+	bar := trace.StartBar(ctx, phase, n)
+	defer bar.Done()
+	return Parallel(n, concurrency, func(i int) error {
+		defer bar.Add(1)
+		return fn(i)
+	})
+}
@@ -0,0 +1,61 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 4} {
+		results := make([]int, 100)
+		err := Parallel(len(results), concurrency, func(i int) error {
+			results[i] = i * 2
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, got := range results {
+			if got != i*2 {
+				t.Errorf("concurrency %d: actual %v\nwant %v", concurrency, got, i*2)
+			}
+		}
+	}
+}
+
+func TestParallelError(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		var called int32
+		err := Parallel(1000, concurrency, func(i int) error {
+			atomic.AddInt32(&called, 1)
+			if i == 10 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+		if err == nil || err.Error() != "failed" {
+			t.Errorf("actual %v\nwant %v", err, "failed")
+		}
+		if called == 1000 {
+			t.Errorf("concurrency %d: actual %v\nwant less than %v", concurrency, called, 1000)
+		}
+	}
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	ctx, cancel := WithQueryTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("actual %v\nwant %v", ok, false)
+	}
+
+	ctx, cancel = WithQueryTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("actual %v\nwant %v", ctx.Err(), context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,177 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+func TestAnalyzeFetchesCatalogInBulk(t *testing.T) {
+	want := -1
+	for _, n := range []int{2, 20} {
+		c := newFakeCatalog(n)
+		db := sql.OpenDB(c)
+		s := &schema.Schema{Name: "testdb"}
+		if err := new(Mysql).Analyze(context.Background(), db, s); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		bulk, perTable := c.counts()
+		if perTable != n {
+			t.Errorf("SHOW CREATE TABLE of %d tables: actual %v\nwant %v", n, perTable, n)
+		}
+		if want < 0 {
+			want = bulk
+		}
+		if bulk != want {
+			t.Errorf("catalog queries of %d tables: actual %v\nwant %v", n, bulk, want)
+		}
+
+		if len(s.Tables) != n {
+			t.Fatalf("actual %v\nwant %v", len(s.Tables), n)
+		}
+		for i, table := range s.Tables {
+			if want := fmt.Sprintf("comment of t%d", i); table.Comment != want {
+				t.Errorf("actual %v\nwant %v", table.Comment, want)
+			}
+			if len(table.Columns) != 2 {
+				t.Fatalf("actual %v\nwant %v", len(table.Columns), 2)
+			}
+			if want := fmt.Sprintf("id of t%d", i); table.Columns[0].Comment != want {
+				t.Errorf("actual %v\nwant %v", table.Columns[0].Comment, want)
+			}
+			if len(table.Indexes) != 1 || len(table.Constraints) != 1 {
+				t.Errorf("indexes and constraints of %s: actual %v, %v\nwant 1, 1", table.Name, len(table.Indexes), len(table.Constraints))
+			}
+		}
+	}
+}
+
+// fakeCatalog is the database/sql driver answering queries of the catalog of n tables, and counting them
+type fakeCatalog struct {
+	mu       sync.Mutex
+	n        int
+	bulk     int
+	perTable int
+}
+
+func newFakeCatalog(n int) *fakeCatalog {
+	return &fakeCatalog{n: n}
+}
+
+func (c *fakeCatalog) counts() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bulk, c.perTable
+}
+
+func (c *fakeCatalog) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{c: c}, nil
+}
+
+func (c *fakeCatalog) Driver() driver.Driver {
+	return nil
+}
+
+func (c *fakeCatalog) rows(query string) ([][]driver.Value, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rows := [][]driver.Value{}
+	switch {
+	case strings.HasPrefix(query, "SHOW CREATE TABLE "):
+		c.perTable++
+		name := strings.TrimPrefix(query, "SHOW CREATE TABLE ")
+		return append(rows, []driver.Value{name, fmt.Sprintf("CREATE TABLE `%s` (...)", name)}), nil
+	}
+	c.bulk++
+	switch {
+	case strings.Contains(query, "information_schema.schemata"):
+		rows = append(rows, []driver.Value{"utf8mb4", "utf8mb4_general_ci"})
+	case strings.Contains(query, "information_schema.tables"):
+		for i := 0; i < c.n; i++ {
+			rows = append(rows, []driver.Value{fmt.Sprintf("t%d", i), "BASE TABLE", fmt.Sprintf("comment of t%d", i), nil})
+		}
+	case strings.Contains(query, "information_schema.views"), strings.Contains(query, "information_schema.triggers"):
+	case strings.Contains(query, "information_schema.statistics"):
+		for i := 0; i < c.n; i++ {
+			rows = append(rows, []driver.Value{fmt.Sprintf("t%d", i), "PRIMARY KEY", "PRIMARY", "id", "BTREE"})
+		}
+	case strings.Contains(query, "information_schema.key_column_usage"):
+		for i := 0; i < c.n; i++ {
+			if i == 0 {
+				rows = append(rows, []driver.Value{"t0", "PRIMARY", "PRIMARY KEY", "id", nil, nil})
+				continue
+			}
+			rows = append(rows, []driver.Value{fmt.Sprintf("t%d", i), fmt.Sprintf("t%d_parent_id_fk", i), "FOREIGN KEY", "parent_id", "t0", "id"})
+		}
+	case strings.Contains(query, "information_schema.columns"):
+		for i := 0; i < c.n; i++ {
+			rows = append(rows, []driver.Value{fmt.Sprintf("t%d", i), "id", nil, "NO", "int(11)", fmt.Sprintf("id of t%d", i), nil, nil})
+			rows = append(rows, []driver.Value{fmt.Sprintf("t%d", i), "parent_id", nil, "YES", "int(11)", nil, nil, nil})
+		}
+	default:
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+	return rows, nil
+}
+
+type fakeConn struct {
+	c *fakeCatalog
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.c.rows(strings.TrimSpace(query))
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{}
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
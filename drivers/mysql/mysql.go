@@ -1,11 +1,14 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/k1LoW/tbls/drivers"
 	"github.com/k1LoW/tbls/schema"
 	"github.com/pkg/errors"
 )
@@ -13,81 +16,273 @@ import (
 var reFK = regexp.MustCompile(`FOREIGN KEY \((.+)\) REFERENCES ([^\s]+)\s?\((.+)\)`)
 
 // Mysql struct
-type Mysql struct{}
+type Mysql struct {
+	Concurrency  int
+	QueryTimeout time.Duration
+}
 
 // Analyze MySQL database schema
-func (m *Mysql) Analyze(db *sql.DB, s *schema.Schema) error {
-	// tables and comments
-	tableRows, err := db.Query(`
-SELECT table_name, table_type, table_comment FROM information_schema.tables WHERE table_schema = ?;`, s.Name)
-	defer tableRows.Close()
+func (m *Mysql) Analyze(ctx context.Context, db *sql.DB, s *schema.Schema) error {
+	// default charset and collation
+	qctx, cancel := drivers.WithQueryTimeout(ctx, m.QueryTimeout)
+	defer cancel()
+	schemaRows, err := db.QueryContext(qctx, `
+SELECT default_character_set_name, default_collation_name FROM information_schema.schemata WHERE schema_name = ?;`, s.Name)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	defer schemaRows.Close()
+	for schemaRows.Next() {
+		err := schemaRows.Scan(&s.Charset, &s.Collation)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
-	relations := []*schema.Relation{}
+	// tables and comments
+	qctx, cancel = drivers.WithQueryTimeout(ctx, m.QueryTimeout)
+	defer cancel()
+	tableRows, err := db.QueryContext(qctx, `
+SELECT table_name, table_type, table_comment, table_collation FROM information_schema.tables WHERE table_schema = ?;`, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer tableRows.Close()
 
 	tables := []*schema.Table{}
 	for tableRows.Next() {
 		var (
-			tableName    string
-			tableType    string
-			tableComment string
+			tableName      string
+			tableType      string
+			tableComment   string
+			tableCollation sql.NullString
 		)
-		err := tableRows.Scan(&tableName, &tableType, &tableComment)
+		err := tableRows.Scan(&tableName, &tableType, &tableComment, &tableCollation)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		table := &schema.Table{
-			Name:    tableName,
-			Type:    tableType,
-			Comment: tableComment,
+		tables = append(tables, &schema.Table{
+			Name:      tableName,
+			Type:      tableType,
+			Comment:   tableComment,
+			Collation: tableCollation.String,
+		})
+	}
+	tableRows.Close()
+
+	// definitions, indexes, constraints, triggers and columns of all tables
+	c := newCatalog()
+	fetches := []func(context.Context, *sql.DB, *schema.Schema, *catalog) error{
+		fetchViewDefinitions,
+		fetchIndexes,
+		fetchConstraints,
+		fetchTriggers,
+		fetchColumns,
+	}
+	err = drivers.ParallelProgress(ctx, "introspect", len(fetches), m.Concurrency, func(i int) error {
+		qctx, cancel := drivers.WithQueryTimeout(ctx, m.QueryTimeout)
+		defer cancel()
+		return fetches[i](qctx, db, s, c)
+	})
+	if err != nil {
+		return err
+	}
+
+	// SHOW CREATE TABLE has no set-based equivalent, so definitions of base tables are fetched one by one
+	baseTables := []*schema.Table{}
+	for _, t := range tables {
+		if t.Type == "BASE TABLE" {
+			baseTables = append(baseTables, t)
 		}
+	}
+	err = drivers.ParallelProgress(ctx, "table definitions", len(baseTables), m.Concurrency, func(i int) error {
+		qctx, cancel := drivers.WithQueryTimeout(ctx, m.QueryTimeout)
+		defer cancel()
+		return fetchTableDefinition(qctx, db, baseTables[i])
+	})
+	if err != nil {
+		return err
+	}
 
-		// table definition
-		if tableType == "BASE TABLE" {
-			tableDefRows, err := db.Query(fmt.Sprintf("SHOW CREATE TABLE %s", tableName))
-			defer tableDefRows.Close()
+	relations := []*schema.Relation{}
+	for _, t := range tables {
+		relations = append(relations, c.build(t)...)
+	}
+
+	s.Tables = tables
+
+	// Relations
+	for _, r := range relations {
+		result := reFK.FindAllStringSubmatch(r.Def, -1)
+		strColumns := strings.Split(result[0][1], ", ")
+		strParentTable := result[0][2]
+		strParentColumns := strings.Split(result[0][3], ", ")
+		for _, c := range strColumns {
+			column, err := r.Table.FindColumnByName(c)
 			if err != nil {
-				return errors.WithStack(err)
-			}
-			for tableDefRows.Next() {
-				var (
-					tableName string
-					tableDef  string
-				)
-				err := tableDefRows.Scan(&tableName, &tableDef)
-				if err != nil {
-					return errors.WithStack(err)
-				}
-				table.Def = tableDef
+				return err
 			}
+			r.Columns = append(r.Columns, column)
 		}
-
-		// view definition
-		if tableType == "VIEW" {
-			viewDefRows, err := db.Query(`
-SELECT view_definition FROM information_schema.views
-WHERE table_schema = ?
-AND table_name = ?;
-		`, s.Name, tableName)
-			defer viewDefRows.Close()
+		parentTable, err := s.FindTableByName(strParentTable)
+		if err != nil {
+			return err
+		}
+		r.ParentTable = parentTable
+		for _, c := range strParentColumns {
+			column, err := parentTable.FindColumnByName(c)
 			if err != nil {
-				return errors.WithStack(err)
+				return err
 			}
-			for viewDefRows.Next() {
-				var tableDef string
-				err := viewDefRows.Scan(&tableDef)
-				if err != nil {
-					return errors.WithStack(err)
-				}
-				table.Def = fmt.Sprintf("CREATE VIEW %s AS (%s)", tableName, tableDef)
+			r.ParentColumns = append(r.ParentColumns, column)
+		}
+	}
+
+	s.Relations = relations
+	s.LinkRelations()
+
+	return nil
+}
+
+// Checksum return the checksum of the catalog of the database. It changes when tables, columns, constraints, indexes, triggers or comments are changed.
+func (m *Mysql) Checksum(ctx context.Context, db *sql.DB, s *schema.Schema) (string, error) {
+	qctx, cancel := drivers.WithQueryTimeout(ctx, m.QueryTimeout)
+	defer cancel()
+	var checksum string
+	err := db.QueryRowContext(qctx, `
+SELECT CONCAT_WS(':',
+  (SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', table_name, table_type, table_comment, table_collation, create_time))), 0)
+   FROM information_schema.tables WHERE table_schema = ?),
+  (SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', table_name, column_name, ordinal_position, column_default, is_nullable, column_type, column_comment, collation_name))), 0)
+   FROM information_schema.columns WHERE table_schema = ?),
+  (SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', table_name, index_name, seq_in_index, column_name, non_unique, index_type))), 0)
+   FROM information_schema.statistics WHERE table_schema = ?),
+  (SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', table_name, constraint_name, column_name, ordinal_position, referenced_table_name, referenced_column_name))), 0)
+   FROM information_schema.key_column_usage WHERE table_schema = ?),
+  (SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', trigger_name, event_object_table, action_timing, event_manipulation, action_statement))), 0)
+   FROM information_schema.triggers WHERE trigger_schema = ?),
+  (SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', table_name, view_definition))), 0)
+   FROM information_schema.views WHERE table_schema = ?)
+)`, s.Name, s.Name, s.Name, s.Name, s.Name, s.Name).Scan(&checksum)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return checksum, nil
+}
+
+// catalog is definitions, indexes, constraints, triggers and columns of all tables fetched in bulk, keyed by table name
+type catalog struct {
+	viewDefs    map[string]string
+	indexes     map[string][]*schema.Index
+	constraints map[string][]*schema.Constraint
+	triggers    map[string][]*schema.Trigger
+	columns     map[string][]*schema.Column
+}
+
+func newCatalog() *catalog {
+	return &catalog{
+		viewDefs:    map[string]string{},
+		indexes:     map[string][]*schema.Index{},
+		constraints: map[string][]*schema.Constraint{},
+		triggers:    map[string][]*schema.Trigger{},
+		columns:     map[string][]*schema.Column{},
+	}
+}
+
+// build set definition, indexes, constraints, triggers and columns of the table, and return foreign key relations of the table whose columns are resolved later
+func (c *catalog) build(table *schema.Table) []*schema.Relation {
+	relations := []*schema.Relation{}
+
+	if table.Type == "VIEW" {
+		table.Def = c.viewDefs[table.Name]
+	}
+
+	indexes := c.indexes[table.Name]
+	if indexes == nil {
+		indexes = []*schema.Index{}
+	}
+	table.Indexes = indexes
+
+	constraints := c.constraints[table.Name]
+	if constraints == nil {
+		constraints = []*schema.Constraint{}
+	}
+	for _, constraint := range constraints {
+		if constraint.Type == "FOREIGN KEY" {
+			relation := &schema.Relation{
+				Table: table,
+				Def:   constraint.Def,
 			}
+			relations = append(relations, relation)
 		}
+	}
+	table.Constraints = constraints
+
+	triggers := c.triggers[table.Name]
+	if triggers == nil {
+		triggers = []*schema.Trigger{}
+	}
+	table.Triggers = triggers
+
+	columns := c.columns[table.Name]
+	if columns == nil {
+		columns = []*schema.Column{}
+	}
+	table.Columns = columns
 
-		// indexes
-		indexRows, err := db.Query(`
+	return relations
+}
+
+// fetchTableDefinition fetch definition of the base table
+func fetchTableDefinition(ctx context.Context, db *sql.DB, table *schema.Table) error {
+	tableDefRows, err := db.QueryContext(ctx, fmt.Sprintf("SHOW CREATE TABLE %s", table.Name))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer tableDefRows.Close()
+	for tableDefRows.Next() {
+		var (
+			tableName string
+			tableDef  string
+		)
+		err := tableDefRows.Scan(&tableName, &tableDef)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		table.Def = tableDef
+	}
+	return nil
+}
+
+// fetchViewDefinitions fetch definitions of all views
+func fetchViewDefinitions(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	viewDefRows, err := db.QueryContext(ctx, `
+SELECT table_name, view_definition FROM information_schema.views
+WHERE table_schema = ?;
+	`, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer viewDefRows.Close()
+	for viewDefRows.Next() {
+		var (
+			tableName string
+			tableDef  string
+		)
+		err := viewDefRows.Scan(&tableName, &tableDef)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		c.viewDefs[tableName] = fmt.Sprintf("CREATE VIEW %s AS (%s)", tableName, tableDef)
+	}
+	return nil
+}
+
+// fetchIndexes fetch indexes of all tables
+func fetchIndexes(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	indexRows, err := db.QueryContext(ctx, `
 SELECT
+s.table_name,
 (CASE WHEN s.index_name='PRIMARY' AND s.non_unique=0 THEN 'PRIMARY KEY'
       WHEN s.index_name!='PRIMARY' AND s.non_unique=0 THEN 'UNIQUE KEY'
       WHEN s.non_unique=1 THEN 'KEY'
@@ -98,44 +293,47 @@ FROM information_schema.statistics AS s
 LEFT JOIN information_schema.columns AS c ON s.table_schema = c.table_schema AND s.table_name = c.table_name AND s.column_name = c.column_name
 WHERE s.table_name = c.table_name
 AND s.table_schema = ?
-AND s.table_name = ?
-GROUP BY key_type, s.table_name, s.index_name, s.index_type`, s.Name, tableName)
-		defer indexRows.Close()
+GROUP BY key_type, s.table_name, s.index_name, s.index_type`, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer indexRows.Close()
+
+	for indexRows.Next() {
+		var (
+			tableName       string
+			indexKeyType    string
+			indexName       string
+			indexColumnName string
+			indexType       string
+			indexDef        string
+		)
+		err = indexRows.Scan(&tableName, &indexKeyType, &indexName, &indexColumnName, &indexType)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 
-		indexes := []*schema.Index{}
-		for indexRows.Next() {
-			var (
-				indexKeyType    string
-				indexName       string
-				indexColumnName string
-				indexType       string
-				indexDef        string
-			)
-			err = indexRows.Scan(&indexKeyType, &indexName, &indexColumnName, &indexType)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-
-			if indexKeyType == "PRIMARY KEY" {
-				indexDef = fmt.Sprintf("%s (%s) USING %s", indexKeyType, indexColumnName, indexType)
-			} else {
-				indexDef = fmt.Sprintf("%s %s (%s) USING %s", indexKeyType, indexName, indexColumnName, indexType)
-			}
+		if indexKeyType == "PRIMARY KEY" {
+			indexDef = fmt.Sprintf("%s (%s) USING %s", indexKeyType, indexColumnName, indexType)
+		} else {
+			indexDef = fmt.Sprintf("%s %s (%s) USING %s", indexKeyType, indexName, indexColumnName, indexType)
+		}
 
-			index := &schema.Index{
-				Name: indexName,
-				Def:  indexDef,
-			}
-			indexes = append(indexes, index)
+		index := &schema.Index{
+			Name:    indexName,
+			Def:     indexDef,
+			Columns: strings.Split(indexColumnName, ", "),
 		}
-		table.Indexes = indexes
+		c.indexes[tableName] = append(c.indexes[tableName], index)
+	}
+	return nil
+}
 
-		// constraints
-		constraintRows, err := db.Query(`
+// fetchConstraints fetch constraints of all tables
+func fetchConstraints(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	constraintRows, err := db.QueryContext(ctx, `
 SELECT
+  kcu.table_name,
   kcu.constraint_name,
   sub.costraint_type,
   GROUP_CONCAT(kcu.column_name ORDER BY kcu.ordinal_position, position_in_unique_constraint SEPARATOR ', ') AS column_name,
@@ -158,61 +356,55 @@ LEFT JOIN
    END) AS costraint_type
    FROM information_schema.key_column_usage AS kcu
    LEFT JOIN information_schema.columns AS c ON kcu.table_schema = c.table_schema AND kcu.table_name = c.table_name AND kcu.column_name = c.column_name
-   WHERE kcu.table_name = ?
+   WHERE kcu.table_schema = ?
    AND kcu.ordinal_position = 1
   ) AS sub
 ON kcu.constraint_name = sub.constraint_name AND kcu.table_schema = sub.table_schema AND kcu.table_name = sub.table_name
 WHERE kcu.table_schema= ?
-   AND kcu.table_name = ?
-GROUP BY kcu.constraint_name, sub.costraint_type, kcu.referenced_table_name`, tableName, s.Name, tableName)
-		defer constraintRows.Close()
+GROUP BY kcu.table_name, kcu.constraint_name, sub.costraint_type, kcu.referenced_table_name`, s.Name, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer constraintRows.Close()
+
+	for constraintRows.Next() {
+		var (
+			tableName               string
+			constraintName          string
+			constraintType          string
+			constraintColumnName    string
+			constraintRefTableName  sql.NullString
+			constraintRefColumnName sql.NullString
+			constraintDef           string
+		)
+		err = constraintRows.Scan(&tableName, &constraintName, &constraintType, &constraintColumnName, &constraintRefTableName, &constraintRefColumnName)
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		switch constraintType {
+		case "PRIMARY KEY":
+			constraintDef = fmt.Sprintf("PRIMARY KEY (%s)", constraintColumnName)
+		case "UNIQUE":
+			constraintDef = fmt.Sprintf("UNIQUE KEY %s (%s)", constraintName, constraintColumnName)
+		case "FOREIGN KEY":
+			constraintDef = fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", constraintColumnName, constraintRefTableName.String, constraintRefColumnName.String)
+		case "UNKNOWN":
+			constraintDef = fmt.Sprintf("UNKNOWN CONSTRAINT (%s) (%s) (%s)", constraintColumnName, constraintRefTableName.String, constraintRefColumnName.String)
+		}
 
-		constraints := []*schema.Constraint{}
-		for constraintRows.Next() {
-			var (
-				constraintName          string
-				constraintType          string
-				constraintColumnName    string
-				constraintRefTableName  sql.NullString
-				constraintRefColumnName sql.NullString
-				constraintDef           string
-			)
-			err = constraintRows.Scan(&constraintName, &constraintType, &constraintColumnName, &constraintRefTableName, &constraintRefColumnName)
-			if err != nil {
-				fmt.Printf("%s\n", tableName)
-				return errors.WithStack(err)
-			}
-			switch constraintType {
-			case "PRIMARY KEY":
-				constraintDef = fmt.Sprintf("PRIMARY KEY (%s)", constraintColumnName)
-			case "UNIQUE":
-				constraintDef = fmt.Sprintf("UNIQUE KEY %s (%s)", constraintName, constraintColumnName)
-			case "FOREIGN KEY":
-				constraintDef = fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", constraintColumnName, constraintRefTableName.String, constraintRefColumnName.String)
-				relation := &schema.Relation{
-					Table: table,
-					Def:   constraintDef,
-				}
-				relations = append(relations, relation)
-			case "UNKNOWN":
-				constraintDef = fmt.Sprintf("UNKNOWN CONSTRAINT (%s) (%s) (%s)", constraintColumnName, constraintRefTableName.String, constraintRefColumnName.String)
-			}
-
-			constraint := &schema.Constraint{
-				Name: constraintName,
-				Type: constraintType,
-				Def:  constraintDef,
-			}
-
-			constraints = append(constraints, constraint)
+		constraint := &schema.Constraint{
+			Name: constraintName,
+			Type: constraintType,
+			Def:  constraintDef,
 		}
-		table.Constraints = constraints
+		c.constraints[tableName] = append(c.constraints[tableName], constraint)
+	}
+	return nil
+}
 
-		// triggers
-		triggerRows, err := db.Query(`
+// fetchTriggers fetch triggers of all tables
+func fetchTriggers(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	triggerRows, err := db.QueryContext(ctx, `
 SELECT
 trigger_name,
 action_timing,
@@ -222,106 +414,71 @@ action_orientation,
 action_statement
 FROM information_schema.triggers
 WHERE event_object_schema = ?
-AND event_object_table = ?
-`, s.Name, tableName)
-		defer triggerRows.Close()
+`, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer triggerRows.Close()
+	for triggerRows.Next() {
+		var (
+			triggerName              string
+			triggerActionTiming      string
+			triggerEventManipulation string
+			triggerEventObjectTable  string
+			triggerActionOrientation string
+			triggerActionStatement   string
+			triggerDef               string
+		)
+		err = triggerRows.Scan(&triggerName, &triggerActionTiming, &triggerEventManipulation, &triggerEventObjectTable, &triggerActionOrientation, &triggerActionStatement)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		triggers := []*schema.Trigger{}
-		for triggerRows.Next() {
-			var (
-				triggerName              string
-				triggerActionTiming      string
-				triggerEventManipulation string
-				triggerEventObjectTable  string
-				triggerActionOrientation string
-				triggerActionStatement   string
-				triggerDef               string
-			)
-			err = triggerRows.Scan(&triggerName, &triggerActionTiming, &triggerEventManipulation, &triggerEventObjectTable, &triggerActionOrientation, &triggerActionStatement)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			triggerDef = fmt.Sprintf("CREATE TRIGGER %s %s %s ON %s\nFOR EACH %s\n%s", triggerName, triggerActionTiming, triggerEventManipulation, triggerEventObjectTable, triggerActionOrientation, triggerActionStatement)
-			trigger := &schema.Trigger{
-				Name: triggerName,
-				Def:  triggerDef,
-			}
-			triggers = append(triggers, trigger)
+		triggerDef = fmt.Sprintf("CREATE TRIGGER %s %s %s ON %s\nFOR EACH %s\n%s", triggerName, triggerActionTiming, triggerEventManipulation, triggerEventObjectTable, triggerActionOrientation, triggerActionStatement)
+		trigger := &schema.Trigger{
+			Name: triggerName,
+			Def:  triggerDef,
 		}
-		table.Triggers = triggers
+		c.triggers[triggerEventObjectTable] = append(c.triggers[triggerEventObjectTable], trigger)
+	}
+	return nil
+}
 
-		// columns and comments
-		columnRows, err := db.Query(`
-SELECT column_name, column_default, is_nullable, column_type, column_comment
+// fetchColumns fetch columns and comments of all tables
+func fetchColumns(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	columnRows, err := db.QueryContext(ctx, `
+SELECT table_name, column_name, column_default, is_nullable, column_type, column_comment, character_set_name, collation_name
 FROM information_schema.columns
-WHERE table_schema = ? AND table_name = ? ORDER BY ordinal_position`, s.Name, tableName)
-		defer columnRows.Close()
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		columns := []*schema.Column{}
-		for columnRows.Next() {
-			var (
-				columnName    string
-				columnDefault sql.NullString
-				isNullable    string
-				columnType    string
-				columnComment sql.NullString
-			)
-			err = columnRows.Scan(&columnName, &columnDefault, &isNullable, &columnType, &columnComment)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			column := &schema.Column{
-				Name:     columnName,
-				Type:     columnType,
-				Nullable: convertColumnNullable(isNullable),
-				Default:  columnDefault,
-				Comment:  columnComment.String,
-			}
-
-			columns = append(columns, column)
-		}
-		table.Columns = columns
-
-		tables = append(tables, table)
+WHERE table_schema = ? ORDER BY table_name, ordinal_position`, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
 	}
-
-	s.Tables = tables
-
-	// Relations
-	for _, r := range relations {
-		result := reFK.FindAllStringSubmatch(r.Def, -1)
-		strColumns := strings.Split(result[0][1], ", ")
-		strParentTable := result[0][2]
-		strParentColumns := strings.Split(result[0][3], ", ")
-		for _, c := range strColumns {
-			column, err := r.Table.FindColumnByName(c)
-			if err != nil {
-				return err
-			}
-			r.Columns = append(r.Columns, column)
-			column.ParentRelations = append(column.ParentRelations, r)
-		}
-		parentTable, err := s.FindTableByName(strParentTable)
+	defer columnRows.Close()
+	for columnRows.Next() {
+		var (
+			tableName     string
+			columnName    string
+			columnDefault sql.NullString
+			isNullable    string
+			columnType    string
+			columnComment sql.NullString
+			charset       sql.NullString
+			collation     sql.NullString
+		)
+		err = columnRows.Scan(&tableName, &columnName, &columnDefault, &isNullable, &columnType, &columnComment, &charset, &collation)
 		if err != nil {
-			return err
+			return errors.WithStack(err)
 		}
-		r.ParentTable = parentTable
-		for _, c := range strParentColumns {
-			column, err := parentTable.FindColumnByName(c)
-			if err != nil {
-				return err
-			}
-			r.ParentColumns = append(r.ParentColumns, column)
-			column.ChildRelations = append(column.ChildRelations, r)
+		column := &schema.Column{
+			Name:      columnName,
+			Type:      columnType,
+			Nullable:  convertColumnNullable(isNullable),
+			Default:   schema.NewDefault(columnDefault),
+			Comment:   columnComment.String,
+			Charset:   charset.String,
+			Collation: collation.String,
 		}
+		c.columns[tableName] = append(c.columns[tableName], column)
 	}
-
-	s.Relations = relations
-
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"testing"
@@ -27,7 +28,7 @@ func TestMain(m *testing.M) {
 
 func TestAnalyzeView(t *testing.T) {
 	driver := new(Mysql)
-	err := driver.Analyze(db, s)
+	err := driver.Analyze(context.Background(), db, s)
 	if err != nil {
 		t.Errorf("%v", err)
 	}
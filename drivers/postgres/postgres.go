@@ -1,11 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/k1LoW/tbls/drivers"
 	"github.com/k1LoW/tbls/schema"
 	"github.com/pkg/errors"
 )
@@ -14,27 +17,50 @@ var reFK = regexp.MustCompile(`FOREIGN KEY \((.+)\) REFERENCES ([^\s]+)\s?\((.+)
 var defaultSchemaName = "public"
 
 // Postgres struct
-type Postgres struct{}
+type Postgres struct {
+	IncludeSystemSchemas bool
+	Concurrency          int
+	QueryTimeout         time.Duration
+}
 
 // Analyze PostgreSQL database schema
-func (p *Postgres) Analyze(db *sql.DB, s *schema.Schema) error {
+func (p *Postgres) Analyze(ctx context.Context, db *sql.DB, s *schema.Schema) error {
+
+	// database encoding and collation
+	qctx, cancel := drivers.WithQueryTimeout(ctx, p.QueryTimeout)
+	defer cancel()
+	databaseRows, err := db.QueryContext(qctx, `
+SELECT pg_encoding_to_char(encoding), datcollate FROM pg_database WHERE datname = $1`, s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer databaseRows.Close()
+	for databaseRows.Next() {
+		err := databaseRows.Scan(&s.Charset, &s.Collation)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
 	// tables
-	tableRows, err := db.Query(`
+	systemSchemasCondition := "table_schema != 'pg_catalog' AND table_schema != 'information_schema' AND"
+	if p.IncludeSystemSchemas {
+		systemSchemasCondition = ""
+	}
+	qctx, cancel = drivers.WithQueryTimeout(ctx, p.QueryTimeout)
+	defer cancel()
+	tableRows, err := db.QueryContext(qctx, fmt.Sprintf(`
 SELECT DISTINCT cls.oid AS oid, cls.relname AS table_name, tbl.table_type AS table_type, tbl.table_schema AS table_schema
 FROM pg_catalog.pg_class cls
 INNER JOIN pg_namespace ns ON cls.relnamespace = ns.oid
 INNER JOIN (SELECT table_name, table_type, table_schema
 FROM information_schema.tables
-WHERE table_schema != 'pg_catalog' AND table_schema != 'information_schema'
-AND table_catalog = $1) tbl ON cls.relname = tbl.table_name
-ORDER BY oid`, s.Name)
-	defer tableRows.Close()
+WHERE %s table_catalog = $1) tbl ON cls.relname = tbl.table_name AND ns.nspname = tbl.table_schema
+ORDER BY oid`, systemSchemasCondition), s.Name)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-
-	relations := []*schema.Relation{}
+	defer tableRows.Close()
 
 	tables := []*schema.Table{}
 	for tableRows.Next() {
@@ -49,279 +75,427 @@ ORDER BY oid`, s.Name)
 			return errors.WithStack(err)
 		}
 
-		name := tableName
-		if tableSchema != defaultSchemaName {
-			name = fmt.Sprintf("%s.%s", tableSchema, tableName)
+		tables = append(tables, &schema.Table{
+			Name: qualifiedName(tableSchema, tableName),
+			Type: tableType,
+		})
+	}
+	tableRows.Close()
+
+	// comments, definitions, indexes, constraints, triggers and columns of all tables
+	c := newCatalog(systemSchemasCondition)
+	fetches := []func(context.Context, *sql.DB, *schema.Schema, *catalog) error{
+		fetchTableComments,
+		fetchViewDefinitions,
+		fetchIndexes,
+		fetchConstraints,
+		fetchTriggers,
+		fetchColumnComments,
+		fetchColumns,
+	}
+	err = drivers.ParallelProgress(ctx, "introspect", len(fetches), p.Concurrency, func(i int) error {
+		qctx, cancel := drivers.WithQueryTimeout(ctx, p.QueryTimeout)
+		defer cancel()
+		return fetches[i](qctx, db, s, c)
+	})
+	if err != nil {
+		return err
+	}
+	relations := []*schema.Relation{}
+	for _, t := range tables {
+		relations = append(relations, c.build(t)...)
+	}
+
+	s.Tables = tables
+
+	// Relations
+	for _, r := range relations {
+		result := reFK.FindAllStringSubmatch(r.Def, -1)
+		strColumns := strings.Split(result[0][1], ", ")
+		strParentTable := result[0][2]
+		strParentColumns := strings.Split(result[0][3], ", ")
+		for _, c := range strColumns {
+			column, err := r.Table.FindColumnByName(c)
+			if err != nil {
+				return err
+			}
+			r.Columns = append(r.Columns, column)
+		}
+		parentTable, err := s.FindTableByName(strParentTable)
+		if err != nil {
+			return err
 		}
+		r.ParentTable = parentTable
+		for _, c := range strParentColumns {
+			column, err := parentTable.FindColumnByName(c)
+			if err != nil {
+				return err
+			}
+			r.ParentColumns = append(r.ParentColumns, column)
+		}
+	}
 
-		table := &schema.Table{
-			Name: name,
-			Type: tableType,
+	s.Relations = relations
+	s.LinkRelations()
+
+	return nil
+}
+
+// Checksum return the checksum of the catalog of the database. It changes when tables, columns, constraints, indexes, triggers or comments are changed.
+func (p *Postgres) Checksum(ctx context.Context, db *sql.DB, s *schema.Schema) (string, error) {
+	qctx, cancel := drivers.WithQueryTimeout(ctx, p.QueryTimeout)
+	defer cancel()
+	var checksum string
+	err := db.QueryRowContext(qctx, `
+SELECT md5(COALESCE(string_agg(v, ',' ORDER BY v), ''))
+FROM (
+  SELECT 'c' || oid::text || ':' || xmin::text FROM pg_class WHERE relpersistence != 't'
+  UNION ALL SELECT 'a' || attrelid::text || '.' || attnum::text || ':' || xmin::text FROM pg_attribute WHERE attnum > 0
+  UNION ALL SELECT 'n' || oid::text || ':' || xmin::text FROM pg_constraint
+  UNION ALL SELECT 'i' || indexrelid::text || ':' || xmin::text FROM pg_index
+  UNION ALL SELECT 't' || oid::text || ':' || xmin::text FROM pg_trigger
+  UNION ALL SELECT 'd' || objoid::text || '.' || objsubid::text || ':' || xmin::text FROM pg_description
+) AS catalog(v)`).Scan(&checksum)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return checksum, nil
+}
+
+// qualifiedName return table name qualified by the schema unless the schema is default
+func qualifiedName(tableSchema, tableName string) string {
+	if tableSchema == defaultSchemaName {
+		return tableName
+	}
+	return fmt.Sprintf("%s.%s", tableSchema, tableName)
+}
+
+// catalog is comments, definitions, indexes, constraints, triggers and columns of all tables fetched in bulk, keyed by qualified table name
+type catalog struct {
+	systemSchemasCondition string
+	comments               map[string]string
+	viewDefs               map[string]string
+	indexes                map[string][]*schema.Index
+	constraints            map[string][]*schema.Constraint
+	triggers               map[string][]*schema.Trigger
+	columnComments         map[string]map[string]string
+	columns                map[string][]*schema.Column
+}
+
+func newCatalog(systemSchemasCondition string) *catalog {
+	return &catalog{
+		systemSchemasCondition: systemSchemasCondition,
+		comments:               map[string]string{},
+		viewDefs:               map[string]string{},
+		indexes:                map[string][]*schema.Index{},
+		constraints:            map[string][]*schema.Constraint{},
+		triggers:               map[string][]*schema.Trigger{},
+		columnComments:         map[string]map[string]string{},
+		columns:                map[string][]*schema.Column{},
+	}
+}
+
+// build set comment, definition, indexes, constraints, triggers and columns of the table, and return foreign key relations of the table whose columns are resolved later
+func (c *catalog) build(table *schema.Table) []*schema.Relation {
+	relations := []*schema.Relation{}
+
+	table.Comment = c.comments[table.Name]
+	if table.Type == "VIEW" {
+		table.Def = c.viewDefs[table.Name]
+	}
+
+	indexes := c.indexes[table.Name]
+	if indexes == nil {
+		indexes = []*schema.Index{}
+	}
+	table.Indexes = indexes
+
+	constraints := c.constraints[table.Name]
+	if constraints == nil {
+		constraints = []*schema.Constraint{}
+	}
+	for _, constraint := range constraints {
+		if constraint.Type == "FOREIGN KEY" {
+			relation := &schema.Relation{
+				Table: table,
+				Def:   constraint.Def,
+			}
+			relations = append(relations, relation)
+		}
+	}
+	table.Constraints = constraints
+
+	triggers := c.triggers[table.Name]
+	if triggers == nil {
+		triggers = []*schema.Trigger{}
+	}
+	table.Triggers = triggers
+
+	columns := c.columns[table.Name]
+	if columns == nil {
+		columns = []*schema.Column{}
+	}
+	for _, column := range columns {
+		if comment, ok := c.columnComments[table.Name][column.Name]; ok {
+			column.Comment = comment
 		}
+	}
+	table.Columns = columns
 
-		// table comment
-		tableCommentRows, err := db.Query(`
-SELECT pd.description as comment
+	return relations
+}
+
+// fetchTableComments fetch comments of all tables
+func fetchTableComments(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	tableCommentRows, err := db.QueryContext(ctx, `
+SELECT ps.schemaname, ps.relname, pd.description as comment
 FROM pg_stat_user_tables AS ps, pg_description AS pd
 WHERE ps.relid=pd.objoid
-AND pd.objsubid=0
-AND ps.relname = $1
-AND ps.schemaname = $2`, tableName, tableSchema)
-		defer tableCommentRows.Close()
+AND pd.objsubid=0`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer tableCommentRows.Close()
+
+	for tableCommentRows.Next() {
+		var (
+			tableSchema  string
+			tableName    string
+			tableComment string
+		)
+		err = tableCommentRows.Scan(&tableSchema, &tableName, &tableComment)
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		c.comments[qualifiedName(tableSchema, tableName)] = tableComment
+	}
+	return nil
+}
 
-		for tableCommentRows.Next() {
-			var tableComment string
-			err = tableCommentRows.Scan(&tableComment)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			table.Comment = tableComment
-		}
-
-		// view definition
-		if tableType == "VIEW" {
-			viewDefRows, err := db.Query(`
-SELECT view_definition FROM information_schema.views
-WHERE table_catalog = $1
-AND table_name = $2
-AND table_schema = $3;
-		`, s.Name, tableName, tableSchema)
-			defer viewDefRows.Close()
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			for viewDefRows.Next() {
-				var tableDef string
-				err := viewDefRows.Scan(&tableDef)
-				if err != nil {
-					return errors.WithStack(err)
-				}
-				table.Def = fmt.Sprintf("CREATE VIEW %s AS (\n%s\n)", tableName, strings.TrimRight(tableDef, ";"))
-			}
+// fetchViewDefinitions fetch definitions of all views
+func fetchViewDefinitions(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	viewDefRows, err := db.QueryContext(ctx, fmt.Sprintf(`
+SELECT table_schema, table_name, view_definition FROM information_schema.views
+WHERE %s table_catalog = $1;
+	`, c.systemSchemasCondition), s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer viewDefRows.Close()
+	for viewDefRows.Next() {
+		var (
+			tableSchema string
+			tableName   string
+			tableDef    string
+		)
+		err := viewDefRows.Scan(&tableSchema, &tableName, &tableDef)
+		if err != nil {
+			return errors.WithStack(err)
 		}
+		c.viewDefs[qualifiedName(tableSchema, tableName)] = fmt.Sprintf("CREATE VIEW %s AS (\n%s\n)", tableName, strings.TrimRight(tableDef, ";"))
+	}
+	return nil
+}
 
-		// indexes
-		indexRows, err := db.Query(`
+// fetchIndexes fetch indexes of all tables
+func fetchIndexes(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	indexRows, err := db.QueryContext(ctx, `
 SELECT
+n.nspname AS schemaname,
+c.relname AS tablename,
 i.relname AS indexname,
-pg_get_indexdef(i.oid) AS indexdef
+pg_get_indexdef(i.oid) AS indexdef,
+ARRAY_TO_STRING(ARRAY(
+  SELECT a.attname
+  FROM UNNEST(x.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+  JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = k.attnum
+  ORDER BY k.ord
+), ',') AS indexcolumns
 FROM ((((pg_index x
 JOIN pg_class c ON ((c.oid = x.indrelid)))
 JOIN pg_class i ON ((i.oid = x.indexrelid)))
 LEFT JOIN pg_namespace n ON ((n.oid = c.relnamespace))))
 WHERE ((c.relkind = ANY (ARRAY['r'::"char", 'm'::"char"])) AND (i.relkind = 'i'::"char"))
-AND c.relname = $1
-AND n.nspname = $2
 ORDER BY x.indexrelid
-`, tableName, tableSchema)
-		defer indexRows.Close()
+`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer indexRows.Close()
+
+	for indexRows.Next() {
+		var (
+			tableSchema  string
+			tableName    string
+			indexName    string
+			indexDef     string
+			indexColumns string
+		)
+		err = indexRows.Scan(&tableSchema, &tableName, &indexName, &indexDef, &indexColumns)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-
-		indexes := []*schema.Index{}
-		for indexRows.Next() {
-			var (
-				indexName string
-				indexDef  string
-			)
-			err = indexRows.Scan(&indexName, &indexDef)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			index := &schema.Index{
-				Name: indexName,
-				Def:  indexDef,
-			}
-			indexes = append(indexes, index)
+		index := &schema.Index{
+			Name:    indexName,
+			Def:     indexDef,
+			Columns: strings.Split(indexColumns, ","),
 		}
-		table.Indexes = indexes
+		name := qualifiedName(tableSchema, tableName)
+		c.indexes[name] = append(c.indexes[name], index)
+	}
+	return nil
+}
 
-		// constraints
-		constraintRows, err := db.Query(`
+// fetchConstraints fetch constraints of all tables
+func fetchConstraints(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	constraintRows, err := db.QueryContext(ctx, `
 SELECT
+  ps.schemaname,
+  ps.relname,
   pc.conname AS name,
   (CASE WHEN contype='t' THEN pg_get_triggerdef((SELECT oid FROM pg_trigger WHERE tgconstraint = pc.oid LIMIT 1))
         ELSE pg_get_constraintdef(pc.oid)
    END) AS def,
   contype AS type
 FROM pg_constraint AS pc
-LEFT JOIN pg_stat_user_tables AS ps ON ps.relid = pc.conrelid
-WHERE ps.relname = $1
-AND ps.schemaname = $2
-ORDER BY pc.conrelid, pc.conindid, pc.conname`, tableName, tableSchema)
-		defer constraintRows.Close()
+INNER JOIN pg_stat_user_tables AS ps ON ps.relid = pc.conrelid
+ORDER BY pc.conrelid, pc.conindid, pc.conname`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer constraintRows.Close()
+
+	for constraintRows.Next() {
+		var (
+			tableSchema    string
+			tableName      string
+			constraintName string
+			constraintDef  string
+			constraintType string
+		)
+		err = constraintRows.Scan(&tableSchema, &tableName, &constraintName, &constraintDef, &constraintType)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-
-		constraints := []*schema.Constraint{}
-		for constraintRows.Next() {
-			var (
-				constraintName string
-				constraintDef  string
-				constraintType string
-			)
-			err = constraintRows.Scan(&constraintName, &constraintDef, &constraintType)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			constraint := &schema.Constraint{
-				Name: constraintName,
-				Type: convertConstraintType(constraintType),
-				Def:  constraintDef,
-			}
-			if constraintType == "f" {
-				relation := &schema.Relation{
-					Table: table,
-					Def:   constraintDef,
-				}
-				relations = append(relations, relation)
-			}
-			constraints = append(constraints, constraint)
+		constraint := &schema.Constraint{
+			Name: constraintName,
+			Type: convertConstraintType(constraintType),
+			Def:  constraintDef,
 		}
-		table.Constraints = constraints
+		name := qualifiedName(tableSchema, tableName)
+		c.constraints[name] = append(c.constraints[name], constraint)
+	}
+	return nil
+}
 
-		// triggers
-		triggerRows, err := db.Query(`
-SELECT tgname, pg_get_triggerdef(pt.oid)
+// fetchTriggers fetch triggers of all tables
+func fetchTriggers(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	triggerRows, err := db.QueryContext(ctx, `
+SELECT ps.schemaname, ps.relname, tgname, pg_get_triggerdef(pt.oid)
 FROM pg_trigger AS pt
-LEFT JOIN pg_stat_user_tables AS ps ON ps.relid = pt.tgrelid
+INNER JOIN pg_stat_user_tables AS ps ON ps.relid = pt.tgrelid
 WHERE pt.tgisinternal = false
-AND ps.relname = $1
-AND ps.schemaname = $2
 ORDER BY pt.tgrelid
-`, tableName, tableSchema)
-		defer triggerRows.Close()
+`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer triggerRows.Close()
+
+	for triggerRows.Next() {
+		var (
+			tableSchema string
+			tableName   string
+			triggerName string
+			triggerDef  string
+		)
+		err = triggerRows.Scan(&tableSchema, &tableName, &triggerName, &triggerDef)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-
-		triggers := []*schema.Trigger{}
-		for triggerRows.Next() {
-			var (
-				triggerName string
-				triggerDef  string
-			)
-			err = triggerRows.Scan(&triggerName, &triggerDef)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			trigger := &schema.Trigger{
-				Name: triggerName,
-				Def:  triggerDef,
-			}
-			triggers = append(triggers, trigger)
+		trigger := &schema.Trigger{
+			Name: triggerName,
+			Def:  triggerDef,
 		}
-		table.Triggers = triggers
+		name := qualifiedName(tableSchema, tableName)
+		c.triggers[name] = append(c.triggers[name], trigger)
+	}
+	return nil
+}
 
-		// columns comments
-		columnCommentRows, err := db.Query(`
-SELECT pa.attname AS column_name, pd.description AS comment
+// fetchColumnComments fetch comments of columns of all tables
+func fetchColumnComments(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	columnCommentRows, err := db.QueryContext(ctx, `
+SELECT ps.schemaname, ps.relname, pa.attname AS column_name, pd.description AS comment
 FROM pg_stat_all_tables AS ps ,pg_description AS pd ,pg_attribute AS pa
 WHERE ps.relid=pd.objoid
 AND pd.objsubid != 0
 AND pd.objoid=pa.attrelid
-AND pd.objsubid=pa.attnum
-AND ps.relname = $1
-AND ps.schemaname = $2`, tableName, tableSchema)
-		defer columnCommentRows.Close()
-		if err != nil {
-			return errors.WithStack(err)
-		}
-
-		columnComments := make(map[string]string)
-		for columnCommentRows.Next() {
-			var (
-				columnName    string
-				columnComment string
-			)
-			err = columnCommentRows.Scan(&columnName, &columnComment)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			columnComments[columnName] = columnComment
-		}
+AND pd.objsubid=pa.attnum`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer columnCommentRows.Close()
 
-		// columns
-		columnRows, err := db.Query(`
-SELECT column_name, column_default, is_nullable, data_type, udt_name, character_maximum_length
-FROM information_schema.columns
-WHERE table_name = $1
-AND table_schema = $2
-ORDER BY ordinal_position
-`, tableName, tableSchema)
-		defer columnRows.Close()
+	for columnCommentRows.Next() {
+		var (
+			tableSchema   string
+			tableName     string
+			columnName    string
+			columnComment string
+		)
+		err = columnCommentRows.Scan(&tableSchema, &tableName, &columnName, &columnComment)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-
-		columns := []*schema.Column{}
-		for columnRows.Next() {
-			var (
-				columnName             string
-				columnDefault          sql.NullString
-				isNullable             string
-				dataType               string
-				udtName                string
-				characterMaximumLength sql.NullInt64
-			)
-			err = columnRows.Scan(&columnName, &columnDefault, &isNullable, &dataType, &udtName, &characterMaximumLength)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			column := &schema.Column{
-				Name:     columnName,
-				Type:     convertColmunType(dataType, udtName, characterMaximumLength),
-				Nullable: convertColumnNullable(isNullable),
-				Default:  columnDefault,
-			}
-			if comment, ok := columnComments[columnName]; ok {
-				column.Comment = comment
-			}
-			columns = append(columns, column)
+		name := qualifiedName(tableSchema, tableName)
+		if _, ok := c.columnComments[name]; !ok {
+			c.columnComments[name] = map[string]string{}
 		}
-		table.Columns = columns
-
-		tables = append(tables, table)
+		c.columnComments[name][columnName] = columnComment
 	}
+	return nil
+}
 
-	s.Tables = tables
+// fetchColumns fetch columns of all tables
+func fetchColumns(ctx context.Context, db *sql.DB, s *schema.Schema, c *catalog) error {
+	columnRows, err := db.QueryContext(ctx, fmt.Sprintf(`
+SELECT table_schema, table_name, column_name, column_default, is_nullable, data_type, udt_name, character_maximum_length, collation_name
+FROM information_schema.columns
+WHERE %s table_catalog = $1
+ORDER BY table_schema, table_name, ordinal_position
+`, c.systemSchemasCondition), s.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer columnRows.Close()
 
-	// Relations
-	for _, r := range relations {
-		result := reFK.FindAllStringSubmatch(r.Def, -1)
-		strColumns := strings.Split(result[0][1], ", ")
-		strParentTable := result[0][2]
-		strParentColumns := strings.Split(result[0][3], ", ")
-		for _, c := range strColumns {
-			column, err := r.Table.FindColumnByName(c)
-			if err != nil {
-				return err
-			}
-			r.Columns = append(r.Columns, column)
-			column.ParentRelations = append(column.ParentRelations, r)
-		}
-		parentTable, err := s.FindTableByName(strParentTable)
+	for columnRows.Next() {
+		var (
+			tableSchema            string
+			tableName              string
+			columnName             string
+			columnDefault          sql.NullString
+			isNullable             string
+			dataType               string
+			udtName                string
+			characterMaximumLength sql.NullInt64
+			collationName          sql.NullString
+		)
+		err = columnRows.Scan(&tableSchema, &tableName, &columnName, &columnDefault, &isNullable, &dataType, &udtName, &characterMaximumLength, &collationName)
 		if err != nil {
-			return err
+			return errors.WithStack(err)
 		}
-		r.ParentTable = parentTable
-		for _, c := range strParentColumns {
-			column, err := parentTable.FindColumnByName(c)
-			if err != nil {
-				return err
-			}
-			r.ParentColumns = append(r.ParentColumns, column)
-			column.ChildRelations = append(column.ChildRelations, r)
+		column := &schema.Column{
+			Name:      columnName,
+			Type:      convertColmunType(dataType, udtName, characterMaximumLength),
+			Nullable:  convertColumnNullable(isNullable),
+			Default:   schema.NewDefault(columnDefault),
+			Collation: collationName.String,
 		}
+		name := qualifiedName(tableSchema, tableName)
+		c.columns[name] = append(c.columns[name], column)
 	}
-
-	s.Relations = relations
-
 	return nil
 }
 
@@ -1,11 +1,15 @@
 package sqlite
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/k1LoW/tbls/drivers"
 	"github.com/k1LoW/tbls/schema"
 	"github.com/pkg/errors"
 	"regexp"
@@ -17,7 +21,11 @@ var reFTS = regexp.MustCompile(`(?i)USING\s+fts([34])`)
 var shadowTables []string
 
 // Sqlite struct
-type Sqlite struct{}
+type Sqlite struct {
+	IncludeSystemSchemas bool
+	Concurrency          int
+	QueryTimeout         time.Duration
+}
 
 type fk struct {
 	ID                 string
@@ -30,18 +38,22 @@ type fk struct {
 }
 
 // Analyze SQLite database schema
-func (l *Sqlite) Analyze(db *sql.DB, s *schema.Schema) error {
+func (l *Sqlite) Analyze(ctx context.Context, db *sql.DB, s *schema.Schema) error {
 	// tables
-	tableRows, err := db.Query(`
+	systemTablesCondition := "substr(name, 1, 7) != 'sqlite_' AND"
+	if l.IncludeSystemSchemas {
+		systemTablesCondition = ""
+	}
+	qctx, cancel := drivers.WithQueryTimeout(ctx, l.QueryTimeout)
+	defer cancel()
+	tableRows, err := db.QueryContext(qctx, fmt.Sprintf(`
 SELECT name, type, sql
 FROM sqlite_master
-WHERE name != 'sqlite_sequence' AND (type = 'table' OR type = 'view');`)
-	defer tableRows.Close()
+WHERE %s (type = 'table' OR type = 'view');`, systemTablesCondition))
 	if err != nil {
 		return errors.WithStack(err)
 	}
-
-	relations := []*schema.Relation{}
+	defer tableRows.Close()
 
 	tables := []*schema.Table{}
 	for tableRows.Next() {
@@ -67,295 +79,386 @@ WHERE name != 'sqlite_sequence' AND (type = 'table' OR type = 'view');`)
 			}
 		}
 
-		table := &schema.Table{
+		tables = append(tables, &schema.Table{
 			Name: tableName,
 			Type: tableType,
 			Def:  tableDef,
+		})
+	}
+	tableRows.Close()
+
+	// columns, constraints, indexes and triggers of all tables
+	c := newCatalog()
+	fetches := []func(context.Context, *sql.DB, *catalog) error{fetchColumns, fetchForeignKeys, fetchIndexes, fetchTriggers}
+	err = drivers.ParallelProgress(ctx, "introspect", len(fetches), l.Concurrency, func(i int) error {
+		qctx, cancel := drivers.WithQueryTimeout(ctx, l.QueryTimeout)
+		defer cancel()
+		return fetches[i](qctx, db, c)
+	})
+	if err != nil {
+		return err
+	}
+	relations := []*schema.Relation{}
+	for _, t := range tables {
+		relations = append(relations, c.build(t)...)
+	}
+
+	filtered := []*schema.Table{}
+	for _, t := range tables {
+		if !contains(shadowTables, t.Name) {
+			filtered = append(filtered, t)
 		}
+	}
 
-		// constraints
-		constraints := []*schema.Constraint{}
+	s.Tables = filtered
 
-		// columns
-		columnRows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", tableName))
-		defer columnRows.Close()
+	// Relations
+	for _, r := range relations {
+		result := reFK.FindAllStringSubmatch(r.Def, -1)
+		strColumns := strings.Split(result[0][1], ", ")
+		strParentTable := result[0][2]
+		strParentColumns := strings.Split(result[0][3], ", ")
+		for _, c := range strColumns {
+			column, err := r.Table.FindColumnByName(c)
+			if err != nil {
+				return err
+			}
+			r.Columns = append(r.Columns, column)
+		}
+		parentTable, err := s.FindTableByName(strParentTable)
 		if err != nil {
-			return errors.WithStack(err)
+			return err
 		}
-
-		columns := []*schema.Column{}
-		for columnRows.Next() {
-			var (
-				columnID      string
-				columnName    string
-				dataType      string
-				columnNotNull string
-				columnDefault sql.NullString
-				columnPk      string
-			)
-			err = columnRows.Scan(&columnID, &columnName, &dataType, &columnNotNull, &columnDefault, &columnPk)
+		r.ParentTable = parentTable
+		for _, c := range strParentColumns {
+			column, err := parentTable.FindColumnByName(c)
 			if err != nil {
-				return errors.WithStack(err)
-			}
-			column := &schema.Column{
-				Name:     columnName,
-				Type:     dataType,
-				Nullable: convertColumnNullable(columnNotNull),
-				Default:  columnDefault,
-			}
-			columns = append(columns, column)
-
-			if columnPk != "0" {
-				constraintDef := fmt.Sprintf("PRIMARY KEY (%s)", columnName)
-				constraint := &schema.Constraint{
-					Name: columnName,
-					Type: "PRIMARY KEY",
-					Def:  constraintDef,
-				}
-				constraints = append(constraints, constraint)
+				return err
 			}
+			r.ParentColumns = append(r.ParentColumns, column)
 		}
+	}
 
-		/// foreign keys
-		fkMap := map[string]*fk{}
-		fkSlice := []*fk{}
+	s.Relations = relations
+	s.LinkRelations()
 
-		foreignKeyRows, err := db.Query(fmt.Sprintf("PRAGMA foreign_key_list(%s)", tableName))
-		defer foreignKeyRows.Close()
+	return nil
+}
+
+// Checksum return the checksum of the catalog of the database. It changes when tables, indexes or triggers are changed.
+func (l *Sqlite) Checksum(ctx context.Context, db *sql.DB, s *schema.Schema) (string, error) {
+	qctx, cancel := drivers.WithQueryTimeout(ctx, l.QueryTimeout)
+	defer cancel()
+	rows, err := db.QueryContext(qctx, `SELECT type, name, tbl_name, COALESCE(sql, '') FROM sqlite_master ORDER BY type, name;`)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer rows.Close()
+	h := sha256.New()
+	for rows.Next() {
+		var objectType, name, tableName, objectDef string
+		err := rows.Scan(&objectType, &name, &tableName, &objectDef)
 		if err != nil {
-			return errors.WithStack(err)
+			return "", errors.WithStack(err)
 		}
-		for foreignKeyRows.Next() {
-			var (
-				foreignKeyID                string
-				foreignKeySeq               string
-				foreignKeyForeignTableName  string
-				foreignKeyColumnName        string
-				foreignKeyForeignColumnName string
-				foreignKeyOnUpdate          string
-				foreignKeyOnDelete          string
-				foreignKeyMatch             string
-			)
-			err = foreignKeyRows.Scan(
-				&foreignKeyID,
-				&foreignKeySeq,
-				&foreignKeyForeignTableName,
-				&foreignKeyColumnName,
-				&foreignKeyForeignColumnName,
-				&foreignKeyOnUpdate,
-				&foreignKeyOnDelete,
-				&foreignKeyMatch,
-			)
-			if err != nil {
-				return errors.WithStack(err)
-			}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\n", objectType, name, tableName, objectDef)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
 
-			if f, ok := fkMap[foreignKeyID]; ok {
-				fkMap[foreignKeyID].ColumnNames = append(f.ColumnNames, foreignKeyColumnName)
-				fkMap[foreignKeyID].ForeignColumnNames = append(f.ForeignColumnNames, foreignKeyForeignColumnName)
-			} else {
-				f := &fk{
-					ID:                 foreignKeyID,
-					ForeignTableName:   foreignKeyForeignTableName,
-					ColumnNames:        []string{foreignKeyColumnName},
-					ForeignColumnNames: []string{foreignKeyForeignColumnName},
-					OnUpdate:           foreignKeyOnUpdate,
-					OnDelete:           foreignKeyOnDelete,
-					Match:              foreignKeyMatch,
-				}
-				fkMap[foreignKeyID] = f
-			}
+// catalog is columns, constraints, indexes and triggers of all tables fetched in bulk, keyed by table name
+type catalog struct {
+	columns          map[string][]*schema.Column
+	primaryKeys      map[string][]*schema.Constraint
+	foreignKeys      map[string][]*fk
+	indexes          map[string][]*schema.Index
+	indexConstraints map[string][]*schema.Constraint
+	triggers         map[string][]*schema.Trigger
+}
+
+func newCatalog() *catalog {
+	return &catalog{
+		columns:          map[string][]*schema.Column{},
+		primaryKeys:      map[string][]*schema.Constraint{},
+		foreignKeys:      map[string][]*fk{},
+		indexes:          map[string][]*schema.Index{},
+		indexConstraints: map[string][]*schema.Constraint{},
+		triggers:         map[string][]*schema.Trigger{},
+	}
+}
+
+// build set columns, constraints, indexes and triggers of the table, and return foreign key relations of the table whose columns are resolved later
+func (c *catalog) build(table *schema.Table) []*schema.Relation {
+	relations := []*schema.Relation{}
+
+	// constraints
+	constraints := []*schema.Constraint{}
+	constraints = append(constraints, c.primaryKeys[table.Name]...)
+
+	/// foreign keys
+	for _, f := range c.foreignKeys[table.Name] {
+		foreignKeyDef := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s) ON UPDATE %s ON DELETE %s MATCH %s",
+			strings.Join(f.ColumnNames, ", "), f.ForeignTableName, strings.Join(f.ForeignColumnNames, ", "), f.OnUpdate, f.OnDelete, f.Match)
+		constraint := &schema.Constraint{
+			Name: fmt.Sprintf("- (Foreign key ID: %s)", f.ID),
+			Type: "FOREIGN KEY",
+			Def:  foreignKeyDef,
 		}
-		for _, f := range fkMap {
-			fkSlice = append(fkSlice, f)
+		relation := &schema.Relation{
+			Table: table,
+			Def:   foreignKeyDef,
 		}
-		sort.SliceStable(fkSlice, func(i, j int) bool {
-			return fkSlice[i].ID < fkSlice[j].ID
-		})
+		relations = append(relations, relation)
 
-		for _, f := range fkSlice {
-			foreignKeyDef := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s) ON UPDATE %s ON DELETE %s MATCH %s",
-				strings.Join(f.ColumnNames, ", "), f.ForeignTableName, strings.Join(f.ForeignColumnNames, ", "), f.OnUpdate, f.OnDelete, f.Match)
-			constraint := &schema.Constraint{
-				Name: fmt.Sprintf("- (Foreign key ID: %s)", f.ID),
-				Type: "FOREIGN KEY",
-				Def:  foreignKeyDef,
-			}
-			relation := &schema.Relation{
-				Table: table,
-				Def:   foreignKeyDef,
-			}
-			relations = append(relations, relation)
+		constraints = append(constraints, constraint)
+	}
 
-			constraints = append(constraints, constraint)
-		}
+	// constraints(UNIQUE, PRIMARY KEY)
+	constraints = append(constraints, c.indexConstraints[table.Name]...)
+
+	// constraints(CHECK)
+	constraints = append(constraints, parseCheckConstraints(table.Def)...)
+
+	columns := c.columns[table.Name]
+	if columns == nil {
+		columns = []*schema.Column{}
+	}
+	indexes := c.indexes[table.Name]
+	if indexes == nil {
+		indexes = []*schema.Index{}
+	}
+	triggers := c.triggers[table.Name]
+	if triggers == nil {
+		triggers = []*schema.Trigger{}
+	}
+
+	table.Columns = columns
+	table.Indexes = indexes
+	table.Constraints = constraints
+	table.Triggers = triggers
+
+	return relations
+}
+
+// fetchColumns fetch columns and constraints(PRIMARY KEY) of all tables
+func fetchColumns(ctx context.Context, db *sql.DB, c *catalog) error {
+	columnRows, err := db.QueryContext(ctx, `
+SELECT m.name, p.name, p.type, p."notnull", p.dflt_value, p.pk
+FROM sqlite_master AS m, pragma_table_info(m.name) AS p
+WHERE m.type = 'table' OR m.type = 'view'
+ORDER BY m.name, p.cid;`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer columnRows.Close()
 
-		// indexes and constraints(UNIQUE, PRIMARY KEY)
-		indexRows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", tableName))
-		defer indexRows.Close()
+	for columnRows.Next() {
+		var (
+			tableName     string
+			columnName    string
+			dataType      string
+			columnNotNull string
+			columnDefault sql.NullString
+			columnPk      string
+		)
+		err = columnRows.Scan(&tableName, &columnName, &dataType, &columnNotNull, &columnDefault, &columnPk)
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		column := &schema.Column{
+			Name:     columnName,
+			Type:     dataType,
+			Nullable: convertColumnNullable(columnNotNull),
+			Default:  schema.NewDefault(columnDefault),
+		}
+		c.columns[tableName] = append(c.columns[tableName], column)
 
-		indexes := []*schema.Index{}
-		for indexRows.Next() {
-			var (
-				indexID        string
-				indexName      string
-				indexIsUnique  string
-				indexCreatedBy string
-				indexPartial   string
-				indexDef       string
-			)
-			err = indexRows.Scan(
-				&indexID,
-				&indexName,
-				&indexIsUnique,
-				&indexCreatedBy,
-				&indexPartial,
-			)
-			if err != nil {
-				return errors.WithStack(err)
+		if columnPk != "0" {
+			constraintDef := fmt.Sprintf("PRIMARY KEY (%s)", columnName)
+			constraint := &schema.Constraint{
+				Name: columnName,
+				Type: "PRIMARY KEY",
+				Def:  constraintDef,
 			}
+			c.primaryKeys[tableName] = append(c.primaryKeys[tableName], constraint)
+		}
+	}
+	return nil
+}
 
-			if indexCreatedBy == "c" {
-				row, err := db.Query(`SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name = ?;
-`, tableName, indexName)
-				for row.Next() {
-					err = row.Scan(
-						&indexDef,
-					)
-					if err != nil {
-						return errors.WithStack(err)
-					}
-				}
-			} else {
-				var (
-					colRank            string
-					colRankWithinTable string
-					col                string
-					cols               []string
-				)
-				row, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", indexName))
-				for row.Next() {
-					err = row.Scan(
-						&colRank,
-						&colRankWithinTable,
-						&col,
-					)
-					if err != nil {
-						return errors.WithStack(err)
-					}
-					cols = append(cols, col)
-				}
-				switch indexCreatedBy {
-				case "u":
-					indexDef = fmt.Sprintf("UNIQUE (%s)", strings.Join(cols, ", "))
-					constraint := &schema.Constraint{
-						Name: indexName,
-						Type: "UNIQUE",
-						Def:  indexDef,
-					}
-					constraints = append(constraints, constraint)
-				case "pk":
-					// MEMO: Does not work ?
-					indexDef = fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(cols, ", "))
-					constraint := &schema.Constraint{
-						Name: indexName,
-						Type: "PRIMARY KEY",
-						Def:  indexDef,
-					}
-					constraints = append(constraints, constraint)
-				}
-			}
+// fetchForeignKeys fetch foreign keys of all tables
+func fetchForeignKeys(ctx context.Context, db *sql.DB, c *catalog) error {
+	foreignKeyRows, err := db.QueryContext(ctx, `
+SELECT m.name, p.id, p."table", p."from", p."to", p.on_update, p.on_delete, p.match
+FROM sqlite_master AS m, pragma_foreign_key_list(m.name) AS p
+WHERE m.type = 'table'
+ORDER BY m.name, p.id, p.seq;`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer foreignKeyRows.Close()
 
-			index := &schema.Index{
-				Name: indexName,
-				Def:  indexDef,
-			}
-			indexes = append(indexes, index)
+	fkMap := map[string]*fk{}
+	for foreignKeyRows.Next() {
+		var (
+			tableName                   string
+			foreignKeyID                string
+			foreignKeyForeignTableName  string
+			foreignKeyColumnName        string
+			foreignKeyForeignColumnName string
+			foreignKeyOnUpdate          string
+			foreignKeyOnDelete          string
+			foreignKeyMatch             string
+		)
+		err = foreignKeyRows.Scan(
+			&tableName,
+			&foreignKeyID,
+			&foreignKeyForeignTableName,
+			&foreignKeyColumnName,
+			&foreignKeyForeignColumnName,
+			&foreignKeyOnUpdate,
+			&foreignKeyOnDelete,
+			&foreignKeyMatch,
+		)
+		if err != nil {
+			return errors.WithStack(err)
 		}
 
-		// constraints(CHECK)
-		checkConstraints := parseCheckConstraints(tableDef)
-		for _, c := range checkConstraints {
-			constraints = append(constraints, c)
+		key := fmt.Sprintf("%s\x00%s", tableName, foreignKeyID)
+		if f, ok := fkMap[key]; ok {
+			f.ColumnNames = append(f.ColumnNames, foreignKeyColumnName)
+			f.ForeignColumnNames = append(f.ForeignColumnNames, foreignKeyForeignColumnName)
+		} else {
+			f := &fk{
+				ID:                 foreignKeyID,
+				ForeignTableName:   foreignKeyForeignTableName,
+				ColumnNames:        []string{foreignKeyColumnName},
+				ForeignColumnNames: []string{foreignKeyForeignColumnName},
+				OnUpdate:           foreignKeyOnUpdate,
+				OnDelete:           foreignKeyOnDelete,
+				Match:              foreignKeyMatch,
+			}
+			fkMap[key] = f
+			c.foreignKeys[tableName] = append(c.foreignKeys[tableName], f)
 		}
+	}
+	for _, fkSlice := range c.foreignKeys {
+		sort.SliceStable(fkSlice, func(i, j int) bool {
+			return fkSlice[i].ID < fkSlice[j].ID
+		})
+	}
+	return nil
+}
 
-		// triggers
-		triggerRows, err := db.Query(`
-SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ?;
-`, tableName)
-		defer triggerRows.Close()
+// fetchIndexes fetch indexes and constraints(UNIQUE, PRIMARY KEY) of all tables
+func fetchIndexes(ctx context.Context, db *sql.DB, c *catalog) error {
+	indexRows, err := db.QueryContext(ctx, `
+SELECT m.name, il.name, il.origin, ii.name, im.sql
+FROM sqlite_master AS m, pragma_index_list(m.name) AS il, pragma_index_info(il.name) AS ii
+LEFT JOIN sqlite_master AS im ON im.type = 'index' AND im.name = il.name
+WHERE m.type = 'table'
+ORDER BY m.name, il.seq, ii.seqno;`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer indexRows.Close()
+
+	type index struct {
+		table     string
+		name      string
+		createdBy string
+		def       string
+		cols      []string
+	}
+	indexes := []*index{}
+	indexMap := map[string]*index{}
+	for indexRows.Next() {
+		var (
+			tableName      string
+			indexName      string
+			indexCreatedBy string
+			col            sql.NullString
+			indexSQL       sql.NullString
+		)
+		err = indexRows.Scan(&tableName, &indexName, &indexCreatedBy, &col, &indexSQL)
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		key := fmt.Sprintf("%s\x00%s", tableName, indexName)
+		i, ok := indexMap[key]
+		if !ok {
+			i = &index{
+				table:     tableName,
+				name:      indexName,
+				createdBy: indexCreatedBy,
+				def:       indexSQL.String,
+			}
+			indexMap[key] = i
+			indexes = append(indexes, i)
+		}
+		i.cols = append(i.cols, col.String)
+	}
 
-		triggers := []*schema.Trigger{}
-		for triggerRows.Next() {
-			var (
-				triggerName string
-				triggerDef  string
-			)
-			err = triggerRows.Scan(&triggerName, &triggerDef)
-			if err != nil {
-				return errors.WithStack(err)
+	for _, i := range indexes {
+		indexDef := ""
+		switch i.createdBy {
+		case "c":
+			indexDef = i.def
+		case "u":
+			indexDef = fmt.Sprintf("UNIQUE (%s)", strings.Join(i.cols, ", "))
+			constraint := &schema.Constraint{
+				Name: i.name,
+				Type: "UNIQUE",
+				Def:  indexDef,
 			}
-			trigger := &schema.Trigger{
-				Name: triggerName,
-				Def:  triggerDef,
+			c.indexConstraints[i.table] = append(c.indexConstraints[i.table], constraint)
+		case "pk":
+			// MEMO: Does not work ?
+			indexDef = fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(i.cols, ", "))
+			constraint := &schema.Constraint{
+				Name: i.name,
+				Type: "PRIMARY KEY",
+				Def:  indexDef,
 			}
-			triggers = append(triggers, trigger)
+			c.indexConstraints[i.table] = append(c.indexConstraints[i.table], constraint)
 		}
 
-		table.Columns = columns
-		table.Indexes = indexes
-		table.Constraints = constraints
-		table.Triggers = triggers
-
-		tables = append(tables, table)
-	}
-
-	filtered := []*schema.Table{}
-	for _, t := range tables {
-		if !contains(shadowTables, t.Name) {
-			filtered = append(filtered, t)
+		index := &schema.Index{
+			Name:    i.name,
+			Def:     indexDef,
+			Columns: i.cols,
 		}
+		c.indexes[i.table] = append(c.indexes[i.table], index)
 	}
+	return nil
+}
 
-	s.Tables = filtered
+// fetchTriggers fetch triggers of all tables
+func fetchTriggers(ctx context.Context, db *sql.DB, c *catalog) error {
+	triggerRows, err := db.QueryContext(ctx, `
+SELECT tbl_name, name, sql FROM sqlite_master WHERE type = 'trigger';
+`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer triggerRows.Close()
 
-	// Relations
-	for _, r := range relations {
-		result := reFK.FindAllStringSubmatch(r.Def, -1)
-		strColumns := strings.Split(result[0][1], ", ")
-		strParentTable := result[0][2]
-		strParentColumns := strings.Split(result[0][3], ", ")
-		for _, c := range strColumns {
-			column, err := r.Table.FindColumnByName(c)
-			if err != nil {
-				return err
-			}
-			r.Columns = append(r.Columns, column)
-			column.ParentRelations = append(column.ParentRelations, r)
-		}
-		parentTable, err := s.FindTableByName(strParentTable)
+	for triggerRows.Next() {
+		var (
+			tableName   string
+			triggerName string
+			triggerDef  string
+		)
+		err = triggerRows.Scan(&tableName, &triggerName, &triggerDef)
 		if err != nil {
-			return err
+			return errors.WithStack(err)
 		}
-		r.ParentTable = parentTable
-		for _, c := range strParentColumns {
-			column, err := parentTable.FindColumnByName(c)
-			if err != nil {
-				return err
-			}
-			r.ParentColumns = append(r.ParentColumns, column)
-			column.ChildRelations = append(column.ChildRelations, r)
+		trigger := &schema.Trigger{
+			Name: triggerName,
+			Def:  triggerDef,
 		}
+		c.triggers[tableName] = append(c.triggers[tableName], trigger)
 	}
-
-	s.Relations = relations
-
 	return nil
 }
 
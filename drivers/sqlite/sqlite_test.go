@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/k1LoW/tbls/schema"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
 	"github.com/xo/dburl"
 )
 
@@ -32,7 +34,7 @@ func TestMain(m *testing.M) {
 
 func TestAnalyzeView(t *testing.T) {
 	driver := new(Sqlite)
-	err := driver.Analyze(db, s)
+	err := driver.Analyze(context.Background(), db, s)
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -43,6 +45,53 @@ func TestAnalyzeView(t *testing.T) {
 	}
 }
 
+func TestAnalyzeIndexColumns(t *testing.T) {
+	driver := new(Sqlite)
+	s := &schema.Schema{
+		Name: "testdb.sqlite3",
+	}
+	err := driver.Analyze(context.Background(), db, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, _ := s.FindTableByName("comments")
+	for _, i := range comments.Indexes {
+		if i.Name != "comments_post_id_user_id_idx" {
+			continue
+		}
+		expected := []string{"post_id", "user_id"}
+		if !reflect.DeepEqual(i.Columns, expected) {
+			t.Errorf("actual %v\nwant %v", i.Columns, expected)
+		}
+		return
+	}
+	t.Error("index comments_post_id_user_id_idx not found")
+}
+
+func TestAnalyzeSystemTables(t *testing.T) {
+	tests := []struct {
+		includeSystemSchemas bool
+		want                 bool
+	}{
+		{false, false},
+		{true, true},
+	}
+	for _, tt := range tests {
+		s := &schema.Schema{
+			Name: "testdb.sqlite3",
+		}
+		driver := &Sqlite{IncludeSystemSchemas: tt.includeSystemSchemas}
+		err := driver.Analyze(context.Background(), db, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.FindTableByName("sqlite_sequence")
+		if actual := err == nil; actual != tt.want {
+			t.Errorf("actual %v\nwant %v", actual, tt.want)
+		}
+	}
+}
+
 func TestParseCheckConstraints(t *testing.T) {
 	sql := `CREATE TABLE check_constraints (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -92,3 +141,49 @@ func testdataDir() string {
 	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
 	return dir
 }
+
+func TestChecksum(t *testing.T) {
+	driver := new(Sqlite)
+	mdb, err := dburl.Open("sq://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mdb.Close()
+	mdb.SetMaxOpenConns(1)
+	_, err = mdb.Exec("CREATE TABLE users (id integer primary key);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := driver.Checksum(context.Background(), mdb, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := driver.Checksum(context.Background(), mdb, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != before {
+		t.Errorf("actual %v\nwant %v", again, before)
+	}
+	_, err = mdb.Exec("CREATE INDEX users_id_idx ON users (id);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := driver.Checksum(context.Background(), mdb, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Errorf("actual %v\nwant %v", after, "changed checksum")
+	}
+}
+
+func TestAnalyzeCanceled(t *testing.T) {
+	driver := new(Sqlite)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := driver.Analyze(ctx, db, &schema.Schema{Name: "testdb.sqlite3"})
+	if errors.Cause(err) != context.Canceled {
+		t.Errorf("actual %v\nwant %v", err, context.Canceled)
+	}
+}
@@ -21,10 +21,6 @@
 package main
 
 import (
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
-	_ "github.com/mattn/go-sqlite3"
-
 	"github.com/k1LoW/tbls/cmd"
 )
 
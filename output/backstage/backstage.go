@@ -0,0 +1,204 @@
+package backstage
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const apiVersion = "backstage.io/v1alpha1"
+
+// invalidNameChars is the characters not allowed in the entity name
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9\-_.]+`)
+
+// Backstage struct
+type Backstage struct {
+	config *config.Config
+}
+
+// Entity is the struct for the entity descriptor of the Backstage software catalog
+type Entity struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   Metadata `yaml:"metadata"`
+	Spec       Spec     `yaml:"spec"`
+}
+
+// Metadata is the struct for the metadata of the entity
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Title       string            `yaml:"title,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	Links       []EntityLink      `yaml:"links,omitempty"`
+}
+
+// EntityLink is the struct for the link of the entity
+type EntityLink struct {
+	URL   string `yaml:"url"`
+	Title string `yaml:"title,omitempty"`
+}
+
+// Spec is the struct for the spec of the Resource entity
+type Spec struct {
+	Type         string   `yaml:"type"`
+	Owner        string   `yaml:"owner"`
+	System       string   `yaml:"system,omitempty"`
+	DependsOn    []string `yaml:"dependsOn,omitempty"`
+	DependencyOf []string `yaml:"dependencyOf,omitempty"`
+}
+
+// New return Backstage
+func New(c *config.Config) *Backstage {
+	return &Backstage{
+		config: c,
+	}
+}
+
+// OutputSchema output the Resource entities of the database and the tables.
+func (b *Backstage) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	entities := []*Entity{b.DatabaseEntity(s)}
+	for _, t := range s.Tables {
+		entities = append(entities, b.TableEntity(s, t))
+	}
+	return encode(wr, entities)
+}
+
+// OutputTable output the Resource entity of the table. The database is referred by the name of the docs target if set.
+func (b *Backstage) OutputTable(wr io.Writer, t *schema.Table) error {
+	return encode(wr, []*Entity{b.TableEntity(&schema.Schema{Name: b.config.Name}, t)})
+}
+
+// DatabaseEntity return the Resource entity of the database. The TechDocs of the database are built from the directory of the entity descriptor.
+func (b *Backstage) DatabaseEntity(s *schema.Schema) *Entity {
+	name := EntityName(s.Name)
+	e := b.newEntity(name, "database")
+	e.Metadata.Title = s.Name
+	if b.config.Title != "" {
+		e.Metadata.Title = b.config.Title
+	}
+	e.Metadata.Annotations = map[string]string{
+		"backstage.io/techdocs-ref": "dir:.",
+	}
+	if u := b.docsURL(name, ""); u != "" {
+		e.Metadata.Links = []EntityLink{{URL: u, Title: "Documentation"}}
+	}
+	return e
+}
+
+// TableEntity return the Resource entity of the table. The table shares the TechDocs of the database unless the name of the database is unknown.
+func (b *Backstage) TableEntity(s *schema.Schema, t *schema.Table) *Entity {
+	dbName := EntityName(s.Name)
+	e := b.newEntity(tableEntityName(s, t.Name), "table")
+	e.Metadata.Title = t.Name
+	e.Metadata.Description = t.Comment
+	if dbName != "" {
+		e.Metadata.Annotations = map[string]string{
+			"backstage.io/techdocs-entity": fmt.Sprintf("resource:%s/%s", b.namespace(), dbName),
+		}
+		if u := b.docsURL(dbName, t.Name); u != "" {
+			e.Metadata.Links = []EntityLink{{URL: u, Title: "Documentation"}}
+		}
+		e.Spec.DependencyOf = []string{fmt.Sprintf("resource:%s/%s", b.namespace(), dbName)}
+	}
+	parents := map[string]struct{}{}
+	for _, c := range t.Columns {
+		for _, r := range c.ParentRelations {
+			if r.ParentTable == nil || r.ParentTable.Name == t.Name {
+				continue
+			}
+			ref := fmt.Sprintf("resource:%s/%s", b.namespace(), tableEntityName(s, r.ParentTable.Name))
+			if _, ok := parents[ref]; ok {
+				continue
+			}
+			parents[ref] = struct{}{}
+			e.Spec.DependsOn = append(e.Spec.DependsOn, ref)
+		}
+	}
+	return e
+}
+
+// EntityName return the name of the entity that consists of [a-zA-Z0-9-_.] only
+func EntityName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-_.")
+}
+
+func tableEntityName(s *schema.Schema, name string) string {
+	if s.Name == "" {
+		return EntityName(name)
+	}
+	return EntityName(fmt.Sprintf("%s.%s", s.Name, name))
+}
+
+func (b *Backstage) newEntity(name string, typ string) *Entity {
+	owner := b.config.Backstage.Owner
+	if owner == "" {
+		owner = config.DefaultBackstageOwner
+	}
+	e := &Entity{
+		APIVersion: apiVersion,
+		Kind:       "Resource",
+		Metadata: Metadata{
+			Name: name,
+		},
+		Spec: Spec{
+			Type:   typ,
+			Owner:  owner,
+			System: b.config.Backstage.System,
+		},
+	}
+	if b.config.Backstage.Namespace != "" {
+		e.Metadata.Namespace = b.config.Backstage.Namespace
+	}
+	return e
+}
+
+func (b *Backstage) namespace() string {
+	if b.config.Backstage.Namespace != "" {
+		return b.config.Backstage.Namespace
+	}
+	return config.DefaultBackstageNamespace
+}
+
+// docsURL return the URL of the TechDocs page. Empty if backstage.url is not set.
+func (b *Backstage) docsURL(name string, table string) string {
+	if b.config.Backstage.URL == "" {
+		return ""
+	}
+	u := fmt.Sprintf("%s/docs/%s/resource/%s/", strings.TrimSuffix(b.config.Backstage.URL, "/"), b.namespace(), strings.ToLower(name))
+	if table != "" {
+		u = fmt.Sprintf("%s%s/", u, table)
+	}
+	return u
+}
+
+func encode(wr io.Writer, entities []*Entity) error {
+	for _, e := range entities {
+		out, err := yaml.Marshal(e)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = fmt.Fprintf(wr, "---\n%s", out)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	output.Register("backstage", func(c *config.Config) output.Output {
+		return New(c)
+	})
+	output.Register("mkdocs", func(c *config.Config) output.Output {
+		return NewMkDocs(c)
+	})
+}
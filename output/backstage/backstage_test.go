@@ -0,0 +1,130 @@
+package backstage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestOutputSchema(t *testing.T) {
+	c := config.New()
+	c.Backstage = config.Backstage{Owner: "team-a", System: "shop", URL: "https://backstage.example.com/"}
+	b := New(c)
+	buf := &bytes.Buffer{}
+	err := b.OutputSchema(buf, newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(buf.String(), "---\n")[1:]
+	if len(docs) != 3 {
+		t.Fatalf("actual %v\nwant %v", len(docs), 3)
+	}
+	entities := []*Entity{}
+	for _, d := range docs {
+		e := &Entity{}
+		if err := yaml.Unmarshal([]byte(d), e); err != nil {
+			t.Fatal(err)
+		}
+		entities = append(entities, e)
+	}
+	db := entities[0]
+	if db.Kind != "Resource" || db.Metadata.Name != "test-schema" || db.Spec.Type != "database" || db.Spec.Owner != "team-a" || db.Spec.System != "shop" {
+		t.Errorf("actual %v\nwant %v", db, "Resource test-schema database team-a shop")
+	}
+	if got := db.Metadata.Annotations["backstage.io/techdocs-ref"]; got != "dir:." {
+		t.Errorf("actual %v\nwant %v", got, "dir:.")
+	}
+	ta := entities[1]
+	if ta.Metadata.Name != "test-schema.a" || ta.Spec.Type != "table" || ta.Metadata.Description != "table a" {
+		t.Errorf("actual %v\nwant %v", ta, "test-schema.a table")
+	}
+	if want := "resource:default/test-schema.b"; len(ta.Spec.DependsOn) != 1 || ta.Spec.DependsOn[0] != want {
+		t.Errorf("actual %v\nwant %v", ta.Spec.DependsOn, want)
+	}
+	if want := "https://backstage.example.com/docs/default/resource/test-schema/a/"; len(ta.Metadata.Links) != 1 || ta.Metadata.Links[0].URL != want {
+		t.Errorf("actual %v\nwant %v", ta.Metadata.Links, want)
+	}
+	if got := ta.Metadata.Annotations["backstage.io/techdocs-entity"]; got != "resource:default/test-schema" {
+		t.Errorf("actual %v\nwant %v", got, "resource:default/test-schema")
+	}
+	if len(entities[2].Spec.DependsOn) != 0 {
+		t.Errorf("actual %v\nwant %v", entities[2].Spec.DependsOn, "[]")
+	}
+}
+
+func TestOutputTable(t *testing.T) {
+	c := config.New()
+	b := New(c)
+	buf := &bytes.Buffer{}
+	err := b.OutputTable(buf, newTestSchema().Tables[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Entity{}
+	if err := yaml.Unmarshal([]byte(strings.TrimPrefix(buf.String(), "---\n")), e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Metadata.Name != "a" || len(e.Spec.DependencyOf) != 0 || e.Spec.DependsOn[0] != "resource:default/b" {
+		t.Errorf("actual %v\nwant %v", e, "a dependsOn resource:default/b")
+	}
+}
+
+func TestEntityName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"users", "users"},
+		{"public.user_options", "public.user_options"},
+		{"my schema/users", "my-schema-users"},
+		{"\"quoted\"", "quoted"},
+	}
+	for _, tt := range tests {
+		got := EntityName(tt.in)
+		if got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Type:    "bigint",
+		Comment: "column b",
+	}
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{ca},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Comment: "table b",
+		Columns: []*schema.Column{cb},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "test schema",
+		Tables:    []*schema.Table{ta, tb},
+		Relations: []*schema.Relation{r},
+	}
+}
@@ -0,0 +1,64 @@
+package backstage
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// MkDocs struct
+type MkDocs struct {
+	config *config.Config
+}
+
+// NewMkDocs return MkDocs
+func NewMkDocs(c *config.Config) *MkDocs {
+	return &MkDocs{
+		config: c,
+	}
+}
+
+// OutputSchema output mkdocs.yml for building the documents in docPath with TechDocs.
+func (m *MkDocs) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	title := s.Name
+	if m.config.Title != "" {
+		title = m.config.Title
+	}
+	nav := []interface{}{
+		yaml.MapSlice{{Key: title, Value: m.config.IndexFileName()}},
+	}
+	if len(m.config.Viewpoints) > 0 {
+		viewpoints := []interface{}{}
+		for i, v := range m.config.Viewpoints {
+			viewpoints = append(viewpoints, yaml.MapSlice{{Key: v.Name, Value: fmt.Sprintf("%s.md", v.FileName(i))}})
+		}
+		nav = append(nav, yaml.MapSlice{{Key: m.config.Dict.Lookup("Viewpoints"), Value: viewpoints}})
+	}
+	tables := []interface{}{}
+	for _, t := range s.Tables {
+		tables = append(tables, yaml.MapSlice{{Key: t.Name, Value: fmt.Sprintf("%s.md", t.Name)}})
+	}
+	nav = append(nav, yaml.MapSlice{{Key: m.config.Dict.Lookup("Tables"), Value: tables}})
+
+	mkdocs := yaml.MapSlice{
+		{Key: "site_name", Value: title},
+		{Key: "docs_dir", Value: m.config.DocPath},
+		{Key: "nav", Value: nav},
+		{Key: "plugins", Value: []string{"techdocs-core"}},
+	}
+	out, err := yaml.Marshal(mkdocs)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = wr.Write(out)
+	return errors.WithStack(err)
+}
+
+// OutputTable is not supported because mkdocs.yml is for the whole documents.
+func (m *MkDocs) OutputTable(wr io.Writer, t *schema.Table) error {
+	return errors.New("the mkdocs format does not support --table")
+}
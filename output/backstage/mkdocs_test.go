@@ -0,0 +1,35 @@
+package backstage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+)
+
+func TestMkDocsOutputSchema(t *testing.T) {
+	c := config.New()
+	c.DocPath = "dbdoc"
+	c.Viewpoints = []config.Viewpoint{{Name: "Core", Tables: []string{"a"}}}
+	m := NewMkDocs(c)
+	buf := &bytes.Buffer{}
+	err := m.OutputSchema(buf, newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `site_name: test schema
+docs_dir: dbdoc
+nav:
+- test schema: README.md
+- Viewpoints:
+  - Core: viewpoint-0.md
+- Tables:
+  - a: a.md
+  - b: b.md
+plugins:
+- techdocs-core
+`
+	if got := buf.String(); got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,379 @@
+package confluence
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// propertyKey is the key of the content property storing the hash of the published content
+const propertyKey = "tbls"
+
+// Confluence publish documents to a Confluence space
+type Confluence struct {
+	config *config.Config
+	client *http.Client
+	token  string
+}
+
+// New return Confluence. The token can be a DSN reference such as env://CONFLUENCE_TOKEN.
+func New(c *config.Config) (*Confluence, error) {
+	token, err := config.ResolveDSN(c.Publish.Confluence.Token)
+	if err != nil {
+		return nil, err
+	}
+	return &Confluence{
+		config: c,
+		client: http.DefaultClient,
+		token:  token,
+	}, nil
+}
+
+type page struct {
+	ID    string `json:"id,omitempty"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Space struct {
+		Key string `json:"key"`
+	} `json:"space"`
+	Ancestors []ancestor `json:"ancestors,omitempty"`
+	Body      struct {
+		Storage struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+	Version *version `json:"version,omitempty"`
+}
+
+type ancestor struct {
+	ID string `json:"id"`
+}
+
+type version struct {
+	Number int `json:"number"`
+}
+
+type property struct {
+	Key   string `json:"key"`
+	Value struct {
+		Hash string `json:"hash"`
+	} `json:"value"`
+	Version *version `json:"version,omitempty"`
+}
+
+// Publish create or update the index page of the schema and a child page per table.
+// Pages whose content and ER diagram are unchanged since the last publish are not updated.
+func (cf *Confluence) Publish(s *schema.Schema) error {
+	box := packr.NewBox("./templates")
+	title := s.Name
+	if cf.config.Title != "" {
+		title = cf.config.Title
+	}
+
+	tmpl, err := cf.parseTemplate(box, "index.xml.tmpl", title)
+	if err != nil {
+		return err
+	}
+	image := cf.image("schema")
+	buf := new(bytes.Buffer)
+	err = tmpl.Execute(buf, map[string]interface{}{
+		"Schema": s,
+		"Image":  attachmentName(image),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	indexID, err := cf.publishPage(title, cf.config.Publish.Confluence.ParentID, buf.String(), image)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err = cf.parseTemplate(box, "table.xml.tmpl", title)
+	if err != nil {
+		return err
+	}
+	for _, t := range s.Tables {
+		image := cf.image(t.Name)
+		buf := new(bytes.Buffer)
+		err = tmpl.Execute(buf, map[string]interface{}{
+			"Table": t,
+			"Image": attachmentName(image),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = cf.publishPage(tablePageTitle(title, t.Name), indexID, buf.String(), image)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tablePageTitle return the title of the table page. Page titles must be unique in a space.
+func tablePageTitle(index string, table string) string {
+	return fmt.Sprintf("%s / %s", index, table)
+}
+
+// image return the path of the ER diagram image generated by `tbls doc`. Empty if it does not exist.
+func (cf *Confluence) image(name string) string {
+	if !cf.config.ER.IsImageFormat() {
+		return ""
+	}
+	path := filepath.Join(cf.config.DocPath, filepath.FromSlash(cf.config.ImagePath(fmt.Sprintf("%s.%s", name, cf.config.ER.FileExt()))))
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func attachmentName(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Base(path)
+}
+
+func (cf *Confluence) parseTemplate(box packr.Box, name string, title string) (*template.Template, error) {
+	ts, err := output.LoadTemplate(box, name, "")
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"lookup": cf.config.Dict.Lookup,
+		"html":   template.HTMLEscapeString,
+		"nl2br": func(text string) string {
+			r := strings.NewReplacer("\r\n", "<br />", "\n", "<br />", "\r", "<br />")
+			return r.Replace(text)
+		},
+		"pageLink": func(t *schema.Table) string {
+			return fmt.Sprintf(`<ac:link><ri:page ri:content-title="%s" /><ac:plain-text-link-body><![CDATA[%s]]></ac:plain-text-link-body></ac:link>`,
+				template.HTMLEscapeString(tablePageTitle(title, t.Name)), strings.Replace(t.Name, "]]>", "]]]]><![CDATA[>", -1))
+		},
+		"columnCount": func(t *schema.Table) int {
+			count := 0
+			for _, c := range t.Columns {
+				if !c.Hidden {
+					count++
+				}
+			}
+			return count
+		},
+	}).Parse(ts)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to parse template '%s'", name))
+	}
+	return tmpl, nil
+}
+
+// publishPage create or update the page and attach the image, and return the page ID
+func (cf *Confluence) publishPage(title string, parentID string, body string, image string) (string, error) {
+	h := sha256.New()
+	_, _ = h.Write([]byte(body))
+	if image != "" {
+		b, err := ioutil.ReadFile(image)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		_, _ = h.Write(b)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	p, err := cf.findPage(title)
+	if err != nil {
+		return "", err
+	}
+	var prop *property
+	if p != nil {
+		prop, err = cf.findProperty(p.ID)
+		if err != nil {
+			return "", err
+		}
+		if prop != nil && prop.Value.Hash == hash {
+			fmt.Printf("%s (unchanged)\n", title)
+			return p.ID, nil
+		}
+	}
+
+	np := &page{Type: "page", Title: title}
+	np.Space.Key = cf.config.Publish.Confluence.Space
+	np.Body.Storage.Value = body
+	np.Body.Storage.Representation = "storage"
+	if p == nil {
+		if parentID != "" {
+			np.Ancestors = []ancestor{ancestor{ID: parentID}}
+		}
+		err = cf.do(http.MethodPost, "/rest/api/content", np, np)
+	} else {
+		np.ID = p.ID
+		np.Version = &version{Number: p.Version.Number + 1}
+		err = cf.do(http.MethodPut, fmt.Sprintf("/rest/api/content/%s", p.ID), np, np)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if image != "" {
+		err = cf.attach(np.ID, image)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	np2 := &property{Key: propertyKey}
+	np2.Value.Hash = hash
+	if prop == nil {
+		err = cf.do(http.MethodPost, fmt.Sprintf("/rest/api/content/%s/property", np.ID), np2, nil)
+	} else {
+		np2.Version = &version{Number: prop.Version.Number + 1}
+		err = cf.do(http.MethodPut, fmt.Sprintf("/rest/api/content/%s/property/%s", np.ID, propertyKey), np2, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("%s\n", title)
+	return np.ID, nil
+}
+
+func (cf *Confluence) findPage(title string) (*page, error) {
+	q := url.Values{}
+	q.Set("spaceKey", cf.config.Publish.Confluence.Space)
+	q.Set("title", title)
+	q.Set("expand", "version")
+	res := struct {
+		Results []*page `json:"results"`
+	}{}
+	err := cf.do(http.MethodGet, fmt.Sprintf("/rest/api/content?%s", q.Encode()), nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) == 0 {
+		return nil, nil
+	}
+	return res.Results[0], nil
+}
+
+func (cf *Confluence) findProperty(id string) (*property, error) {
+	req, err := cf.newRequest(http.MethodGet, fmt.Sprintf("/rest/api/content/%s/property/%s", id, propertyKey), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cf.client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	prop := &property{}
+	err = decodeResponse(resp, prop)
+	if err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
+// attach create or update the attachment of the page
+func (cf *Confluence) attach(id string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.Copy(part, f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = w.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := cf.newRequest(http.MethodPut, fmt.Sprintf("/rest/api/content/%s/child/attachment", id), body, w.FormDataContentType())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Atlassian-Token", "nocheck")
+	resp, err := cf.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, nil)
+}
+
+func (cf *Confluence) do(method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		body = bytes.NewReader(b)
+		contentType = "application/json"
+	}
+	req, err := cf.newRequest(method, path, body, contentType)
+	if err != nil {
+		return err
+	}
+	resp, err := cf.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, out)
+}
+
+// newRequest return the request authenticated with basic auth (user and API token) or bearer token (personal access token)
+func (cf *Confluence) newRequest(method string, path string, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(cf.config.Publish.Confluence.URL, "/")+path, body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if cf.config.Publish.Confluence.User != "" {
+		req.SetBasicAuth(cf.config.Publish.Confluence.User, cf.token)
+	} else {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cf.token))
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return errors.WithStack(fmt.Errorf("Confluence API error: %s %s: %s", resp.Request.Method, resp.Request.URL.Path, strings.TrimSpace(string(b))))
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.WithStack(json.NewDecoder(resp.Body).Decode(out))
+}
@@ -0,0 +1,227 @@
+package confluence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+type fakeConfluence struct {
+	mu          sync.Mutex
+	pages       map[string]*page
+	properties  map[string]*property
+	attachments map[string][]string
+	updates     int
+}
+
+func newFakeConfluence() *fakeConfluence {
+	return &fakeConfluence{
+		pages:       map[string]*page{},
+		properties:  map[string]*property{},
+		attachments: map[string][]string{},
+	}
+}
+
+func (f *fakeConfluence) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/rest/api/content")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		res := struct {
+			Results []*page `json:"results"`
+		}{Results: []*page{}}
+		for _, p := range f.pages {
+			if p.Title == r.URL.Query().Get("title") {
+				res.Results = append(res.Results, p)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	case r.Method == http.MethodPost && path == "":
+		p := &page{}
+		_ = json.NewDecoder(r.Body).Decode(p)
+		p.ID = fmt.Sprintf("%d", len(f.pages)+1)
+		p.Version = &version{Number: 1}
+		f.pages[p.ID] = p
+		_ = json.NewEncoder(w).Encode(p)
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		p := &page{}
+		_ = json.NewDecoder(r.Body).Decode(p)
+		f.pages[parts[0]] = p
+		f.updates++
+		_ = json.NewEncoder(w).Encode(p)
+	case len(parts) == 3 && parts[1] == "property" && r.Method == http.MethodGet:
+		prop, ok := f.properties[parts[0]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(prop)
+	case len(parts) >= 2 && parts[1] == "property":
+		prop := &property{}
+		_ = json.NewDecoder(r.Body).Decode(prop)
+		if prop.Version == nil {
+			prop.Version = &version{Number: 1}
+		}
+		f.properties[parts[0]] = prop
+	case len(parts) == 3 && parts[2] == "attachment" && r.Method == http.MethodPut:
+		if r.Header.Get("X-Atlassian-Token") != "nocheck" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, h, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.attachments[parts[0]] = append(f.attachments[parts[0]], h.Filename)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	f := newFakeConfluence()
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	err := ioutil.WriteFile(filepath.Join(tempDir, "schema.png"), []byte("png"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TBLS_TEST_CONFLUENCE_TOKEN", "secret")
+	defer os.Unsetenv("TBLS_TEST_CONFLUENCE_TOKEN")
+
+	c := config.New()
+	c.DocPath = tempDir
+	c.Publish.Confluence = config.Confluence{
+		URL:      ts.URL,
+		Space:    "DOC",
+		ParentID: "100",
+		User:     "user",
+		Token:    "env://TBLS_TEST_CONFLUENCE_TOKEN",
+	}
+	s := newTestSchema()
+	cf, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cf.Publish(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.pages) != 3 {
+		t.Fatalf("actual %v\nwant %v", len(f.pages), 3)
+	}
+	index := f.pages["1"]
+	if index.Title != "testschema" || len(index.Ancestors) != 1 || index.Ancestors[0].ID != "100" {
+		t.Errorf("actual %v %v\nwant %v %v", index.Title, index.Ancestors, "testschema", "[100]")
+	}
+	for _, want := range []string{`<ri:attachment ri:filename="schema.png" />`, `<ri:page ri:content-title="testschema / a" />`} {
+		if !strings.Contains(index.Body.Storage.Value, want) {
+			t.Errorf("actual %v\nwant %v", index.Body.Storage.Value, want)
+		}
+	}
+	if got := strings.Join(f.attachments["1"], ","); got != "schema.png" {
+		t.Errorf("actual %v\nwant %v", got, "schema.png")
+	}
+	table := f.pages["2"]
+	if table.Title != "testschema / a" || table.Ancestors[0].ID != "1" {
+		t.Errorf("actual %v %v\nwant %v %v", table.Title, table.Ancestors, "testschema / a", "[1]")
+	}
+	if want := "<td>column a</td>"; !strings.Contains(table.Body.Storage.Value, want) {
+		t.Errorf("actual %v\nwant %v", table.Body.Storage.Value, want)
+	}
+
+	// unchanged pages are not updated
+	err = cf.Publish(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.updates != 0 {
+		t.Errorf("actual %v\nwant %v", f.updates, 0)
+	}
+	s.Tables[0].Comment = "changed"
+	err = cf.Publish(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.updates != 2 {
+		t.Errorf("actual %v\nwant %v", f.updates, 2)
+	}
+	if f.pages["2"].Version.Number != 2 || f.properties["2"].Version.Number != 2 {
+		t.Errorf("actual %v %v\nwant %v", f.pages["2"].Version.Number, f.properties["2"].Version.Number, 2)
+	}
+}
+
+func TestPublishError(t *testing.T) {
+	ts := httptest.NewServer(newFakeConfluence())
+	defer ts.Close()
+	c := config.New()
+	c.Publish.Confluence = config.Confluence{URL: ts.URL, Space: "DOC", User: "user", Token: "wrong"}
+	cf, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cf.Publish(newTestSchema())
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if want := "Confluence API error: GET /rest/api/content"; !strings.Contains(err.Error(), want) {
+		t.Errorf("actual %v\nwant %v", err.Error(), want)
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Type:    "bigint",
+		Comment: "column b",
+	}
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{ca},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Comment: "table b",
+		Columns: []*schema.Column{cb},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "testschema",
+		Tables:    []*schema.Table{ta, tb},
+		Relations: []*schema.Relation{r},
+	}
+}
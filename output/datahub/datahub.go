@@ -0,0 +1,264 @@
+package datahub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// DefaultEnv is the default environment (fabric type) of datasets
+const DefaultEnv = "PROD"
+
+// platforms is the DataHub data platforms of drivers
+var platforms = map[string]string{
+	"postgres": "postgres",
+	"mysql":    "mysql",
+	"sqlite3":  "sqlite",
+}
+
+// typeClasses is the DataHub schema field types of column types, matched in order
+var typeClasses = []struct {
+	re    *regexp.Regexp
+	class string
+}{
+	{regexp.MustCompile(`(?i)bool`), "BooleanType"},
+	{regexp.MustCompile(`(?i)int|serial|numeric|decimal|float|double|real|number|money`), "NumberType"},
+	{regexp.MustCompile(`(?i)timestamp|datetime`), "TimeType"},
+	{regexp.MustCompile(`(?i)date`), "DateType"},
+	{regexp.MustCompile(`(?i)time`), "TimeType"},
+	{regexp.MustCompile(`(?i)blob|binary|bytea`), "BytesType"},
+	{regexp.MustCompile(`(?i)enum`), "EnumType"},
+	{regexp.MustCompile(`(?i)\[\]|array`), "ArrayType"},
+	{regexp.MustCompile(`(?i)json|struct|record`), "RecordType"},
+}
+
+// DataHub emit metadata of tables to a DataHub GMS endpoint
+type DataHub struct {
+	config *config.Config
+	client *http.Client
+	token  string
+}
+
+// New return DataHub. The token can be a DSN reference such as env://DATAHUB_TOKEN.
+func New(c *config.Config) (*DataHub, error) {
+	token := ""
+	if c.Publish.DataHub.Token != "" {
+		t, err := config.ResolveDSN(c.Publish.DataHub.Token)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+	return &DataHub{
+		config: c,
+		client: http.DefaultClient,
+		token:  token,
+	}, nil
+}
+
+// Aspect is the struct for an aspect of a dataset
+type Aspect struct {
+	Name  string
+	Value interface{}
+}
+
+// Publish emit the properties, the schema with FK relations, and the sub type of datasets of the tables.
+func (d *DataHub) Publish(s *schema.Schema) error {
+	for _, t := range s.Tables {
+		urn := d.DatasetURN(s, t.Name)
+		for _, a := range d.Aspects(s, t) {
+			err := d.ingest(urn, a)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to emit %s of %s", a.Name, t.Name))
+			}
+		}
+		fmt.Printf("%s\n", urn)
+	}
+	return nil
+}
+
+// Platform return the data platform of the schema
+func (d *DataHub) Platform(s *schema.Schema) string {
+	if d.config.Publish.DataHub.Platform != "" {
+		return d.config.Publish.DataHub.Platform
+	}
+	if p, ok := platforms[s.Driver]; ok {
+		return p
+	}
+	return s.Driver
+}
+
+// DatasetURN return the URN of the dataset of the table
+func (d *DataHub) DatasetURN(s *schema.Schema, table string) string {
+	env := d.config.Publish.DataHub.Env
+	if env == "" {
+		env = DefaultEnv
+	}
+	return fmt.Sprintf("urn:li:dataset:(urn:li:dataPlatform:%s,%s.%s,%s)", d.Platform(s), s.Name, table, env)
+}
+
+// Aspects return the aspects of the dataset of the table
+func (d *DataHub) Aspects(s *schema.Schema, t *schema.Table) []Aspect {
+	urn := d.DatasetURN(s, t.Name)
+	fields := []map[string]interface{}{}
+	primaryKeys := []string{}
+	foreignKeys := []map[string]interface{}{}
+	for _, c := range t.Columns {
+		if c.Hidden {
+			continue
+		}
+		fields = append(fields, map[string]interface{}{
+			"fieldPath":      c.Name,
+			"nativeDataType": c.Type,
+			"type": map[string]interface{}{
+				"type": map[string]interface{}{fmt.Sprintf("com.linkedin.schema.%s", TypeClass(c.Type)): map[string]interface{}{}},
+			},
+			"description": c.Comment,
+			"nullable":    c.Nullable,
+		})
+		if keys := t.ColumnKeys(c); len(keys) > 0 && keys[0] == schema.KeyPrimary {
+			primaryKeys = append(primaryKeys, c.Name)
+		}
+	}
+	for _, r := range foreignRelations(t) {
+		parentURN := d.DatasetURN(s, r.ParentTable.Name)
+		sourceFields := []string{}
+		for _, c := range r.Columns {
+			sourceFields = append(sourceFields, fieldURN(urn, c.Name))
+		}
+		foreignFields := []string{}
+		for _, c := range r.ParentColumns {
+			foreignFields = append(foreignFields, fieldURN(parentURN, c.Name))
+		}
+		foreignKeys = append(foreignKeys, map[string]interface{}{
+			"name":           relationName(r),
+			"foreignDataset": parentURN,
+			"sourceFields":   sourceFields,
+			"foreignFields":  foreignFields,
+		})
+	}
+	subType := "Table"
+	if strings.Contains(strings.ToUpper(t.Type), "VIEW") {
+		subType = "View"
+	}
+	return []Aspect{
+		{
+			Name: "datasetProperties",
+			Value: map[string]interface{}{
+				"name":             t.Name,
+				"description":      t.Comment,
+				"customProperties": map[string]string{"type": t.Type},
+			},
+		},
+		{
+			Name: "schemaMetadata",
+			Value: map[string]interface{}{
+				"schemaName": fmt.Sprintf("%s.%s", s.Name, t.Name),
+				"platform":   fmt.Sprintf("urn:li:dataPlatform:%s", d.Platform(s)),
+				"version":    0,
+				"hash":       "",
+				"platformSchema": map[string]interface{}{
+					"com.linkedin.schema.OtherSchema": map[string]interface{}{"rawSchema": t.Def},
+				},
+				"fields":      fields,
+				"primaryKeys": primaryKeys,
+				"foreignKeys": foreignKeys,
+			},
+		},
+		{
+			Name:  "subTypes",
+			Value: map[string]interface{}{"typeNames": []string{subType}},
+		},
+	}
+}
+
+// TypeClass return the DataHub schema field type of the column type
+func TypeClass(typ string) string {
+	for _, t := range typeClasses {
+		if t.re.MatchString(typ) {
+			return t.class
+		}
+	}
+	return "StringType"
+}
+
+func fieldURN(datasetURN string, field string) string {
+	return fmt.Sprintf("urn:li:schemaField:(%s,%s)", datasetURN, field)
+}
+
+// foreignRelations return relations of the table to the parent tables
+func foreignRelations(t *schema.Table) []*schema.Relation {
+	relations := []*schema.Relation{}
+	seen := map[*schema.Relation]struct{}{}
+	for _, c := range t.Columns {
+		for _, r := range c.ParentRelations {
+			if _, ok := seen[r]; ok {
+				continue
+			}
+			seen[r] = struct{}{}
+			relations = append(relations, r)
+		}
+	}
+	return relations
+}
+
+func relationName(r *schema.Relation) string {
+	if name := r.ConstraintName(); name != "" {
+		return name
+	}
+	columns := []string{}
+	for _, c := range r.Columns {
+		columns = append(columns, c.Name)
+	}
+	return fmt.Sprintf("%s_%s_fkey", r.Table.Name, strings.Join(columns, "_"))
+}
+
+// ingest upsert the aspect of the dataset with the ingestProposal action of Rest.li API
+func (d *DataHub) ingest(urn string, a Aspect) error {
+	value, err := json.Marshal(a.Value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"proposal": map[string]interface{}{
+			"entityType": "dataset",
+			"entityUrn":  urn,
+			"changeType": "UPSERT",
+			"aspectName": a.Name,
+			"aspect": map[string]interface{}{
+				"value":       string(value),
+				"contentType": "application/json",
+			},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/aspects?action=ingestProposal", strings.TrimSuffix(d.config.Publish.DataHub.URL, "/")), bytes.NewReader(b))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-RestLi-Protocol-Version", "2.0.0")
+	if d.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", d.token))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.WithStack(fmt.Errorf("DataHub API error: %s: %s", resp.Status, strings.TrimSpace(string(body))))
+	}
+	return nil
+}
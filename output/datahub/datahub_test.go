@@ -0,0 +1,168 @@
+package datahub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+type proposal struct {
+	EntityType string `json:"entityType"`
+	EntityURN  string `json:"entityUrn"`
+	ChangeType string `json:"changeType"`
+	AspectName string `json:"aspectName"`
+	Aspect     struct {
+		Value       string `json:"value"`
+		ContentType string `json:"contentType"`
+	} `json:"aspect"`
+}
+
+type fakeGMS struct {
+	mu        sync.Mutex
+	proposals []proposal
+}
+
+func (f *fakeGMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost || r.URL.Path != "/aspects" || r.URL.Query().Get("action") != "ingestProposal" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	req := struct {
+		Proposal proposal `json:"proposal"`
+	}{}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	f.proposals = append(f.proposals, req.Proposal)
+}
+
+func TestPublish(t *testing.T) {
+	f := &fakeGMS{}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c := config.New()
+	c.Publish.DataHub = config.DataHub{URL: ts.URL, Token: "secret"}
+	d, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Publish(newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.proposals) != 6 {
+		t.Fatalf("actual %v\nwant %v", len(f.proposals), 6)
+	}
+	p := f.proposals[1]
+	if want := "urn:li:dataset:(urn:li:dataPlatform:postgres,testschema.a,PROD)"; p.EntityURN != want || p.AspectName != "schemaMetadata" {
+		t.Errorf("actual %v %v\nwant %v %v", p.EntityURN, p.AspectName, want, "schemaMetadata")
+	}
+	for _, want := range []string{
+		`"fieldPath":"a"`,
+		`"com.linkedin.schema.NumberType":{}`,
+		`"description":"column a"`,
+		`"foreignDataset":"urn:li:dataset:(urn:li:dataPlatform:postgres,testschema.b,PROD)"`,
+		`"foreignFields":["urn:li:schemaField:(urn:li:dataset:(urn:li:dataPlatform:postgres,testschema.b,PROD),b)"]`,
+		`"primaryKeys":["a"]`,
+	} {
+		if !strings.Contains(p.Aspect.Value, want) {
+			t.Errorf("actual %v\nwant %v", p.Aspect.Value, want)
+		}
+	}
+
+	c.Publish.DataHub.Token = ""
+	d, _ = New(c)
+	err = d.Publish(newTestSchema())
+	if err == nil || !strings.Contains(err.Error(), "DataHub API error: 401") {
+		t.Errorf("actual %v\nwant %v", err, "DataHub API error: 401")
+	}
+}
+
+func TestDatasetURN(t *testing.T) {
+	c := config.New()
+	c.Publish.DataHub = config.DataHub{Platform: "redshift", Env: "DEV"}
+	d, _ := New(c)
+	got := d.DatasetURN(newTestSchema(), "a")
+	if want := "urn:li:dataset:(urn:li:dataPlatform:redshift,testschema.a,DEV)"; got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestTypeClass(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"bigint", "NumberType"},
+		{"numeric(10,2)", "NumberType"},
+		{"boolean", "BooleanType"},
+		{"timestamp without time zone", "TimeType"},
+		{"date", "DateType"},
+		{"varchar(255)", "StringType"},
+		{"jsonb", "RecordType"},
+		{"text[]", "ArrayType"},
+		{"bytea", "BytesType"},
+	}
+	for _, tt := range tests {
+		got := TypeClass(tt.in)
+		if got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Type:    "bigint",
+		Comment: "column b",
+	}
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{ca},
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{
+				Name: "a_pkey",
+				Type: "PRIMARY KEY",
+				Def:  "PRIMARY KEY (a)",
+			},
+		},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Comment: "table b",
+		Columns: []*schema.Column{cb},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "testschema",
+		Tables:    []*schema.Table{ta, tb},
+		Relations: []*schema.Relation{r},
+		Driver:    "postgres",
+	}
+}
@@ -1,24 +1,47 @@
 package dot
 
 import (
+	"fmt"
+	"html"
 	"io"
+	"strings"
 	"text/template"
 
 	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
 	"github.com/k1LoW/tbls/schema"
 	"github.com/pkg/errors"
 )
 
 // Dot struct
-type Dot struct{}
+type Dot struct {
+	config *config.Config
+}
+
+// New return Dot
+func New(c *config.Config) *Dot {
+	return &Dot{
+		config: c,
+	}
+}
 
 // OutputSchema output dot format for full relation.
 func (d *Dot) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	if er := d.config.ER.SchemaDiagramER(s); er.Columns != d.config.ER.Columns {
+		// oversized diagram is rendered with reduced columns
+		c := *d.config
+		c.ER = er
+		return New(&c).OutputSchema(wr, s)
+	}
 	box := packr.NewBox("./templates")
-	ts, _ := box.FindString("schema.dot.tmpl")
-	tmpl := template.Must(template.New(s.Name).Parse(ts))
-	err := tmpl.Execute(wr, map[string]interface{}{
-		"Schema": s,
+	tmpl, err := d.parseTemplate(box, "schema.dot.tmpl", d.config.Templates.Dot.Schema)
+	if err != nil {
+		return err
+	}
+	err = tmpl.Execute(wr, map[string]interface{}{
+		"Schema": d.config.ER.SchemaDiagram(s),
+		"ER":     d.config.ER,
 	})
 	if err != nil {
 		return errors.WithStack(err)
@@ -29,38 +52,19 @@ func (d *Dot) OutputSchema(wr io.Writer, s *schema.Schema) error {
 
 // OutputTable output dot format for table.
 func (d *Dot) OutputTable(wr io.Writer, t *schema.Table) error {
-	encountered := make(map[string]bool)
-	tables := []*schema.Table{}
-	relations := []*schema.Relation{}
-	for _, c := range t.Columns {
-		for _, r := range c.ParentRelations {
-			if !encountered[r.ParentTable.Name] {
-				encountered[r.ParentTable.Name] = true
-				tables = append(tables, r.ParentTable)
-			}
-			if !contains(relations, r) {
-				relations = append(relations, r)
-			}
-		}
-		for _, r := range c.ChildRelations {
-			if !encountered[r.Table.Name] {
-				encountered[r.Table.Name] = true
-				tables = append(tables, r.Table)
-			}
-			if !contains(relations, r) {
-				relations = append(relations, r)
-			}
-		}
-	}
+	tables, relations := d.config.ER.TableDiagram(t)
 
 	box := packr.NewBox("./templates")
 
-	ts, _ := box.FindString("table.dot.tmpl")
-	tmpl := template.Must(template.New(t.Name).Parse(ts))
-	err := tmpl.Execute(wr, map[string]interface{}{
+	tmpl, err := d.parseTemplate(box, "table.dot.tmpl", d.config.Templates.Dot.Table)
+	if err != nil {
+		return err
+	}
+	err = tmpl.Execute(wr, map[string]interface{}{
 		"Table":     t,
 		"Tables":    tables,
 		"Relations": relations,
+		"ER":        d.config.ER,
 	})
 	if err != nil {
 		return errors.WithStack(err)
@@ -69,11 +73,108 @@ func (d *Dot) OutputTable(wr io.Writer, t *schema.Table) error {
 	return nil
 }
 
-func contains(rs []*schema.Relation, e *schema.Relation) bool {
-	for _, r := range rs {
-		if e == r {
-			return true
-		}
+func (d *Dot) parseTemplate(box packr.Box, name string, path string) (*template.Template, error) {
+	ts, err := output.LoadTemplate(box, name, path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(d.funcMap()).Parse(ts)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to parse template '%s'", name))
+	}
+	return tmpl, nil
+}
+
+func (d *Dot) funcMap() map[string]interface{} {
+	return template.FuncMap{
+		"showColumn":    d.config.ER.ShowColumn,
+		"relationLabel": d.config.ER.RelationLabel,
+		"legendColors":  d.config.ER.LegendColors,
+		"legendKeys":    d.config.ER.LegendKeys,
+		"tableLink":     d.config.TableLink,
+		"tooltip": func(t *schema.Table) string {
+			text := t.Comment
+			if text == "" {
+				text = t.Name
+			}
+			r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+			return r.Replace(text)
+		},
+		"clusters": d.config.ER.Clusters,
+		"keyIcons": func(t *schema.Table, c *schema.Column) string {
+			keys := d.config.ER.ColumnKeys(t, c)
+			if len(keys) == 0 {
+				return ""
+			}
+			return fmt.Sprintf(`<font color="%s"><b>%s</b></font> `, d.config.ER.ThemeColors().Type, strings.Join(keys, " "))
+		},
+		"keyBgcolor": func(t *schema.Table, c *schema.Column) string {
+			keys := d.config.ER.ColumnKeys(t, c)
+			if len(keys) == 0 || keys[0] != schema.KeyPrimary {
+				return ""
+			}
+			return fmt.Sprintf(` bgcolor="%s"`, d.config.ER.ThemeColors().Header)
+		},
+		"port": func(t *schema.Table, columns []*schema.Column) string {
+			for _, c := range columns {
+				if d.config.ER.ShowColumn(t, c) {
+					r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+					return fmt.Sprintf(`:"%s"`, r.Replace(c.Name))
+				}
+			}
+			return ""
+		},
+		"tableName": func(t *schema.Table) string {
+			return d.displayName(t.Name, t.LogicalName(d.config.LogicalName.Delimiter))
+		},
+		"columnName": func(c *schema.Column) string {
+			return d.displayName(c.Name, c.LogicalName(d.config.LogicalName.Delimiter))
+		},
+		"headerColor": func(t *schema.Table) string {
+			if c := d.config.ER.TableColor(t); c != "" {
+				return c
+			}
+			return d.config.ER.ThemeColors().Header
+		},
+		"arrowtail": func(r *schema.Relation) string {
+			switch r.Cardinality {
+			case schema.ZeroOrOne:
+				return "odottee"
+			case schema.ExactlyOne:
+				return "teetee"
+			case schema.ZeroOrMore:
+				return "crowodot"
+			case schema.OneOrMore:
+				return "crowtee"
+			}
+			return "crow"
+		},
+		"arrowhead": func(r *schema.Relation) string {
+			if r.ParentCardinality == schema.ZeroOrOne {
+				return "odottee"
+			}
+			return "teetee"
+		},
+		"comment": func(text string) string {
+			r := strings.NewReplacer("\r\n", `<br align="left"/>`, "\n", `<br align="left"/>`, "\r", `<br align="left"/>`)
+			return r.Replace(html.EscapeString(d.config.ER.NodeComment(text)))
+		},
+	}
+}
+
+// displayName return the name shown in ER nodes according to logicalName config
+func (d *Dot) displayName(name string, logicalName string) string {
+	if !d.config.LogicalName.ShowInER || logicalName == "" || logicalName == name {
+		return name
 	}
-	return false
+	if d.config.LogicalName.Replace {
+		return logicalName
+	}
+	return fmt.Sprintf("%s (%s)", name, logicalName)
+}
+
+func init() {
+	output.Register("dot", func(c *config.Config) output.Output {
+		return New(c)
+	})
 }
@@ -5,8 +5,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/k1LoW/tbls/config"
 	"github.com/k1LoW/tbls/schema"
 )
 
@@ -16,7 +18,7 @@ func TestOutputSchema(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	o := new(Dot)
+	o := New(config.New())
 	buf := &bytes.Buffer{}
 	err = o.OutputSchema(buf, s)
 	if err != nil {
@@ -37,7 +39,7 @@ func TestOutputTable(t *testing.T) {
 	}
 	ta := s.Tables[0]
 
-	o := new(Dot)
+	o := New(config.New())
 	buf := &bytes.Buffer{}
 	_ = o.OutputTable(buf, ta)
 	expected, _ := ioutil.ReadFile(filepath.Join(testdataDir(), "dot_test_a.dot.golden"))
@@ -47,12 +49,402 @@ func TestOutputTable(t *testing.T) {
 	}
 }
 
+func TestOutputSchemaWithCardinality(t *testing.T) {
+	s := newTestSchema()
+	s.Relations[0].Cardinality = schema.ZeroOrOne
+	o := New(config.New())
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "arrowtail=odottee,"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithParentCardinality(t *testing.T) {
+	s := newTestSchema()
+	s.Relations[0].Cardinality = schema.ZeroOrMore
+	s.Relations[0].ParentCardinality = schema.ZeroOrOne
+	o := New(config.New())
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "dir=both, arrowhead=odottee, arrowtail=crowodot,"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithLogicalName(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[0].Comment = "Table A|table a description"
+	c := config.New()
+	c.LogicalName.ShowInER = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `point-size="18">a (Table A)</font>`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+
+	c.LogicalName.Replace = true
+	buf = &bytes.Buffer{}
+	err = o.OutputSchema(buf, s)
+	if err != nil {
+		t.Error(err)
+	}
+	expected = `point-size="18">Table A</font>`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithColumnsMode(t *testing.T) {
+	tests := []struct {
+		columns string
+		want    []string
+		notWant []string
+	}{
+		{"", []string{`port="a"`, `port="a2"`}, []string{}},
+		{"keys", []string{`port="a"`, `"a":"a" -> "b":"b"`}, []string{`port="a2"`}},
+		{"none", []string{`"a" -> "b" `}, []string{`port="a"`, `port="a2"`}},
+	}
+	for _, tt := range tests {
+		s := newTestSchema()
+		c := config.New()
+		c.ER.Columns = tt.columns
+		o := New(c)
+		buf := &bytes.Buffer{}
+		err := o.OutputSchema(buf, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("%s: actual %v\nwant %v", tt.columns, buf.String(), w)
+			}
+		}
+		for _, nw := range tt.notWant {
+			if strings.Contains(buf.String(), nw) {
+				t.Errorf("%s: actual %v\nnot want %v", tt.columns, buf.String(), nw)
+			}
+		}
+	}
+}
+
+func TestOutputSchemaWithColumnPorts(t *testing.T) {
+	s := newTestSchema()
+	ta, tb := s.Tables[0], s.Tables[1]
+	ta.Columns[0].Hidden = true
+	ta.Columns[1].Name = "parent b2"
+	s.Relations[0].Columns = append(s.Relations[0].Columns, ta.Columns[1])
+	s.Relations[0].ParentColumns = append(s.Relations[0].ParentColumns, tb.Columns[1])
+	c := config.New()
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"a":"parent b2" -> "b":"b" `
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("actual %v\nwant %v", buf.String(), want)
+	}
+}
+
+func TestOutputSchemaWithOversize(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.SchemaMaxTables = 1
+	c.ER.Oversize = "none"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `port="a2"`) {
+		t.Errorf("actual %v\nwant %v", buf.String(), "no columns")
+	}
+}
+
+func TestOutputSchemaWithAdditionalRelation(t *testing.T) {
+	s := newTestSchema()
+	s.Relations[0].IsAdditional = true
+	c := config.New()
+	c.ER.Legend = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`style="dashed", color="#3C78D8",`,
+		`<font color="#3C78D8">- - -</font></td><td align="left">Additional relation</td>`,
+		"labelloc=b;",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func TestOutputSchemaWithLegendColors(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[0].Labels = []*schema.Label{&schema.Label{Name: "core", Color: "#FF0000"}}
+	c := config.New()
+	c.ER.Legend = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<tr><td bgcolor="#FF0000"> </td><td align="left">core</td></tr>`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithLegendKeys(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Legend = true
+	c.ER.KeyIcons = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<tr><td align="left" bgcolor="#EFEFEF"><font color="#666666"><b>PK</b></font></td><td align="left">Primary key</td></tr>`,
+		`<tr><td align="left"><font color="#666666"><b>FK</b></font></td><td align="left">Foreign key</td></tr>`,
+		`<tr><td align="left"><font color="#666666"><b>UK</b></font></td><td align="left">Unique key</td></tr>`,
+		`<tr><td align="left"><font color="#666666"><b>IDX</b></font></td><td align="left">Indexed</td></tr>`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func TestOutputSchemaWithLinks(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[0].Comment = "table \"a\"\nline2"
+	c := config.New()
+	c.ER.Links = true
+	c.Link.Style = "noext"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"a" [shape=none, URL="a", tooltip="table \"a\"\nline2", label=`,
+		`"b" [shape=none, URL="b", tooltip="table b", label=`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func TestOutputSchemaWithLayout(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Font = "Noto Sans CJK JP"
+	c.ER.FontSize = 10
+	c.ER.Rankdir = "LR"
+	c.ER.DPI = 300
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`graph [rankdir=LR, layout=dot, fontname="Noto Sans CJK JP", dpi=300];`,
+		`node [shape=record, fontsize=10, margin=0.6, fontname="Noto Sans CJK JP"];`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func TestOutputDeterministic(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Deterministic = true
+	o := New(c)
+	expected := `graph [rankdir=TB, layout=dot, start=1, fontname="Arial"];`
+	buf := &bytes.Buffer{}
+	if err := o.OutputSchema(buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+	buf = &bytes.Buffer{}
+	if err := o.OutputTable(buf, s.Tables[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithEdgeRouting(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Splines = "ortho"
+	c.ER.Overlap = "false"
+	c.ER.Nodesep = 0.5
+	c.ER.Ranksep = 1.2
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `graph [rankdir=TB, layout=dot, fontname="Arial", splines=ortho, overlap="false", nodesep=0.5, ranksep=1.2];`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithTheme(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Theme = "dark"
+	c.ER.Colors.Background = "transparent"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`bgcolor="transparent", fontcolor="#E0E0E0"];`,
+		`edge [fontsize=10, labelfloat=false, splines=none, fontname="Arial", color="#C0C0C0", fontcolor="#C0C0C0"];`,
+		`cellpadding="6" bgcolor="#2D2D2D" color="#808080">`,
+		`<td bgcolor="#3C3C3C">`,
+		`<font color="#A0A0A0">`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func TestOutputSchemaWithEdgeLabel(t *testing.T) {
+	tests := []struct {
+		edgeLabel string
+		want      string
+	}{
+		{"name", `arrowtail=crow,  taillabel=<<table cellpadding="5" border="0" cellborder="0"><tr><td>a_b_fk</td></tr></table>>];`},
+		{"none", `arrowtail=crow, ];`},
+	}
+	for _, tt := range tests {
+		s := newTestSchema()
+		s.Relations[0].Def = "FOREIGN KEY (a) REFERENCES b (b)"
+		s.Tables[0].Constraints = []*schema.Constraint{
+			&schema.Constraint{Name: "a_b_fk", Type: "FOREIGN KEY", Def: s.Relations[0].Def},
+		}
+		c := config.New()
+		c.ER.EdgeLabel = tt.edgeLabel
+		o := New(c)
+		buf := &bytes.Buffer{}
+		err := o.OutputSchema(buf, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("actual %v\nwant %v", buf.String(), tt.want)
+		}
+	}
+}
+
+func TestOutputSchemaWithClusters(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[0].Labels = []*schema.Label{&schema.Label{Name: "core"}}
+	c := config.New()
+	c.ER.ClusterBy = "label"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "subgraph \"cluster_core\" {\n    label=<<font face=\"Arial Bold\">core</font>>;\n    style=\"rounded,dashed\";\n    color=\"#999999\";\n    \"a\";\n  }"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithCommentMaxLength(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Comment = true
+	c.ER.CommentMaxLength = 5
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<font color="#333333">table...</font>`,
+		`<font color="#333333">colum...</font>`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
 func testdataDir() string {
 	wd, _ := os.Getwd()
 	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
 	return dir
 }
 
+func TestOutputSchemaWithKeyIcons(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[1].Constraints = []*schema.Constraint{
+		&schema.Constraint{Name: "b_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (b)"},
+	}
+	c := config.New()
+	c.ER.KeyIcons = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<td port="b" align="left" bgcolor="#EFEFEF"><font color="#666666"><b>PK</b></font> b`,
+		`<td port="a" align="left"><font color="#666666"><b>FK</b></font> a`,
+		`<td port="b2" align="left">b2`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
 func newTestSchema() *schema.Schema {
 	ca := &schema.Column{
 		Name:    "a",
@@ -0,0 +1,300 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// Anomaly kinds
+const (
+	AnomalyNoPrimaryKey           = "No primary key"
+	AnomalyUnindexedForeignKey    = "Unindexed foreign key"
+	AnomalyForeignKeyTypeMismatch = "Foreign key type mismatch"
+	AnomalyImpliedRelation        = "Implied relation"
+	AnomalySingleColumnTable      = "Single column table"
+	AnomalyNullableUniqueColumn   = "Nullable unique column"
+	AnomalyNullStringDefault      = "NULL string default"
+)
+
+// Anomaly is the struct for a possible problem of the schema
+type Anomaly struct {
+	Kind    string
+	Table   string
+	Column  string
+	Message string
+}
+
+// Report generate the HTML report of the schema like SchemaSpy
+type Report struct {
+	config *config.Config
+}
+
+// New return Report
+func New(c *config.Config) *Report {
+	return &Report{
+		config: c,
+	}
+}
+
+type tableSummary struct {
+	Table     *schema.Table
+	Link      string
+	Columns   int
+	Parents   int
+	Children  int
+	Anomalies int
+}
+
+type columnRow struct {
+	Column    *schema.Column
+	Default   string
+	Keys      string
+	Parents   []*schema.Table
+	Children  []*schema.Table
+	Anomalies []Anomaly
+}
+
+type constraintRow struct {
+	Table      *schema.Table
+	Link       string
+	Constraint *schema.Constraint
+}
+
+type relationRow struct {
+	Relation   *schema.Relation
+	Link       string
+	ParentLink string
+	Columns    string
+	Parents    string
+}
+
+// Output write the pages of the report to the directory
+//
+//	index.html, relationships.html, constraints.html, orphans.html, anomalies.html and tables/<table>.html
+func (r *Report) Output(dir string, s *schema.Schema) error {
+	err := os.MkdirAll(filepath.Join(dir, "tables"), 0755) // #nosec
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	anomalies := Anomalies(s)
+	tableAnomalies := map[string][]Anomaly{}
+	for _, a := range anomalies {
+		tableAnomalies[a.Table] = append(tableAnomalies[a.Table], a)
+	}
+	title := s.Name
+	if r.config.Title != "" {
+		title = r.config.Title
+	}
+
+	summaries := []tableSummary{}
+	orphans := []tableSummary{}
+	constraints := []constraintRow{}
+	columns := 0
+	for _, t := range s.Tables {
+		ts := tableSummary{
+			Table:     t,
+			Link:      TableFileName(t),
+			Parents:   len(parentTables(t)),
+			Children:  len(childTables(t)),
+			Anomalies: len(tableAnomalies[t.Name]),
+		}
+		for _, c := range t.Columns {
+			if !c.Hidden {
+				ts.Columns++
+			}
+		}
+		columns += ts.Columns
+		summaries = append(summaries, ts)
+		if ts.Parents == 0 && ts.Children == 0 {
+			orphans = append(orphans, ts)
+		}
+		for _, c := range t.Constraints {
+			constraints = append(constraints, constraintRow{Table: t, Link: TableFileName(t), Constraint: c})
+		}
+	}
+	relations := []relationRow{}
+	for _, rel := range s.Relations {
+		relations = append(relations, relationRow{
+			Relation:   rel,
+			Link:       TableFileName(rel.Table),
+			ParentLink: TableFileName(rel.ParentTable),
+			Columns:    columnNames(rel.Columns),
+			Parents:    columnNames(rel.ParentColumns),
+		})
+	}
+
+	base := map[string]interface{}{
+		"Title":       title,
+		"Schema":      s,
+		"Tables":      summaries,
+		"Columns":     columns,
+		"Relations":   relations,
+		"Constraints": constraints,
+		"Orphans":     orphans,
+		"Anomalies":   anomalies,
+		"Root":        "",
+	}
+	for _, p := range []string{"index", "relationships", "constraints", "orphans", "anomalies"} {
+		err := r.write(filepath.Join(dir, fmt.Sprintf("%s.html", p)), p, base)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range s.Tables {
+		rows := []columnRow{}
+		for _, c := range t.Columns {
+			if c.Hidden {
+				continue
+			}
+			row := columnRow{
+				Column:   c,
+				Keys:     strings.Join(t.ColumnKeys(c), ", "),
+				Parents:  []*schema.Table{},
+				Children: []*schema.Table{},
+			}
+			if c.Default != nil {
+				row.Default = *c.Default
+			}
+			for _, rel := range c.ParentRelations {
+				row.Parents = append(row.Parents, rel.ParentTable)
+			}
+			for _, rel := range c.ChildRelations {
+				row.Children = append(row.Children, rel.Table)
+			}
+			for _, a := range tableAnomalies[t.Name] {
+				if a.Column == c.Name {
+					row.Anomalies = append(row.Anomalies, a)
+				}
+			}
+			rows = append(rows, row)
+		}
+		data := map[string]interface{}{}
+		for k, v := range base {
+			data[k] = v
+		}
+		data["Root"] = "../"
+		data["Table"] = t
+		data["ColumnRows"] = rows
+		data["TableAnomalies"] = tableAnomalies[t.Name]
+		err := r.write(filepath.Join(dir, TableFileName(t)), "table", data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TableFileName return the path of the table page relative to the report directory
+func TableFileName(t *schema.Table) string {
+	return fmt.Sprintf("tables/%s.html", t.Name)
+}
+
+func (r *Report) write(path string, page string, data map[string]interface{}) error {
+	box := packr.NewBox("./templates")
+	layout, _ := box.FindString("layout.html.tmpl")
+	ts, _ := box.FindString(fmt.Sprintf("%s.html.tmpl", page))
+	tmpl, err := template.New(page).Funcs(template.FuncMap{
+		"link": TableFileName,
+	}).Parse(layout)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tmpl, err = tmpl.Parse(ts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	err = tmpl.ExecuteTemplate(file, "layout", data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+var reNullStringDefault = regexp.MustCompile(`(?i)^\(?'null'`)
+
+// Anomalies return the possible problems of the schema.
+// Missing primary keys, unindexed foreign keys and foreign key type mismatches are checked with the lint rules,
+// and columns named like `<table>_id` without a foreign key are reported as implied relations.
+func Anomalies(s *schema.Schema) []Anomaly {
+	anomalies := []Anomaly{}
+	for _, w := range (config.RequirePrimaryKey{Enabled: true}).Check(s) {
+		anomalies = append(anomalies, Anomaly{Kind: AnomalyNoPrimaryKey, Table: w.Table, Message: w.Message})
+	}
+	for _, w := range (config.UnindexedForeignKey{Enabled: true}).Check(s) {
+		anomalies = append(anomalies, Anomaly{Kind: AnomalyUnindexedForeignKey, Table: w.Table, Message: fmt.Sprintf("%s: %s", w.Target, w.Message)})
+	}
+	for _, w := range (config.ForeignKeyTypeMismatch{Enabled: true}).Check(s) {
+		anomalies = append(anomalies, Anomaly{Kind: AnomalyForeignKeyTypeMismatch, Table: w.Table, Column: strings.TrimPrefix(w.Target, fmt.Sprintf("%s.", w.Table)), Message: w.Message})
+	}
+	for _, t := range s.Tables {
+		if !strings.Contains(strings.ToUpper(t.Type), "VIEW") && len(t.Columns) == 1 {
+			anomalies = append(anomalies, Anomaly{Kind: AnomalySingleColumnTable, Table: t.Name, Message: "table has only one column."})
+		}
+		for _, c := range t.Columns {
+			if len(c.ParentRelations) == 0 {
+				if pt := s.ImpliedParentTable(c); pt != nil && pt != t {
+					anomalies = append(anomalies, Anomaly{Kind: AnomalyImpliedRelation, Table: t.Name, Column: c.Name, Message: fmt.Sprintf("column seems to refer to %s, but there is no foreign key.", pt.Name)})
+				}
+			}
+			if c.Nullable && contains(t.ColumnKeys(c), "UK") {
+				anomalies = append(anomalies, Anomaly{Kind: AnomalyNullableUniqueColumn, Table: t.Name, Column: c.Name, Message: "unique column is nullable."})
+			}
+			if c.Default != nil && reNullStringDefault.MatchString(*c.Default) {
+				anomalies = append(anomalies, Anomaly{Kind: AnomalyNullStringDefault, Table: t.Name, Column: c.Name, Message: "default value is the string 'NULL', not NULL."})
+			}
+		}
+	}
+	return anomalies
+}
+
+func parentTables(t *schema.Table) map[string]bool {
+	tables := map[string]bool{}
+	for _, c := range t.Columns {
+		for _, r := range c.ParentRelations {
+			tables[r.ParentTable.Name] = true
+		}
+	}
+	return tables
+}
+
+func childTables(t *schema.Table) map[string]bool {
+	tables := map[string]bool{}
+	for _, c := range t.Columns {
+		for _, r := range c.ChildRelations {
+			tables[r.Table.Name] = true
+		}
+	}
+	return tables
+}
+
+func columnNames(columns []*schema.Column) string {
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
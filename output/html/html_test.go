@@ -0,0 +1,126 @@
+package html
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestOutput(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	r := New(c)
+	err := r.Output(tempDir, newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"index.html", []string{`<a href="tables/users.html">users</a>`, "Orphan Tables: 2", `<table class="sortable">`}},
+		{"relationships.html", []string{`<a href="tables/posts.html">posts</a>`, "FOREIGN KEY (user_id) REFERENCES users(id)"}},
+		{"constraints.html", []string{"users_pkey"}},
+		{"orphans.html", []string{`<a href="tables/logs.html">logs</a>`}},
+		{"anomalies.html", []string{AnomalyNoPrimaryKey, AnomalyImpliedRelation, AnomalySingleColumnTable, AnomalyNullStringDefault}},
+		{"tables/posts.html", []string{`<a href="../tables/users.html">users</a>`, "Unindexed foreign key: posts(user_id)"}},
+		{"tables/comments.html", []string{`title="Implied relation: column seems to refer to posts, but there is no foreign key."`}},
+	}
+	for _, tt := range tests {
+		b, err := ioutil.ReadFile(filepath.Join(tempDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("%s: actual %v\nwant %v", tt.file, string(b), want)
+			}
+		}
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	got := map[string]string{}
+	for _, a := range Anomalies(newTestSchema()) {
+		got[a.Kind] = strings.Trim(a.Table+"."+a.Column, ".")
+	}
+	want := map[string]string{
+		AnomalyNoPrimaryKey:         "comments",
+		AnomalyUnindexedForeignKey:  "posts",
+		AnomalyImpliedRelation:      "comments.post_id",
+		AnomalySingleColumnTable:    "logs",
+		AnomalyNullableUniqueColumn: "users.email",
+		AnomalyNullStringDefault:    "logs.message",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: actual %v\nwant %v", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	userID := &schema.Column{Name: "id", Type: "bigint"}
+	email := &schema.Column{Name: "email", Type: "text", Nullable: true}
+	users := &schema.Table{
+		Name:    "users",
+		Type:    "BASE TABLE",
+		Comment: "users table",
+		Columns: []*schema.Column{userID, email},
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{Name: "users_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"},
+			&schema.Constraint{Name: "users_email_key", Type: "UNIQUE", Def: "UNIQUE (email)"},
+		},
+	}
+	postID := &schema.Column{Name: "id", Type: "bigint"}
+	postUserID := &schema.Column{Name: "user_id", Type: "bigint"}
+	posts := &schema.Table{
+		Name:    "posts",
+		Type:    "BASE TABLE",
+		Columns: []*schema.Column{postID, postUserID},
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{Name: "posts_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"},
+			&schema.Constraint{Name: "posts_user_id_fk", Type: "FOREIGN KEY", Def: "FOREIGN KEY (user_id) REFERENCES users(id)"},
+		},
+	}
+	comments := &schema.Table{
+		Name: "comments",
+		Type: "BASE TABLE",
+		Columns: []*schema.Column{
+			&schema.Column{Name: "body", Type: "text"},
+			&schema.Column{Name: "post_id", Type: "bigint"},
+		},
+	}
+	logs := &schema.Table{
+		Name:    "logs",
+		Type:    "BASE TABLE",
+		Columns: []*schema.Column{&schema.Column{Name: "message", Type: "text", Default: schema.NewDefault(sql.NullString{String: "'NULL'::text", Valid: true})}},
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{Name: "logs_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (message)"},
+		},
+	}
+	r := &schema.Relation{
+		Table:         posts,
+		Columns:       []*schema.Column{postUserID},
+		ParentTable:   users,
+		ParentColumns: []*schema.Column{userID},
+		Def:           "FOREIGN KEY (user_id) REFERENCES users(id)",
+	}
+	postUserID.ParentRelations = []*schema.Relation{r}
+	userID.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "testschema",
+		Tables:    []*schema.Table{users, posts, comments, logs},
+		Relations: []*schema.Relation{r},
+	}
+}
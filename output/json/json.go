@@ -1,21 +1,50 @@
 package json
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
 	"io"
 
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
 	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
 )
 
 // JSON struct
 type JSON struct{}
 
 // OutputSchema output JSON format for full relation.
+// Tables and relations are encoded one by one so that the whole output is not built in memory.
 func (j *JSON) OutputSchema(wr io.Writer, s *schema.Schema) error {
-	encoder := json.NewEncoder(wr)
-	encoder.SetIndent("", "  ")
-	encoder.Encode(s)
-	return nil
+	w := bufio.NewWriter(wr)
+	o := &objectWriter{w: w}
+	o.begin()
+	o.field("name", s.Name)
+	o.array("tables", s.Tables == nil, len(s.Tables), func(i int) interface{} {
+		return s.Tables[i]
+	})
+	o.array("relations", s.Relations == nil, len(s.Relations), func(i int) interface{} {
+		return s.Relations[i]
+	})
+	if len(s.Labels) > 0 {
+		o.field("labels", s.Labels)
+	}
+	if s.Driver != "" {
+		o.field("driver", s.Driver)
+	}
+	if s.Charset != "" {
+		o.field("charset", s.Charset)
+	}
+	if s.Collation != "" {
+		o.field("collation", s.Collation)
+	}
+	o.end()
+	if o.err != nil {
+		return o.err
+	}
+	return errors.WithStack(w.Flush())
 }
 
 // OutputTable output dot format for table.
@@ -25,3 +54,81 @@ func (j *JSON) OutputTable(wr io.Writer, t *schema.Table) error {
 	encoder.Encode(t)
 	return nil
 }
+
+// objectWriter write the JSON object field by field in the same format as json.Encoder with the indent of 2 spaces
+type objectWriter struct {
+	w      *bufio.Writer
+	fields int
+	err    error
+}
+
+func (o *objectWriter) begin() {
+	o.write([]byte("{\n"))
+}
+
+func (o *objectWriter) end() {
+	o.write([]byte("\n}\n"))
+}
+
+func (o *objectWriter) key(k string) {
+	if o.fields > 0 {
+		o.write([]byte(",\n"))
+	}
+	o.fields++
+	o.write([]byte(fmt.Sprintf("  %q: ", k)))
+}
+
+func (o *objectWriter) field(k string, v interface{}) {
+	o.key(k)
+	o.value(v, "  ")
+}
+
+// array write the array field element by element. isNil is whether the slice is nil (encoded as null).
+func (o *objectWriter) array(k string, isNil bool, n int, elem func(i int) interface{}) {
+	o.key(k)
+	switch {
+	case isNil:
+		o.write([]byte("null"))
+		return
+	case n == 0:
+		o.write([]byte("[]"))
+		return
+	}
+	o.write([]byte("[\n"))
+	for i := 0; i < n; i++ {
+		o.write([]byte("    "))
+		o.value(elem(i), "    ")
+		if i < n-1 {
+			o.write([]byte(","))
+		}
+		o.write([]byte("\n"))
+	}
+	o.write([]byte("  ]"))
+}
+
+func (o *objectWriter) value(v interface{}, prefix string) {
+	if o.err != nil {
+		return
+	}
+	b, err := json.MarshalIndent(v, prefix, "  ")
+	if err != nil {
+		o.err = errors.WithStack(err)
+		return
+	}
+	o.write(b)
+}
+
+func (o *objectWriter) write(b []byte) {
+	if o.err != nil {
+		return
+	}
+	if _, err := o.w.Write(b); err != nil {
+		o.err = errors.WithStack(err)
+	}
+}
+
+func init() {
+	output.Register("json", func(c *config.Config) output.Output {
+		return new(JSON)
+	})
+}
@@ -3,6 +3,7 @@ package json
 import (
 	"bytes"
 	"database/sql"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -26,6 +27,37 @@ func TestOutputSchema(t *testing.T) {
 	}
 }
 
+func TestOutputSchemaSameAsEncoder(t *testing.T) {
+	tests := []func(s *schema.Schema){
+		func(s *schema.Schema) {},
+		func(s *schema.Schema) { s.Relations = nil },
+		func(s *schema.Schema) { s.Tables = []*schema.Table{}; s.Relations = []*schema.Relation{} },
+		func(s *schema.Schema) {
+			s.Labels = []*schema.Label{&schema.Label{Name: "<core>"}}
+			s.Driver = "postgres"
+			s.Charset = "UTF8"
+			s.Collation = "en_US.UTF-8"
+		},
+	}
+	for i, modify := range tests {
+		s := newTestSchema()
+		modify(s)
+		want := &bytes.Buffer{}
+		encoder := json.NewEncoder(want)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(s); err != nil {
+			t.Fatal(err)
+		}
+		got := &bytes.Buffer{}
+		if err := new(JSON).OutputSchema(got, s); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%d: actual %v\nwant %v", i, got.String(), want.String())
+		}
+	}
+}
+
 func testdataDir() string {
 	wd, _ := os.Getwd()
 	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
@@ -57,10 +89,7 @@ func newTestSchema() *schema.Schema {
 				Type:     "datetime",
 				Comment:  "column a2",
 				Nullable: false,
-				Default: sql.NullString{
-					String: "CURRENT_TIMESTAMP",
-					Valid:  true,
-				},
+				Default: schema.NewDefault(sql.NullString{String: "CURRENT_TIMESTAMP", Valid: true}),
 			},
 		},
 	}
@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/version"
+	"github.com/pkg/errors"
+)
+
+// Warn is the struct for rule violation with the file location
+type Warn struct {
+	config.RuleWarn
+	File string `json:"file,omitempty"`
+}
+
+// Output output lint results in the format
+func Output(wr io.Writer, format string, warns []Warn) error {
+	switch format {
+	case "", "text":
+		return outputText(wr, warns)
+	case "json":
+		return outputJSON(wr, warns)
+	case "github":
+		return outputGitHub(wr, warns)
+	case "sarif":
+		return outputSARIF(wr, warns)
+	}
+	return errors.WithStack(fmt.Errorf("unsupported lint output format '%s'", format))
+}
+
+func outputText(wr io.Writer, warns []Warn) error {
+	for _, w := range warns {
+		_, err := fmt.Fprintf(wr, "[%s] %s\n", w.Severity, w.RuleWarn)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if len(warns) > 0 {
+		_, err := fmt.Fprintf(wr, "\n%d detected\n", len(warns))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func outputJSON(wr io.Writer, warns []Warn) error {
+	encoder := json.NewEncoder(wr)
+	encoder.SetIndent("", "  ")
+	return errors.WithStack(encoder.Encode(warns))
+}
+
+// outputGitHub output GitHub Actions workflow commands (annotations)
+func outputGitHub(wr io.Writer, warns []Warn) error {
+	for _, w := range warns {
+		props := []string{}
+		if w.File != "" {
+			props = append(props, fmt.Sprintf("file=%s", output.EscapeGitHubProperty(w.File)))
+		}
+		props = append(props, fmt.Sprintf("title=%s", output.EscapeGitHubProperty(w.Rule)))
+		_, err := fmt.Fprintf(wr, "::%s %s::%s\n", gitHubCommand(w.Severity), strings.Join(props, ","), output.EscapeGitHubData(fmt.Sprintf("%s: %s", w.Target, w.Message)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func gitHubCommand(severity string) string {
+	switch severity {
+	case config.SeverityWarning:
+		return "warning"
+	case config.SeverityInfo:
+		return "notice"
+	}
+	return "error"
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case config.SeverityWarning:
+		return "warning"
+	case config.SeverityInfo:
+		return "note"
+	}
+	return "error"
+}
+
+// outputSARIF output SARIF v2.1.0 log
+func outputSARIF(wr io.Writer, warns []Warn) error {
+	ruleIDs := map[string]bool{}
+	results := []sarifResult{}
+	for _, w := range warns {
+		ruleIDs[w.Rule] = true
+		result := sarifResult{
+			RuleID:  w.Rule,
+			Level:   sarifLevel(w.Severity),
+			Message: sarifMessage{Text: fmt.Sprintf("%s: %s", w.Target, w.Message)},
+		}
+		if w.File != "" {
+			result.Locations = []sarifLocation{
+				sarifLocation{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: w.File},
+					},
+				},
+			}
+		}
+		results = append(results, result)
+	}
+	rules := []sarifRule{}
+	for id := range ruleIDs {
+		rules = append(rules, sarifRule{ID: id})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{
+			sarifRun{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           version.Name,
+						InformationURI: "https://github.com/k1LoW/tbls",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+	encoder := json.NewEncoder(wr)
+	encoder.SetIndent("", "  ")
+	return errors.WithStack(encoder.Encode(log))
+}
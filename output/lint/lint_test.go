@@ -0,0 +1,75 @@
+package lint
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+)
+
+var tests = []struct {
+	format       string
+	expectedFile string
+}{
+	{"text", "lint_test.txt.golden"},
+	{"json", "lint_test.json.golden"},
+	{"github", "lint_test.github.golden"},
+	{"sarif", "lint_test.sarif.golden"},
+}
+
+func TestOutput(t *testing.T) {
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := Output(buf, tt.format, newTestWarns())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := ioutil.ReadFile(filepath.Join(testdataDir(), tt.expectedFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(expected) {
+			t.Errorf("%s: actual %v\nwant %v", tt.format, buf.String(), string(expected))
+		}
+	}
+}
+
+func TestOutputUnsupportedFormat(t *testing.T) {
+	err := Output(&bytes.Buffer{}, "xml", newTestWarns())
+	if err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func testdataDir() string {
+	wd, _ := os.Getwd()
+	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
+	return dir
+}
+
+func newTestWarns() []Warn {
+	return []Warn{
+		Warn{
+			RuleWarn: config.RuleWarn{
+				Rule:     "requireTableComment",
+				Severity: config.SeverityError,
+				Table:    "users",
+				Target:   "users",
+				Message:  "table comment required.",
+			},
+			File: "dbdoc/users.md",
+		},
+		Warn{
+			RuleWarn: config.RuleWarn{
+				Rule:     "requireColumnComment",
+				Severity: config.SeverityWarning,
+				Table:    "posts",
+				Target:   "posts.title",
+				Message:  "column comment required.",
+			},
+		},
+	}
+}
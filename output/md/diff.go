@@ -0,0 +1,249 @@
+package md
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContextLines is the number of unchanged lines shown around changes in unified diffs
+const diffContextLines = 3
+
+// FileDiff is the difference between database and a markdown file
+type FileDiff struct {
+	Target string
+	File   string
+	Diffs  []diffmatchpatch.Diff
+}
+
+// Unified return the difference as unified diff lines from the document to the database. Unchanged lines far from changes are omitted.
+func (d *FileDiff) Unified() string {
+	type line struct {
+		op   string
+		text string
+	}
+	lines := []line{}
+	for _, diff := range d.Diffs {
+		op := " "
+		switch diff.Type {
+		case diffmatchpatch.DiffDelete:
+			// in the database, not in the document
+			op = "+"
+		case diffmatchpatch.DiffInsert:
+			op = "-"
+		}
+		for _, l := range strings.SplitAfter(diff.Text, "\n") {
+			if l == "" {
+				continue
+			}
+			lines = append(lines, line{op, strings.TrimSuffix(l, "\n")})
+		}
+	}
+	shown := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == " " {
+			continue
+		}
+		for j := i - diffContextLines; j <= i+diffContextLines; j++ {
+			if j >= 0 && j < len(lines) {
+				shown[j] = true
+			}
+		}
+	}
+	var b strings.Builder
+	prev := -1
+	for i, l := range lines {
+		if !shown[i] {
+			continue
+		}
+		if prev < 0 || i != prev+1 {
+			b.WriteString("@@\n")
+		}
+		b.WriteString(fmt.Sprintf("%s%s\n", l.op, l.text))
+		prev = i
+	}
+	return b.String()
+}
+
+// withoutData return the document without the data of tables collected by profiling (row counts, freshness, statistics, sample rows and storage),
+// so that documents are compared by the schema only. Rendered columns of the data are removed from tables, and sections of the data are removed.
+func withoutData(doc string, d config.Dict) string {
+	lines := []string{d.Lookup("Rows") + ": ", d.Lookup("Last written") + ": "}
+	sections := map[string]bool{
+		"## " + d.Lookup("Sample Rows"):    true,
+		"## " + d.Lookup("Storage"):        true,
+		"## " + d.Lookup("Largest Tables"): true,
+	}
+	columns := map[string]bool{}
+	for _, k := range []string{"Rows", "Last written", "Values", "Nulls", "Distinct", "Min", "Max", "Percentiles"} {
+		columns[d.Lookup(k)] = true
+	}
+	out := []string{}
+	var removed map[int]bool
+	// skip is whether lines are in the section of the data, and rows is whether the table of the section has started
+	skip, rows := false, false
+	inTable := false
+	for _, l := range strings.Split(doc, "\n") {
+		if skip {
+			switch {
+			case strings.HasPrefix(l, "|"):
+				rows = true
+				continue
+			case l == "":
+				skip = !rows
+				continue
+			}
+			skip = false
+		}
+		if sections[l] {
+			skip, rows = true, false
+			continue
+		}
+		if hasAnyPrefix(l, lines) {
+			if len(out) > 0 && out[len(out)-1] == "" {
+				out = out[:len(out)-1]
+			}
+			continue
+		}
+		if !strings.HasPrefix(l, "|") {
+			inTable = false
+			out = append(out, l)
+			continue
+		}
+		cells := splitTableRow(l)
+		if !inTable {
+			// header of the table
+			inTable = true
+			removed = map[int]bool{}
+			for i, c := range cells {
+				if columns[strings.TrimSpace(c)] {
+					removed[i] = true
+				}
+			}
+		}
+		if len(removed) == 0 {
+			out = append(out, l)
+			continue
+		}
+		kept := []string{}
+		for i, c := range cells {
+			if !removed[i] {
+				kept = append(kept, c)
+			}
+		}
+		out = append(out, "|"+strings.Join(kept, "|")+"|")
+	}
+	return strings.Join(out, "\n")
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTableRow split the row of the markdown table into cells. Escaped pipes (\|) are a part of cells.
+func splitTableRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := []string{}
+	start := 0
+	for i := 0; i < len(row); i++ {
+		switch row[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, row[start:i])
+			start = i + 1
+		}
+	}
+	return append(cells, row[start:])
+}
+
+// ANSI escape sequences of colored diff lines
+const (
+	colorHeader = "\x1b[1m"
+	colorHunk   = "\x1b[36m"
+	colorAdd    = "\x1b[32m"
+	colorDelete = "\x1b[31m"
+	colorReset  = "\x1b[0m"
+)
+
+// OutputDiffText output unified diffs of drifted files. Lines are colored with ANSI escape sequences when color is true.
+func OutputDiffText(wr io.Writer, fileDiffs []*FileDiff, color bool) error {
+	var b strings.Builder
+	for _, d := range fileDiffs {
+		header := fmt.Sprintf("diff %s %s", d.Target, d.File)
+		if color {
+			header = colorHeader + header + colorReset
+		}
+		b.WriteString(header + "\n")
+		for _, l := range strings.SplitAfter(d.Unified(), "\n") {
+			if l == "" {
+				continue
+			}
+			if !color {
+				b.WriteString(l)
+				continue
+			}
+			c := ""
+			switch {
+			case strings.HasPrefix(l, "@@"):
+				c = colorHunk
+			case strings.HasPrefix(l, "+"):
+				c = colorAdd
+			case strings.HasPrefix(l, "-"):
+				c = colorDelete
+			}
+			if c == "" {
+				b.WriteString(l)
+				continue
+			}
+			b.WriteString(c + strings.TrimSuffix(l, "\n") + colorReset + "\n")
+		}
+	}
+	_, err := io.WriteString(wr, b.String())
+	return errors.WithStack(err)
+}
+
+// OutputDiffGitHub output GitHub Actions workflow commands (annotations) for each drifted file
+func OutputDiffGitHub(wr io.Writer, fileDiffs []*FileDiff) error {
+	for _, d := range fileDiffs {
+		_, err := fmt.Fprintf(wr, "::error file=%s,title=%s::%s\n",
+			output.EscapeGitHubProperty(filepath.ToSlash(d.File)),
+			output.EscapeGitHubProperty("tbls diff"),
+			output.EscapeGitHubData(fmt.Sprintf("%s: document is out of date with the database schema. Run `tbls doc --force` to update it.", d.Target)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// OutputDiffSummary output the markdown summary of differences suitable for a pull request comment
+func OutputDiffSummary(wr io.Writer, fileDiffs []*FileDiff) error {
+	var b strings.Builder
+	b.WriteString("## tbls diff\n\n")
+	if len(fileDiffs) == 0 {
+		b.WriteString("Documents are up to date with the database schema.\n")
+	} else {
+		b.WriteString(fmt.Sprintf("%d document file(s) are out of date with the database schema. Run `tbls doc --force` to update them.\n\n", len(fileDiffs)))
+		b.WriteString("| Target | File |\n| ------ | ---- |\n")
+		for _, d := range fileDiffs {
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", d.Target, d.File))
+		}
+		for _, d := range fileDiffs {
+			b.WriteString(fmt.Sprintf("\n<details><summary>%s</summary>\n\n```diff\n%s```\n\n</details>\n", d.File, d.Unified()))
+		}
+	}
+	_, err := io.WriteString(wr, b.String())
+	return errors.WithStack(err)
+}
@@ -0,0 +1,194 @@
+package md
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestDiffFiles(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DiffFiles(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("actual %v\nwant %v", len(got), 0)
+	}
+	s.Tables[0].Comment = "changed comment"
+	got, err = DiffFiles(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := []string{}
+	for _, d := range got {
+		targets = append(targets, d.Target)
+	}
+	if want := "[database],a"; strings.Join(targets, ",") != want {
+		t.Errorf("actual %v\nwant %v", targets, want)
+	}
+	if want := filepath.Join(tempDir, "a.md"); got[1].File != want {
+		t.Errorf("actual %v\nwant %v", got[1].File, want)
+	}
+	if want := "+changed comment\n"; !strings.Contains(got[1].Unified(), want) {
+		t.Errorf("actual %v\nwant %v", got[1].Unified(), want)
+	}
+}
+
+func TestDiffFilesWithoutData(t *testing.T) {
+	for _, adjust := range []bool{false, true} {
+		s := newTestSchema()
+		ta, _ := s.FindTableByName("a")
+		tb, _ := s.FindTableByName("b")
+		last := "2024-01-02 03:04:05"
+		distinct := int64(2)
+		min, max := "1", "a|b"
+		ta.RowCount = &schema.RowCount{Count: 12345}
+		ta.Freshness = &schema.Freshness{Column: "updated_at", LastWritten: &last}
+		ta.SampleRows = &schema.SampleRows{Columns: []string{"a", "a2"}, Rows: [][]string{{"1", "x | y"}}}
+		ta.Storage = &schema.Storage{Table: 8192, Indexes: 16384}
+		ta.Columns[0].Stats = &schema.ColumnStats{Rows: 4, Nulls: 1, Distinct: &distinct, Min: &min, Max: &max, Percentiles: []*schema.Percentile{{P: 50, Value: "2"}}}
+		ta.Columns[0].EnumValues = []*schema.EnumValue{{Value: "paid", Count: 2}}
+		ta.Columns[1].Sensitivity = &schema.Sensitivity{Label: "PII"}
+		tb.Storage = &schema.Storage{Table: 1024}
+		tempDir, _ := ioutil.TempDir("", "tbls")
+		defer os.RemoveAll(tempDir)
+		c := config.New()
+		c.DocPath = tempDir
+		c.ER.Format = "mermaid"
+		c.Format.Adjust = adjust
+		c.Storage = config.Storage{Enabled: true}
+		err := Output(s, c, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// documents are compared without the data of tables
+		ta.RowCount = &schema.RowCount{Count: 12346}
+		ta.Freshness = nil
+		ta.SampleRows = nil
+		ta.Storage = nil
+		ta.Columns[0].Stats = nil
+		ta.Columns[0].EnumValues = nil
+		tb.Storage = nil
+		got, err := DiffFiles(s, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range got {
+			t.Errorf("adjust %v: %s\n%s", adjust, d.File, d.Unified())
+		}
+		ta.Columns[1].Sensitivity = nil
+		got, err = DiffFiles(s, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Target != "a" {
+			t.Errorf("adjust %v: actual %v\nwant %v", adjust, got, "a")
+		}
+	}
+}
+
+func TestFileDiffUnified(t *testing.T) {
+	d := &FileDiff{
+		Diffs: []diffmatchpatch.Diff{
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: "1\n2\n3\n4\n5\n"},
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffInsert, Text: "old\n"},
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: "new\n"},
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: "6\n7\n8\n9\n10\n11\n12\n13\n"},
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: "added\n"},
+		},
+	}
+	want := "@@\n 3\n 4\n 5\n-old\n+new\n 6\n 7\n 8\n@@\n 11\n 12\n 13\n+added\n"
+	if got := d.Unified(); got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestOutputDiffText(t *testing.T) {
+	fileDiffs := []*FileDiff{
+		&FileDiff{Target: "users", File: "dbdoc/users.md", Diffs: []diffmatchpatch.Diff{
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: "| id |\n"},
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffInsert, Text: "| name |\n"},
+			diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: "| email |\n"},
+		}},
+	}
+	tests := []struct {
+		color bool
+		want  string
+	}{
+		{false, "diff users dbdoc/users.md\n@@\n | id |\n-| name |\n+| email |\n"},
+		{true, "\x1b[1mdiff users dbdoc/users.md\x1b[0m\n\x1b[36m@@\x1b[0m\n | id |\n\x1b[31m-| name |\x1b[0m\n\x1b[32m+| email |\x1b[0m\n"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		if err := OutputDiffText(buf, fileDiffs, tt.color); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("actual %q\nwant %q", buf.String(), tt.want)
+		}
+	}
+}
+
+func TestOutputDiffGitHub(t *testing.T) {
+	fileDiffs := []*FileDiff{
+		&FileDiff{Target: "users", File: "dbdoc/users.md"},
+	}
+	buf := &bytes.Buffer{}
+	err := OutputDiffGitHub(buf, fileDiffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=dbdoc/users.md,title=tbls diff::users: document is out of date with the database schema. Run `tbls doc --force` to update it.\n"
+	if buf.String() != want {
+		t.Errorf("actual %v\nwant %v", buf.String(), want)
+	}
+}
+
+func TestOutputDiffSummary(t *testing.T) {
+	tests := []struct {
+		fileDiffs []*FileDiff
+		want      []string
+	}{
+		{[]*FileDiff{}, []string{"## tbls diff\n\nDocuments are up to date with the database schema.\n"}},
+		{
+			[]*FileDiff{
+				&FileDiff{Target: "users", File: "dbdoc/users.md", Diffs: []diffmatchpatch.Diff{
+					diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: "new\n"},
+				}},
+			},
+			[]string{
+				"1 document file(s) are out of date",
+				"| users | dbdoc/users.md |\n",
+				"<details><summary>dbdoc/users.md</summary>\n\n```diff\n@@\n+new\n```\n\n</details>\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := OutputDiffSummary(buf, tt.fileDiffs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("actual %v\nwant %v", buf.String(), w)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package md
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+var reUnsafeFileName = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// indexPage is a page of the table list of the index split by format.indexPages
+type indexPage struct {
+	Name   string
+	File   string
+	Tables []*schema.Table
+}
+
+// makeIndexPages group tables into pages of the table list. It returns nil when the table list is not split.
+func makeIndexPages(s *schema.Schema, c *config.Config) []*indexPage {
+	if !c.Format.IndexPages.Enabled(len(s.Tables)) {
+		return nil
+	}
+	pages := map[string]*indexPage{}
+	add := func(name string, t *schema.Table) {
+		p, ok := pages[name]
+		if !ok {
+			p = &indexPage{
+				Name: name,
+				File: fmt.Sprintf("tables-%s", strings.ToLower(reUnsafeFileName.ReplaceAllString(name, "_"))),
+			}
+			pages[name] = p
+		}
+		p.Tables = append(p.Tables, t)
+	}
+	for _, t := range s.Tables {
+		switch c.Format.IndexPages.By {
+		case "schema":
+			name := "default"
+			if i := strings.LastIndex(t.Name, "."); i > 0 {
+				name = t.Name[:i]
+			}
+			add(name, t)
+		case "label":
+			if len(t.Labels) == 0 {
+				add("unlabeled", t)
+			}
+			for _, l := range t.Labels {
+				add(l.Name, t)
+			}
+		default:
+			name := "other"
+			if r := []rune(t.Name); len(r) > 0 && unicode.IsLetter(r[0]) {
+				name = strings.ToUpper(string(r[0]))
+			}
+			add(name, t)
+		}
+	}
+	sorted := []*indexPage{}
+	for _, p := range pages {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// makeIndexPagesData return the table of the pages of the table list in the index
+func makeIndexPagesData(pages []*indexPage, cfg *config.Config) [][]string {
+	d := cfg.Dict
+	data := [][]string{
+		[]string{d.Lookup("Name"), d.Lookup("Tables")},
+		[]string{"----", "------"},
+	}
+	for _, p := range pages {
+		data = append(data, []string{
+			fmt.Sprintf("[%s](%s)", p.Name, cfg.TableLink(p.File)),
+			fmt.Sprintf("%d", len(p.Tables)),
+		})
+	}
+	return data
+}
+
+func makeIndexPageTemplateData(p *indexPage, s *schema.Schema, cfg *config.Config) map[string]interface{} {
+	title := s.Name
+	if cfg.Title != "" {
+		title = cfg.Title
+	}
+	tablesData := makeTablesData(p.Tables, cfg)
+	if cfg.Format.Adjust {
+		tablesData = adjustTable(tablesData)
+	}
+	return map[string]interface{}{
+		"Schema": s,
+		"Title":  title,
+		"Page":   p.Name,
+		"Index":  cfg.TableLink(strings.TrimSuffix(cfg.IndexFileName(), ".md")),
+		"Tables": tablesData,
+	}
+}
+
+// renderIndexPage render the page of the table list with the template
+func renderIndexPage(wr io.Writer, box packr.Box, p *indexPage, s *schema.Schema, c *config.Config) error {
+	tmpl, err := parseTemplate(box, "index_page.md.tmpl", c.Templates.MD.IndexPage, c)
+	if err != nil {
+		return err
+	}
+	err = tmpl.Execute(wr, makeIndexPageTemplateData(p, s, c))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
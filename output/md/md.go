@@ -3,13 +3,19 @@ package md
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/output/mermaid"
+	"github.com/k1LoW/tbls/output/viewer"
 	"github.com/k1LoW/tbls/schema"
 	"github.com/mattn/go-runewidth"
 	"github.com/pkg/errors"
@@ -17,58 +23,109 @@ import (
 )
 
 // Output generate markdown files.
-func Output(s *schema.Schema, path string, force bool, adjust bool, erFormat string) error {
+func Output(s *schema.Schema, c *config.Config, force bool) error {
+	path := c.DocPath
 	fullPath, err := filepath.Abs(path)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if !force && outputExists(s, fullPath) {
+	if !force && outputExists(s, fullPath, c) {
 		return errors.New("output files already exists")
 	}
 
+	return outputFiles(s, c, nil)
+}
+
+// OutputTables generate markdown files of the index, viewpoints and the tables, overwriting existing files.
+// Markdown files of other tables are left as they are.
+func OutputTables(s *schema.Schema, c *config.Config, tables []string) error {
+	only := map[string]bool{}
+	for _, name := range tables {
+		only[name] = true
+	}
+	return outputFiles(s, c, only)
+}
+
+// outputFiles generate markdown files. When only is not nil, markdown files of tables not in only are not generated.
+func outputFiles(s *schema.Schema, c *config.Config, only map[string]bool) error {
+	path := c.DocPath
+	fullPath, err := filepath.Abs(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	box := packr.NewBox("./templates")
 
 	// README.md
-	file, err := os.Create(filepath.Join(fullPath, "README.md"))
+	file, err := os.Create(filepath.Join(fullPath, c.IndexFileName()))
 	defer file.Close()
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	ts, _ := box.FindString("index.md.tmpl")
-	tmpl := template.Must(template.New("index").Funcs(funcMap()).Parse(ts))
-	er := false
-	if _, err := os.Lstat(filepath.Join(fullPath, fmt.Sprintf("schema.%s", erFormat))); err == nil {
-		er = true
+	tmpl, err := parseTemplate(box, "index.md.tmpl", c.Templates.MD.Index, c)
+	if err != nil {
+		return err
+	}
+	templateData := makeSchemaTemplateData(s, c)
+	err = addERTemplateData(templateData, fullPath, "schema", c.ER.SkipSchemaDiagram(s), c, func(wr io.Writer) error {
+		return mermaid.New(c).OutputSchema(wr, s)
+	})
+	templateData["erOversized"] = !c.ER.Skip && !c.ER.SkipSchema && c.ER.SkipSchemaDiagram(s)
+	if c.ER.Viewer {
+		templateData["erViewer"] = c.FileLink(viewer.FileName)
+	}
+	if err != nil {
+		return err
 	}
-
-	templateData := makeSchemaTemplateData(s, adjust)
-	templateData["er"] = er
-	templateData["erFormat"] = erFormat
 
 	err = tmpl.Execute(file, templateData)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	fmt.Printf("%s\n", filepath.Join(path, "README.md"))
+	fmt.Printf("%s\n", filepath.Join(path, c.IndexFileName()))
+
+	// pages of the table list
+	for _, p := range makeIndexPages(s, c) {
+		file, err := os.Create(filepath.Join(fullPath, fmt.Sprintf("%s.md", p.File)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = renderIndexPage(file, box, p, s, c)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", filepath.Join(path, fmt.Sprintf("%s.md", p.File)))
+	}
 
 	// tables
 	for _, t := range s.Tables {
+		if only != nil && !only[t.Name] {
+			continue
+		}
 		file, err := os.Create(filepath.Join(fullPath, fmt.Sprintf("%s.md", t.Name)))
 		if err != nil {
 			file.Close()
 			return errors.WithStack(err)
 		}
-		ts, _ := box.FindString("table.md.tmpl")
-		tmpl := template.Must(template.New(t.Name).Funcs(funcMap()).Parse(ts))
-		er := false
-		if _, err := os.Lstat(filepath.Join(fullPath, fmt.Sprintf("%s.%s", t.Name, erFormat))); err == nil {
-			er = true
+		tmpl, err := parseTemplate(box, "table.md.tmpl", c.Templates.MD.Table, c)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		templateData, err := makeTableTemplateData(t, c)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		err = addERTemplateData(templateData, fullPath, t.Name, c.ER.SkipTableDiagram(t), c, func(wr io.Writer) error {
+			return mermaid.New(c).OutputTable(wr, t)
+		})
+		if err != nil {
+			file.Close()
+			return err
 		}
-
-		templateData := makeTableTemplateData(t, adjust)
-		templateData["er"] = er
-		templateData["erFormat"] = erFormat
 
 		err = tmpl.Execute(file, templateData)
 		if err != nil {
@@ -78,19 +135,70 @@ func Output(s *schema.Schema, path string, force bool, adjust bool, erFormat str
 		fmt.Printf("%s\n", filepath.Join(path, fmt.Sprintf("%s.md", t.Name)))
 		file.Close()
 	}
+
+	// viewpoints
+	for i, v := range c.Viewpoints {
+		name := v.FileName(i)
+		file, err := os.Create(filepath.Join(fullPath, fmt.Sprintf("%s.md", name)))
+		if err != nil {
+			file.Close()
+			return errors.WithStack(err)
+		}
+		tmpl, err := parseTemplate(box, "viewpoint.md.tmpl", c.Templates.MD.Viewpoint, c)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		templateData, err := makeViewpointTemplateData(fullPath, i, v, s, c)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		err = tmpl.Execute(file, templateData)
+		if err != nil {
+			file.Close()
+			return errors.WithStack(err)
+		}
+		fmt.Printf("%s\n", filepath.Join(path, fmt.Sprintf("%s.md", name)))
+		file.Close()
+	}
+
+	// sidebar of the wiki
+	if sidebar := c.SidebarFileName(); sidebar != "" {
+		err := ioutil.WriteFile(filepath.Join(fullPath, sidebar), []byte(makeSidebar(s, c)), 0644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Printf("%s\n", filepath.Join(path, sidebar))
+	}
 	return nil
 }
 
-// Diff database and markdown files.
-func Diff(s *schema.Schema, path string, adjust bool, erFormat string) (string, error) {
-	var diff string
+// Diff database and markdown files, and return unified diffs of drifted files.
+func Diff(s *schema.Schema, c *config.Config) (string, error) {
+	fileDiffs, err := DiffFiles(s, c)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := OutputDiffText(buf, fileDiffs, false); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// DiffFiles return the differences between database and markdown files per file.
+// The data of tables collected by profiling (row counts, statistics, sample rows, ...) is left out of the comparison.
+func DiffFiles(s *schema.Schema, c *config.Config) ([]*FileDiff, error) {
+	fileDiffs := []*FileDiff{}
+	path := c.DocPath
 	fullPath, err := filepath.Abs(path)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
-	if !outputExists(s, fullPath) {
-		return "", errors.New("target files does not exists")
+	if !outputExists(s, fullPath, c) {
+		return nil, errors.New("target files does not exists")
 	}
 
 	box := packr.NewBox("./templates")
@@ -98,55 +206,84 @@ func Diff(s *schema.Schema, path string, adjust bool, erFormat string) (string,
 
 	// README.md
 	a := new(bytes.Buffer)
-	ts, _ := box.FindString("index.md.tmpl")
-	tmpl := template.Must(template.New("index").Funcs(funcMap()).Parse(ts))
-	er := false
-	if _, err := os.Lstat(filepath.Join(fullPath, fmt.Sprintf("schema.%s", erFormat))); err == nil {
-		er = true
+	tmpl, err := parseTemplate(box, "index.md.tmpl", c.Templates.MD.Index, c)
+	if err != nil {
+		return nil, err
+	}
+	templateData := makeSchemaTemplateData(s, c)
+	err = addERTemplateData(templateData, fullPath, "schema", c.ER.SkipSchemaDiagram(s), c, func(wr io.Writer) error {
+		return mermaid.New(c).OutputSchema(wr, s)
+	})
+	templateData["erOversized"] = !c.ER.Skip && !c.ER.SkipSchema && c.ER.SkipSchemaDiagram(s)
+	if c.ER.Viewer {
+		templateData["erViewer"] = c.FileLink(viewer.FileName)
+	}
+	if err != nil {
+		return nil, err
 	}
-
-	templateData := makeSchemaTemplateData(s, adjust)
-	templateData["er"] = er
-	templateData["erFormat"] = erFormat
 
 	err = tmpl.Execute(a, templateData)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
-	targetPath := filepath.Join(fullPath, "README.md")
+	targetPath := filepath.Join(fullPath, c.IndexFileName())
 	b, err := ioutil.ReadFile(targetPath)
 	if err != nil {
 		b = []byte{}
 	}
 
-	da, db, dc := dmp.DiffLinesToChars(a.String(), string(b))
+	da, db, dc := dmp.DiffLinesToChars(withoutData(a.String(), c.Dict), withoutData(string(b), c.Dict))
 	diffs := dmp.DiffMain(da, db, false)
 	result := dmp.DiffCharsToLines(diffs, dc)
 
 	if len(result) != 1 || result[0].Type != diffmatchpatch.DiffEqual {
-		diff += fmt.Sprintf("diff [database] %s\n", filepath.Join(path, "README.md"))
-		diff += fmt.Sprintln(dmp.DiffPrettyText(result))
+		fileDiffs = append(fileDiffs, &FileDiff{Target: "[database]", File: filepath.Join(path, c.IndexFileName()), Diffs: result})
+	}
+
+	// pages of the table list
+	for _, p := range makeIndexPages(s, c) {
+		a := new(bytes.Buffer)
+		err := renderIndexPage(a, box, p, s, c)
+		if err != nil {
+			return nil, err
+		}
+		targetPath := filepath.Join(fullPath, fmt.Sprintf("%s.md", p.File))
+		b, err := ioutil.ReadFile(targetPath)
+		if err != nil {
+			b = []byte{}
+		}
+
+		da, db, dc := dmp.DiffLinesToChars(withoutData(a.String(), c.Dict), withoutData(string(b), c.Dict))
+		diffs := dmp.DiffMain(da, db, false)
+		result := dmp.DiffCharsToLines(diffs, dc)
+		if len(result) != 1 || result[0].Type != diffmatchpatch.DiffEqual {
+			fileDiffs = append(fileDiffs, &FileDiff{Target: p.File, File: filepath.Join(path, fmt.Sprintf("%s.md", p.File)), Diffs: result})
+		}
 	}
 
 	// tables
 	for _, t := range s.Tables {
 		a := new(bytes.Buffer)
-		ts, _ := box.FindString("table.md.tmpl")
-		tmpl := template.Must(template.New(t.Name).Funcs(funcMap()).Parse(ts))
-		er := false
-		if _, err := os.Lstat(filepath.Join(fullPath, fmt.Sprintf("%s.%s", t.Name, erFormat))); err == nil {
-			er = true
+		tmpl, err := parseTemplate(box, "table.md.tmpl", c.Templates.MD.Table, c)
+		if err != nil {
+			return nil, err
+		}
+		templateData, err := makeTableTemplateData(t, c)
+		if err != nil {
+			return nil, err
+		}
+		err = addERTemplateData(templateData, fullPath, t.Name, c.ER.SkipTableDiagram(t), c, func(wr io.Writer) error {
+			return mermaid.New(c).OutputTable(wr, t)
+		})
+		if err != nil {
+			return nil, err
 		}
-
-		templateData := makeTableTemplateData(t, adjust)
-		templateData["er"] = er
-		templateData["erFormat"] = erFormat
 
 		err = tmpl.Execute(a, templateData)
 
 		if err != nil {
-			return "", errors.WithStack(err)
+			return nil, errors.WithStack(err)
 		}
 		targetPath := filepath.Join(fullPath, fmt.Sprintf("%s.md", t.Name))
 		b, err := ioutil.ReadFile(targetPath)
@@ -154,20 +291,127 @@ func Diff(s *schema.Schema, path string, adjust bool, erFormat string) (string,
 			b = []byte{}
 		}
 
-		da, db, dc := dmp.DiffLinesToChars(a.String(), string(b))
+		da, db, dc := dmp.DiffLinesToChars(withoutData(a.String(), c.Dict), withoutData(string(b), c.Dict))
 		diffs := dmp.DiffMain(da, db, false)
 		result := dmp.DiffCharsToLines(diffs, dc)
 		if len(result) != 1 || result[0].Type != diffmatchpatch.DiffEqual {
-			diff += fmt.Sprintf("diff %s %s\n", t.Name, filepath.Join(path, fmt.Sprintf("%s.md", t.Name)))
-			diff += fmt.Sprintln(dmp.DiffPrettyText(result))
+			fileDiffs = append(fileDiffs, &FileDiff{Target: t.Name, File: filepath.Join(path, fmt.Sprintf("%s.md", t.Name)), Diffs: result})
 		}
 	}
-	return diff, nil
+
+	// viewpoints
+	for i, v := range c.Viewpoints {
+		name := v.FileName(i)
+		a := new(bytes.Buffer)
+		tmpl, err := parseTemplate(box, "viewpoint.md.tmpl", c.Templates.MD.Viewpoint, c)
+		if err != nil {
+			return nil, err
+		}
+		templateData, err := makeViewpointTemplateData(fullPath, i, v, s, c)
+		if err != nil {
+			return nil, err
+		}
+		err = tmpl.Execute(a, templateData)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		targetPath := filepath.Join(fullPath, fmt.Sprintf("%s.md", name))
+		b, err := ioutil.ReadFile(targetPath)
+		if err != nil {
+			b = []byte{}
+		}
+
+		da, db, dc := dmp.DiffLinesToChars(withoutData(a.String(), c.Dict), withoutData(string(b), c.Dict))
+		diffs := dmp.DiffMain(da, db, false)
+		result := dmp.DiffCharsToLines(diffs, dc)
+		if len(result) != 1 || result[0].Type != diffmatchpatch.DiffEqual {
+			fileDiffs = append(fileDiffs, &FileDiff{Target: v.Name, File: filepath.Join(path, fmt.Sprintf("%s.md", name)), Diffs: result})
+		}
+	}
+
+	// sidebar of the wiki
+	if sidebar := c.SidebarFileName(); sidebar != "" {
+		b, err := ioutil.ReadFile(filepath.Join(fullPath, sidebar))
+		if err != nil {
+			b = []byte{}
+		}
+		da, db, dc := dmp.DiffLinesToChars(makeSidebar(s, c), string(b))
+		diffs := dmp.DiffMain(da, db, false)
+		result := dmp.DiffCharsToLines(diffs, dc)
+		if len(result) != 1 || result[0].Type != diffmatchpatch.DiffEqual {
+			fileDiffs = append(fileDiffs, &FileDiff{Target: "[sidebar]", File: filepath.Join(path, sidebar), Diffs: result})
+		}
+	}
+	return fileDiffs, nil
 }
 
-func outputExists(s *schema.Schema, path string) bool {
+// makeSidebar return the sidebar of the wiki linking the index, viewpoints and tables
+func makeSidebar(s *schema.Schema, c *config.Config) string {
+	title := s.Name
+	if c.Title != "" {
+		title = c.Title
+	}
+	index := strings.TrimSuffix(c.IndexFileName(), ".md")
+	lines := []string{fmt.Sprintf("### [%s](%s)", title, c.FileLink(index)), ""}
+	if len(c.Viewpoints) > 0 {
+		lines = append(lines, fmt.Sprintf("**%s**", c.Dict.Lookup("Viewpoints")), "")
+		for i, v := range c.Viewpoints {
+			lines = append(lines, fmt.Sprintf("- [%s](%s)", v.Name, c.TableLink(v.FileName(i))))
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, fmt.Sprintf("**%s**", c.Dict.Lookup("Tables")), "")
+	for _, t := range s.Tables {
+		lines = append(lines, fmt.Sprintf("- [%s](%s)", c.TableTitle(t.Name), c.TableLink(t.Name)))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// makeViewpointTemplateData return template data of the viewpoint document including its ER diagram
+func makeViewpointTemplateData(fullPath string, i int, v config.Viewpoint, s *schema.Schema, c *config.Config) (map[string]interface{}, error) {
+	vs := v.Schema(s)
+	templateData := makeSchemaTemplateData(vs, c)
+	templateData["Name"] = v.Name
+	templateData["Desc"] = v.Desc
+	vc := *c
+	vc.ER = c.ER.ViewpointER()
+	err := addERTemplateData(templateData, fullPath, v.FileName(i), vc.ER.SkipSchemaDiagram(vs), &vc, func(wr io.Writer) error {
+		return mermaid.New(&vc).OutputSchema(wr, vs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templateData, nil
+}
+
+// addERTemplateData set ER diagram data for templates
+func addERTemplateData(data map[string]interface{}, fullPath string, name string, skip bool, c *config.Config, mmd func(io.Writer) error) error {
+	data["er"] = false
+	data["erFormat"] = c.ER.FileExt()
+	data["erPath"] = c.FileLink(c.ImagePath(fmt.Sprintf("%s.%s", name, c.ER.FileExt())))
+	if skip {
+		return nil
+	}
+	if c.ER.Format == "mermaid" {
+		buf := new(bytes.Buffer)
+		err := mmd(buf)
+		if err != nil {
+			return err
+		}
+		data["er"] = true
+		data["erMermaid"] = buf.String()
+		return nil
+	}
+	if _, err := os.Lstat(filepath.Join(fullPath, filepath.FromSlash(c.ImagePath(fmt.Sprintf("%s.%s", name, c.ER.FileExt()))))); err == nil {
+		data["er"] = true
+		data["erLink"] = !c.ER.IsImageFormat()
+	}
+	return nil
+}
+
+func outputExists(s *schema.Schema, path string, c *config.Config) bool {
 	// README.md
-	if _, err := os.Lstat(filepath.Join(path, "README.md")); err == nil {
+	if _, err := os.Lstat(filepath.Join(path, c.IndexFileName())); err == nil {
 		return true
 	}
 	// tables
@@ -179,8 +423,9 @@ func outputExists(s *schema.Schema, path string) bool {
 	return false
 }
 
-func funcMap() map[string]interface{} {
+func funcMap(c *config.Config) map[string]interface{} {
 	return template.FuncMap{
+		"lookup": c.Dict.Lookup,
 		"nl2br": func(text string) string {
 			r := strings.NewReplacer("\r\n", "<br>", "\n", "<br>", "\r", "<br>")
 			return r.Replace(text)
@@ -192,64 +437,309 @@ func funcMap() map[string]interface{} {
 	}
 }
 
-func makeSchemaTemplateData(s *schema.Schema, adjust bool) map[string]interface{} {
+func parseTemplate(box packr.Box, name string, path string, c *config.Config) (*template.Template, error) {
+	ts, err := output.LoadTemplate(box, name, path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(funcMap(c)).Parse(ts)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to parse template '%s'", name))
+	}
+	return tmpl, nil
+}
+
+func makeSchemaTemplateData(s *schema.Schema, cfg *config.Config) map[string]interface{} {
+	d := cfg.Dict
+	tablesData := makeTablesData(s.Tables, cfg)
+	if pages := makeIndexPages(s, cfg); pages != nil {
+		tablesData = makeIndexPagesData(pages, cfg)
+	}
+
+	labelsData := [][]string{
+		[]string{d.Lookup("Label"), d.Lookup("Description"), d.Lookup("Tables")},
+		[]string{"-----", "-----------", "------"},
+	}
+	for _, l := range s.Labels {
+		tables := []string{}
+		for _, t := range s.Tables {
+			for _, tl := range t.Labels {
+				if tl == l {
+					tables = append(tables, fmt.Sprintf("[%s](%s)", t.Name, cfg.TableLink(t.Name)))
+				}
+			}
+		}
+		if len(tables) == 0 {
+			continue
+		}
+		labelsData = append(labelsData, []string{l.Name, l.Description, strings.Join(tables, " ")})
+	}
+
+	viewpointsData := [][]string{
+		[]string{d.Lookup("Name"), d.Lookup("Description")},
+		[]string{"----", "-----------"},
+	}
+	for i, v := range cfg.Viewpoints {
+		viewpointsData = append(viewpointsData, []string{
+			fmt.Sprintf("[%s](%s)", v.Name, cfg.TableLink(v.FileName(i))),
+			v.Desc,
+		})
+	}
+
+	largestTablesData := makeLargestTablesData(s.Tables, cfg)
+
+	title := s.Name
+	if cfg.Title != "" {
+		title = cfg.Title
+	}
+
+	if cfg.Format.Adjust {
+		if len(largestTablesData) > 0 {
+			largestTablesData = adjustTable(largestTablesData)
+		}
+		return map[string]interface{}{
+			"Schema":        s,
+			"Title":         title,
+			"Tables":        adjustTable(tablesData),
+			"LargestTables": largestTablesData,
+			"Labels":        adjustTable(labelsData),
+			"Viewpoints":    adjustTable(viewpointsData),
+		}
+	}
+
+	return map[string]interface{}{
+		"Schema":        s,
+		"Title":         title,
+		"Tables":        tablesData,
+		"LargestTables": largestTablesData,
+		"Labels":        labelsData,
+		"Viewpoints":    viewpointsData,
+	}
+}
+
+// makeLargestTablesData return the list of the largest tables by the total size in descending order. It is empty when sizes are not collected.
+func makeLargestTablesData(tables []*schema.Table, cfg *config.Config) [][]string {
+	d := cfg.Dict
+	sized := []*schema.Table{}
+	toast := false
+	for _, t := range tables {
+		if t.Storage == nil {
+			continue
+		}
+		sized = append(sized, t)
+		if t.Storage.Toast != nil {
+			toast = true
+		}
+	}
+	if len(sized) == 0 {
+		return [][]string{}
+	}
+	sort.SliceStable(sized, func(i, j int) bool {
+		return sized[i].Storage.Total() > sized[j].Storage.Total()
+	})
+	if len(sized) > cfg.Storage.N() {
+		sized = sized[:cfg.Storage.N()]
+	}
+	data := [][]string{
+		[]string{d.Lookup("Name"), d.Lookup("Total"), d.Lookup("Table"), d.Lookup("Indexes")},
+		[]string{"----", "-----", "-----", "-------"},
+	}
+	if toast {
+		data[0] = append(data[0], "TOAST")
+		data[1] = append(data[1], "-----")
+	}
+	for _, t := range sized {
+		row := []string{
+			fmt.Sprintf("[%s](%s)", cfg.TableTitle(t.Name), cfg.TableLink(t.Name)),
+			formatSize(t.Storage.Total()),
+			formatSize(t.Storage.Table),
+			formatSize(t.Storage.Indexes),
+		}
+		if toast {
+			row = append(row, formatToastSize(t.Storage))
+		}
+		data = append(data, row)
+	}
+	return data
+}
+
+// makeTablesData return the table list of tables in the index
+func makeTablesData(tables []*schema.Table, cfg *config.Config) [][]string {
+	d := cfg.Dict
+	rowCounts := false
+	freshness := false
+	for _, t := range tables {
+		if t.RowCount != nil {
+			rowCounts = true
+		}
+		if t.Freshness != nil {
+			freshness = true
+		}
+	}
 	tablesData := [][]string{
-		[]string{"Name", "Columns", "Comment", "Type"},
+		[]string{d.Lookup("Name"), d.Lookup("Columns"), d.Lookup("Comment"), d.Lookup("Type")},
 		[]string{"----", "-------", "-------", "----"},
 	}
-	for _, t := range s.Tables {
+	if rowCounts {
+		tablesData[0] = append(tablesData[0], d.Lookup("Rows"))
+		tablesData[1] = append(tablesData[1], "----")
+	}
+	if freshness {
+		tablesData[0] = append(tablesData[0], d.Lookup("Last written"))
+		tablesData[1] = append(tablesData[1], "------------")
+	}
+	for _, t := range tables {
+		columnCount := 0
+		for _, c := range t.Columns {
+			if !c.Hidden {
+				columnCount++
+			}
+		}
 		data := []string{
-			fmt.Sprintf("[%s](%s.md)", t.Name, t.Name),
-			fmt.Sprintf("%d", len(t.Columns)),
+			fmt.Sprintf("[%s](%s)", cfg.TableTitle(t.Name), cfg.TableLink(t.Name)),
+			fmt.Sprintf("%d", columnCount),
 			t.Comment,
 			t.Type,
 		}
+		if rowCounts {
+			rc := ""
+			if t.RowCount != nil {
+				rc = t.RowCount.String()
+			}
+			data = append(data, rc)
+		}
+		if freshness {
+			lw := ""
+			if t.Freshness != nil {
+				lw = t.Freshness.String()
+			}
+			data = append(data, lw)
+		}
 		tablesData = append(tablesData, data)
 	}
+	return tablesData
+}
 
-	if adjust {
-		return map[string]interface{}{
-			"Schema": s,
-			"Tables": adjustTable(tablesData),
+func makeTableTemplateData(t *schema.Table, cfg *config.Config) (map[string]interface{}, error) {
+	d := cfg.Dict
+
+	// Description
+	description := t.Comment
+	tc, _ := cfg.FindTable(t.Name)
+	if tc.DescriptionFile != "" {
+		buf, err := ioutil.ReadFile(tc.DescriptionFile)
+		if err != nil {
+			return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to read description file of table '%s'", t.Name))
 		}
+		description = strings.TrimSpace(string(buf))
 	}
 
-	return map[string]interface{}{
-		"Schema": s,
-		"Tables": tablesData,
+	// Snippets
+	snippets := []string{}
+	for _, path := range tc.Snippets {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to read snippet of table '%s'", t.Name))
+		}
+		snippets = append(snippets, strings.TrimSpace(string(buf)))
 	}
-}
 
-func makeTableTemplateData(t *schema.Table, adjust bool) map[string]interface{} {
 	// Columns
 	columnsData := [][]string{
-		[]string{"Name", "Type", "Default", "Nullable", "Children", "Parents", "Comment"},
+		[]string{d.Lookup("Name"), d.Lookup("Type"), d.Lookup("Default"), d.Lookup("Nullable"), d.Lookup("Children"), d.Lookup("Parents"), d.Lookup("Comment")},
 		[]string{"----", "----", "-------", "--------", "--------", "-------", "-------"},
 	}
+	stats := false
+	percentiles := false
+	sensitivity := false
+	enumValues := false
+	labels := false
 	for _, c := range t.Columns {
+		if c.Hidden {
+			continue
+		}
+		if len(c.Labels) > 0 || len(c.Metadata) > 0 {
+			labels = true
+		}
+		if c.Stats != nil {
+			stats = true
+			if len(c.Stats.Percentiles) > 0 {
+				percentiles = true
+			}
+		}
+		if c.Sensitivity != nil {
+			sensitivity = true
+		}
+		if len(c.EnumValues) > 0 {
+			enumValues = true
+		}
+	}
+	if labels {
+		columnsData[0] = append(columnsData[0], d.Lookup("Labels"))
+		columnsData[1] = append(columnsData[1], "------")
+	}
+	if sensitivity {
+		columnsData[0] = append(columnsData[0], d.Lookup("Sensitivity"))
+		columnsData[1] = append(columnsData[1], "-----------")
+	}
+	if enumValues {
+		columnsData[0] = append(columnsData[0], d.Lookup("Values"))
+		columnsData[1] = append(columnsData[1], "------")
+	}
+	if stats {
+		columnsData[0] = append(columnsData[0], d.Lookup("Nulls"), d.Lookup("Distinct"), d.Lookup("Min"), d.Lookup("Max"))
+		columnsData[1] = append(columnsData[1], "-----", "--------", "---", "---")
+	}
+	if percentiles {
+		columnsData[0] = append(columnsData[0], d.Lookup("Percentiles"))
+		columnsData[1] = append(columnsData[1], "-----------")
+	}
+	for _, c := range t.Columns {
+		if c.Hidden {
+			continue
+		}
 		childRelations := []string{}
 		for _, r := range c.ChildRelations {
-			childRelations = append(childRelations, fmt.Sprintf("[%s](%s.md)", r.Table.Name, r.Table.Name))
+			childRelations = append(childRelations, fmt.Sprintf("[%s](%s)", r.Table.Name, cfg.TableLink(r.Table.Name)))
 		}
 		parentRelations := []string{}
 		for _, r := range c.ParentRelations {
-			parentRelations = append(parentRelations, fmt.Sprintf("[%s](%s.md)", r.ParentTable.Name, r.ParentTable.Name))
+			if r.Cardinality != "" {
+				parentRelations = append(parentRelations, fmt.Sprintf("[%s](%s) (%s)", r.ParentTable.Name, cfg.TableLink(r.ParentTable.Name), r.Cardinality))
+				continue
+			}
+			parentRelations = append(parentRelations, fmt.Sprintf("[%s](%s)", r.ParentTable.Name, cfg.TableLink(r.ParentTable.Name)))
 		}
 		data := []string{
 			c.Name,
 			c.Type,
-			c.Default.String,
+			c.DefaultValue(),
 			fmt.Sprintf("%v", c.Nullable),
 			strings.Join(childRelations, " "),
 			strings.Join(parentRelations, " "),
 			c.Comment,
 		}
+		if labels {
+			data = append(data, makeColumnLabelsData(c))
+		}
+		if sensitivity {
+			data = append(data, c.Sensitivity.String())
+		}
+		if enumValues {
+			data = append(data, makeEnumValuesData(c.EnumValues))
+		}
+		if stats {
+			data = append(data, makeColumnStatsData(c.Stats)...)
+		}
+		if percentiles {
+			data = append(data, makePercentilesData(c.Stats))
+		}
 		columnsData = append(columnsData, data)
 	}
 
 	// Constraints
 	constraintsData := [][]string{
-		[]string{"Name", "Type", "Definition"},
+		[]string{d.Lookup("Name"), d.Lookup("Type"), d.Lookup("Definition")},
 		[]string{"----", "----", "----------"},
 	}
 	for _, c := range t.Constraints {
@@ -263,7 +753,7 @@ func makeTableTemplateData(t *schema.Table, adjust bool) map[string]interface{}
 
 	// Indexes
 	indexesData := [][]string{
-		[]string{"Name", "Definition"},
+		[]string{d.Lookup("Name"), d.Lookup("Definition")},
 		[]string{"----", "----------"},
 	}
 	for _, i := range t.Indexes {
@@ -276,7 +766,7 @@ func makeTableTemplateData(t *schema.Table, adjust bool) map[string]interface{}
 
 	// Triggers
 	triggersData := [][]string{
-		[]string{"Name", "Definition"},
+		[]string{d.Lookup("Name"), d.Lookup("Definition")},
 		[]string{"----", "----------"},
 	}
 	for _, i := range t.Triggers {
@@ -287,23 +777,160 @@ func makeTableTemplateData(t *schema.Table, adjust bool) map[string]interface{}
 		triggersData = append(triggersData, data)
 	}
 
-	if adjust {
+	// Sample rows
+	samplesData := makeSampleRowsData(t.SampleRows)
+
+	// Storage
+	storageData := makeStorageData(t.Storage, cfg)
+
+	if cfg.Format.Adjust {
+		if len(samplesData) > 0 {
+			samplesData = adjustTable(samplesData)
+		}
+		if len(storageData) > 0 {
+			storageData = adjustTable(storageData)
+		}
 		return map[string]interface{}{
 			"Table":       t,
+			"Title":       cfg.TableTitle(t.Name),
+			"Description": description,
 			"Columns":     adjustTable(columnsData),
 			"Constraints": adjustTable(constraintsData),
 			"Indexes":     adjustTable(indexesData),
 			"Triggers":    adjustTable(triggersData),
-		}
+			"SampleRows":  samplesData,
+			"Storage":     storageData,
+			"Snippets":    snippets,
+		}, nil
 	}
 
 	return map[string]interface{}{
 		"Table":       t,
+		"Title":       cfg.TableTitle(t.Name),
+		"Description": description,
 		"Columns":     columnsData,
 		"Constraints": constraintsData,
 		"Indexes":     indexesData,
 		"Triggers":    triggersData,
+		"SampleRows":  samplesData,
+		"Storage":     storageData,
+		"Snippets":    snippets,
+	}, nil
+}
+
+// makeColumnStatsData return the null ratio, the distinct count, min and max of the column. They are empty when not collected.
+func makeColumnStatsData(stats *schema.ColumnStats) []string {
+	data := []string{"", "", "", ""}
+	if stats == nil {
+		return data
+	}
+	r := strings.NewReplacer("|", "\\|")
+	data[0] = stats.NullRatio()
+	if stats.Distinct != nil {
+		data[1] = fmt.Sprintf("%d", *stats.Distinct)
+	}
+	if stats.Min != nil {
+		data[2] = r.Replace(*stats.Min)
+	}
+	if stats.Max != nil {
+		data[3] = r.Replace(*stats.Max)
+	}
+	return data
+}
+
+// makePercentilesData return percentiles of the column such as `p50: 10<br>p90: 42`. It is empty when not collected.
+func makePercentilesData(stats *schema.ColumnStats) string {
+	if stats == nil {
+		return ""
+	}
+	r := strings.NewReplacer("|", "\\|")
+	data := []string{}
+	for _, p := range stats.Percentiles {
+		data = append(data, fmt.Sprintf("p%d: %s", p.P, r.Replace(p.Value)))
+	}
+	return strings.Join(data, "<br>")
+}
+
+// makeEnumValuesData return distinct values of the column with their counts. It is empty when not collected.
+func makeEnumValuesData(values []*schema.EnumValue) string {
+	r := strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ", "\r", " ")
+	data := []string{}
+	for _, v := range values {
+		data = append(data, fmt.Sprintf("%s (%d)", r.Replace(v.Value), v.Count))
+	}
+	return strings.Join(data, "<br>")
+}
+
+// makeStorageData return the table of on-disk sizes of the table. It is empty when sizes are not collected.
+func makeStorageData(storage *schema.Storage, cfg *config.Config) [][]string {
+	if storage == nil {
+		return [][]string{}
+	}
+	d := cfg.Dict
+	data := [][]string{
+		[]string{d.Lookup("Table"), d.Lookup("Indexes")},
+		[]string{"-----", "-------"},
+		[]string{formatSize(storage.Table), formatSize(storage.Indexes)},
+	}
+	if storage.Toast != nil {
+		data[0] = append(data[0], "TOAST")
+		data[1] = append(data[1], "-----")
+		data[2] = append(data[2], formatToastSize(storage))
+	}
+	data[0] = append(data[0], d.Lookup("Total"))
+	data[1] = append(data[1], "-----")
+	data[2] = append(data[2], formatSize(storage.Total()))
+	return data
+}
+
+// formatToastSize return the size of TOAST of the table. It is empty for databases without TOAST.
+func formatToastSize(storage *schema.Storage) string {
+	if storage.Toast == nil {
+		return ""
 	}
+	return formatSize(*storage.Toast)
+}
+
+// formatSize return the size in bytes with binary units such as `1.5 MiB`
+func formatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	size := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		size /= 1024
+		if size < 1024 || unit == "TiB" {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+	}
+	return ""
+}
+
+// makeSampleRowsData return the table of sample rows. It is empty when no rows are selected.
+func makeSampleRowsData(samples *schema.SampleRows) [][]string {
+	if samples == nil || len(samples.Rows) == 0 {
+		return [][]string{}
+	}
+	r := strings.NewReplacer("|", "\\|")
+	header := []string{}
+	separator := []string{}
+	for _, c := range samples.Columns {
+		header = append(header, c)
+		l := len(c)
+		if l < 3 {
+			l = 3
+		}
+		separator = append(separator, strings.Repeat("-", l))
+	}
+	data := [][]string{header, separator}
+	for _, row := range samples.Rows {
+		values := []string{}
+		for _, v := range row {
+			values = append(values, r.Replace(v))
+		}
+		data = append(data, values)
+	}
+	return data
 }
 
 func adjustTable(data [][]string) [][]string {
@@ -329,3 +956,24 @@ func adjustTable(data [][]string) [][]string {
 
 	return data
 }
+
+// makeColumnLabelsData return the labels of the column as badges, followed by the metadata lines `key: value` in key order
+func makeColumnLabelsData(c *schema.Column) string {
+	lines := []string{}
+	badges := []string{}
+	for _, l := range c.Labels {
+		badges = append(badges, fmt.Sprintf("`%s`", l.Name))
+	}
+	if len(badges) > 0 {
+		lines = append(lines, strings.Join(badges, " "))
+	}
+	keys := []string{}
+	for k := range c.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, c.Metadata[k]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,12 +1,16 @@
 package md
 
 import (
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/k1LoW/tbls/config"
 	"github.com/k1LoW/tbls/schema"
 )
 
@@ -30,10 +34,11 @@ func TestOutput(t *testing.T) {
 		}
 		tempDir, _ := ioutil.TempDir("", "tbls")
 		force := true
-		adjust := tt.adjust
-		erFormat := "png"
+		c := config.New()
+		c.DocPath = tempDir
+		c.Format.Adjust = tt.adjust
 		defer os.RemoveAll(tempDir)
-		err = Output(s, tempDir, force, adjust, erFormat)
+		err = Output(s, c, force)
 		if err != nil {
 			t.Error(err)
 		}
@@ -60,15 +65,16 @@ func TestDiff(t *testing.T) {
 		}
 		tempDir, _ := ioutil.TempDir("", "tbls")
 		force := true
-		adjust := tt.adjust
-		erFormat := "png"
+		c := config.New()
+		c.DocPath = tempDir
+		c.Format.Adjust = tt.adjust
 		defer os.RemoveAll(tempDir)
-		err = Output(s, tempDir, force, adjust, erFormat)
+		err = Output(s, c, force)
 		if err != nil {
 			t.Error(err)
 		}
 		expected := ""
-		actual, err := Diff(s, tempDir, adjust, erFormat)
+		actual, err := Diff(s, c)
 		if err != nil {
 			t.Error(err)
 		}
@@ -78,6 +84,751 @@ func TestDiff(t *testing.T) {
 	}
 }
 
+func TestOutputWithDict(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.Dict = config.Dict{
+		"Columns": "カラム一覧",
+		"Comment": "コメント",
+	}
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"## カラム一覧", "| コメント |", "## Description"} {
+		if !strings.Contains(string(actual), expected) {
+			t.Errorf("actual %v\nwant %v", string(actual), expected)
+		}
+	}
+}
+
+func TestOutputWithTitleAndAlias(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	descPath := filepath.Join(tempDir, "a_description.md")
+	err := ioutil.WriteFile(descPath, []byte("description of table a from file\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snippetPath := filepath.Join(tempDir, "a_snippet.md")
+	err = ioutil.WriteFile(snippetPath, []byte("## Sample queries\n\n    SELECT * FROM a;\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := config.New()
+	c.DocPath = tempDir
+	c.Title = "Test Database"
+	c.Tables = []config.Table{
+		config.Table{
+			Name:            "a",
+			Alias:           "Table A",
+			DescriptionFile: descPath,
+			Snippets:        []string{snippetPath},
+		},
+	}
+	err = Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"# Test Database", "[Table A (a)](a.md)", "[b](b.md)"} {
+		if !strings.Contains(string(index), expected) {
+			t.Errorf("actual %v\nwant %v", string(index), expected)
+		}
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"# Table A (a)", "description of table a from file", "## Sample queries\n\n    SELECT * FROM a;\n\n---"} {
+		if !strings.Contains(string(actual), expected) {
+			t.Errorf("actual %v\nwant %v", string(actual), expected)
+		}
+	}
+}
+
+func TestOutputWithLink(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.Link.Style = "noext"
+	c.Link.BaseURL = "https://example.com/wiki/"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[a](https://example.com/wiki/a)"
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("actual %v\nwant %v", string(index), expected)
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(actual), expected) {
+		t.Errorf("actual %v\nwant %v", string(actual), expected)
+	}
+}
+
+func TestOutputWithOversizedER(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	c.ER.SchemaMaxTables = 1
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "> The ER diagram is skipped because the schema is too large."
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("actual %v\nwant %v", string(index), expected)
+	}
+	if strings.Contains(string(index), "```mermaid") {
+		t.Errorf("actual %v\nwant %v", string(index), "no mermaid diagram")
+	}
+}
+
+func TestOutputWithViewer(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	c.ER.Viewer = true
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[Interactive ER diagram](schema.html)"
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("actual %v\nwant %v", string(index), expected)
+	}
+}
+
+func TestOutputWithLabels(t *testing.T) {
+	s := newTestSchema()
+	err := s.AssignLabels([]schema.Label{
+		schema.Label{
+			Name:        "core",
+			Description: "core tables",
+			Tables:      []string{"a"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	err = Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "## Labels\n\n| Label | Description | Tables |\n| ----- | ----------- | ------ |\n| core | core tables | [a](a.md) |"
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("actual %v\nwant %v", string(index), expected)
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected2 := "# a\n\n`core`\n\n## Description"
+	if !strings.Contains(string(actual), expected2) {
+		t.Errorf("actual %v\nwant %v", string(actual), expected2)
+	}
+}
+
+func TestOutputWithViewpoints(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	c.Viewpoints = []config.Viewpoint{
+		config.Viewpoint{Name: "only a", Desc: "table a", Tables: []string{"a"}},
+	}
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "## Viewpoints\n\n| Name | Description |\n| ---- | ----------- |\n| [only a](viewpoint-0.md) | table a |"
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("actual %v\nwant %v", string(index), expected)
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(tempDir, "viewpoint-0.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"# only a\n\ntable a\n", "| [a](a.md) |", "```mermaid\nerDiagram"} {
+		if !strings.Contains(string(actual), expected) {
+			t.Errorf("actual %v\nwant %v", string(actual), expected)
+		}
+	}
+	if strings.Contains(string(actual), "[b](b.md)") {
+		t.Errorf("actual %v\nwant %v", string(actual), "no table b")
+	}
+	diff, err := Diff(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Errorf("actual %v\nwant %v", diff, "")
+	}
+}
+
+func TestOutputWithWiki(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	err := os.MkdirAll(filepath.Join(tempDir, "uploads"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(tempDir, "uploads", "schema.svg"), []byte("<svg></svg>"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "svg"
+	c.Format.Wiki = "gitlab"
+	err = Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "README.md")); err == nil {
+		t.Errorf("actual %v\nwant %v", "README.md", "home.md only")
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "home.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"| [a](a) |", "![er](uploads/schema.svg)"} {
+		if !strings.Contains(string(index), expected) {
+			t.Errorf("actual %v\nwant %v", string(index), expected)
+		}
+	}
+	sidebar, err := ioutil.ReadFile(filepath.Join(tempDir, "_sidebar.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "### [testschema](home)\n\n**Tables**\n\n- [a](a)\n- [b](b)\n"
+	if string(sidebar) != expected {
+		t.Errorf("actual %v\nwant %v", string(sidebar), expected)
+	}
+	diff, err := Diff(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Errorf("actual %v\nwant %v", diff, "")
+	}
+	err = ioutil.WriteFile(filepath.Join(tempDir, "_sidebar.md"), []byte("stale"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileDiffs, err := DiffFiles(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileDiffs) != 1 || fileDiffs[0].Target != "[sidebar]" {
+		t.Errorf("actual %v\nwant %v", fileDiffs, "[sidebar]")
+	}
+}
+
+func TestOutputWithTemplates(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	indexPath := filepath.Join(tempDir, "index.tmpl")
+	err := ioutil.WriteFile(indexPath, []byte("# Custom {{ .Schema.Name }}\n{{ range .Schema.Tables }}- {{ .Name }}\n{{ end }}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := config.New()
+	c.DocPath = tempDir
+	c.Templates.MD.Index = indexPath
+	err = Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Custom testschema\n- a\n- b\n"
+	if string(actual) != expected {
+		t.Errorf("actual %v\nwant %v", string(actual), expected)
+	}
+}
+
+func TestOutputWithIndexPages(t *testing.T) {
+	s := newTestSchema()
+	s.Tables = append(s.Tables, &schema.Table{Name: "apples", Comment: "table apples", Columns: []*schema.Column{}})
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	c.Format.IndexPages = config.IndexPages{Threshold: 2}
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ioutil.ReadFile(filepath.Join(tempDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "## Tables\n\n| Name | Tables |\n| ---- | ------ |\n| [A](tables-a.md) | 2 |\n| [B](tables-b.md) | 1 |\n"
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("actual %v\nwant %v", string(index), expected)
+	}
+	page, err := ioutil.ReadFile(filepath.Join(tempDir, "tables-a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"# A\n\n[testschema](README.md)\n", "| [a](a.md) | 2 | table a |  |", "| [apples](apples.md) | 0 | table apples |  |"} {
+		if !strings.Contains(string(page), expected) {
+			t.Errorf("actual %v\nwant %v", string(page), expected)
+		}
+	}
+	diff, err := Diff(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Errorf("actual %v\nwant %v", diff, "")
+	}
+
+	c.Format.IndexPages.Threshold = 3
+	if pages := makeIndexPages(s, c); pages != nil {
+		t.Errorf("actual %v\nwant %v", len(pages), nil)
+	}
+}
+
+func TestOutputWithSampleRows(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	ta.SampleRows = &schema.SampleRows{
+		Columns: []string{"a", "a2"},
+		Rows: [][]string{
+			{"1", "2019-05-01 12:00:00"},
+			{"2", "line1\nline2 | x"},
+		},
+	}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "## Sample Rows\n\n| a | a2 |\n| --- | --- |\n| 1 | 2019-05-01 12:00:00 |\n| 2 | line1<br>line2 \\| x |\n\n"
+	if !strings.Contains(string(got), expected) {
+		t.Errorf("actual %v\nwant %v", string(got), expected)
+	}
+	got, err = ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "## Sample Rows") {
+		t.Errorf("actual %v\nwant %v", string(got), "no sample rows")
+	}
+}
+
+func TestOutputWithRowCounts(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	ta.RowCount = &schema.RowCount{Count: 12345, Estimated: true}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"README.md", "| Name | Columns | Comment | Type | Rows |\n| ---- | ------- | ------- | ---- | ---- |\n| [a](a.md) | 2 | table a |  | ~12,345 |\n| [b](b.md) | 2 | table b |  |  |\n"},
+		{"a.md", "table a\n\nRows: ~12,345\n"},
+	}
+	for _, tt := range tests {
+		got, err := ioutil.ReadFile(filepath.Join(tempDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), tt.want) {
+			t.Errorf("%s: actual %v\nwant %v", tt.file, string(got), tt.want)
+		}
+	}
+	got, _ := ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if strings.Contains(string(got), "Rows:") {
+		t.Errorf("actual %v\nwant %v", string(got), "no row count")
+	}
+}
+
+func TestOutputWithColumnStats(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	distinct := int64(2)
+	min, max := "1", "a|b"
+	ta.Columns[0].Stats = &schema.ColumnStats{Rows: 4, Nulls: 1, Distinct: &distinct, Min: &min, Max: &max}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Name | Type | Default | Nullable | Children | Parents | Comment | Nulls | Distinct | Min | Max |",
+		"| column a | 25.0% | 2 | 1 | a\\|b |",
+		"| column a2 |  |  |  |  |",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("actual %v\nwant %v", string(got), want)
+		}
+	}
+	got, _ = ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if strings.Contains(string(got), "Distinct") {
+		t.Errorf("actual %v\nwant %v", string(got), "no statistics")
+	}
+}
+
+func TestOutputWithSensitivity(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	ta.Columns[0].Sensitivity = &schema.Sensitivity{Label: "PII", Detectors: []string{"email", "phone"}}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Name | Type | Default | Nullable | Children | Parents | Comment | Sensitivity |",
+		"| column a | PII (email, phone) |",
+		"| column a2 |  |",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("actual %v\nwant %v", string(got), want)
+		}
+	}
+	got, _ = ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if strings.Contains(string(got), "Sensitivity") {
+		t.Errorf("actual %v\nwant %v", string(got), "no sensitivity")
+	}
+}
+
+func TestOutputWithColumnLabels(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	ta.Columns[0].Labels = []*schema.Label{{Name: "pii"}, {Name: "deprecated"}}
+	ta.Columns[0].Metadata = map[string]string{"owner": "billing", "source": "crm"}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Name | Type | Default | Nullable | Children | Parents | Comment | Labels |",
+		"| column a | `pii` `deprecated`<br>owner: billing<br>source: crm |",
+		"| column a2 |  |",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("actual %v\nwant %v", string(got), want)
+		}
+	}
+	got, _ = ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if strings.Contains(string(got), "Labels") {
+		t.Errorf("actual %v\nwant %v", string(got), "no labels")
+	}
+}
+
+func TestOutputWithEnumValues(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	ta.Columns[0].EnumValues = []*schema.EnumValue{{Value: "paid", Count: 2}, {Value: "a|b", Count: 1}}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Name | Type | Default | Nullable | Children | Parents | Comment | Values |",
+		"| column a | paid (2)<br>a\\|b (1) |",
+		"| column a2 |  |",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("actual %v\nwant %v", string(got), want)
+		}
+	}
+	got, _ = ioutil.ReadFile(filepath.Join(tempDir, "b.md"))
+	if strings.Contains(string(got), "Values") {
+		t.Errorf("actual %v\nwant %v", string(got), "no values")
+	}
+}
+
+func TestOutputWithStorage(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	tb, _ := s.FindTableByName("b")
+	toast := int64(0)
+	ta.Storage = &schema.Storage{Table: 8192, Indexes: 16384, Toast: &toast}
+	tb.Storage = &schema.Storage{Table: 3 * 1024 * 1024, Indexes: 512}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	c.Storage = config.Storage{Enabled: true, Top: 1}
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file string
+		want []string
+		not  []string
+	}{
+		{"README.md", []string{"## Largest Tables", "| [b](b.md) | 3.0 MiB | 3.0 MiB | 512 B |  |"}, []string{"[a](a.md) | 24.0 KiB"}},
+		{"a.md", []string{"## Storage", "| Table | Indexes | TOAST | Total |", "| 8.0 KiB | 16.0 KiB | 0 B | 24.0 KiB |"}, nil},
+		{"b.md", []string{"| Table | Indexes | Total |", "| 3.0 MiB | 512 B | 3.0 MiB |"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := ioutil.ReadFile(filepath.Join(tempDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(got), want) {
+				t.Errorf("%s: actual %v\nwant %v", tt.file, string(got), want)
+			}
+		}
+		for _, not := range tt.not {
+			if strings.Contains(string(got), not) {
+				t.Errorf("%s: actual %v\nwant without %v", tt.file, string(got), not)
+			}
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536 * 1024, "1.5 MiB"},
+		{5 * 1024 * 1024 * 1024 * 1024 * 1024, "5120.0 TiB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.in); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestOutputWithFreshness(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	tb, _ := s.FindTableByName("b")
+	last := "2024-01-02 03:04:05"
+	ta.Freshness = &schema.Freshness{Column: "updated_at", LastWritten: &last}
+	tb.Freshness = &schema.Freshness{Column: "created_at"}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"README.md", []string{"| Name | Columns | Comment | Type | Last written |", "| table a |  | 2024-01-02 03:04:05 |", "| table b |  | - |"}},
+		{"a.md", []string{"Last written: 2024-01-02 03:04:05 (`updated_at`)"}},
+		{"b.md", []string{"Last written: - (`created_at`)"}},
+	}
+	for _, tt := range tests {
+		got, err := ioutil.ReadFile(filepath.Join(tempDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(got), want) {
+				t.Errorf("%s: actual %v\nwant %v", tt.file, string(got), want)
+			}
+		}
+	}
+}
+
+func TestOutputWithPercentiles(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	ta.Columns[0].Stats = &schema.ColumnStats{Rows: 4, Percentiles: []*schema.Percentile{{P: 50, Value: "2"}, {P: 90, Value: "4"}}}
+	ta.Columns[1].Stats = &schema.ColumnStats{Rows: 4}
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	c.ER.Format = "mermaid"
+	err := Output(s, c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Nulls | Distinct | Min | Max | Percentiles |",
+		"| p50: 2<br>p90: 4 |",
+		"| column a2 | 0.0% |  |  |  |  |",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("actual %v\nwant %v", string(got), want)
+		}
+	}
+}
+
+func TestMakeIndexPages(t *testing.T) {
+	s := &schema.Schema{
+		Tables: []*schema.Table{
+			&schema.Table{Name: "users", Labels: []*schema.Label{&schema.Label{Name: "core"}}},
+			&schema.Table{Name: "billing.invoices", Labels: []*schema.Label{&schema.Label{Name: "core"}, &schema.Label{Name: "billing"}}},
+			&schema.Table{Name: "_logs"},
+		},
+	}
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{"", []string{"B:tables-b:1", "U:tables-u:1", "other:tables-other:1"}},
+		{"schema", []string{"billing:tables-billing:1", "default:tables-default:2"}},
+		{"label", []string{"billing:tables-billing:1", "core:tables-core:2", "unlabeled:tables-unlabeled:1"}},
+	}
+	for _, tt := range tests {
+		c := config.New()
+		c.Format.IndexPages = config.IndexPages{Threshold: 1, By: tt.by}
+		got := []string{}
+		for _, p := range makeIndexPages(s, c) {
+			got = append(got, fmt.Sprintf("%s:%s:%d", p.Name, p.File, len(p.Tables)))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: actual %v\nwant %v", tt.by, got, tt.want)
+		}
+	}
+}
+
+func TestOutputTables(t *testing.T) {
+	s := newTestSchema()
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	c := config.New()
+	c.DocPath = tempDir
+	err := OutputTables(s, c, []string{"b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		file string
+		want bool
+	}{
+		{"README.md", true},
+		{"a.md", false},
+		{"b.md", true},
+	} {
+		_, err := os.Lstat(filepath.Join(tempDir, tt.file))
+		if got := err == nil; got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.file, got, tt.want)
+		}
+	}
+}
+
 func testdataDir() string {
 	wd, _ := os.Getwd()
 	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
@@ -0,0 +1,84 @@
+package md
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/profile"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// OutputProfile output the data profiling report of `tbls profile`
+func OutputProfile(wr io.Writer, s *schema.Schema, reports []*profile.TableReport, c *config.Config) error {
+	box := packr.NewBox("./templates")
+	tmpl, err := parseTemplate(box, "profile.md.tmpl", c.Templates.MD.Profile, c)
+	if err != nil {
+		return err
+	}
+	tables := []map[string]interface{}{}
+	for _, r := range reports {
+		columnsData := makeProfileColumnsData(r, c)
+		if c.Format.Adjust {
+			columnsData = adjustTable(columnsData)
+		}
+		tables = append(tables, map[string]interface{}{
+			"Table":   r.Table,
+			"Rows":    r.Rows,
+			"Columns": columnsData,
+		})
+	}
+	if err := tmpl.Execute(wr, map[string]interface{}{
+		"Schema": s,
+		"Tables": tables,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// makeProfileColumnsData return the table of the data profile of columns with the enabled metrics
+func makeProfileColumnsData(r *profile.TableReport, c *config.Config) [][]string {
+	p := c.Profile
+	header := []string{c.Dict.Lookup("Name"), c.Dict.Lookup("Type")}
+	if p.Enabled(config.ProfileNulls) {
+		header = append(header, c.Dict.Lookup("Nulls"))
+	}
+	if p.Enabled(config.ProfileCardinality) {
+		header = append(header, c.Dict.Lookup("Cardinality"))
+	}
+	if p.Enabled(config.ProfileTopK) {
+		header = append(header, c.Dict.Lookup("Top values"))
+	}
+	separator := []string{}
+	for range header {
+		separator = append(separator, "---")
+	}
+	data := [][]string{header, separator}
+	e := strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ", "\r", " ")
+	for _, cr := range r.Columns {
+		row := []string{cr.Column.Name, cr.Column.Type}
+		if p.Enabled(config.ProfileNulls) {
+			row = append(row, r.NullRatio(cr))
+		}
+		if p.Enabled(config.ProfileCardinality) {
+			cardinality := ""
+			if cr.Cardinality != nil {
+				cardinality = fmt.Sprintf("%d", *cr.Cardinality)
+			}
+			row = append(row, cardinality)
+		}
+		if p.Enabled(config.ProfileTopK) {
+			values := []string{}
+			for _, v := range cr.TopValues {
+				values = append(values, fmt.Sprintf("%s (%d)", e.Replace(v.Value), v.Count))
+			}
+			row = append(row, strings.Join(values, "<br>"))
+		}
+		data = append(data, row)
+	}
+	return data
+}
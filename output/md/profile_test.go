@@ -0,0 +1,61 @@
+package md
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/profile"
+)
+
+func TestOutputProfile(t *testing.T) {
+	s := newTestSchema()
+	ta, _ := s.FindTableByName("a")
+	nulls, cardinality := int64(1), int64(2)
+	reports := []*profile.TableReport{
+		{
+			Table: ta,
+			Rows:  4,
+			Columns: []*profile.ColumnReport{
+				{Column: ta.Columns[0], Nulls: &nulls, Cardinality: &cardinality, TopValues: []*profile.ValueCount{{Value: "x|y", Count: 2}, {Value: "z", Count: 1}}},
+				{Column: ta.Columns[1], TopValues: []*profile.ValueCount{}},
+			},
+		},
+	}
+	tests := []struct {
+		metrics []string
+		want    []string
+	}{
+		{
+			nil,
+			[]string{
+				"## a\n\nRows scanned: 4\n\n",
+				"| Name | Type | Nulls | Cardinality | Top values |",
+				"| a |  | 25.0% | 2 | x\\|y (2)<br>z (1) |",
+				"| a2 |  |  |  |  |",
+			},
+		},
+		{
+			[]string{config.ProfileNulls},
+			[]string{
+				"| Name | Type | Nulls |",
+				"| a |  | 25.0% |",
+			},
+		},
+	}
+	for _, tt := range tests {
+		c := config.New()
+		c.Format.Adjust = false
+		c.Profile.Metrics = tt.metrics
+		buf := &bytes.Buffer{}
+		if err := OutputProfile(buf, s, reports, c); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("actual %v\nwant %v", buf.String(), want)
+			}
+		}
+	}
+}
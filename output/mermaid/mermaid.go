@@ -0,0 +1,134 @@
+package mermaid
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+var reAttr = regexp.MustCompile(`[^A-Za-z0-9_\-\[\]]`)
+
+// Mermaid struct
+type Mermaid struct {
+	config *config.Config
+}
+
+// New return Mermaid
+func New(c *config.Config) *Mermaid {
+	return &Mermaid{
+		config: c,
+	}
+}
+
+// OutputSchema output Mermaid format for full relation.
+func (m *Mermaid) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	if er := m.config.ER.SchemaDiagramER(s); er.Columns != m.config.ER.Columns {
+		// oversized diagram is rendered with reduced columns
+		c := *m.config
+		c.ER = er
+		return New(&c).OutputSchema(wr, s)
+	}
+	box := packr.NewBox("./templates")
+	ts, _ := box.FindString("schema.mmd.tmpl")
+	tmpl := template.Must(template.New(s.Name).Funcs(m.funcMap()).Parse(ts))
+	err := tmpl.Execute(wr, map[string]interface{}{
+		"Schema": m.config.ER.SchemaDiagram(s),
+		"ER":     m.config.ER,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// OutputTable output Mermaid format for table.
+func (m *Mermaid) OutputTable(wr io.Writer, t *schema.Table) error {
+	tables, relations := m.config.ER.TableDiagram(t)
+
+	box := packr.NewBox("./templates")
+	ts, _ := box.FindString("table.mmd.tmpl")
+	tmpl := template.Must(template.New(t.Name).Funcs(m.funcMap()).Parse(ts))
+	err := tmpl.Execute(wr, map[string]interface{}{
+		"Table":     t,
+		"Tables":    tables,
+		"Relations": relations,
+		"ER":        m.config.ER,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (m *Mermaid) funcMap() map[string]interface{} {
+	return template.FuncMap{
+		"showColumn":    m.config.ER.ShowColumn,
+		"relationLabel": m.config.ER.RelationLabel,
+		"keyIcons": func(t *schema.Table, c *schema.Column) string {
+			keys := []string{}
+			for _, k := range m.config.ER.ColumnKeys(t, c) {
+				// Mermaid supports PK, FK and UK attribute keys only
+				if k != schema.KeyIndex {
+					keys = append(keys, k)
+				}
+			}
+			if len(keys) == 0 {
+				return ""
+			}
+			return " " + strings.Join(keys, ",")
+		},
+		"attrType": func(t string) string {
+			if t == "" {
+				return "unknown"
+			}
+			return reAttr.ReplaceAllString(t, "_")
+		},
+		"attrName": func(n string) string {
+			return reAttr.ReplaceAllString(n, "_")
+		},
+		"crowfoot": func(r *schema.Relation) string {
+			line := "--"
+			if r.IsAdditional {
+				line = ".."
+			}
+			parent := "||"
+			if r.ParentCardinality == schema.ZeroOrOne {
+				parent = "o|"
+			}
+			switch r.Cardinality {
+			case schema.ZeroOrOne:
+				return "|o" + line + parent
+			case schema.ExactlyOne:
+				return "||" + line + parent
+			case schema.OneOrMore:
+				return "}|" + line + parent
+			}
+			return "}o" + line + parent
+		},
+		"label": label,
+		"comment": func(text string) string {
+			return label(m.config.ER.NodeComment(text))
+		},
+	}
+}
+
+// label return the text escaped for Mermaid labels
+func label(text string) string {
+	r := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", `"`, "'")
+	return r.Replace(text)
+}
+
+func init() {
+	output.Register("mermaid", func(c *config.Config) output.Output {
+		return New(c)
+	})
+}
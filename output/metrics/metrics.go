@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// Target is the result of the analysis of a documentation target
+type Target struct {
+	Name        string
+	Schema      *schema.Schema
+	Up          bool
+	LastSuccess time.Time
+	Duration    time.Duration
+	// Failures is the number of failed analyses
+	Failures int
+}
+
+type metric struct {
+	name  string
+	typ   string
+	help  string
+	value func(t *Target) (float64, bool)
+}
+
+var metrics = []metric{
+	{"tbls_up", "gauge", "Whether the last analysis of the database succeeded.", func(t *Target) (float64, bool) {
+		return boolValue(t.Up), true
+	}},
+	{"tbls_analysis_failures_total", "counter", "Number of failed analyses of the database.", func(t *Target) (float64, bool) {
+		return float64(t.Failures), true
+	}},
+	{"tbls_schema_stale", "gauge", "Whether the metrics of the schema are of an earlier analysis because the last analysis failed.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		return boolValue(!t.Up), true
+	}},
+	{"tbls_last_successful_analysis_timestamp_seconds", "gauge", "Unix time of the last successful analysis.", func(t *Target) (float64, bool) {
+		if t.LastSuccess.IsZero() {
+			return 0, false
+		}
+		return float64(t.LastSuccess.UnixNano()) / 1e9, true
+	}},
+	{"tbls_analysis_duration_seconds", "gauge", "Duration of the last analysis.", func(t *Target) (float64, bool) {
+		return t.Duration.Seconds(), true
+	}},
+	{"tbls_tables", "gauge", "Number of tables.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		return float64(len(t.Schema.Tables)), true
+	}},
+	{"tbls_columns", "gauge", "Number of columns.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		total, _ := columnComments(t.Schema)
+		return float64(total), true
+	}},
+	{"tbls_foreign_keys", "gauge", "Number of foreign keys (relations not defined in additional data).", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		count := 0
+		for _, r := range t.Schema.Relations {
+			if !r.IsAdditional {
+				count++
+			}
+		}
+		return float64(count), true
+	}},
+	{"tbls_relations", "gauge", "Number of relations including additional and virtual relations.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		return float64(len(t.Schema.Relations)), true
+	}},
+	{"tbls_tables_without_primary_key", "gauge", "Number of tables (except views) without primary key.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		return float64(len((config.RequirePrimaryKey{Enabled: true}).Check(t.Schema))), true
+	}},
+	{"tbls_table_comment_coverage_ratio", "gauge", "Ratio of tables with comment.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		commented := 0
+		for _, tbl := range t.Schema.Tables {
+			if tbl.Comment != "" {
+				commented++
+			}
+		}
+		return ratio(commented, len(t.Schema.Tables)), true
+	}},
+	{"tbls_column_comment_coverage_ratio", "gauge", "Ratio of columns with comment.", func(t *Target) (float64, bool) {
+		if t.Schema == nil {
+			return 0, false
+		}
+		total, commented := columnComments(t.Schema)
+		return ratio(commented, total), true
+	}},
+}
+
+// Output write metrics of the targets in the Prometheus text exposition format.
+// Metrics of the schema are omitted for targets never analyzed successfully.
+func Output(wr io.Writer, targets []*Target) error {
+	for _, m := range metrics {
+		lines := []string{}
+		for _, t := range targets {
+			v, ok := m.value(t)
+			if !ok {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s{name=\"%s\"} %v", m.name, escapeLabel(t.Name), v))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		_, err := fmt.Fprintf(wr, "# HELP %s %s\n# TYPE %s %s\n%s\n", m.name, m.help, m.name, m.typ, strings.Join(lines, "\n"))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// columnComments return the number of columns and columns with comment, except hidden columns
+func columnComments(s *schema.Schema) (int, int) {
+	total := 0
+	commented := 0
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			if c.Hidden {
+				continue
+			}
+			total++
+			if c.Comment != "" {
+				commented++
+			}
+		}
+	}
+	return total, commented
+}
+
+func ratio(n int, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(n) / float64(total)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelReplacer.Replace(v)
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestOutput(t *testing.T) {
+	targets := []*Target{
+		&Target{
+			Name:        "testdb",
+			Schema:      newTestSchema(),
+			Up:          true,
+			LastSuccess: time.Unix(1600000000, 0),
+			Duration:    1500 * time.Millisecond,
+		},
+		&Target{
+			Name:     `broken "db"`,
+			Failures: 2,
+		},
+		&Target{
+			Name:        "stale",
+			Schema:      newTestSchema(),
+			LastSuccess: time.Unix(1600000000, 0),
+			Failures:    1,
+		},
+	}
+	buf := &bytes.Buffer{}
+	err := Output(buf, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# HELP tbls_up Whether the last analysis of the database succeeded.\n# TYPE tbls_up gauge\ntbls_up{name=\"testdb\"} 1\ntbls_up{name=\"broken \\\"db\\\"\"} 0\n",
+		"# TYPE tbls_analysis_failures_total counter\ntbls_analysis_failures_total{name=\"testdb\"} 0\ntbls_analysis_failures_total{name=\"broken \\\"db\\\"\"} 2\ntbls_analysis_failures_total{name=\"stale\"} 1\n",
+		"tbls_schema_stale{name=\"testdb\"} 0\ntbls_schema_stale{name=\"stale\"} 1\n",
+		`tbls_last_successful_analysis_timestamp_seconds{name="testdb"} 1.6e+09`,
+		`tbls_analysis_duration_seconds{name="testdb"} 1.5`,
+		`tbls_tables{name="testdb"} 2`,
+		`tbls_columns{name="testdb"} 2`,
+		`tbls_foreign_keys{name="testdb"} 1`,
+		`tbls_relations{name="testdb"} 1`,
+		`tbls_tables_without_primary_key{name="testdb"} 1`,
+		`tbls_table_comment_coverage_ratio{name="testdb"} 0.5`,
+		`tbls_column_comment_coverage_ratio{name="testdb"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("actual %v\nwant %v", got, want)
+		}
+	}
+	if strings.Contains(got, `tbls_tables{name="broken`) {
+		t.Errorf("actual %v\nwant %v", got, "no schema metrics of broken db")
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Type:    "bigint",
+		Comment: "column b",
+	}
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{ca},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Columns: []*schema.Column{cb},
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{Name: "b_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (b)"},
+		},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "testschema",
+		Tables:    []*schema.Table{ta, tb},
+		Relations: []*schema.Relation{r},
+	}
+}
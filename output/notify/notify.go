@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// client is the HTTP client for webhooks
+var client = http.DefaultClient
+
+// Notify post the summary of schema drift to the configured Slack and Teams webhooks
+func Notify(c *config.Config, name string, d *schema.Drift) error {
+	if c.Notify.Slack.WebhookURL != "" {
+		err := post(c.Notify.Slack.WebhookURL, Message(name, d, "*%s*", RunURL()))
+		if err != nil {
+			return errors.Wrap(err, "failed to notify Slack")
+		}
+	}
+	if c.Notify.Teams.WebhookURL != "" {
+		err := post(c.Notify.Teams.WebhookURL, Message(name, d, "**%s**", RunURL()))
+		if err != nil {
+			return errors.Wrap(err, "failed to notify Teams")
+		}
+	}
+	return nil
+}
+
+// Message return the summary of schema drift. bold is the format to emphasize text in the markup of the service.
+func Message(name string, d *schema.Drift, bold string, runURL string) string {
+	lines := []string{fmt.Sprintf("Schema drift detected in %s", fmt.Sprintf(bold, name))}
+	for _, l := range []struct {
+		label  string
+		tables []string
+	}{
+		{"Added", d.Added},
+		{"Changed", d.Changed},
+		{"Dropped", d.Dropped},
+	} {
+		if len(l.tables) > 0 {
+			lines = append(lines, fmt.Sprintf("%s (%d): %s", l.label, len(l.tables), strings.Join(l.tables, ", ")))
+		}
+	}
+	if runURL != "" {
+		lines = append(lines, runURL)
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// RunURL return the URL of the CI run from environment variables of GitHub Actions, GitLab CI, CircleCI or Jenkins
+func RunURL() string {
+	if id := os.Getenv("GITHUB_RUN_ID"); id != "" {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, os.Getenv("GITHUB_REPOSITORY"), id)
+	}
+	for _, env := range []string{"CI_JOB_URL", "CIRCLE_BUILD_URL", "BUILD_URL"} {
+		if u := os.Getenv(env); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// Payload is the JSON payload posted to custom webhooks
+type Payload struct {
+	Command string               `json:"command"`
+	Name    string               `json:"name"`
+	RunURL  string               `json:"run_url,omitempty"`
+	Added   []string             `json:"added"`
+	Changed []*schema.TableDrift `json:"changed"`
+	Dropped []string             `json:"dropped"`
+}
+
+// NewPayload return the payload of schema drift detected by the command (diff or doc) with the differences of changed tables
+func NewPayload(command string, name string, d *schema.Drift, changed []*schema.TableDrift) *Payload {
+	return &Payload{
+		Command: command,
+		Name:    name,
+		RunURL:  RunURL(),
+		Added:   d.Added,
+		Changed: changed,
+		Dropped: d.Dropped,
+	}
+}
+
+// Webhooks post the JSON payload to the configured custom webhooks
+func Webhooks(c *config.Config, p *Payload) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, w := range c.Notify.Webhooks {
+		u, err := config.ResolveDSN(w.URL)
+		if err != nil {
+			return err
+		}
+		headers := map[string]string{}
+		for k, v := range w.Headers {
+			hv, err := config.ResolveDSN(v)
+			if err != nil {
+				return err
+			}
+			headers[k] = hv
+		}
+		err = postJSON(u, headers, b)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to notify webhooks[%d]", i))
+		}
+	}
+	return nil
+}
+
+func post(webhookURL string, text string) error {
+	u, err := config.ResolveDSN(webhookURL)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return postJSON(u, nil, b)
+}
+
+func postJSON(u string, headers map[string]string, b []byte) error {
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.WithStack(fmt.Errorf("webhook error: %s: %s", resp.Status, strings.TrimSpace(string(body))))
+	}
+	return nil
+}
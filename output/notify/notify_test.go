@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestMessage(t *testing.T) {
+	d := &schema.Drift{
+		Added:   []string{"comments"},
+		Changed: []string{"users", "posts"},
+		Dropped: []string{},
+	}
+	got := Message("testdb", d, "*%s*", "https://ci.example.com/runs/1")
+	want := "Schema drift detected in *testdb*\n\nAdded (1): comments\n\nChanged (2): users, posts\n\nhttps://ci.example.com/runs/1"
+	if got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestRunURL(t *testing.T) {
+	for _, env := range []string{"GITHUB_RUN_ID", "GITHUB_SERVER_URL", "GITHUB_REPOSITORY", "CI_JOB_URL", "CIRCLE_BUILD_URL", "BUILD_URL"} {
+		if v, ok := os.LookupEnv(env); ok {
+			defer os.Setenv(env, v)
+			os.Unsetenv(env)
+		}
+	}
+	if got := RunURL(); got != "" {
+		t.Errorf("actual %v\nwant %v", got, "")
+	}
+	os.Setenv("CI_JOB_URL", "https://gitlab.example.com/group/project/-/jobs/1")
+	defer os.Unsetenv("CI_JOB_URL")
+	if got, want := RunURL(), "https://gitlab.example.com/group/project/-/jobs/1"; got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+	os.Setenv("GITHUB_RUN_ID", "123")
+	os.Setenv("GITHUB_REPOSITORY", "k1LoW/tbls")
+	defer os.Unsetenv("GITHUB_RUN_ID")
+	defer os.Unsetenv("GITHUB_REPOSITORY")
+	if got, want := RunURL(), "https://github.com/k1LoW/tbls/actions/runs/123"; got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	received := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&v)
+		received[r.URL.Path] = v["text"]
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("TBLS_TEST_SLACK_WEBHOOK_URL", ts.URL+"/slack")
+	defer os.Unsetenv("TBLS_TEST_SLACK_WEBHOOK_URL")
+	d := &schema.Drift{Dropped: []string{"posts"}}
+
+	c := config.New()
+	c.Notify.Slack.WebhookURL = "env://TBLS_TEST_SLACK_WEBHOOK_URL"
+	c.Notify.Teams.WebhookURL = ts.URL + "/teams"
+	err := Notify(c, "testdb", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*testdb*"; !strings.Contains(received["/slack"], want) {
+		t.Errorf("actual %v\nwant %v", received["/slack"], want)
+	}
+	if want := "**testdb**"; !strings.Contains(received["/teams"], want) {
+		t.Errorf("actual %v\nwant %v", received["/teams"], want)
+	}
+
+	c.Notify.Teams.WebhookURL = ts.URL + "/error"
+	err = Notify(c, "testdb", d)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if want := "failed to notify Teams"; !strings.Contains(err.Error(), want) {
+		t.Errorf("actual %v\nwant %v", err.Error(), want)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var received *Payload
+	auth := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = &Payload{}
+		_ = json.NewDecoder(r.Body).Decode(received)
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("TBLS_TEST_WEBHOOK_TOKEN", "Bearer secret")
+	defer os.Unsetenv("TBLS_TEST_WEBHOOK_TOKEN")
+	d := &schema.Drift{Added: []string{"comments"}, Changed: []string{"users"}, Dropped: []string{}}
+	changed := []*schema.TableDrift{
+		&schema.TableDrift{
+			Name:       "users",
+			Attributes: []string{},
+			Columns:    &schema.Drift{Added: []string{"email"}, Changed: []string{}, Dropped: []string{}},
+		},
+	}
+
+	c := config.New()
+	c.Notify.Webhooks = []config.CustomWebhook{
+		config.CustomWebhook{URL: ts.URL + "/hook", Headers: map[string]string{"Authorization": "env://TBLS_TEST_WEBHOOK_TOKEN"}},
+	}
+	err := Webhooks(c, NewPayload("diff", "testdb", d, changed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("actual %v\nwant %v", auth, "Bearer secret")
+	}
+	if received.Command != "diff" || received.Name != "testdb" || received.Added[0] != "comments" {
+		t.Errorf("actual %v\nwant %v", received, "diff testdb [comments]")
+	}
+	if got := received.Changed[0].Columns.Added; len(got) != 1 || got[0] != "email" {
+		t.Errorf("actual %v\nwant %v", got, []string{"email"})
+	}
+
+	c.Notify.Webhooks = append(c.Notify.Webhooks, config.CustomWebhook{URL: ts.URL + "/error"})
+	err = Webhooks(c, NewPayload("doc", "testdb", d, changed))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if want := "failed to notify webhooks[1]"; !strings.Contains(err.Error(), want) {
+		t.Errorf("actual %v\nwant %v", err.Error(), want)
+	}
+}
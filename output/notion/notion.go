@@ -0,0 +1,308 @@
+package notion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// DefaultURL is the default base URL of Notion API
+const DefaultURL = "https://api.notion.com"
+
+// apiVersion is the Notion-Version header value
+const apiVersion = "2022-06-28"
+
+// maxBlocks is the maximum number of blocks in a request
+const maxBlocks = 100
+
+// maxTextLength is the maximum length of a text content
+const maxTextLength = 2000
+
+// Notion publish documents to Notion pages
+type Notion struct {
+	config *config.Config
+	client *http.Client
+	token  string
+}
+
+// New return Notion. The token can be a DSN reference such as env://NOTION_TOKEN.
+func New(c *config.Config) (*Notion, error) {
+	token, err := config.ResolveDSN(c.Publish.Notion.Token)
+	if err != nil {
+		return nil, err
+	}
+	return &Notion{
+		config: c,
+		client: http.DefaultClient,
+		token:  token,
+	}, nil
+}
+
+type block map[string]interface{}
+
+// Publish create the page of the schema under the parent page, and a child page per table with the properties table of columns.
+// The page of the schema previously published is archived and replaced.
+func (n *Notion) Publish(s *schema.Schema) error {
+	d := n.config.Dict
+	title := s.Name
+	if n.config.Title != "" {
+		title = n.config.Title
+	}
+	parentID := n.config.Publish.Notion.ParentID
+
+	existing, err := n.findChildPage(parentID, title)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		err := n.do(http.MethodPatch, fmt.Sprintf("/v1/pages/%s", existing), map[string]interface{}{"archived": true}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows := [][]string{[]string{d.Lookup("Name"), d.Lookup("Columns"), d.Lookup("Comment"), d.Lookup("Type")}}
+	for _, t := range s.Tables {
+		rows = append(rows, []string{t.Name, fmt.Sprintf("%d", columnCount(t)), t.Comment, t.Type})
+	}
+	blocks := []block{heading(d.Lookup("Tables"))}
+	blocks = append(blocks, tables(rows)...)
+	indexID, err := n.createPage(parentID, title, blocks)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", title)
+
+	for _, t := range s.Tables {
+		blocks := []block{}
+		if t.Comment != "" {
+			blocks = append(blocks, paragraph(t.Comment))
+		}
+		rows := [][]string{[]string{d.Lookup("Name"), d.Lookup("Type"), d.Lookup("Default"), d.Lookup("Nullable"), d.Lookup("Parents"), d.Lookup("Comment")}}
+		for _, c := range t.Columns {
+			if c.Hidden {
+				continue
+			}
+			def := ""
+			if c.Default != nil {
+				def = *c.Default
+			}
+			parents := []string{}
+			for _, r := range c.ParentRelations {
+				parents = append(parents, r.ParentTable.Name)
+			}
+			rows = append(rows, []string{c.Name, c.Type, def, fmt.Sprintf("%v", c.Nullable), strings.Join(parents, " "), c.Comment})
+		}
+		blocks = append(blocks, heading(d.Lookup("Columns")))
+		blocks = append(blocks, tables(rows)...)
+		if len(t.Constraints) > 0 {
+			rows := [][]string{[]string{d.Lookup("Name"), d.Lookup("Type"), d.Lookup("Definition")}}
+			for _, c := range t.Constraints {
+				rows = append(rows, []string{c.Name, c.Type, c.Def})
+			}
+			blocks = append(blocks, heading(d.Lookup("Constraints")))
+			blocks = append(blocks, tables(rows)...)
+		}
+		if len(t.Indexes) > 0 {
+			rows := [][]string{[]string{d.Lookup("Name"), d.Lookup("Definition")}}
+			for _, i := range t.Indexes {
+				rows = append(rows, []string{i.Name, i.Def})
+			}
+			blocks = append(blocks, heading(d.Lookup("Indexes")))
+			blocks = append(blocks, tables(rows)...)
+		}
+		_, err := n.createPage(indexID, t.Name, blocks)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s / %s\n", title, t.Name)
+	}
+	return nil
+}
+
+func columnCount(t *schema.Table) int {
+	count := 0
+	for _, c := range t.Columns {
+		if !c.Hidden {
+			count++
+		}
+	}
+	return count
+}
+
+// createPage create the page and append blocks exceeding the limit of a request, and return the page ID
+func (n *Notion) createPage(parentID string, title string, blocks []block) (string, error) {
+	first := blocks
+	if len(first) > maxBlocks {
+		first = blocks[:maxBlocks]
+	}
+	page := map[string]interface{}{
+		"parent": map[string]interface{}{"page_id": parentID},
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{"title": richText(title)},
+		},
+		"children": first,
+	}
+	res := struct {
+		ID string `json:"id"`
+	}{}
+	err := n.do(http.MethodPost, "/v1/pages", page, &res)
+	if err != nil {
+		return "", err
+	}
+	for i := maxBlocks; i < len(blocks); i += maxBlocks {
+		end := i + maxBlocks
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+		err := n.do(http.MethodPatch, fmt.Sprintf("/v1/blocks/%s/children", res.ID), map[string]interface{}{"children": blocks[i:end]}, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	return res.ID, nil
+}
+
+// findChildPage return the ID of the child page with the title. Empty if not found.
+func (n *Notion) findChildPage(parentID string, title string) (string, error) {
+	cursor := ""
+	for {
+		q := url.Values{}
+		q.Set("page_size", fmt.Sprintf("%d", maxBlocks))
+		if cursor != "" {
+			q.Set("start_cursor", cursor)
+		}
+		res := struct {
+			Results []struct {
+				ID        string `json:"id"`
+				Type      string `json:"type"`
+				ChildPage struct {
+					Title string `json:"title"`
+				} `json:"child_page"`
+			} `json:"results"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		}{}
+		err := n.do(http.MethodGet, fmt.Sprintf("/v1/blocks/%s/children?%s", parentID, q.Encode()), nil, &res)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range res.Results {
+			if b.Type == "child_page" && b.ChildPage.Title == title {
+				return b.ID, nil
+			}
+		}
+		if !res.HasMore {
+			return "", nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+func richText(text string) []interface{} {
+	r := []rune(text)
+	if len(r) > maxTextLength {
+		r = r[:maxTextLength]
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"type": "text",
+			"text": map[string]interface{}{"content": string(r)},
+		},
+	}
+}
+
+func heading(text string) block {
+	return block{
+		"type":      "heading_2",
+		"heading_2": map[string]interface{}{"rich_text": richText(text)},
+	}
+}
+
+func paragraph(text string) block {
+	return block{
+		"type":      "paragraph",
+		"paragraph": map[string]interface{}{"rich_text": richText(text)},
+	}
+}
+
+// tables return table blocks of the rows. The first row is the header, and repeated in each table when rows exceed the limit of a request.
+func tables(rows [][]string) []block {
+	blocks := []block{}
+	header := rows[0]
+	body := rows[1:]
+	for i := 0; i == 0 || i < len(body); i += maxBlocks - 1 {
+		end := i + maxBlocks - 1
+		if end > len(body) {
+			end = len(body)
+		}
+		children := []block{tableRow(header)}
+		for _, row := range body[i:end] {
+			children = append(children, tableRow(row))
+		}
+		blocks = append(blocks, block{
+			"type": "table",
+			"table": map[string]interface{}{
+				"table_width":       len(header),
+				"has_column_header": true,
+				"children":          children,
+			},
+		})
+	}
+	return blocks
+}
+
+func tableRow(row []string) block {
+	cells := []interface{}{}
+	for _, c := range row {
+		cells = append(cells, richText(c))
+	}
+	return block{
+		"type":      "table_row",
+		"table_row": map[string]interface{}{"cells": cells},
+	}
+}
+
+func (n *Notion) do(method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		body = bytes.NewReader(b)
+	}
+	base := n.config.Publish.Notion.URL
+	if base == "" {
+		base = DefaultURL
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(base, "/")+path, body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", n.token))
+	req.Header.Set("Notion-Version", apiVersion)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return errors.WithStack(fmt.Errorf("Notion API error: %s %s: %s", method, req.URL.Path, strings.TrimSpace(string(b))))
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.WithStack(json.NewDecoder(resp.Body).Decode(out))
+}
@@ -0,0 +1,189 @@
+package notion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+type fakePage struct {
+	ID       string
+	ParentID string
+	Title    string
+	Blocks   []map[string]interface{}
+	Archived bool
+}
+
+type fakeNotion struct {
+	mu    sync.Mutex
+	pages []*fakePage
+}
+
+func (f *fakeNotion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/pages":
+		req := struct {
+			Parent struct {
+				PageID string `json:"page_id"`
+			} `json:"parent"`
+			Properties struct {
+				Title struct {
+					Title []struct {
+						Text struct {
+							Content string `json:"content"`
+						} `json:"text"`
+					} `json:"title"`
+				} `json:"title"`
+			} `json:"properties"`
+			Children []map[string]interface{} `json:"children"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Children) > maxBlocks {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		p := &fakePage{
+			ID:       fmt.Sprintf("page-%d", len(f.pages)+1),
+			ParentID: req.Parent.PageID,
+			Title:    req.Properties.Title.Title[0].Text.Content,
+			Blocks:   req.Children,
+		}
+		f.pages = append(f.pages, p)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": p.ID})
+	case r.Method == http.MethodPatch && parts[1] == "pages":
+		for _, p := range f.pages {
+			if p.ID == parts[2] {
+				p.Archived = true
+			}
+		}
+	case r.Method == http.MethodGet && parts[1] == "blocks":
+		results := []map[string]interface{}{}
+		for _, p := range f.pages {
+			if p.ParentID == parts[2] && !p.Archived {
+				results = append(results, map[string]interface{}{
+					"id":         p.ID,
+					"type":       "child_page",
+					"child_page": map[string]string{"title": p.Title},
+				})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"results": results, "has_more": false})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	f := &fakeNotion{}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c := config.New()
+	c.Publish.Notion = config.Notion{URL: ts.URL, ParentID: "root", Token: "secret"}
+	n, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestSchema()
+	err = n.Publish(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.pages) != 3 {
+		t.Fatalf("actual %v\nwant %v", len(f.pages), 3)
+	}
+	index := f.pages[0]
+	if index.ParentID != "root" || index.Title != "testschema" {
+		t.Errorf("actual %v %v\nwant %v %v", index.ParentID, index.Title, "root", "testschema")
+	}
+	table := f.pages[1]
+	if table.ParentID != index.ID || table.Title != "a" {
+		t.Errorf("actual %v %v\nwant %v %v", table.ParentID, table.Title, index.ID, "a")
+	}
+	b, _ := json.Marshal(table.Blocks)
+	for _, want := range []string{`"content":"table a"`, `"content":"column a"`, `"table_width":6`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("actual %v\nwant %v", string(b), want)
+		}
+	}
+
+	// the page previously published is replaced
+	err = n.Publish(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.pages[0].Archived || f.pages[3].Archived {
+		t.Errorf("actual %v %v\nwant %v %v", f.pages[0].Archived, f.pages[3].Archived, true, false)
+	}
+}
+
+func TestTables(t *testing.T) {
+	rows := [][]string{[]string{"Name"}}
+	for i := 0; i < 150; i++ {
+		rows = append(rows, []string{fmt.Sprintf("c%d", i)})
+	}
+	got := tables(rows)
+	if len(got) != 2 {
+		t.Fatalf("actual %v\nwant %v", len(got), 2)
+	}
+	for i, want := range []int{100, 52} {
+		children := got[i]["table"].(map[string]interface{})["children"].([]block)
+		if len(children) != want {
+			t.Errorf("actual %v\nwant %v", len(children), want)
+		}
+	}
+	if got := tables([][]string{[]string{"Name"}}); len(got) != 1 {
+		t.Errorf("actual %v\nwant %v", len(got), 1)
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Type:    "bigint",
+		Comment: "column b",
+	}
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{ca},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Comment: "table b",
+		Columns: []*schema.Column{cb},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "testschema",
+		Tables:    []*schema.Table{ta, tb},
+		Relations: []*schema.Relation{r},
+	}
+}
@@ -0,0 +1,197 @@
+package openlineage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/version"
+	"github.com/pkg/errors"
+	"github.com/xo/dburl"
+)
+
+// DefaultEndpoint is the default path of the lineage API (Marquez)
+const DefaultEndpoint = "api/v1/lineage"
+
+const (
+	datasetEventSchemaURL = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/DatasetEvent"
+	schemaFacetURL        = "https://openlineage.io/spec/facets/1-1-1/SchemaDatasetFacet.json#/$defs/SchemaDatasetFacet"
+	documentationFacetURL = "https://openlineage.io/spec/facets/1-0-1/DocumentationDatasetFacet.json#/$defs/DocumentationDatasetFacet"
+)
+
+// namespaceSchemes is the OpenLineage namespace schemes of drivers
+var namespaceSchemes = map[string]string{
+	"postgres": "postgres",
+	"mysql":    "mysql",
+	"sqlite3":  "sqlite",
+}
+
+// defaultPorts is the default port of the database server of the driver
+var defaultPorts = map[string]string{
+	"postgres": "5432",
+	"mysql":    "3306",
+}
+
+// OpenLineage emit dataset events of tables to an OpenLineage endpoint
+type OpenLineage struct {
+	config    *config.Config
+	client    *http.Client
+	apiKey    string
+	namespace string
+	now       func() time.Time
+}
+
+// New return OpenLineage. The API key can be a DSN reference such as env://OPENLINEAGE_API_KEY.
+// The namespace is derived from the host of DSN (e.g. postgres://db.example.com:5432) unless publish.openlineage.namespace is set.
+func New(c *config.Config) (*OpenLineage, error) {
+	o := c.Publish.OpenLineage
+	apiKey := ""
+	if o.APIKey != "" {
+		k, err := config.ResolveDSN(o.APIKey)
+		if err != nil {
+			return nil, err
+		}
+		apiKey = k
+	}
+	namespace := o.Namespace
+	if namespace == "" {
+		dsn, err := config.ResolveDSN(string(c.DSN))
+		if err != nil {
+			return nil, err
+		}
+		namespace, err = Namespace(dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &OpenLineage{
+		config:    c,
+		client:    http.DefaultClient,
+		apiKey:    apiKey,
+		namespace: namespace,
+		now:       time.Now,
+	}, nil
+}
+
+// Namespace return the OpenLineage namespace of the database of DSN without credentials
+//
+//	postgres://host:port, mysql://host:port, sqlite://path
+func Namespace(dsn string) (string, error) {
+	u, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	scheme, ok := namespaceSchemes[u.Driver]
+	if !ok {
+		return "", errors.WithStack(fmt.Errorf("unsupported driver '%s'", u.Driver))
+	}
+	if u.Driver == "sqlite3" {
+		return fmt.Sprintf("%s://%s", scheme, u.DSN), nil
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPorts[u.Driver]
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(u.Hostname(), port)), nil
+}
+
+// Publish emit a dataset event with the schema facet per table
+func (o *OpenLineage) Publish(s *schema.Schema) error {
+	for _, t := range s.Tables {
+		e := o.Event(s, t)
+		err := o.post(e)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to emit dataset event of %s", t.Name))
+		}
+		fmt.Printf("%s/%s\n", o.namespace, DatasetName(s, t))
+	}
+	return nil
+}
+
+// DatasetName return the dataset name of the table: database.schema.table (PostgreSQL) or database.table
+func DatasetName(s *schema.Schema, t *schema.Table) string {
+	if s.Driver == "postgres" && !strings.Contains(t.Name, ".") {
+		return fmt.Sprintf("%s.public.%s", s.Name, t.Name)
+	}
+	return fmt.Sprintf("%s.%s", s.Name, t.Name)
+}
+
+// Event return the dataset event of the table
+func (o *OpenLineage) Event(s *schema.Schema, t *schema.Table) map[string]interface{} {
+	producer := fmt.Sprintf("https://github.com/k1LoW/tbls/tree/v%s", version.Version)
+	fields := []map[string]interface{}{}
+	for _, c := range t.Columns {
+		if c.Hidden {
+			continue
+		}
+		f := map[string]interface{}{
+			"name": c.Name,
+			"type": c.Type,
+		}
+		if c.Comment != "" {
+			f["description"] = c.Comment
+		}
+		fields = append(fields, f)
+	}
+	facets := map[string]interface{}{
+		"schema": map[string]interface{}{
+			"_producer":  producer,
+			"_schemaURL": schemaFacetURL,
+			"fields":     fields,
+		},
+	}
+	if t.Comment != "" {
+		facets["documentation"] = map[string]interface{}{
+			"_producer":   producer,
+			"_schemaURL":  documentationFacetURL,
+			"description": t.Comment,
+		}
+	}
+	return map[string]interface{}{
+		"eventTime": o.now().UTC().Format(time.RFC3339Nano),
+		"producer":  producer,
+		"schemaURL": datasetEventSchemaURL,
+		"dataset": map[string]interface{}{
+			"namespace": o.namespace,
+			"name":      DatasetName(s, t),
+			"facets":    facets,
+		},
+	}
+}
+
+func (o *OpenLineage) post(e map[string]interface{}) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	endpoint := o.config.Publish.OpenLineage.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	u := fmt.Sprintf("%s/%s", strings.TrimSuffix(o.config.Publish.OpenLineage.URL, "/"), strings.TrimPrefix(endpoint, "/"))
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", o.apiKey))
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.WithStack(fmt.Errorf("OpenLineage API error: %s: %s", resp.Status, strings.TrimSpace(string(body))))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package openlineage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+type event struct {
+	EventTime string `json:"eventTime"`
+	SchemaURL string `json:"schemaURL"`
+	Dataset   struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+		Facets    struct {
+			Schema struct {
+				Fields []struct {
+					Name        string `json:"name"`
+					Type        string `json:"type"`
+					Description string `json:"description"`
+				} `json:"fields"`
+			} `json:"schema"`
+			Documentation *struct {
+				Description string `json:"description"`
+			} `json:"documentation"`
+		} `json:"facets"`
+	} `json:"dataset"`
+}
+
+type fakeMarquez struct {
+	mu     sync.Mutex
+	events []event
+}
+
+func (f *fakeMarquez) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost || r.URL.Path != "/api/v1/lineage" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	e := event{}
+	_ = json.NewDecoder(r.Body).Decode(&e)
+	f.events = append(f.events, e)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func TestPublish(t *testing.T) {
+	f := &fakeMarquez{}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c := config.New()
+	c.DSN = "pg://postgres:pgpass@db.example.com/testdb?sslmode=disable"
+	c.Publish.OpenLineage = config.OpenLineage{URL: ts.URL + "/", APIKey: "secret"}
+	o, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+	err = o.Publish(newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.events) != 2 {
+		t.Fatalf("actual %v\nwant %v", len(f.events), 2)
+	}
+	e := f.events[0]
+	if e.EventTime != "2020-01-02T03:04:05Z" || !strings.HasSuffix(e.SchemaURL, "DatasetEvent") {
+		t.Errorf("actual %v %v\nwant %v", e.EventTime, e.SchemaURL, "2020-01-02T03:04:05Z DatasetEvent")
+	}
+	if e.Dataset.Namespace != "postgres://db.example.com:5432" || e.Dataset.Name != "testdb.public.a" {
+		t.Errorf("actual %v %v\nwant %v %v", e.Dataset.Namespace, e.Dataset.Name, "postgres://db.example.com:5432", "testdb.public.a")
+	}
+	fields := e.Dataset.Facets.Schema.Fields
+	if len(fields) != 1 || fields[0].Name != "a" || fields[0].Type != "bigint" || fields[0].Description != "column a" {
+		t.Errorf("actual %v\nwant %v", fields, "a bigint column a")
+	}
+	if e.Dataset.Facets.Documentation == nil || e.Dataset.Facets.Documentation.Description != "table a" {
+		t.Errorf("actual %v\nwant %v", e.Dataset.Facets.Documentation, "table a")
+	}
+	if f.events[1].Dataset.Name != "testdb.other.b" || f.events[1].Dataset.Facets.Documentation != nil {
+		t.Errorf("actual %v %v\nwant %v", f.events[1].Dataset.Name, f.events[1].Dataset.Facets.Documentation, "testdb.other.b without documentation")
+	}
+
+	c.Publish.OpenLineage.APIKey = "wrong"
+	o, _ = New(c)
+	err = o.Publish(newTestSchema())
+	if err == nil || !strings.Contains(err.Error(), "OpenLineage API error: 401") {
+		t.Errorf("actual %v\nwant %v", err, "OpenLineage API error: 401")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"pg://postgres:pgpass@localhost:55432/testdb?sslmode=disable", "postgres://localhost:55432"},
+		{"my://root:mypass@db.example.com/testdb", "mysql://db.example.com:3306"},
+		{"sq:///path/to/testdb.sqlite3", "sqlite:///path/to/testdb.sqlite3"},
+	}
+	for _, tt := range tests {
+		got, err := Namespace(tt.dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	return &schema.Schema{
+		Name:   "testdb",
+		Driver: "postgres",
+		Tables: []*schema.Table{
+			&schema.Table{
+				Name:    "a",
+				Type:    "BASE TABLE",
+				Comment: "table a",
+				Columns: []*schema.Column{
+					&schema.Column{Name: "a", Type: "bigint", Comment: "column a"},
+					&schema.Column{Name: "secret", Type: "text", Hidden: true},
+				},
+			},
+			&schema.Table{
+				Name:    "other.b",
+				Type:    "BASE TABLE",
+				Columns: []*schema.Column{&schema.Column{Name: "b", Type: "text"}},
+			},
+		},
+	}
+}
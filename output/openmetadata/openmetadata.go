@@ -0,0 +1,290 @@
+package openmetadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// dataTypes is the OpenMetadata column data types of column types, matched in order
+var dataTypes = []struct {
+	re       *regexp.Regexp
+	dataType string
+}{
+	{regexp.MustCompile(`(?i)\[\]|^array`), "ARRAY"},
+	{regexp.MustCompile(`(?i)^bigint|^int8|^bigserial`), "BIGINT"},
+	{regexp.MustCompile(`(?i)^smallint|^int2|^smallserial`), "SMALLINT"},
+	{regexp.MustCompile(`(?i)^tinyint`), "TINYINT"},
+	{regexp.MustCompile(`(?i)^int|^serial|^mediumint`), "INT"},
+	{regexp.MustCompile(`(?i)^numeric`), "NUMERIC"},
+	{regexp.MustCompile(`(?i)^decimal`), "DECIMAL"},
+	{regexp.MustCompile(`(?i)^double|^float8`), "DOUBLE"},
+	{regexp.MustCompile(`(?i)^float|^real|^float4`), "FLOAT"},
+	{regexp.MustCompile(`(?i)^bool`), "BOOLEAN"},
+	{regexp.MustCompile(`(?i)^timestamp`), "TIMESTAMP"},
+	{regexp.MustCompile(`(?i)^datetime`), "DATETIME"},
+	{regexp.MustCompile(`(?i)^date`), "DATE"},
+	{regexp.MustCompile(`(?i)^time`), "TIME"},
+	{regexp.MustCompile(`(?i)^interval`), "INTERVAL"},
+	{regexp.MustCompile(`(?i)^(character varying|varchar|nvarchar)`), "VARCHAR"},
+	{regexp.MustCompile(`(?i)^(character|char|nchar|bpchar)`), "CHAR"},
+	{regexp.MustCompile(`(?i)text|clob`), "TEXT"},
+	{regexp.MustCompile(`(?i)^uuid`), "UUID"},
+	{regexp.MustCompile(`(?i)^json`), "JSON"},
+	{regexp.MustCompile(`(?i)^bytea`), "BYTEA"},
+	{regexp.MustCompile(`(?i)blob`), "BLOB"},
+	{regexp.MustCompile(`(?i)^varbinary`), "VARBINARY"},
+	{regexp.MustCompile(`(?i)^binary`), "BINARY"},
+	{regexp.MustCompile(`(?i)^enum`), "ENUM"},
+	{regexp.MustCompile(`(?i)^set`), "SET"},
+}
+
+// reDataLength is the pattern of the length of the column type
+var reDataLength = regexp.MustCompile(`\((\d+)\)`)
+
+// lengthRequired is the data types which requires dataLength
+var lengthRequired = map[string]bool{
+	"VARCHAR":   true,
+	"CHAR":      true,
+	"BINARY":    true,
+	"VARBINARY": true,
+}
+
+// OpenMetadata struct
+type OpenMetadata struct {
+	config *config.Config
+}
+
+// Metadata is the struct for the create requests of the entities of OpenMetadata API
+type Metadata struct {
+	Database        *Database         `json:"database"`
+	DatabaseSchemas []*DatabaseSchema `json:"databaseSchemas"`
+	Tables          []*Table          `json:"tables"`
+}
+
+// Database is the struct for the request to create a database
+type Database struct {
+	Name    string `json:"name"`
+	Service string `json:"service"`
+}
+
+// DatabaseSchema is the struct for the request to create a database schema
+type DatabaseSchema struct {
+	Name     string `json:"name"`
+	Database string `json:"database"`
+}
+
+// Table is the struct for the request to create a table
+type Table struct {
+	Name             string             `json:"name"`
+	Description      string             `json:"description,omitempty"`
+	TableType        string             `json:"tableType"`
+	Columns          []*Column          `json:"columns"`
+	TableConstraints []*TableConstraint `json:"tableConstraints,omitempty"`
+	DatabaseSchema   string             `json:"databaseSchema"`
+}
+
+// Column is the struct for the column of the table
+type Column struct {
+	Name            string `json:"name"`
+	DataType        string `json:"dataType"`
+	DataTypeDisplay string `json:"dataTypeDisplay"`
+	DataLength      int    `json:"dataLength,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Constraint      string `json:"constraint,omitempty"`
+}
+
+// TableConstraint is the struct for the constraint of the table
+type TableConstraint struct {
+	ConstraintType  string   `json:"constraintType"`
+	Columns         []string `json:"columns"`
+	ReferredColumns []string `json:"referredColumns,omitempty"`
+}
+
+// New return OpenMetadata
+func New(c *config.Config) *OpenMetadata {
+	return &OpenMetadata{
+		config: c,
+	}
+}
+
+// OutputSchema output the requests to create the database, the database schemas and the tables.
+func (o *OpenMetadata) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	m := &Metadata{
+		Database: &Database{
+			Name:    o.databaseName(s),
+			Service: o.service(),
+		},
+		DatabaseSchemas: []*DatabaseSchema{},
+		Tables:          []*Table{},
+	}
+	schemas := map[string]bool{}
+	for _, t := range s.Tables {
+		schemaName, _ := o.splitName(s, t.Name)
+		if !schemas[schemaName] {
+			schemas[schemaName] = true
+			m.DatabaseSchemas = append(m.DatabaseSchemas, &DatabaseSchema{
+				Name:     schemaName,
+				Database: fqn(o.service(), m.Database.Name),
+			})
+		}
+		m.Tables = append(m.Tables, o.table(s, t))
+	}
+	return encode(wr, m)
+}
+
+// OutputTable output the request to create the table.
+func (o *OpenMetadata) OutputTable(wr io.Writer, t *schema.Table) error {
+	return encode(wr, o.table(&schema.Schema{Name: o.config.Name}, t))
+}
+
+func (o *OpenMetadata) table(s *schema.Schema, t *schema.Table) *Table {
+	schemaName, name := o.splitName(s, t.Name)
+	tableType := "Regular"
+	switch {
+	case strings.Contains(strings.ToUpper(t.Type), "MATERIALIZED"):
+		tableType = "MaterializedView"
+	case strings.Contains(strings.ToUpper(t.Type), "VIEW"):
+		tableType = "View"
+	}
+	table := &Table{
+		Name:           name,
+		Description:    t.Comment,
+		TableType:      tableType,
+		Columns:        []*Column{},
+		DatabaseSchema: fqn(o.service(), o.databaseName(s), schemaName),
+	}
+	pk := []string{}
+	unique := []string{}
+	for _, c := range t.Columns {
+		if c.Hidden {
+			continue
+		}
+		dataType, length := DataType(c.Type)
+		col := &Column{
+			Name:            c.Name,
+			DataType:        dataType,
+			DataTypeDisplay: c.Type,
+			DataLength:      length,
+			Description:     c.Comment,
+			Constraint:      "NULL",
+		}
+		if !c.Nullable {
+			col.Constraint = "NOT_NULL"
+		}
+		keys := t.ColumnKeys(c)
+		if len(keys) > 0 && keys[0] == schema.KeyPrimary {
+			pk = append(pk, c.Name)
+		} else if len(keys) > 0 && keys[len(keys)-1] == schema.KeyUnique {
+			unique = append(unique, c.Name)
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	if len(pk) > 0 {
+		table.TableConstraints = append(table.TableConstraints, &TableConstraint{ConstraintType: "PRIMARY_KEY", Columns: pk})
+	}
+	for _, c := range unique {
+		table.TableConstraints = append(table.TableConstraints, &TableConstraint{ConstraintType: "UNIQUE", Columns: []string{c}})
+	}
+	seen := map[*schema.Relation]bool{}
+	for _, c := range t.Columns {
+		for _, r := range c.ParentRelations {
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			parentSchema, parentName := o.splitName(s, r.ParentTable.Name)
+			tc := &TableConstraint{ConstraintType: "FOREIGN_KEY", Columns: []string{}, ReferredColumns: []string{}}
+			for _, c := range r.Columns {
+				tc.Columns = append(tc.Columns, c.Name)
+			}
+			for _, c := range r.ParentColumns {
+				tc.ReferredColumns = append(tc.ReferredColumns, fqn(o.service(), o.databaseName(s), parentSchema, parentName, c.Name))
+			}
+			table.TableConstraints = append(table.TableConstraints, tc)
+		}
+	}
+	return table
+}
+
+// DataType return the OpenMetadata data type and the data length of the column type
+func DataType(typ string) (string, int) {
+	dataType := "UNKNOWN"
+	for _, d := range dataTypes {
+		if d.re.MatchString(typ) {
+			dataType = d.dataType
+			break
+		}
+	}
+	if !lengthRequired[dataType] {
+		return dataType, 0
+	}
+	length := 1
+	if m := reDataLength.FindStringSubmatch(typ); m != nil {
+		length, _ = strconv.Atoi(m[1])
+	}
+	return dataType, length
+}
+
+func (o *OpenMetadata) service() string {
+	if o.config.OpenMetadata.Service != "" {
+		return o.config.OpenMetadata.Service
+	}
+	return config.DefaultOpenMetadataService
+}
+
+// databaseName return the name of the database. MySQL databases are the schemas of the database "default" in OpenMetadata.
+func (o *OpenMetadata) databaseName(s *schema.Schema) string {
+	if s.Driver == "mysql" {
+		return "default"
+	}
+	return s.Name
+}
+
+// splitName return the schema name and the table name
+func (o *OpenMetadata) splitName(s *schema.Schema, name string) (string, string) {
+	if i := strings.Index(name, "."); i > 0 {
+		return name[:i], name[i+1:]
+	}
+	switch s.Driver {
+	case "postgres":
+		return "public", name
+	case "mysql":
+		return s.Name, name
+	case "sqlite3":
+		return "main", name
+	}
+	return "default", name
+}
+
+// fqn return the fully qualified name of the entity. Names including dots are quoted.
+func fqn(names ...string) string {
+	quoted := []string{}
+	for _, n := range names {
+		if strings.Contains(n, ".") {
+			n = fmt.Sprintf(`"%s"`, n)
+		}
+		quoted = append(quoted, n)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func encode(wr io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(wr)
+	encoder.SetIndent("", "  ")
+	return errors.WithStack(encoder.Encode(v))
+}
+
+func init() {
+	output.Register("openmetadata", func(c *config.Config) output.Output {
+		return New(c)
+	})
+}
@@ -0,0 +1,104 @@
+package openmetadata
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestOutputSchema(t *testing.T) {
+	c := config.New()
+	c.OpenMetadata.Service = "warehouse"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := ioutil.ReadFile(filepath.Join(testdataDir(), "openmetadata_test_schema.json.golden"))
+	actual := buf.String()
+	if actual != string(expected) {
+		t.Errorf("actual %v\nwant %v", actual, string(expected))
+	}
+}
+
+func TestDataType(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantType   string
+		wantLength int
+	}{
+		{"bigint", "BIGINT", 0},
+		{"integer", "INT", 0},
+		{"character varying(255)", "VARCHAR", 255},
+		{"varchar", "VARCHAR", 1},
+		{"timestamp without time zone", "TIMESTAMP", 0},
+		{"text[]", "ARRAY", 0},
+		{"jsonb", "JSON", 0},
+		{"geometry", "UNKNOWN", 0},
+	}
+	for _, tt := range tests {
+		gotType, gotLength := DataType(tt.in)
+		if gotType != tt.wantType || gotLength != tt.wantLength {
+			t.Errorf("%s: actual %v %v\nwant %v %v", tt.in, gotType, gotLength, tt.wantType, tt.wantLength)
+		}
+	}
+}
+
+func testdataDir() string {
+	wd, _ := os.Getwd()
+	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
+	return dir
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Type:    "bigint",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:     "b",
+		Type:     "character varying(100)",
+		Nullable: true,
+		Comment:  "column b",
+	}
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{ca},
+		Constraints: []*schema.Constraint{
+			&schema.Constraint{
+				Name: "a_pkey",
+				Type: "PRIMARY KEY",
+				Def:  "PRIMARY KEY (a)",
+			},
+		},
+	}
+	tb := &schema.Table{
+		Name:    "sub.b",
+		Type:    "VIEW",
+		Comment: "table b",
+		Columns: []*schema.Column{cb},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+	return &schema.Schema{
+		Name:      "testdb",
+		Tables:    []*schema.Table{ta, tb},
+		Relations: []*schema.Relation{r},
+		Driver:    "postgres",
+	}
+}
@@ -1,9 +1,15 @@
+// Package output is the common interface of the renderers of the schema. Each renderer (output/json, output/dot, output/plantuml, output/mermaid, ...) implements Output.
 package output
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 
+	"github.com/gobuffalo/packr"
 	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
 )
 
 // Output is interface for output
@@ -11,3 +17,25 @@ type Output interface {
 	OutputSchema(wr io.Writer, s *schema.Schema) error
 	OutputTable(wr io.Writer, s *schema.Table) error
 }
+
+// LoadTemplate return template text. If path is not empty, load the user-provided template file instead of the builtin one.
+func LoadTemplate(box packr.Box, name string, path string) (string, error) {
+	if path == "" {
+		return box.FindString(name)
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to load template '%s'", path))
+	}
+	return string(buf), nil
+}
+
+// EscapeGitHubData escape the message of GitHub Actions workflow commands
+func EscapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// EscapeGitHubProperty escape the property value of GitHub Actions workflow commands
+func EscapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
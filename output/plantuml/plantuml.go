@@ -0,0 +1,161 @@
+package plantuml
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+var reAlias = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// PlantUML struct
+type PlantUML struct {
+	config *config.Config
+}
+
+// New return PlantUML
+func New(c *config.Config) *PlantUML {
+	return &PlantUML{
+		config: c,
+	}
+}
+
+// OutputSchema output PlantUML format for full relation.
+func (p *PlantUML) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	if er := p.config.ER.SchemaDiagramER(s); er.Columns != p.config.ER.Columns {
+		// oversized diagram is rendered with reduced columns
+		c := *p.config
+		c.ER = er
+		return New(&c).OutputSchema(wr, s)
+	}
+	box := packr.NewBox("./templates")
+	tmpl, err := p.parseTemplate(box, "schema.puml.tmpl", p.config.Templates.PUML.Schema)
+	if err != nil {
+		return err
+	}
+	err = tmpl.Execute(wr, map[string]interface{}{
+		"Schema": p.config.ER.SchemaDiagram(s),
+		"ER":     p.config.ER,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// OutputTable output PlantUML format for table.
+func (p *PlantUML) OutputTable(wr io.Writer, t *schema.Table) error {
+	tables, relations := p.config.ER.TableDiagram(t)
+
+	box := packr.NewBox("./templates")
+	tmpl, err := p.parseTemplate(box, "table.puml.tmpl", p.config.Templates.PUML.Table)
+	if err != nil {
+		return err
+	}
+	err = tmpl.Execute(wr, map[string]interface{}{
+		"Table":     t,
+		"Tables":    tables,
+		"Relations": relations,
+		"ER":        p.config.ER,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (p *PlantUML) parseTemplate(box packr.Box, name string, path string) (*template.Template, error) {
+	ts, err := output.LoadTemplate(box, name, path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(p.funcMap()).Parse(ts)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to parse template '%s'", name))
+	}
+	return tmpl, nil
+}
+
+func (p *PlantUML) funcMap() map[string]interface{} {
+	return template.FuncMap{
+		"showColumn":    p.config.ER.ShowColumn,
+		"relationLabel": p.config.ER.RelationLabel,
+		"legendColors":  p.config.ER.LegendColors,
+		"legendKeys":    p.config.ER.LegendKeys,
+		"keyIcons": func(t *schema.Table, c *schema.Column) string {
+			icons := ""
+			for _, k := range p.config.ER.ColumnKeys(t, c) {
+				icons += fmt.Sprintf(" <<%s>>", k)
+			}
+			return icons
+		},
+		"alias": func(name string) string {
+			return reAlias.ReplaceAllString(name, "_")
+		},
+		"tableName": func(t *schema.Table) string {
+			return p.displayName(t.Name, t.LogicalName(p.config.LogicalName.Delimiter))
+		},
+		"columnName": func(c *schema.Column) string {
+			return p.displayName(c.Name, c.LogicalName(p.config.LogicalName.Delimiter))
+		},
+		"labelColor": func(t *schema.Table) string {
+			if c := p.config.ER.TableColor(t); c != "" {
+				return fmt.Sprintf("%s ", c)
+			}
+			return ""
+		},
+		"crowfoot": func(r *schema.Relation) string {
+			parent := "||"
+			if r.ParentCardinality == schema.ZeroOrOne {
+				parent = "o|"
+			}
+			switch r.Cardinality {
+			case schema.ZeroOrOne:
+				return "|o--" + parent
+			case schema.ExactlyOne:
+				return "||--" + parent
+			case schema.OneOrMore:
+				return "}|--" + parent
+			}
+			return "}o--" + parent
+		},
+		"inch": func(v float64) int {
+			// PlantUML nodesep and ranksep are in pixels, Graphviz ones are in inches
+			return int(v * 72)
+		},
+		"lineColor": func(c string) string {
+			return strings.TrimPrefix(c, "#")
+		},
+		"comment": func(text string) string {
+			r := strings.NewReplacer("\r\n", "\\n", "\n", "\\n", "\r", "\\n")
+			return r.Replace(p.config.ER.NodeComment(text))
+		},
+	}
+}
+
+// displayName return the name shown in ER nodes according to logicalName config
+func (p *PlantUML) displayName(name string, logicalName string) string {
+	if !p.config.LogicalName.ShowInER || logicalName == "" || logicalName == name {
+		return name
+	}
+	if p.config.LogicalName.Replace {
+		return logicalName
+	}
+	return fmt.Sprintf("%s (%s)", name, logicalName)
+}
+
+func init() {
+	output.Register("plantuml", func(c *config.Config) output.Output {
+		return New(c)
+	})
+}
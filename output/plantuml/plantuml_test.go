@@ -0,0 +1,224 @@
+package plantuml
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestOutputSchema(t *testing.T) {
+	s := newTestSchema()
+	err := s.LoadAdditionalData(filepath.Join(testdataDir(), "md_test_additional_data.yml"))
+	if err != nil {
+		t.Error(err)
+	}
+	o := New(config.New())
+	buf := &bytes.Buffer{}
+	err = o.OutputSchema(buf, s)
+	if err != nil {
+		t.Error(err)
+	}
+	expected, _ := ioutil.ReadFile(filepath.Join(testdataDir(), "plantuml_test_schema.puml.golden"))
+	actual := buf.String()
+	if actual != string(expected) {
+		t.Errorf("actual %v\nwant %v", actual, string(expected))
+	}
+}
+
+func TestOutputTable(t *testing.T) {
+	s := newTestSchema()
+	err := s.LoadAdditionalData(filepath.Join(testdataDir(), "md_test_additional_data.yml"))
+	if err != nil {
+		t.Error(err)
+	}
+	ta := s.Tables[0]
+
+	o := New(config.New())
+	buf := &bytes.Buffer{}
+	_ = o.OutputTable(buf, ta)
+	expected, _ := ioutil.ReadFile(filepath.Join(testdataDir(), "plantuml_test_a.puml.golden"))
+	actual := buf.String()
+	if actual != string(expected) {
+		t.Errorf("actual %v\nwant %v", actual, string(expected))
+	}
+}
+
+func TestOutputSchemaWithLayout(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.FontSize = 10
+	c.ER.Rankdir = "LR"
+	c.ER.DPI = 300
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "skinparam defaultFontName Arial\nskinparam defaultFontSize 10\nskinparam dpi 300\nleft to right direction\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithEdgeRouting(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Splines = "ortho"
+	c.ER.Nodesep = 0.5
+	c.ER.Ranksep = 1
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "skinparam linetype ortho\nskinparam nodesep 36\nskinparam ranksep 72\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithTheme(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Theme = "dark"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "skinparam backgroundColor #1E1E1E\nskinparam defaultFontColor #E0E0E0\nskinparam classBackgroundColor #2D2D2D\nskinparam classBorderColor #808080\nskinparam arrowColor #C0C0C0\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithEdgeLabel(t *testing.T) {
+	s := newTestSchema()
+	s.Relations[0].Def = "FOREIGN KEY (a) REFERENCES b (b)"
+	c := config.New()
+	c.ER.EdgeLabel = "def"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "a }o--|| b : FOREIGN KEY (a) REFERENCES b (b)\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("actual %v\nwant %v", buf.String(), expected)
+	}
+}
+
+func TestOutputSchemaWithAdditionalRelation(t *testing.T) {
+	s := newTestSchema()
+	s.Relations[0].IsAdditional = true
+	c := config.New()
+	c.ER.Legend = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"#line.dashed;line:3C78D8",
+		"legend right\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func testdataDir() string {
+	wd, _ := os.Getwd()
+	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
+	return dir
+}
+
+func TestOutputSchemaWithKeyIcons(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[1].Constraints = []*schema.Constraint{
+		&schema.Constraint{Name: "b_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (b)"},
+	}
+	c := config.New()
+	c.ER.KeyIcons = true
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`a :  <<FK>>`,
+		`b :  <<PK>>`,
+		"b2 : \n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Comment: "column b",
+	}
+
+	ta := &schema.Table{
+		Name:    "a",
+		Comment: "table a",
+		Columns: []*schema.Column{
+			ca,
+			&schema.Column{
+				Name:    "a2",
+				Comment: "column a2",
+			},
+		},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Comment: "table b",
+		Columns: []*schema.Column{
+			cb,
+			&schema.Column{
+				Name:    "b2",
+				Comment: "column b2",
+			},
+		},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+
+	s := &schema.Schema{
+		Name: "testschema",
+		Tables: []*schema.Table{
+			ta,
+			tb,
+		},
+		Relations: []*schema.Relation{
+			r,
+		},
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// PluginPrefix is the prefix of the executable name of output plugins. The output plugin of the format `<format>` is `tbls-output-<format>`.
+const PluginPrefix = "tbls-output-"
+
+// Plugin is the Output rendered by the output plugin.
+// The plugin is run with the argument `schema` or `table`, reads the JSON of the schema or the table (same as `tbls out -t json`) from stdin, and writes the output to stdout.
+type Plugin struct {
+	Path string
+	ctx  context.Context
+}
+
+// FindPlugin return the output plugin of the format in PATH
+func FindPlugin(format string) (*Plugin, bool) {
+	path, err := exec.LookPath(PluginPrefix + format)
+	if err != nil {
+		return nil, false
+	}
+	return &Plugin{Path: path}, true
+}
+
+// OutputSchema output the schema rendered by the plugin
+func (p *Plugin) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	return p.run(wr, "schema", s)
+}
+
+// OutputTable output the table rendered by the plugin
+func (p *Plugin) OutputTable(wr io.Writer, t *schema.Table) error {
+	return p.run(wr, "table", t)
+}
+
+func (p *Plugin) run(wr io.Writer, arg string, v interface{}) error {
+	in, err := json.Marshal(v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, p.Path, arg)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = wr
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to run output plugin '%s': %s", p.Path, strings.TrimSpace(stderr.String())))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestPlugin(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	plugin := `#!/bin/sh
+case "$1" in
+  schema) echo "# schema"; cat ;;
+  table) echo "broken table" >&2; exit 1 ;;
+esac
+`
+	err := ioutil.WriteFile(filepath.Join(tempDir, PluginPrefix+"fake"), []byte(plugin), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", tempDir+string(os.PathListSeparator)+path)
+
+	o, err := New("fake", config.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := o.OutputSchema(buf, &schema.Schema{Name: "testdb"}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "# schema\n") || !strings.Contains(got, `"name":"testdb"`) {
+		t.Errorf("actual %v\nwant %v", got, `# schema\n{"name":"testdb",...}`)
+	}
+
+	err = o.OutputTable(buf, &schema.Table{Name: "users"})
+	if err == nil || !strings.Contains(err.Error(), "broken table") {
+		t.Errorf("actual %v\nwant %v", err, "broken table")
+	}
+
+	if _, ok := FindPlugin("unknown"); ok {
+		t.Errorf("actual %v\nwant %v", ok, false)
+	}
+}
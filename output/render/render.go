@@ -0,0 +1,98 @@
+package render
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/pkg/errors"
+)
+
+// client is the HTTP client for rendering servers
+var client = http.DefaultClient
+
+// plantUMLAlphabet is the alphabet of the text encoding of PlantUML
+const plantUMLAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-_"
+
+// Remote render the ER diagram source with the server of er.renderer, and return the image.
+// Kroki renders Graphviz sources, and PlantUML server renders PlantUML sources. The request is canceled when ctx is done.
+func Remote(ctx context.Context, e config.ER, src []byte) ([]byte, error) {
+	renderer, u := e.RendererURL()
+	format := e.Format
+	var (
+		req *http.Request
+		err error
+	)
+	switch renderer {
+	case "kroki":
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/graphviz/%s", strings.TrimSuffix(u, "/"), format), bytes.NewReader(src))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		req.Header.Set("Content-Type", "text/plain")
+	case "plantuml":
+		encoded, err := EncodePlantUML(src)
+		if err != nil {
+			return nil, err
+		}
+		req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(u, "/"), format, encoded), nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	default:
+		return nil, errors.WithStack(fmt.Errorf("unsupported ER renderer '%s'", renderer))
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.WithStack(fmt.Errorf("failed to render ER diagram with %s: %s: %s", u, resp.Status, strings.TrimSpace(string(b))))
+	}
+	return b, nil
+}
+
+// EncodePlantUML encode the PlantUML source for the URL of PlantUML server (deflate and the base64 like encoding of PlantUML)
+func EncodePlantUML(src []byte) (string, error) {
+	buf := new(bytes.Buffer)
+	w, err := flate.NewWriter(buf, flate.BestCompression)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if _, err := w.Write(src); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	b := buf.Bytes()
+	var sb strings.Builder
+	for i := 0; i < len(b); i += 3 {
+		var b1, b2, b3 byte
+		b1 = b[i]
+		if i+1 < len(b) {
+			b2 = b[i+1]
+		}
+		if i+2 < len(b) {
+			b3 = b[i+2]
+		}
+		sb.WriteByte(plantUMLAlphabet[b1>>2])
+		sb.WriteByte(plantUMLAlphabet[((b1&0x3)<<4)|(b2>>4)])
+		sb.WriteByte(plantUMLAlphabet[((b2&0xF)<<2)|(b3>>6)])
+		sb.WriteByte(plantUMLAlphabet[b3&0x3F])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+)
+
+func TestRemoteKroki(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.URL.Path != "/graphviz/jpeg" || string(b) != "digraph {}" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer ts.Close()
+	e := config.ER{Format: "jpg", Renderer: "kroki:" + ts.URL + "/"}
+	got, err := Remote(context.Background(), e, []byte("digraph {}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "image" {
+		t.Errorf("actual %v\nwant %v", string(got), "image")
+	}
+
+	e.Format = "gif"
+	_, err = Remote(context.Background(), e, []byte("digraph {}"))
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request") {
+		t.Errorf("actual %v\nwant %v", err, "400 Bad Request")
+	}
+}
+
+func TestRemotePlantUML(t *testing.T) {
+	src := "@startuml\nBob -> Alice : hello\n@enduml\n"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/plantuml/svg/") || decodePlantUML(strings.TrimPrefix(r.URL.Path, "/plantuml/svg/")) != src {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("<svg></svg>"))
+	}))
+	defer ts.Close()
+	e := config.ER{Format: "svg", Renderer: "plantuml:" + ts.URL + "/plantuml"}
+	got, err := Remote(context.Background(), e, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "<svg></svg>" {
+		t.Errorf("actual %v\nwant %v", string(got), "<svg></svg>")
+	}
+}
+
+func decodePlantUML(s string) string {
+	b := []byte{}
+	for i := 0; i+3 < len(s); i += 4 {
+		c := [4]byte{}
+		for j := 0; j < 4; j++ {
+			c[j] = byte(strings.IndexByte(plantUMLAlphabet, s[i+j]))
+		}
+		b = append(b, c[0]<<2|c[1]>>4, (c[1]&0xF)<<4|c[2]>>2, (c[2]&0x3)<<6|c[3])
+	}
+	out, _ := ioutil.ReadAll(flate.NewReader(bytes.NewReader(b)))
+	return string(out)
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/pkg/errors"
+)
+
+// Renderer create the Output of the format with the config
+type Renderer func(c *config.Config) Output
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{}
+)
+
+// Register register the renderer of the format. Renderers of builtin formats are registered by their packages.
+// Registering the format again replaces the renderer.
+func Register(format string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[format] = r
+}
+
+// Formats return registered formats in order
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	formats := []string{}
+	for f := range renderers {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// New return the Output of the format. When the format is not registered, the output plugin `tbls-output-<format>` in PATH is used.
+func New(format string, c *config.Config) (Output, error) {
+	return NewContext(context.Background(), format, c)
+}
+
+// NewContext is New with the context. The output plugin is killed when ctx is done.
+func NewContext(ctx context.Context, format string, c *config.Config) (Output, error) {
+	renderersMu.RLock()
+	r, ok := renderers[format]
+	renderersMu.RUnlock()
+	if ok {
+		return r(c), nil
+	}
+	if p, ok := FindPlugin(format); ok {
+		p.ctx = ctx
+		return p, nil
+	}
+	return nil, errors.WithStack(fmt.Errorf("unsupported format '%s'", format))
+}
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+type testOutput struct {
+	name string
+}
+
+func (o *testOutput) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	_, err := io.WriteString(wr, o.name+":"+s.Name)
+	return err
+}
+
+func (o *testOutput) OutputTable(wr io.Writer, t *schema.Table) error {
+	_, err := io.WriteString(wr, o.name+":"+t.Name)
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	Register("test", func(c *config.Config) Output {
+		return &testOutput{name: c.Name}
+	})
+	found := false
+	for _, f := range Formats() {
+		if f == "test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("actual %v\nwant %v", Formats(), "test")
+	}
+
+	c := config.New()
+	c.Name = "corp"
+	o, err := New("test", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := o.OutputSchema(buf, &schema.Schema{Name: "testdb"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "corp:testdb"
+	if got := buf.String(); got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestNewUnsupportedFormat(t *testing.T) {
+	_, err := New("unsupported", config.New())
+	if err == nil || !strings.Contains(err.Error(), "unsupported format 'unsupported'") {
+		t.Errorf("actual %v\nwant %v", err, "unsupported format 'unsupported'")
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// runCommand run external command (aws, gsutil, az)
+var runCommand = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// contentTypes is the content types of document files not registered in the system
+var contentTypes = map[string]string{
+	".md":   "text/markdown; charset=utf-8",
+	".svg":  "image/svg+xml",
+	".html": "text/html; charset=utf-8",
+	".json": "application/json",
+	".dot":  "text/vnd.graphviz; charset=utf-8",
+	".puml": "text/plain; charset=utf-8",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+}
+
+// IsRemote return whether the document path is an object storage URI (s3://, gs:// or azblob://)
+func IsRemote(docPath string) bool {
+	for _, scheme := range []string{"s3://", "gs://", "azblob://"} {
+		if strings.HasPrefix(docPath, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType return the content type of the file
+func ContentType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// Upload upload files in the directory to the object storage URI with content types, using the CLI of the storage (aws, gsutil or az).
+//
+//	s3://bucket/prefix
+//	gs://bucket/prefix
+//	azblob://account/container/prefix
+func Upload(dir string, uri string) error {
+	i := strings.Index(uri, "://")
+	if i < 0 || !IsRemote(uri) {
+		return errors.WithStack(fmt.Errorf("unsupported object storage URI '%s'", uri))
+	}
+	scheme := uri[:i]
+	dest := strings.TrimSuffix(uri[i+3:], "/")
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		key := filepath.ToSlash(rel)
+		ct := ContentType(key)
+		switch scheme {
+		case "s3":
+			err = runCommand("aws", "s3", "cp", p, fmt.Sprintf("s3://%s", path.Join(dest, key)), "--content-type", ct, "--only-show-errors")
+		case "gs":
+			err = runCommand("gsutil", "-q", "-h", fmt.Sprintf("Content-Type:%s", ct), "cp", p, fmt.Sprintf("gs://%s", path.Join(dest, key)))
+		case "azblob":
+			parts := strings.SplitN(dest, "/", 3)
+			if len(parts) < 2 {
+				return errors.WithStack(fmt.Errorf("invalid azblob URI '%s': account and container are required", uri))
+			}
+			name := key
+			if len(parts) == 3 {
+				name = path.Join(parts[2], key)
+			}
+			err = runCommand("az", "storage", "blob", "upload", "--account-name", parts[0], "--container-name", parts[1], "--name", name, "--file", p, "--content-type", ct, "--overwrite", "--only-show-errors")
+		}
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to upload '%s' to %s", key, uri))
+		}
+		fmt.Printf("%s/%s\n", strings.TrimSuffix(uri, "/"), key)
+		return nil
+	})
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsRemote(t *testing.T) {
+	tests := []struct {
+		docPath string
+		want    bool
+	}{
+		{"dbdoc", false},
+		{"/path/to/dbdoc", false},
+		{"s3://bucket/dbdoc", true},
+		{"gs://bucket/dbdoc", true},
+		{"azblob://account/container/dbdoc", true},
+		{"https://example.com/dbdoc", false},
+	}
+	for _, tt := range tests {
+		if got := IsRemote(tt.docPath); got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.docPath, got, tt.want)
+		}
+	}
+}
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"README.md", "text/markdown; charset=utf-8"},
+		{"schema.svg", "image/svg+xml"},
+		{"schema.html", "text/html; charset=utf-8"},
+		{"schema.png", "image/png"},
+		{"unknown.tblsunknown", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := ContentType(tt.name); got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "tbls")
+	defer os.RemoveAll(tempDir)
+	for _, name := range []string{"README.md", "schema.svg"} {
+		err := ioutil.WriteFile(filepath.Join(tempDir, name), []byte("test"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var calls []string
+	runCommand = func(name string, args ...string) error {
+		calls = append(calls, strings.Replace(strings.Join(append([]string{name}, args...), " "), tempDir, "DIR", -1))
+		return nil
+	}
+	tests := []struct {
+		uri  string
+		want []string
+	}{
+		{"s3://bucket/dbdoc/", []string{
+			"aws s3 cp DIR/README.md s3://bucket/dbdoc/README.md --content-type text/markdown; charset=utf-8 --only-show-errors",
+			"aws s3 cp DIR/schema.svg s3://bucket/dbdoc/schema.svg --content-type image/svg+xml --only-show-errors",
+		}},
+		{"gs://bucket", []string{
+			"gsutil -q -h Content-Type:text/markdown; charset=utf-8 cp DIR/README.md gs://bucket/README.md",
+			"gsutil -q -h Content-Type:image/svg+xml cp DIR/schema.svg gs://bucket/schema.svg",
+		}},
+		{"azblob://account/container/dbdoc", []string{
+			"az storage blob upload --account-name account --container-name container --name dbdoc/README.md --file DIR/README.md --content-type text/markdown; charset=utf-8 --overwrite --only-show-errors",
+			"az storage blob upload --account-name account --container-name container --name dbdoc/schema.svg --file DIR/schema.svg --content-type image/svg+xml --overwrite --only-show-errors",
+		}},
+	}
+	for _, tt := range tests {
+		calls = []string{}
+		err := Upload(tempDir, tt.uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(calls, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: actual %v\nwant %v", tt.uri, calls, tt.want)
+		}
+	}
+	for _, uri := range []string{"azblob://account", "ftp://host/dbdoc"} {
+		if err := Upload(tempDir, uri); err == nil {
+			t.Errorf("%s: want error", uri)
+		}
+	}
+}
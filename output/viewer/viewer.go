@@ -0,0 +1,112 @@
+package viewer
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/gobuffalo/packr"
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// FileName is the file name of the interactive HTML ER viewer in the document path
+const FileName = "schema.html"
+
+// Viewer struct
+type Viewer struct {
+	config *config.Config
+}
+
+// New return Viewer
+func New(c *config.Config) *Viewer {
+	return &Viewer{
+		config: c,
+	}
+}
+
+type node struct {
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Comment string   `json:"comment"`
+	Labels  []string `json:"labels"`
+	Color   string   `json:"color"`
+	Columns []column `json:"columns"`
+	Link    string   `json:"link"`
+}
+
+type column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type edge struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Def          string `json:"def"`
+	IsAdditional bool   `json:"additional"`
+}
+
+// OutputSchema output the interactive HTML ER viewer of the whole schema.
+// Tables excluded from ER diagrams are not included. Seeds are ignored because the viewer is for exploring the whole schema.
+func (v *Viewer) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	er := v.config.ER
+	er.Seeds = nil
+	d := er.SchemaDiagram(s)
+
+	nodes := []node{}
+	labels := []string{}
+	encountered := map[string]bool{}
+	for _, t := range d.Tables {
+		n := node{
+			ID:      t.Name,
+			Type:    t.Type,
+			Comment: t.Comment,
+			Labels:  []string{},
+			Color:   er.TableColor(t),
+			Columns: []column{},
+			Link:    v.config.TableLink(t.Name),
+		}
+		for _, l := range t.Labels {
+			n.Labels = append(n.Labels, l.Name)
+			if !encountered[l.Name] {
+				encountered[l.Name] = true
+				labels = append(labels, l.Name)
+			}
+		}
+		for _, c := range t.Columns {
+			if !er.ShowColumn(t, c) {
+				continue
+			}
+			n.Columns = append(n.Columns, column{Name: c.Name, Type: c.Type})
+		}
+		nodes = append(nodes, n)
+	}
+	edges := []edge{}
+	for _, r := range d.Relations {
+		edges = append(edges, edge{
+			Source:       r.Table.Name,
+			Target:       r.ParentTable.Name,
+			Def:          r.Def,
+			IsAdditional: r.IsAdditional,
+		})
+	}
+
+	box := packr.NewBox("./templates")
+	ts, _ := box.FindString("viewer.html.tmpl")
+	tmpl, err := template.New("viewer").Parse(ts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = tmpl.Execute(wr, map[string]interface{}{
+		"Name":   s.Name,
+		"Nodes":  nodes,
+		"Edges":  edges,
+		"Labels": labels,
+		"ER":     er,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package viewer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestOutputSchema(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[0].Labels = []*schema.Label{&schema.Label{Name: "core", Color: "#FF0000"}}
+	c := config.New()
+	c.Link.Style = "noext"
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<title>testschema</title>`,
+		`<option value="a"><option value="b">`,
+		`<input type="checkbox" class="label" value="core" checked> core</label>`,
+		`var nodes = [{"id":"a","type":"","comment":"table a \u003cb\u003e","labels":["core"],"color":"#FF0000","columns":[{"name":"a","type":""},{"name":"a2","type":""}],"link":"a"},`,
+		`var edges = [{"source":"a","target":"b","def":"","additional":false}];`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("actual %v\nwant %v", buf.String(), expected)
+		}
+	}
+}
+
+func TestOutputSchemaWithExclude(t *testing.T) {
+	s := newTestSchema()
+	c := config.New()
+	c.ER.Exclude = []string{"b"}
+	c.ER.Seeds = []string{"x"}
+	o := New(c)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `"id":"b"`) {
+		t.Errorf("actual %v\nwant %v", buf.String(), "no table b")
+	}
+	if !strings.Contains(buf.String(), `"id":"a"`) {
+		t.Errorf("actual %v\nwant %v", buf.String(), "table a")
+	}
+	if !strings.Contains(buf.String(), `var edges = [];`) {
+		t.Errorf("actual %v\nwant %v", buf.String(), "no relations")
+	}
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:    "a",
+		Comment: "column a",
+	}
+	cb := &schema.Column{
+		Name:    "b",
+		Comment: "column b",
+	}
+
+	ta := &schema.Table{
+		Name:    "a",
+		Comment: "table a <b>",
+		Columns: []*schema.Column{
+			ca,
+			&schema.Column{
+				Name:    "a2",
+				Comment: "column a2",
+			},
+		},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Comment: "table b",
+		Columns: []*schema.Column{
+			cb,
+			&schema.Column{
+				Name:    "b2",
+				Comment: "column b2",
+			},
+		},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+
+	s := &schema.Schema{
+		Name: "testschema",
+		Tables: []*schema.Table{
+			ta,
+			tb,
+		},
+		Relations: []*schema.Relation{
+			r,
+		},
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package yaml
+
+import (
+	"io"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/output"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// YAML struct
+type YAML struct{}
+
+// OutputSchema output YAML format for full relation.
+// Tables and columns of relations are referred by their names.
+func (y *YAML) OutputSchema(wr io.Writer, s *schema.Schema) error {
+	return encode(wr, s)
+}
+
+// OutputTable output YAML format for table.
+func (y *YAML) OutputTable(wr io.Writer, t *schema.Table) error {
+	return encode(wr, t)
+}
+
+func encode(wr io.Writer, v interface{}) error {
+	encoder := yaml.NewEncoder(wr)
+	if err := encoder.Encode(v); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(encoder.Close())
+}
+
+func init() {
+	output.Register("yaml", func(c *config.Config) output.Output {
+		return new(YAML)
+	})
+}
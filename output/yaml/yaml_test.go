@@ -0,0 +1,125 @@
+package yaml
+
+import (
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k1LoW/tbls/schema"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestOutputSchema(t *testing.T) {
+	s := newTestSchema()
+	o := new(YAML)
+	buf := &bytes.Buffer{}
+	err := o.OutputSchema(buf, s)
+	if err != nil {
+		t.Error(err)
+	}
+	expected, _ := ioutil.ReadFile(filepath.Join(testdataDir(), "yaml_test_schema.yml.golden"))
+	actual := buf.String()
+	if actual != string(expected) {
+		t.Errorf("actual %v\nwant %v", actual, string(expected))
+	}
+}
+
+func TestOutputSchemaRoundTrip(t *testing.T) {
+	s := newTestSchema()
+	o := new(YAML)
+	want := &bytes.Buffer{}
+	if err := o.OutputSchema(want, s); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &schema.Schema{}
+	if err := yaml.Unmarshal(want.Bytes(), loaded); err != nil {
+		t.Fatal(err)
+	}
+	got := &bytes.Buffer{}
+	if err := o.OutputSchema(got, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("actual %v\nwant %v", got.String(), want.String())
+	}
+}
+
+func testdataDir() string {
+	wd, _ := os.Getwd()
+	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(filepath.Dir(wd)), "testdata"))
+	return dir
+}
+
+func newTestSchema() *schema.Schema {
+	ca := &schema.Column{
+		Name:     "a",
+		Type:     "bigint(20)",
+		Comment:  "column a",
+		Nullable: false,
+	}
+	cb := &schema.Column{
+		Name:     "b",
+		Type:     "text",
+		Comment:  "column b",
+		Nullable: true,
+	}
+
+	ta := &schema.Table{
+		Name:    "a",
+		Type:    "BASE TABLE",
+		Comment: "table a",
+		Columns: []*schema.Column{
+			ca,
+			&schema.Column{
+				Name:     "a2",
+				Type:     "datetime",
+				Comment:  "column a2",
+				Nullable: false,
+				Default: schema.NewDefault(sql.NullString{String: "CURRENT_TIMESTAMP", Valid: true}),
+			},
+		},
+		Indexes:     []*schema.Index{},
+		Constraints: []*schema.Constraint{},
+		Triggers:    []*schema.Trigger{},
+	}
+	tb := &schema.Table{
+		Name:    "b",
+		Type:    "BASE TABLE",
+		Comment: "table b",
+		Columns: []*schema.Column{
+			cb,
+			&schema.Column{
+				Name:     "b2",
+				Comment:  "column b2",
+				Type:     "text",
+				Nullable: true,
+			},
+		},
+		Indexes:     []*schema.Index{},
+		Constraints: []*schema.Constraint{},
+		Triggers:    []*schema.Trigger{},
+	}
+	r := &schema.Relation{
+		Table:         ta,
+		Columns:       []*schema.Column{ca},
+		ParentTable:   tb,
+		ParentColumns: []*schema.Column{cb},
+	}
+	ca.ParentRelations = []*schema.Relation{r}
+	cb.ChildRelations = []*schema.Relation{r}
+
+	s := &schema.Schema{
+		Name: "testschema",
+		Tables: []*schema.Table{
+			ta,
+			tb,
+		},
+		Relations: []*schema.Relation{
+			r,
+		},
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// orderableTypes is the keywords of types whose values have MIN and MAX
+var orderableTypes = []string{"int", "serial", "numeric", "decimal", "real", "float", "double", "money", "date", "time", "year", "char", "text", "string"}
+
+// summarizableTypes is the keywords of numeric and date/time types whose values have percentiles
+var summarizableTypes = []string{"int", "serial", "numeric", "decimal", "real", "float", "double", "money", "date", "time", "year"}
+
+// unequalTypes is the keywords of types whose values can not be compared for COUNT(DISTINCT)
+var unequalTypes = []string{"json", "xml", "point", "line", "lseg", "box", "path", "polygon", "circle", "geometry", "geography"}
+
+// orderable return whether MIN and MAX of values of the type are collected. Types of SQLite are only affinities, so all columns are orderable.
+func orderable(driver, t string) bool {
+	if driver == "sqlite3" {
+		return true
+	}
+	if !distinguishable(driver, t) {
+		return false
+	}
+	t = strings.ToLower(t)
+	if strings.HasSuffix(t, "]") {
+		return false
+	}
+	for _, k := range orderableTypes {
+		if strings.Contains(t, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizable return whether percentiles of values of the type are collected.
+// Types of SQLite are only affinities, so the declared types are matched with the keywords.
+func summarizable(driver, t string) bool {
+	if !orderable(driver, t) {
+		return false
+	}
+	t = strings.ToLower(t)
+	for _, k := range summarizableTypes {
+		if strings.Contains(t, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// distinguishable return whether distinct values of the type are counted
+func distinguishable(driver, t string) bool {
+	if driver == "sqlite3" {
+		return true
+	}
+	t = strings.ToLower(t)
+	for _, k := range unequalTypes {
+		if strings.Contains(t, k) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectColumnStats collect the null ratio, the distinct count, min and max of values of the first sampleSize rows (all rows when it is zero) of the table in one query.
+// Min and max of columns masked by samples and sensitive columns are masked.
+func collectColumnStats(ctx context.Context, db *sql.DB, driver string, t *schema.Table, sampleSize int, samples config.Samples) error {
+	columns := visibleColumns(t)
+	if len(columns) == 0 {
+		return nil
+	}
+	selects := []string{"COUNT(*)"}
+	names := []string{}
+	for _, col := range columns {
+		q := quoteIdent(driver, col.Name)
+		names = append(names, q)
+		selects = append(selects, fmt.Sprintf("COUNT(%s)", q))
+		if distinguishable(driver, col.Type) {
+			selects = append(selects, fmt.Sprintf("COUNT(DISTINCT %s)", q))
+		}
+		if orderable(driver, col.Type) {
+			selects = append(selects, fmt.Sprintf("MIN(%s)", q), fmt.Sprintf("MAX(%s)", q))
+		}
+	}
+	from := sampledFrom(driver, t.Name, names, sampleSize)
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), from)
+
+	var rows int64
+	stats := make([]*schema.ColumnStats, len(columns))
+	nonNulls := make([]int64, len(columns))
+	mins := make([]sql.NullString, len(columns))
+	maxes := make([]sql.NullString, len(columns))
+	dest := []interface{}{&rows}
+	for i, col := range columns {
+		stats[i] = &schema.ColumnStats{}
+		dest = append(dest, &nonNulls[i])
+		if distinguishable(driver, col.Type) {
+			stats[i].Distinct = new(int64)
+			dest = append(dest, stats[i].Distinct)
+		}
+		if orderable(driver, col.Type) {
+			dest = append(dest, &mins[i], &maxes[i])
+		}
+	}
+	if err := db.QueryRowContext(ctx, query).Scan(dest...); err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to collect statistics of columns of table '%s'", t.Name))
+	}
+	for i, col := range columns {
+		stats[i].Rows = rows
+		stats[i].Nulls = rows - nonNulls[i]
+		if orderable(driver, col.Type) && mins[i].Valid {
+			min, max := formatValue(mins[i]), formatValue(maxes[i])
+			if r, ok := maskValue(samples, t.Name, col); ok {
+				min, max = r, r
+			}
+			stats[i].Min, stats[i].Max = &min, &max
+		}
+		col.Stats = stats[i]
+	}
+	return nil
+}
+
+// collectPercentiles collect the nearest-rank percentiles of values of numeric and date/time columns of the table whose statistics are collected.
+// Columns masked by samples.masks and sensitive columns are skipped. Each query is canceled after the query timeout.
+func collectPercentiles(ctx context.Context, db *sql.DB, driver string, t *schema.Table, c *config.Config) error {
+	columns := visibleColumns(t)
+	names := []string{}
+	for _, col := range columns {
+		names = append(names, quoteIdent(driver, col.Name))
+	}
+	from := sampledFrom(driver, t.Name, names, c.ColumnStats.SampleSize)
+	for _, col := range columns {
+		if col.Stats == nil || !summarizable(driver, col.Type) {
+			continue
+		}
+		if _, masked := maskValue(c.Samples, t.Name, col); masked {
+			continue
+		}
+		n := col.Stats.Rows - col.Stats.Nulls
+		if n == 0 {
+			continue
+		}
+		q := quoteIdent(driver, col.Name)
+		percentiles := []*schema.Percentile{}
+		for _, p := range c.ColumnStats.Percentiles {
+			offset := percentileOffset(p, n)
+			// the value is selected with MIN so that it is formatted in the same way as min and max
+			query := fmt.Sprintf("SELECT MIN(%s) FROM (SELECT %s FROM %s WHERE %s IS NOT NULL ORDER BY %s LIMIT 1 OFFSET %d) AS percentile", q, q, from, q, q, offset)
+			var v sql.NullString
+			err := runStep(ctx, c, func(ctx context.Context) error {
+				return db.QueryRowContext(ctx, query).Scan(&v)
+			})
+			if err != nil {
+				return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to collect percentiles of column '%s.%s'", t.Name, col.Name))
+			}
+			percentiles = append(percentiles, &schema.Percentile{P: p, Value: formatValue(v)})
+		}
+		col.Stats.Percentiles = percentiles
+	}
+	return nil
+}
+
+// percentileOffset return the offset of the nearest-rank p-th percentile in n sorted values, that is the smallest value
+// whose rank is at least p percent of n (ceil(p/100*n)-th, and the first for p0).
+func percentileOffset(p int, n int64) int64 {
+	offset := (int64(p)*n+99)/100 - 1
+	switch {
+	case offset < 0:
+		return 0
+	case offset > n-1:
+		return n - 1
+	}
+	return offset
+}
+
+// sampledFrom return the FROM clause of the first sampleSize rows of the table with the quoted columns. It is the table itself when sampleSize is zero.
+func sampledFrom(driver, table string, columns []string, sampleSize int) string {
+	from := quoteTable(driver, table)
+	if sampleSize > 0 {
+		from = fmt.Sprintf("(SELECT %s FROM %s LIMIT %d) AS sampled", strings.Join(columns, ", "), from, sampleSize)
+	}
+	return from
+}
@@ -0,0 +1,76 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// collectEnumValues list distinct non-NULL values and their counts of columns of the table with at most enumValues.threshold distinct values, in descending order of the count.
+// Columns masked by samples.masks, sensitive columns and columns whose values are all unique are skipped. Each column is queried with the query timeout.
+func collectEnumValues(ctx context.Context, db *sql.DB, driver string, t *schema.Table, c *config.Config) error {
+	e := c.EnumValues
+	for _, col := range visibleColumns(t) {
+		if !distinguishable(driver, col.Type) {
+			continue
+		}
+		if _, masked := maskValue(c.Samples, t.Name, col); masked {
+			continue
+		}
+		var values []*schema.EnumValue
+		err := runStep(ctx, c, func(ctx context.Context) error {
+			var err error
+			values, err = enumValues(ctx, db, driver, t, col, e.Max(), e.SampleSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 || len(values) > e.Max() {
+			continue
+		}
+		unique := true
+		for _, v := range values {
+			if v.Count > 1 {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			continue
+		}
+		col.EnumValues = values
+	}
+	return nil
+}
+
+// enumValues return at most max+1 distinct non-NULL values of the column, so that the caller can tell whether the column has more than max values
+func enumValues(ctx context.Context, db *sql.DB, driver string, t *schema.Table, col *schema.Column, max, sampleSize int) ([]*schema.EnumValue, error) {
+	q := quoteIdent(driver, col.Name)
+	from := sampledFrom(driver, t.Name, []string{q}, sampleSize)
+	query := fmt.Sprintf("SELECT %s, COUNT(*) AS n FROM %s WHERE %s IS NOT NULL GROUP BY %s ORDER BY n DESC, %s LIMIT %d", q, from, q, q, q, max+1)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to select values of column '%s.%s'", t.Name, col.Name))
+	}
+	defer rows.Close()
+	values := []*schema.EnumValue{}
+	for rows.Next() {
+		var (
+			v sql.NullString
+			n int64
+		)
+		if err := rows.Scan(&v, &n); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		values = append(values, &schema.EnumValue{Value: formatValue(v), Count: n})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return values, nil
+}
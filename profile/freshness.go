@@ -0,0 +1,25 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// collectFreshness set the last written time of the table, the max value of the timestamp column
+func collectFreshness(ctx context.Context, db *sql.DB, driver string, t *schema.Table, col *schema.Column) error {
+	var last sql.NullString
+	query := fmt.Sprintf("SELECT MAX(%s) FROM %s", quoteIdent(driver, col.Name), quoteTable(driver, t.Name))
+	if err := db.QueryRowContext(ctx, query).Scan(&last); err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to select the last written time of table '%s'", t.Name))
+	}
+	t.Freshness = &schema.Freshness{Column: col.Name}
+	if last.Valid {
+		v := formatValue(last)
+		t.Freshness.LastWritten = &v
+	}
+	return nil
+}
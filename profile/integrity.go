@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/drivers"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
+	"github.com/pkg/errors"
+)
+
+// maxOrphanSamples is the max number of keys of orphaned rows reported per relation
+const maxOrphanSamples = 5
+
+// Orphans is the rows of the child table of the relation whose parent rows do not exist
+type Orphans struct {
+	Relation *schema.Relation
+	Count    int64
+	// Keys is the distinct values of the columns of the relation of orphaned rows (at most 5)
+	Keys []string
+}
+
+// CheckIntegrity find orphaned rows of relations which are not declared in the database (additional and virtual relations) with anti-join queries.
+// Relations without orphaned rows are not returned. Keys of columns masked by samples.masks and sensitive columns (of the child or the parent table) are masked.
+func CheckIntegrity(ctx context.Context, db *sql.DB, s *schema.Schema, c *config.Config) ([]*Orphans, error) {
+	defer trace.Start(ctx, "check integrity")()
+	relations := []*schema.Relation{}
+	for _, r := range s.Relations {
+		// virtual tables do not exist in the database
+		if r.IsAdditional && len(r.Columns) > 0 && len(r.Columns) == len(r.ParentColumns) && !r.Table.Virtual && !r.ParentTable.Virtual {
+			relations = append(relations, r)
+		}
+	}
+	results := make([]*Orphans, len(relations))
+	err := drivers.Parallel(len(relations), c.Concurrency, func(i int) error {
+		return runStep(ctx, c, func(ctx context.Context) error {
+			o, err := findOrphans(ctx, db, s.Driver, relations[i], c.Samples)
+			results[i] = o
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	orphans := []*Orphans{}
+	for _, o := range results {
+		if o.Count > 0 {
+			orphans = append(orphans, o)
+		}
+	}
+	return orphans, nil
+}
+
+// Target return the columns of the relation in the child table such as `posts.user_id`
+func (o *Orphans) Target() string {
+	targets := []string{}
+	for _, c := range o.Relation.Columns {
+		targets = append(targets, fmt.Sprintf("%s.%s", o.Relation.Table.Name, c.Name))
+	}
+	return strings.Join(targets, ", ")
+}
+
+// String return the orphaned rows such as `3 orphaned rows refer to missing users(id): 4, 5, 6`
+func (o *Orphans) String() string {
+	noun := "rows"
+	if o.Count == 1 {
+		noun = "row"
+	}
+	return fmt.Sprintf("%d orphaned %s refer to missing %s(%s): %s", o.Count, noun, o.Relation.ParentTable.Name, strings.Join(columnNames(o.Relation.ParentColumns), ", "), strings.Join(o.Keys, ", "))
+}
+
+// findOrphans count rows of the child table whose columns of the relation are not NULL and do not match any row of the parent table
+func findOrphans(ctx context.Context, db *sql.DB, driver string, r *schema.Relation, samples config.Samples) (*Orphans, error) {
+	keys := []string{}
+	conds := []string{}
+	joins := []string{}
+	for i, col := range r.Columns {
+		k := fmt.Sprintf("child.%s", quoteIdent(driver, col.Name))
+		keys = append(keys, k)
+		conds = append(conds, fmt.Sprintf("%s IS NOT NULL", k))
+		joins = append(joins, fmt.Sprintf("parent.%s = %s", quoteIdent(driver, r.ParentColumns[i].Name), k))
+	}
+	where := fmt.Sprintf("%s AND NOT EXISTS (SELECT 1 FROM %s AS parent WHERE %s)", strings.Join(conds, " AND "), quoteTable(driver, r.ParentTable.Name), strings.Join(joins, " AND "))
+	from := fmt.Sprintf("%s AS child", quoteTable(driver, r.Table.Name))
+	o := &Orphans{Relation: r, Keys: []string{}}
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", from, where)
+	if err := db.QueryRowContext(ctx, query).Scan(&o.Count); err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to check integrity of relation '%s'", relationName(r)))
+	}
+	if o.Count == 0 {
+		return o, nil
+	}
+	query = fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s ORDER BY %s LIMIT %d", strings.Join(keys, ", "), from, where, strings.Join(keys, ", "), maxOrphanSamples)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to select orphaned rows of relation '%s'", relationName(r)))
+	}
+	defer rows.Close()
+	for rows.Next() {
+		values := make([]sql.NullString, len(r.Columns))
+		dest := make([]interface{}, len(r.Columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		key := []string{}
+		for i, v := range values {
+			m, ok := maskValue(samples, r.Table.Name, r.Columns[i])
+			if !ok {
+				// keys are also values of the parent columns
+				m, ok = maskValue(samples, r.ParentTable.Name, r.ParentColumns[i])
+			}
+			if ok {
+				key = append(key, m)
+				continue
+			}
+			key = append(key, formatValue(v))
+		}
+		if len(key) == 1 {
+			o.Keys = append(o.Keys, key[0])
+			continue
+		}
+		o.Keys = append(o.Keys, fmt.Sprintf("(%s)", strings.Join(key, ", ")))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return o, nil
+}
+
+// relationName return the name of the relation such as `posts(user_id) -> users(id)`
+func relationName(r *schema.Relation) string {
+	return fmt.Sprintf("%s(%s) -> %s(%s)", r.Table.Name, strings.Join(columnNames(r.Columns), ", "), r.ParentTable.Name, strings.Join(columnNames(r.ParentColumns), ", "))
+}
+
+func columnNames(columns []*schema.Column) []string {
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	return names
+}
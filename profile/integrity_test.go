@@ -0,0 +1,54 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	tests := []struct {
+		isAdditional bool
+		masks        []config.SampleMask
+		sensitive    bool
+		want         []string
+	}{
+		{true, nil, false, []string{"posts.user_id: 3 orphaned rows refer to missing users(id): 5, 6"}},
+		{true, []config.SampleMask{{Columns: []string{"posts.user_id"}}}, false, []string{"posts.user_id: 3 orphaned rows refer to missing users(id): ****, ****"}},
+		{true, nil, true, []string{"posts.user_id: 3 orphaned rows refer to missing users(id): ****, ****"}},
+		{false, nil, false, []string{}},
+	}
+	for _, tt := range tests {
+		conn, s := newTestDB(t, "INSERT INTO posts VALUES (2, 5, 'a'), (3, 5, 'b'), (4, 6, 'c'), (5, 1, 'd')")
+		users, _ := s.FindTableByName("users")
+		posts, _ := s.FindTableByName("posts")
+		uid, _ := users.FindColumnByName("id")
+		pid, _ := posts.FindColumnByName("user_id")
+		if tt.sensitive {
+			uid.Sensitivity = &schema.Sensitivity{Label: "PII", Detectors: []string{"id"}}
+		}
+		s.Relations = append(s.Relations, &schema.Relation{
+			Table:         posts,
+			Columns:       []*schema.Column{pid},
+			ParentTable:   users,
+			ParentColumns: []*schema.Column{uid},
+			IsAdditional:  tt.isAdditional,
+		})
+		c := config.New()
+		c.Samples.Masks = tt.masks
+		orphans, err := CheckIntegrity(context.Background(), conn, s, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, o := range orphans {
+			got = append(got, fmt.Sprintf("%s: %s", o.Target(), o))
+		}
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
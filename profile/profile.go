@@ -0,0 +1,184 @@
+// Package profile collects the data of tables (sample rows, ...) from the database in addition to the analyzed schema
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/drivers"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
+)
+
+// maxValueLength is the max length (in runes) of values rendered in the documents
+const maxValueLength = 100
+
+// Enabled return whether the config collects the data of tables
+func Enabled(c *config.Config) bool {
+	return c.Samples.Enabled() || c.RowCount != "" || c.ColumnStats.Enabled() || c.EnumValues.Enabled() || c.Storage.Enabled || c.Freshness.Enabled() || detectsSensitiveValues(c)
+}
+
+// WithoutData return the copy of the config collecting nothing from the data of tables except sensitive values to detect.
+// Sensitivity detected from values is a part of the schema, but row counts, statistics, sample rows, storage and freshness change with the data.
+func WithoutData(c *config.Config) *config.Config {
+	cc := *c
+	cc.Samples = config.Samples{}
+	cc.RowCount = ""
+	cc.ColumnStats = config.ColumnStats{}
+	cc.EnumValues = config.EnumValues{}
+	cc.Storage = config.Storage{}
+	cc.Freshness = config.Freshness{}
+	return &cc
+}
+
+// Run collect the data of tables of the schema enabled in the config. Tables are profiled by c.Concurrency workers, and each query is canceled after c.QueryTimeout.
+func Run(ctx context.Context, db *sql.DB, s *schema.Schema, c *config.Config) error {
+	defer trace.Start(ctx, "profile")()
+	var (
+		estimates   map[string]int64
+		hasEstimate bool
+	)
+	if c.RowCount == config.RowCountEstimate {
+		qctx, cancel := drivers.WithQueryTimeout(ctx, c.QueryTimeout)
+		defer cancel()
+		var err error
+		estimates, hasEstimate, err = estimateRowCounts(qctx, db, s)
+		if err != nil {
+			return err
+		}
+	}
+	if c.Storage.Enabled {
+		if err := runStep(ctx, c, func(ctx context.Context) error {
+			return collectStorage(ctx, db, s)
+		}); err != nil {
+			return err
+		}
+	}
+	return drivers.ParallelProgress(ctx, "profile", len(s.Tables), c.Concurrency, func(i int) error {
+		t := s.Tables[i]
+		if t.Virtual {
+			// virtual tables declared in additional data do not exist in the database
+			return nil
+		}
+		steps := []func(context.Context) error{}
+		switch {
+		case c.RowCount == "" || !countable(t):
+		case hasEstimate:
+			if count, ok := estimates[t.Name]; ok {
+				t.RowCount = &schema.RowCount{Count: count, Estimated: true}
+			}
+		default:
+			// drivers without statistics (SQLite) count rows exactly
+			steps = append(steps, func(ctx context.Context) error {
+				return countRows(ctx, db, s.Driver, t)
+			})
+		}
+		if col, ok := c.Freshness.Column(t); ok && countable(t) {
+			steps = append(steps, func(ctx context.Context) error {
+				return collectFreshness(ctx, db, s.Driver, t, col)
+			})
+		}
+		// values of sensitive columns are masked, so they are detected before collecting values
+		if detectsSensitiveValues(c) {
+			steps = append(steps, func(ctx context.Context) error {
+				return detectSensitiveValues(ctx, db, s.Driver, t, c.DetectSensitivity.SampleRows, c.DetectSensitivity.SensitivityDetectors())
+			})
+		}
+		if c.ColumnStats.Allowed(t.Name) {
+			steps = append(steps, func(ctx context.Context) error {
+				return collectColumnStats(ctx, db, s.Driver, t, c.ColumnStats.SampleSize, c.Samples)
+			})
+		}
+		if c.Samples.Allowed(t.Name) {
+			steps = append(steps, func(ctx context.Context) error {
+				return selectSampleRows(ctx, db, s.Driver, t, c.Samples)
+			})
+		}
+		for _, step := range steps {
+			if err := runStep(ctx, c, step); err != nil {
+				return err
+			}
+		}
+		// percentiles need the number of non-NULL values from the statistics
+		if c.ColumnStats.Allowed(t.Name) && len(c.ColumnStats.Percentiles) > 0 {
+			if err := collectPercentiles(ctx, db, s.Driver, t, c); err != nil {
+				return err
+			}
+		}
+		if c.EnumValues.Allowed(t.Name) {
+			return collectEnumValues(ctx, db, s.Driver, t, c)
+		}
+		return nil
+	})
+}
+
+// detectsSensitiveValues return whether values of columns are matched with sensitivity detectors
+func detectsSensitiveValues(c *config.Config) bool {
+	return c.DetectSensitivity.Enabled && c.DetectSensitivity.SampleRows > 0
+}
+
+// maskValue return the replacement of values of the column, and whether values of the column are masked.
+// Values of sensitive columns are masked with the default replacement unless samples.masks matches the column.
+func maskValue(samples config.Samples, table string, col *schema.Column) (string, bool) {
+	if r, ok := samples.Mask(table, col.Name); ok {
+		return r, true
+	}
+	if col.Sensitivity != nil {
+		return config.DefaultSampleMaskReplacement, true
+	}
+	return "", false
+}
+
+// runStep run the step of profiling with the query timeout of the config
+func runStep(ctx context.Context, c *config.Config, step func(context.Context) error) error {
+	qctx, cancel := drivers.WithQueryTimeout(ctx, c.QueryTimeout)
+	defer cancel()
+	return step(qctx)
+}
+
+// quoteIdent quote the identifier in the SQL of the driver
+func quoteIdent(driver, name string) string {
+	if driver == "mysql" {
+		return fmt.Sprintf("`%s`", strings.Replace(name, "`", "``", -1))
+	}
+	return fmt.Sprintf(`"%s"`, strings.Replace(name, `"`, `""`, -1))
+}
+
+// quoteTable quote the table name in the SQL of the driver. Table names of PostgreSQL are qualified by the schema unless it is public.
+func quoteTable(driver, name string) string {
+	if driver == "postgres" {
+		if splitted := strings.SplitN(name, ".", 2); len(splitted) == 2 {
+			return fmt.Sprintf("%s.%s", quoteIdent(driver, splitted[0]), quoteIdent(driver, splitted[1]))
+		}
+	}
+	return quoteIdent(driver, name)
+}
+
+// visibleColumns return columns of the table not hidden
+func visibleColumns(t *schema.Table) []*schema.Column {
+	columns := []*schema.Column{}
+	for _, c := range t.Columns {
+		if !c.Hidden {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// formatValue return the value to be rendered in the documents
+func formatValue(v sql.NullString) string {
+	if !v.Valid {
+		return "NULL"
+	}
+	if !utf8.ValidString(v.String) {
+		return "(binary)"
+	}
+	if utf8.RuneCountInString(v.String) > maxValueLength {
+		return string([]rune(v.String)[:maxValueLength]) + "..."
+	}
+	return v.String
+}
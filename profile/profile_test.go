@@ -0,0 +1,463 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/db"
+	"github.com/k1LoW/tbls/schema"
+)
+
+func TestRunSampleRows(t *testing.T) {
+	conn, s := newTestDB(t)
+	c := config.New()
+	c.Samples = config.Samples{
+		Rows:   2,
+		Tables: []string{"users"},
+		Masks: []config.SampleMask{
+			{Columns: []string{"users.email"}},
+		},
+	}
+	if err := Run(context.Background(), conn, s, c); err != nil {
+		t.Fatal(err)
+	}
+	users, _ := s.FindTableByName("users")
+	want := &schema.SampleRows{
+		Columns: []string{"id", "email", "bio"},
+		Rows: [][]string{
+			{"1", config.DefaultSampleMaskReplacement, "NULL"},
+			{"2", config.DefaultSampleMaskReplacement, strings.Repeat("a", maxValueLength) + "..."},
+		},
+	}
+	if fmt.Sprintf("%v", users.SampleRows) != fmt.Sprintf("%v", want) {
+		t.Errorf("actual %v\nwant %v", users.SampleRows, want)
+	}
+	posts, _ := s.FindTableByName("posts")
+	if posts.SampleRows != nil {
+		t.Errorf("actual %v\nwant %v", posts.SampleRows, nil)
+	}
+}
+
+func TestRunSampleRowsHiddenColumns(t *testing.T) {
+	conn, s := newTestDB(t)
+	if err := s.HideColumns([]string{"bio"}); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New()
+	c.Samples = config.Samples{Rows: 1, Tables: []string{"*"}}
+	if err := Run(context.Background(), conn, s, c); err != nil {
+		t.Fatal(err)
+	}
+	users, _ := s.FindTableByName("users")
+	want := []string{"id", "email"}
+	if fmt.Sprintf("%v", users.SampleRows.Columns) != fmt.Sprintf("%v", want) {
+		t.Errorf("actual %v\nwant %v", users.SampleRows.Columns, want)
+	}
+}
+
+func TestRunRowCount(t *testing.T) {
+	for _, mode := range []string{config.RowCountExact, config.RowCountEstimate} {
+		conn, s := newTestDB(t)
+		c := config.New()
+		c.RowCount = mode
+		if err := Run(context.Background(), conn, s, c); err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			table string
+			want  *schema.RowCount
+		}{
+			{"users", &schema.RowCount{Count: 3}},
+			{"posts", &schema.RowCount{Count: 1}},
+			{"user_posts", nil},
+		}
+		for _, tt := range tests {
+			table, _ := s.FindTableByName(tt.table)
+			if fmt.Sprintf("%v", table.RowCount) != fmt.Sprintf("%v", tt.want) {
+				t.Errorf("%s, %s: actual %v\nwant %v", mode, tt.table, table.RowCount, tt.want)
+			}
+		}
+	}
+}
+
+func TestRunColumnStats(t *testing.T) {
+	tests := []struct {
+		sampleSize int
+		want       map[string]string
+	}{
+		{0, map[string]string{
+			"id":    "rows=3 nulls=0 distinct=3 min=1 max=3",
+			"email": "rows=3 nulls=0 distinct=3 min=**** max=****",
+			"bio":   "rows=3 nulls=1 distinct=2 min=" + strings.Repeat("a", maxValueLength) + "... max=c",
+		}},
+		{1, map[string]string{
+			"id":    "rows=1 nulls=0 distinct=1 min=1 max=1",
+			"email": "rows=1 nulls=0 distinct=1 min=**** max=****",
+			"bio":   "rows=1 nulls=1 distinct=0",
+		}},
+	}
+	for _, tt := range tests {
+		conn, s := newTestDB(t)
+		c := config.New()
+		c.ColumnStats = config.ColumnStats{Tables: []string{"users"}, SampleSize: tt.sampleSize}
+		c.Samples.Masks = []config.SampleMask{{Columns: []string{"email"}}}
+		if err := Run(context.Background(), conn, s, c); err != nil {
+			t.Fatal(err)
+		}
+		users, _ := s.FindTableByName("users")
+		for _, col := range users.Columns {
+			if got := formatStats(col.Stats); got != tt.want[col.Name] {
+				t.Errorf("%d, %s: actual %v\nwant %v", tt.sampleSize, col.Name, got, tt.want[col.Name])
+			}
+		}
+		posts, _ := s.FindTableByName("posts")
+		if posts.Columns[0].Stats != nil {
+			t.Errorf("actual %v\nwant %v", posts.Columns[0].Stats, nil)
+		}
+	}
+}
+
+func TestRunDetectSensitiveValues(t *testing.T) {
+	conn, s := newTestDB(t)
+	c := config.New()
+	c.DetectSensitivity = config.DetectSensitivity{
+		Enabled:    true,
+		SampleRows: 10,
+		Detectors:  []schema.SensitivityDetector{{Name: "title", Label: "internal", Value: "^hello$"}},
+	}
+	if err := Run(context.Background(), conn, s, c); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		table  string
+		column string
+		want   string
+	}{
+		{"users", "id", ""},
+		{"users", "email", "PII (email)"},
+		{"users", "bio", ""},
+		{"posts", "title", "internal (title)"},
+		{"user_posts", "email", "PII (email)"},
+	}
+	for _, tt := range tests {
+		table, _ := s.FindTableByName(tt.table)
+		col, err := table.FindColumnByName(tt.column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := col.Sensitivity.String(); got != tt.want {
+			t.Errorf("%s.%s: actual %v\nwant %v", tt.table, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestRunMaskSensitiveColumns(t *testing.T) {
+	conn, s := newTestDB(t)
+	c := config.New()
+	c.DetectSensitivity = config.DetectSensitivity{
+		Enabled:    true,
+		SampleRows: 10,
+		Detectors:  []schema.SensitivityDetector{{Name: "title", Label: "internal", Value: "^hello$"}},
+	}
+	c.Samples = config.Samples{Rows: 1, Tables: []string{"*"}}
+	c.ColumnStats = config.ColumnStats{Tables: []string{"*"}}
+	if err := Run(context.Background(), conn, s, c); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		table     string
+		wantRows  string
+		column    string
+		wantStats string
+	}{
+		{"users", "[[1 **** NULL]]", "email", "rows=3 nulls=0 distinct=3 min=**** max=****"},
+		{"posts", "[[1 1 ****]]", "title", "rows=1 nulls=0 distinct=1 min=**** max=****"},
+	}
+	for _, tt := range tests {
+		table, _ := s.FindTableByName(tt.table)
+		if got := fmt.Sprintf("%v", table.SampleRows.Rows); got != tt.wantRows {
+			t.Errorf("%s: actual %v\nwant %v", tt.table, got, tt.wantRows)
+		}
+		col, _ := table.FindColumnByName(tt.column)
+		if got := formatStats(col.Stats); got != tt.wantStats {
+			t.Errorf("%s.%s: actual %v\nwant %v", tt.table, tt.column, got, tt.wantStats)
+		}
+	}
+}
+
+func TestRunEnumValues(t *testing.T) {
+	tests := []struct {
+		enumValues config.EnumValues
+		masks      []config.SampleMask
+		want       map[string]string
+	}{
+		{config.EnumValues{Tables: []string{"posts"}}, nil, map[string]string{
+			"id":      "[]",
+			"user_id": "[1 (2) 2 (1)]",
+			"title":   "[hello (2) bye (1)]",
+		}},
+		{config.EnumValues{Tables: []string{"posts"}, Threshold: 1}, nil, map[string]string{
+			"id":      "[]",
+			"user_id": "[]",
+			"title":   "[]",
+		}},
+		{config.EnumValues{Tables: []string{"posts"}, SampleSize: 2}, []config.SampleMask{{Columns: []string{"title"}}}, map[string]string{
+			"id":      "[]",
+			"user_id": "[1 (2)]",
+			"title":   "[]",
+		}},
+	}
+	for _, tt := range tests {
+		conn, s := newTestDB(t, "INSERT INTO posts VALUES (2, 1, 'hello'), (3, 2, 'bye')")
+		c := config.New()
+		c.EnumValues = tt.enumValues
+		c.Samples.Masks = tt.masks
+		if err := Run(context.Background(), conn, s, c); err != nil {
+			t.Fatal(err)
+		}
+		posts, _ := s.FindTableByName("posts")
+		for _, col := range posts.Columns {
+			got := []string{}
+			for _, v := range col.EnumValues {
+				got = append(got, fmt.Sprintf("%s (%d)", v.Value, v.Count))
+			}
+			if fmt.Sprintf("%v", got) != tt.want[col.Name] {
+				t.Errorf("%v, %s: actual %v\nwant %v", tt.enumValues, col.Name, got, tt.want[col.Name])
+			}
+		}
+		users, _ := s.FindTableByName("users")
+		if users.Columns[0].EnumValues != nil {
+			t.Errorf("actual %v\nwant %v", users.Columns[0].EnumValues, nil)
+		}
+	}
+}
+
+func TestRunStorageUnsupportedDriver(t *testing.T) {
+	conn, s := newTestDB(t)
+	c := config.New()
+	c.Storage.Enabled = true
+	if err := Run(context.Background(), conn, s, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range s.Tables {
+		if table.Storage != nil {
+			t.Errorf("%s: actual %v\nwant %v", table.Name, table.Storage, nil)
+		}
+	}
+}
+
+func TestRunFreshness(t *testing.T) {
+	conn, s := newTestDB(t, "CREATE TABLE logs (id INTEGER, created_at TEXT)")
+	c := config.New()
+	c.Freshness.Columns = []string{"title", "created_at", "email"}
+	if err := Run(context.Background(), conn, s, c); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"users", "email: carol@example.com"},
+		{"posts", "title: hello"},
+		{"user_posts", "<nil>"},
+		{"logs", "created_at: -"},
+	}
+	for _, tt := range tests {
+		table, _ := s.FindTableByName(tt.table)
+		got := "<nil>"
+		if table.Freshness != nil {
+			got = fmt.Sprintf("%s: %s", table.Freshness.Column, table.Freshness)
+		}
+		if got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestRunPercentiles(t *testing.T) {
+	tests := []struct {
+		sampleSize int
+		masks      []config.SampleMask
+		want       map[string]string
+	}{
+		{0, nil, map[string]string{"id": "[p0: 1 p50: 2 p90: 3 p100: 3]", "email": "[]", "bio": "[]"}},
+		{2, nil, map[string]string{"id": "[p0: 1 p50: 1 p90: 2 p100: 2]", "email": "[]", "bio": "[]"}},
+		{0, []config.SampleMask{{Columns: []string{"users.id"}}}, map[string]string{"id": "[]", "email": "[]", "bio": "[]"}},
+	}
+	for _, tt := range tests {
+		conn, s := newTestDB(t)
+		c := config.New()
+		c.ColumnStats = config.ColumnStats{Tables: []string{"users"}, SampleSize: tt.sampleSize, Percentiles: []int{0, 50, 90, 100}}
+		c.Samples.Masks = tt.masks
+		if err := Run(context.Background(), conn, s, c); err != nil {
+			t.Fatal(err)
+		}
+		users, _ := s.FindTableByName("users")
+		for _, col := range users.Columns {
+			got := []string{}
+			for _, p := range col.Stats.Percentiles {
+				got = append(got, fmt.Sprintf("p%d: %s", p.P, p.Value))
+			}
+			if fmt.Sprintf("%v", got) != tt.want[col.Name] {
+				t.Errorf("%d, %s: actual %v\nwant %v", tt.sampleSize, col.Name, got, tt.want[col.Name])
+			}
+		}
+	}
+}
+
+func TestPercentileOffset(t *testing.T) {
+	tests := []struct {
+		p    int
+		n    int64
+		want int64
+	}{
+		{0, 3, 0},
+		{50, 3, 1},
+		{90, 3, 2},
+		{100, 3, 2},
+		{50, 4, 1},
+		{75, 4, 2},
+		{99, 100, 98},
+		{1, 1000, 9},
+		{90, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := percentileOffset(tt.p, tt.n); got != tt.want {
+			t.Errorf("p%d of %d: actual %v\nwant %v", tt.p, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizable(t *testing.T) {
+	tests := []struct {
+		driver string
+		typ    string
+		want   bool
+	}{
+		{"postgres", "integer", true},
+		{"postgres", "timestamp without time zone", true},
+		{"postgres", "character varying(255)", false},
+		{"postgres", "point", false},
+		{"postgres", "integer[]", false},
+		{"mysql", "decimal(10,2)", true},
+		{"sqlite3", "DATETIME", true},
+		{"sqlite3", "TEXT", false},
+	}
+	for _, tt := range tests {
+		if got := summarizable(tt.driver, tt.typ); got != tt.want {
+			t.Errorf("%s %s: actual %v\nwant %v", tt.driver, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestOrderable(t *testing.T) {
+	tests := []struct {
+		driver          string
+		typ             string
+		orderable       bool
+		distinguishable bool
+	}{
+		{"postgres", "integer", true, true},
+		{"postgres", "character varying(255)", true, true},
+		{"postgres", "timestamp without time zone", true, true},
+		{"postgres", "boolean", false, true},
+		{"postgres", "uuid", false, true},
+		{"postgres", "json", false, false},
+		{"postgres", "jsonb", false, false},
+		{"postgres", "point", false, false},
+		{"postgres", "integer[]", false, true},
+		{"mysql", "bigint(20) unsigned", true, true},
+		{"mysql", "enum('a','b')", false, true},
+		{"mysql", "linestring", false, false},
+		{"sqlite3", "", true, true},
+	}
+	for _, tt := range tests {
+		if got := orderable(tt.driver, tt.typ); got != tt.orderable {
+			t.Errorf("%s %s: actual %v\nwant %v", tt.driver, tt.typ, got, tt.orderable)
+		}
+		if got := distinguishable(tt.driver, tt.typ); got != tt.distinguishable {
+			t.Errorf("%s %s: actual %v\nwant %v", tt.driver, tt.typ, got, tt.distinguishable)
+		}
+	}
+}
+
+func TestQuoteTable(t *testing.T) {
+	tests := []struct {
+		driver string
+		name   string
+		want   string
+	}{
+		{"postgres", "users", `"users"`},
+		{"postgres", "administrator.blogs", `"administrator"."blogs"`},
+		{"mysql", "user`s", "`user``s`"},
+		{"sqlite3", "a.b", `"a.b"`},
+	}
+	for _, tt := range tests {
+		if got := quoteTable(tt.driver, tt.name); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func formatStats(s *schema.ColumnStats) string {
+	if s == nil {
+		return "<nil>"
+	}
+	f := fmt.Sprintf("rows=%d nulls=%d", s.Rows, s.Nulls)
+	if s.Distinct != nil {
+		f += fmt.Sprintf(" distinct=%d", *s.Distinct)
+	}
+	if s.Min != nil {
+		f += fmt.Sprintf(" min=%s max=%s", *s.Min, *s.Max)
+	}
+	return f
+}
+
+// newTestDB return the connection to the SQLite database with rows and its schema, running extra statements before analyzing it
+func newTestDB(t *testing.T, extra ...string) (*sql.DB, *schema.Schema) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "tbls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "profile.sqlite3")
+	dsn := fmt.Sprintf("sq://%s", path)
+	// rows are inserted without db.Open, since its sessions are read-only
+	w, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stmts := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL, bio TEXT)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL, title TEXT)",
+		"INSERT INTO users VALUES (1, 'alice@example.com', NULL)",
+		fmt.Sprintf("INSERT INTO users VALUES (2, 'bob@example.com', '%s')", strings.Repeat("a", maxValueLength+1)),
+		"INSERT INTO users VALUES (3, 'carol@example.com', 'c')",
+		"INSERT INTO posts VALUES (1, 1, 'hello')",
+		"CREATE VIEW user_posts AS SELECT users.email, posts.title FROM users INNER JOIN posts ON posts.user_id = users.id",
+	}
+	for _, stmt := range append(stmts, extra...) {
+		if _, err := w.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn, err := db.Open(context.Background(), dsn, db.Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s, err := db.Analyze(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, s
+}
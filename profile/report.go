@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/drivers"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/k1LoW/tbls/trace"
+	"github.com/pkg/errors"
+)
+
+// TableReport is the data profile of the table of `tbls profile`
+type TableReport struct {
+	Table *schema.Table
+	// Rows is the number of rows scanned
+	Rows    int64
+	Columns []*ColumnReport
+}
+
+// ColumnReport is the data profile of the column
+type ColumnReport struct {
+	Column *schema.Column
+	// Nulls is the number of NULL values. It is nil when nulls are not profiled.
+	Nulls *int64
+	// Cardinality is the number of distinct values. It is nil when it is not profiled or the values can not be compared.
+	Cardinality *int64
+	// TopValues is the most frequent values in descending order of the count
+	TopValues []*ValueCount
+}
+
+// ValueCount is the value and the number of rows with it
+type ValueCount struct {
+	Value string
+	Count int64
+}
+
+// NullRatio return the ratio of NULL values formatted in percent
+func (r *TableReport) NullRatio(c *ColumnReport) string {
+	if c.Nulls == nil || r.Rows == 0 {
+		return ""
+	}
+	return schema.ColumnStats{Rows: r.Rows, Nulls: *c.Nulls}.NullRatio()
+}
+
+// Report profile tables of the schema allowed by profile.tables of the config with the metrics of profile.metrics.
+// Values of columns masked by samples.masks are masked.
+func Report(ctx context.Context, db *sql.DB, s *schema.Schema, c *config.Config) ([]*TableReport, error) {
+	defer trace.Start(ctx, "profile")()
+	tables := []*schema.Table{}
+	for _, t := range s.Tables {
+		if c.Profile.Allowed(t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	reports := make([]*TableReport, len(tables))
+	err := drivers.Parallel(len(tables), c.Concurrency, func(i int) error {
+		r, err := reportTable(ctx, db, s.Driver, tables[i], c)
+		if err != nil {
+			return err
+		}
+		reports[i] = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// reportTable profile the table
+func reportTable(ctx context.Context, db *sql.DB, driver string, t *schema.Table, c *config.Config) (*TableReport, error) {
+	p := c.Profile
+	r := &TableReport{Table: t, Columns: []*ColumnReport{}}
+	columns := visibleColumns(t)
+	if len(columns) == 0 {
+		return r, nil
+	}
+	// the aggregate query also counts rows scanned, so it runs even when neither nulls nor cardinality is profiled
+	err := runStep(ctx, c, func(ctx context.Context) error {
+		return collectColumnStats(ctx, db, driver, t, p.SampleSize, c.Samples)
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, col := range columns {
+		names = append(names, quoteIdent(driver, col.Name))
+	}
+	for _, col := range columns {
+		cr := &ColumnReport{Column: col, TopValues: []*ValueCount{}}
+		if col.Stats != nil {
+			r.Rows = col.Stats.Rows
+			if p.Enabled(config.ProfileNulls) {
+				nulls := col.Stats.Nulls
+				cr.Nulls = &nulls
+			}
+			if p.Enabled(config.ProfileCardinality) {
+				cr.Cardinality = col.Stats.Distinct
+			}
+		}
+		if p.Enabled(config.ProfileTopK) && distinguishable(driver, col.Type) {
+			err := runStep(ctx, c, func(ctx context.Context) error {
+				values, err := topValues(ctx, db, driver, t, col, sampledFrom(driver, t.Name, names, p.SampleSize), p.K(), c.Samples)
+				cr.TopValues = values
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		r.Columns = append(r.Columns, cr)
+	}
+	return r, nil
+}
+
+// topValues return the k most frequent values of the column. Ties are ordered by the value.
+func topValues(ctx context.Context, db *sql.DB, driver string, t *schema.Table, col *schema.Column, from string, k int, samples config.Samples) ([]*ValueCount, error) {
+	q := quoteIdent(driver, col.Name)
+	query := fmt.Sprintf("SELECT %s, COUNT(*) AS n FROM %s GROUP BY %s ORDER BY n DESC, %s LIMIT %d", q, from, q, q, k)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to select top values of column '%s.%s'", t.Name, col.Name))
+	}
+	defer rows.Close()
+	mask, masked := samples.Mask(t.Name, col.Name)
+	values := []*ValueCount{}
+	for rows.Next() {
+		var (
+			v sql.NullString
+			n int64
+		)
+		if err := rows.Scan(&v, &n); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		value := formatValue(v)
+		if masked {
+			value = mask
+		}
+		values = append(values, &ValueCount{Value: value, Count: n})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return values, nil
+}
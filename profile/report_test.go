@@ -0,0 +1,68 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/tbls/config"
+)
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		profile config.Profile
+		want    []string
+	}{
+		{
+			config.Profile{Tables: []string{"users"}, TopK: 2},
+			[]string{
+				"users rows=3",
+				"id nulls=0 cardinality=3 top=[1 (1), 2 (1)]",
+				"email nulls=0 cardinality=3 top=[**** (1), **** (1)]",
+				fmt.Sprintf("bio nulls=1 cardinality=2 top=[NULL (1), %s... (1)]", strings.Repeat("a", maxValueLength)),
+			},
+		},
+		{
+			config.Profile{Tables: []string{"posts"}, Metrics: []string{config.ProfileTopK}, SampleSize: 1},
+			[]string{
+				"posts rows=1",
+				"id top=[1 (1)]",
+				"user_id top=[1 (1)]",
+				"title top=[hello (1)]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		conn, s := newTestDB(t)
+		c := config.New()
+		c.Profile = tt.profile
+		c.Samples.Masks = []config.SampleMask{{Columns: []string{"email"}}}
+		reports, err := Report(context.Background(), conn, s, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reports) != 1 {
+			t.Fatalf("actual %v\nwant %v", len(reports), 1)
+		}
+		got := []string{fmt.Sprintf("%s rows=%d", reports[0].Table.Name, reports[0].Rows)}
+		for _, cr := range reports[0].Columns {
+			f := cr.Column.Name
+			if cr.Nulls != nil {
+				f += fmt.Sprintf(" nulls=%d", *cr.Nulls)
+			}
+			if cr.Cardinality != nil {
+				f += fmt.Sprintf(" cardinality=%d", *cr.Cardinality)
+			}
+			values := []string{}
+			for _, v := range cr.TopValues {
+				values = append(values, fmt.Sprintf("%s (%d)", v.Value, v.Count))
+			}
+			f += fmt.Sprintf(" top=[%s]", strings.Join(values, ", "))
+			got = append(got, f)
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("actual %v\nwant %v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// countable return whether rows of the table are counted. Views and foreign tables are not counted since counting them runs their queries.
+func countable(t *schema.Table) bool {
+	switch strings.ToUpper(t.Type) {
+	case "BASE TABLE", "TABLE":
+		return true
+	}
+	return false
+}
+
+// countRows count rows of the table with COUNT(*)
+func countRows(ctx context.Context, db *sql.DB, driver string, t *schema.Table) error {
+	var count int64
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteTable(driver, t.Name))).Scan(&count)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to count rows of table '%s'", t.Name))
+	}
+	t.RowCount = &schema.RowCount{Count: count}
+	return nil
+}
+
+// estimateRowCounts return the estimated row counts of tables from the statistics of the planner, keyed by table name.
+// It returns false when the driver has no statistics of row counts.
+func estimateRowCounts(ctx context.Context, db *sql.DB, s *schema.Schema) (map[string]int64, bool, error) {
+	var query string
+	var args []interface{}
+	switch s.Driver {
+	case "postgres":
+		// reltuples is -1 (or 0 before PostgreSQL 14) for tables never vacuumed or analyzed
+		query = `
+SELECT n.nspname, c.relname, c.reltuples::bigint
+FROM pg_class c
+INNER JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p', 'm') AND c.reltuples >= 0`
+	case "mysql":
+		query = `SELECT table_schema, table_name, table_rows FROM information_schema.tables WHERE table_schema = ? AND table_rows IS NOT NULL`
+		args = append(args, s.Name)
+	default:
+		return nil, false, nil
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.WithStack(err), "failed to estimate row counts")
+	}
+	defer rows.Close()
+	counts := map[string]int64{}
+	for rows.Next() {
+		var (
+			tableSchema string
+			tableName   string
+			count       int64
+		)
+		if err := rows.Scan(&tableSchema, &tableName, &count); err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		if s.Driver == "postgres" && tableSchema != "public" {
+			tableName = fmt.Sprintf("%s.%s", tableSchema, tableName)
+		}
+		counts[tableName] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return counts, true, nil
+}
@@ -0,0 +1,64 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/k1LoW/tbls/config"
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// selectSampleRows select sample rows of the table, replacing values of masked columns and sensitive columns
+func selectSampleRows(ctx context.Context, db *sql.DB, driver string, t *schema.Table, c config.Samples) error {
+	columns := visibleColumns(t)
+	if len(columns) == 0 {
+		return nil
+	}
+	names := []string{}
+	selects := []string{}
+	masks := make([]*string, len(columns))
+	for i, col := range columns {
+		names = append(names, col.Name)
+		if r, ok := maskValue(c, t.Name, col); ok {
+			// masked values are never selected
+			masks[i] = &r
+			selects = append(selects, "NULL")
+			continue
+		}
+		selects = append(selects, quoteIdent(driver, col.Name))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s LIMIT %d", strings.Join(selects, ", "), quoteTable(driver, t.Name), c.Rows)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to select sample rows of table '%s'", t.Name))
+	}
+	defer rows.Close()
+	samples := &schema.SampleRows{Columns: names, Rows: [][]string{}}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return errors.WithStack(err)
+		}
+		row := []string{}
+		for i, v := range values {
+			if masks[i] != nil {
+				row = append(row, *masks[i])
+				continue
+			}
+			row = append(row, formatValue(v))
+		}
+		samples.Rows = append(samples.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	t.SampleRows = samples
+	return nil
+}
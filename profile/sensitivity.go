@@ -0,0 +1,65 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// detectSensitiveValues tag columns of the table whose all non-NULL values of the first n rows match the value patterns of the detectors
+func detectSensitiveValues(ctx context.Context, db *sql.DB, driver string, t *schema.Table, n int, detectors []schema.SensitivityDetector) error {
+	columns := []*schema.Column{}
+	for _, col := range visibleColumns(t) {
+		if distinguishable(driver, col.Type) {
+			columns = append(columns, col)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	names := []string{}
+	for _, col := range columns {
+		names = append(names, quoteIdent(driver, col.Name))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s LIMIT %d", strings.Join(names, ", "), quoteTable(driver, t.Name), n)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to select values of table '%s'", t.Name))
+	}
+	defer rows.Close()
+	values := make([][]string, len(columns))
+	for rows.Next() {
+		row := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return errors.WithStack(err)
+		}
+		for i, v := range row {
+			if v.Valid {
+				values[i] = append(values[i], v.String)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, d := range detectors {
+		for i, col := range columns {
+			match, err := d.MatchValues(values[i])
+			if err != nil {
+				return err
+			}
+			if match {
+				col.TagSensitivity(d)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/k1LoW/tbls/schema"
+	"github.com/pkg/errors"
+)
+
+// collectStorage set on-disk sizes of tables from the catalog. Sizes are not collected for drivers other than PostgreSQL and MySQL.
+func collectStorage(ctx context.Context, db *sql.DB, s *schema.Schema) error {
+	var query string
+	var args []interface{}
+	switch s.Driver {
+	case "postgres":
+		// pg_table_size includes TOAST, so it is subtracted
+		query = `
+SELECT n.nspname, c.relname,
+  pg_table_size(c.oid) - COALESCE(pg_total_relation_size(NULLIF(c.reltoastrelid, 0)), 0),
+  pg_indexes_size(c.oid),
+  COALESCE(pg_total_relation_size(NULLIF(c.reltoastrelid, 0)), 0)
+FROM pg_class c
+INNER JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p', 'm')`
+	case "mysql":
+		query = `SELECT table_schema, table_name, COALESCE(data_length, 0), COALESCE(index_length, 0), NULL FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE'`
+		args = append(args, s.Name)
+	default:
+		return nil
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to collect sizes of tables")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			tableSchema string
+			tableName   string
+			size        int64
+			indexes     int64
+			toast       sql.NullInt64
+		)
+		if err := rows.Scan(&tableSchema, &tableName, &size, &indexes, &toast); err != nil {
+			return errors.WithStack(err)
+		}
+		if s.Driver == "postgres" && tableSchema != "public" {
+			tableName = fmt.Sprintf("%s.%s", tableSchema, tableName)
+		}
+		t, err := s.FindTableByName(tableName)
+		if err != nil {
+			continue
+		}
+		t.Storage = &schema.Storage{Table: size, Indexes: indexes}
+		if toast.Valid {
+			t.Storage.Toast = &toast.Int64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
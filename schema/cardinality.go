@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Cardinality of child rows per parent row
+const (
+	ZeroOrOne  = "zero-or-one"
+	ExactlyOne = "exactly-one"
+	ZeroOrMore = "zero-or-more"
+	OneOrMore  = "one-or-more"
+)
+
+var cardinalityAliases = map[string]string{
+	"one-to-one":         ExactlyOne,
+	"one-to-zero-or-one": ZeroOrOne,
+	"one-to-many":        ZeroOrMore,
+	"one-to-one-or-more": OneOrMore,
+}
+
+// ParseCardinality normalize cardinality of relation. Empty means unspecified (rendered as zero-or-more).
+func ParseCardinality(c string) (string, error) {
+	switch c {
+	case "", ZeroOrOne, ExactlyOne, ZeroOrMore, OneOrMore:
+		return c, nil
+	}
+	if n, ok := cardinalityAliases[c]; ok {
+		return n, nil
+	}
+	return "", errors.WithStack(fmt.Errorf("unsupported cardinality '%s'", c))
+}
+
+// DetectCardinalities derive cardinalities of relations without cardinality from the nullability of foreign key columns and unique keys.
+// Child rows are zero-or-one per parent row when the foreign key columns are unique, otherwise zero-or-more.
+// The parent row is zero-or-one when any foreign key column is nullable, otherwise exactly-one.
+func (s *Schema) DetectCardinalities() {
+	for _, r := range s.Relations {
+		if r.Cardinality == "" {
+			r.Cardinality = ZeroOrMore
+			if r.Table.isUniqueColumns(r.Columns) {
+				r.Cardinality = ZeroOrOne
+			}
+		}
+		if r.ParentCardinality == "" {
+			r.ParentCardinality = ExactlyOne
+			for _, c := range r.Columns {
+				if c.Nullable {
+					r.ParentCardinality = ZeroOrOne
+					break
+				}
+			}
+		}
+	}
+}
+
+// isUniqueColumns return whether the columns are exactly the columns of the primary key or an unique key of the table
+func (t *Table) isUniqueColumns(columns []*Column) bool {
+	for _, i := range t.Indexes {
+		def := strings.ToUpper(i.Def)
+		if !strings.Contains(def, "UNIQUE") && !strings.Contains(def, "PRIMARY KEY") {
+			continue
+		}
+		if matchColumnNames(i.Columns, columns) {
+			return true
+		}
+	}
+	for _, cs := range t.Constraints {
+		if cs.Type != "PRIMARY KEY" && cs.Type != "UNIQUE" {
+			continue
+		}
+		m := reConstraintColumns.FindStringSubmatch(cs.Def)
+		if m == nil {
+			continue
+		}
+		names := []string{}
+		for _, name := range strings.Split(m[1], ",") {
+			names = append(names, strings.Trim(strings.TrimSpace(name), "`\""))
+		}
+		if matchColumnNames(names, columns) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchColumnNames(names []string, columns []*Column) bool {
+	if len(names) != len(columns) {
+		return false
+	}
+	encountered := map[string]bool{}
+	for _, n := range names {
+		encountered[n] = true
+	}
+	for _, c := range columns {
+		if !encountered[c.Name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,331 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ChangedTables return names of tables which are added or modified from the base schema JSON (output of `tbls out -t json`)
+func (s *Schema) ChangedTables(base []byte) ([]string, error) {
+	baseTables, _, err := tablesJSON(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse base schema JSON")
+	}
+	current, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	currentTables, _, err := tablesJSON(current)
+	if err != nil {
+		return nil, err
+	}
+	changed := []string{}
+	for _, t := range s.Tables {
+		b, ok := baseTables[t.Name]
+		if !ok || b != currentTables[t.Name] {
+			changed = append(changed, t.Name)
+		}
+	}
+	return changed, nil
+}
+
+// AffectedTables return names of tables whose documents are affected by the difference from the base schema JSON.
+// They are tables added or modified, tables at either end of relations added or dropped, and tables within distance (at least 1) hops from them.
+func (s *Schema) AffectedTables(base []byte, distance int) ([]string, error) {
+	changed, err := s.ChangedTables(base)
+	if err != nil {
+		return nil, err
+	}
+	seeds := map[string]bool{}
+	for _, name := range changed {
+		seeds[name] = true
+	}
+	baseRelations, err := relationsJSON(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse base schema JSON")
+	}
+	current, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	currentRelations, err := relationsJSON(current)
+	if err != nil {
+		return nil, err
+	}
+	for k, ends := range baseRelations {
+		if _, ok := currentRelations[k]; !ok {
+			seeds[ends[0]] = true
+			seeds[ends[1]] = true
+		}
+	}
+	for k, ends := range currentRelations {
+		if _, ok := baseRelations[k]; !ok {
+			seeds[ends[0]] = true
+			seeds[ends[1]] = true
+		}
+	}
+
+	if distance < 1 {
+		distance = 1
+	}
+	affected := map[*Table]bool{}
+	for _, t := range s.Tables {
+		if !seeds[t.Name] {
+			continue
+		}
+		affected[t] = true
+		for _, rt := range s.RelatedTables(t, distance) {
+			affected[rt] = true
+		}
+	}
+	names := []string{}
+	for _, t := range s.Tables {
+		if affected[t] {
+			names = append(names, t.Name)
+		}
+	}
+	return names, nil
+}
+
+// Drift is the difference of tables from the base schema
+type Drift struct {
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Dropped []string `json:"dropped"`
+}
+
+// HasDrift return whether any table is added, changed or dropped
+func (d *Drift) HasDrift() bool {
+	return len(d.Added) > 0 || len(d.Changed) > 0 || len(d.Dropped) > 0
+}
+
+// Drift return names of tables which are added, modified or dropped from the base schema JSON (output of `tbls out -t json`)
+func (s *Schema) Drift(base []byte) (*Drift, error) {
+	baseTables, baseNames, err := tablesJSON(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse base schema JSON")
+	}
+	current, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	currentTables, _, err := tablesJSON(current)
+	if err != nil {
+		return nil, err
+	}
+	d := &Drift{Added: []string{}, Changed: []string{}, Dropped: []string{}}
+	for _, t := range s.Tables {
+		b, ok := baseTables[t.Name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, t.Name)
+		case b != currentTables[t.Name]:
+			d.Changed = append(d.Changed, t.Name)
+		}
+	}
+	for _, name := range baseNames {
+		if _, ok := currentTables[name]; !ok {
+			d.Dropped = append(d.Dropped, name)
+		}
+	}
+	return d, nil
+}
+
+// TableDrift is the difference of a changed table from the base schema
+type TableDrift struct {
+	Name        string   `json:"name"`
+	Attributes  []string `json:"attributes"`
+	Columns     *Drift   `json:"columns"`
+	Indexes     *Drift   `json:"indexes"`
+	Constraints *Drift   `json:"constraints"`
+	Triggers    *Drift   `json:"triggers"`
+}
+
+// tableObjectKeys is the keys of table JSON compared per named object
+var tableObjectKeys = []string{"columns", "indexes", "constraints", "triggers"}
+
+// TableDrifts return columns, indexes, constraints and triggers added, modified or dropped, and other attributes (comment, def, ...) modified
+// of each table modified from the base schema JSON
+func (s *Schema) TableDrifts(base []byte) ([]*TableDrift, error) {
+	baseTables, _, err := tableObjects(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse base schema JSON")
+	}
+	current, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	currentTables, _, err := tableObjects(current)
+	if err != nil {
+		return nil, err
+	}
+	drifts := []*TableDrift{}
+	for _, t := range s.Tables {
+		b, ok := baseTables[t.Name]
+		if !ok {
+			continue
+		}
+		c := currentTables[t.Name]
+		td := &TableDrift{
+			Name:        t.Name,
+			Attributes:  []string{},
+			Columns:     objectsDrift(b["columns"], c["columns"]),
+			Indexes:     objectsDrift(b["indexes"], c["indexes"]),
+			Constraints: objectsDrift(b["constraints"], c["constraints"]),
+			Triggers:    objectsDrift(b["triggers"], c["triggers"]),
+		}
+		keys := []string{}
+		for k := range b {
+			keys = append(keys, k)
+		}
+		for k := range c {
+			if _, ok := b[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "name" || contains(tableObjectKeys, k) {
+				continue
+			}
+			if canonicalJSON(b[k]) != canonicalJSON(c[k]) {
+				td.Attributes = append(td.Attributes, k)
+			}
+		}
+		if len(td.Attributes) > 0 || td.Columns.HasDrift() || td.Indexes.HasDrift() || td.Constraints.HasDrift() || td.Triggers.HasDrift() {
+			drifts = append(drifts, td)
+		}
+	}
+	return drifts, nil
+}
+
+// objectsDrift return names of objects (columns, indexes, ...) added, modified or dropped
+func objectsDrift(base interface{}, current interface{}) *Drift {
+	d := &Drift{Added: []string{}, Changed: []string{}, Dropped: []string{}}
+	baseObjects, baseNames := namedObjects(base)
+	currentObjects, currentNames := namedObjects(current)
+	for _, name := range currentNames {
+		b, ok := baseObjects[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, name)
+		case b != currentObjects[name]:
+			d.Changed = append(d.Changed, name)
+		}
+	}
+	for _, name := range baseNames {
+		if _, ok := currentObjects[name]; !ok {
+			d.Dropped = append(d.Dropped, name)
+		}
+	}
+	return d
+}
+
+// namedObjects return canonical JSON of each object in the array and object names in order
+func namedObjects(v interface{}) (map[string]string, []string) {
+	objects := map[string]string{}
+	names := []string{}
+	list, _ := v.([]interface{})
+	for _, o := range list {
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		objects[name] = canonicalJSON(m)
+		names = append(names, name)
+	}
+	return objects, names
+}
+
+func canonicalJSON(v interface{}) string {
+	// json.Marshal sorts map keys
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
+
+// tablesJSON return canonical JSON of each table and table names in order
+func tablesJSON(b []byte) (map[string]string, []string, error) {
+	objects, names, err := tableObjects(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	tables := map[string]string{}
+	for name, t := range objects {
+		tables[name] = canonicalJSON(t)
+	}
+	return tables, names, nil
+}
+
+// relationsJSON return names of the child table and the parent table of each relation keyed by canonical JSON of the relation.
+// Tables and columns of the relation are identified by their names.
+func relationsJSON(b []byte) (map[string][2]string, error) {
+	v := struct {
+		Relations []map[string]interface{} `json:"relations"`
+	}{}
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	relations := map[string][2]string{}
+	for _, r := range v.Relations {
+		key := map[string]interface{}{}
+		for k, v := range r {
+			key[k] = objectNames(v)
+		}
+		table, _ := key["table"].(string)
+		parentTable, _ := key["parent_table"].(string)
+		relations[canonicalJSON(key)] = [2]string{table, parentTable}
+	}
+	return relations, nil
+}
+
+// objectNames replace the object and objects in the array with their names
+func objectNames(v interface{}) interface{} {
+	switch o := v.(type) {
+	case map[string]interface{}:
+		return o["name"]
+	case []interface{}:
+		names := []interface{}{}
+		for _, e := range o {
+			names = append(names, objectNames(e))
+		}
+		return names
+	default:
+		return v
+	}
+}
+
+// tableObjects return JSON objects of each table and table names in order
+func tableObjects(b []byte) (map[string]map[string]interface{}, []string, error) {
+	v := struct {
+		Tables []map[string]interface{} `json:"tables"`
+	}{}
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	tables := map[string]map[string]interface{}{}
+	names := []string{}
+	for _, t := range v.Tables {
+		name, ok := t["name"].(string)
+		if !ok {
+			return nil, nil, errors.WithStack(fmt.Errorf("invalid table: %v", t))
+		}
+		tables[name] = t
+		names = append(names, name)
+	}
+	return tables, names, nil
+}
@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchema_ChangedTables(t *testing.T) {
+	base, err := json.Marshal(newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestSchema()
+	got, err := s.ChangedTables(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("actual %v\nwant %v", got, []string{})
+	}
+
+	s.Tables[0].Comment = "users table"
+	s.Tables[1].Columns = append(s.Tables[1].Columns, &Column{Name: "title", Type: "text"})
+	s.Tables = append(s.Tables[:2], &Table{Name: "comments"})
+	got, err = s.ChangedTables(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"users", "posts", "comments"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+
+	_, err = s.ChangedTables([]byte("invalid"))
+	if err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestSchema_AffectedTables(t *testing.T) {
+	base, err := json.Marshal(newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		modify func(s *Schema)
+		want   []string
+	}{
+		{func(s *Schema) {}, []string{}},
+		{func(s *Schema) { s.Tables[2].Comment = "temporary" }, []string{"tmp_posts"}},
+		{func(s *Schema) { s.Tables[0].Comment = "users table" }, []string{"users", "posts"}},
+		{func(s *Schema) {
+			s.Tables[1].Columns[0].ParentRelations = nil
+			s.Tables[0].Columns[0].ChildRelations = nil
+			s.Relations = []*Relation{}
+		}, []string{"users", "posts"}},
+		{func(s *Schema) {
+			s.Tables[0].Columns[0].ChildRelations = nil
+			s.Tables = s.Tables[:1]
+			s.Relations = []*Relation{}
+		}, []string{"users"}},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		tt.modify(s)
+		got, err := s.AffectedTables(base, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: actual %v\nwant %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestSchema_Drift(t *testing.T) {
+	base, err := json.Marshal(newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestSchema()
+	got, err := s.Drift(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HasDrift() {
+		t.Errorf("actual %v\nwant %v", got, "no drift")
+	}
+
+	s.Tables[0].Comment = "users table"
+	s.Tables = append(s.Tables[:1], &Table{Name: "comments"})
+	got, err = s.Drift(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Drift{
+		Added:   []string{"comments"},
+		Changed: []string{"users"},
+		Dropped: []string{"posts", "tmp_posts"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestSchema_TableDrifts(t *testing.T) {
+	base, err := json.Marshal(newTestSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestSchema()
+	s.Tables[0].Comment = "users table"
+	s.Tables[0].Columns[0].Type = "bigserial"
+	s.Tables[1].Columns = append(s.Tables[1].Columns, &Column{Name: "title", Type: "text"})
+	s.Tables[1].Indexes = []*Index{&Index{Name: "posts_user_id_idx", Def: "CREATE INDEX posts_user_id_idx ON posts (user_id)", Columns: []string{"user_id"}}}
+	s.Tables = append(s.Tables[:2], &Table{Name: "comments"})
+	got, err := s.TableDrifts(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("actual %v\nwant %v", len(got), 2)
+	}
+	empty := &Drift{Added: []string{}, Changed: []string{}, Dropped: []string{}}
+	want := []*TableDrift{
+		&TableDrift{
+			Name:        "users",
+			Attributes:  []string{"comment"},
+			Columns:     &Drift{Added: []string{}, Changed: []string{"id"}, Dropped: []string{}},
+			Indexes:     empty,
+			Constraints: empty,
+			Triggers:    empty,
+		},
+		&TableDrift{
+			Name:        "posts",
+			Attributes:  []string{},
+			Columns:     &Drift{Added: []string{"title"}, Changed: []string{}, Dropped: []string{}},
+			Indexes:     &Drift{Added: []string{"posts_user_id_idx"}, Changed: []string{}, Dropped: []string{}},
+			Constraints: empty,
+			Triggers:    empty,
+		},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			gb, _ := json.Marshal(got[i])
+			wb, _ := json.Marshal(want[i])
+			t.Errorf("actual %s\nwant %s", gb, wb)
+		}
+	}
+}
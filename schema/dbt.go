@@ -0,0 +1,254 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// reDbtRef is the pattern of ref() and source() in the `to` argument of relationships tests
+var reDbtRef = regexp.MustCompile(`^\s*(ref|source)\((.+)\)\s*$`)
+
+// DbtManifest is the struct for the dbt artifact manifest.json
+type DbtManifest struct {
+	Nodes   map[string]*DbtNode `json:"nodes"`
+	Sources map[string]*DbtNode `json:"sources"`
+}
+
+// DbtNode is the struct for a node (model, seed, snapshot, test) or a source of the manifest
+type DbtNode struct {
+	UniqueID     string                `json:"unique_id"`
+	ResourceType string                `json:"resource_type"`
+	Name         string                `json:"name"`
+	Alias        string                `json:"alias"`
+	Identifier   string                `json:"identifier"`
+	Schema       string                `json:"schema"`
+	SourceName   string                `json:"source_name"`
+	Description  string                `json:"description"`
+	Columns      map[string]*DbtColumn `json:"columns"`
+	ColumnName   string                `json:"column_name"`
+	AttachedNode string                `json:"attached_node"`
+	DependsOn    struct {
+		Nodes []string `json:"nodes"`
+	} `json:"depends_on"`
+	TestMetadata *struct {
+		Name   string                 `json:"name"`
+		Kwargs map[string]interface{} `json:"kwargs"`
+	} `json:"test_metadata"`
+}
+
+// DbtColumn is the struct for a column of the node
+type DbtColumn struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// LoadDbtManifest merge descriptions of models, seeds, snapshots and sources in the dbt manifest into comments of tables and columns,
+// and tests into constraints. relationships tests are also added as relations.
+// Nodes not found in the database are ignored.
+func (s *Schema) LoadDbtManifest(path string) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to load dbt manifest")
+	}
+	m := &DbtManifest{}
+	if err := json.Unmarshal(buf, m); err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("failed to load dbt manifest '%s'", path))
+	}
+	return s.MergeDbtManifest(m)
+}
+
+// MergeDbtManifest merge the dbt manifest into the schema
+func (s *Schema) MergeDbtManifest(m *DbtManifest) error {
+	nodes := map[string]*DbtNode{}
+	for id, n := range m.Nodes {
+		nodes[id] = n
+	}
+	for id, n := range m.Sources {
+		nodes[id] = n
+	}
+	ids := []string{}
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		n := nodes[id]
+		if n.ResourceType == "test" {
+			continue
+		}
+		t := s.findDbtTable(n)
+		if t == nil {
+			continue
+		}
+		if n.Description != "" {
+			t.Comment = n.Description
+		}
+		for name, dc := range n.Columns {
+			if dc.Description == "" {
+				continue
+			}
+			if c := findColumnByNameFold(t, name); c != nil {
+				c.Comment = dc.Description
+			}
+		}
+	}
+
+	for _, id := range ids {
+		n := nodes[id]
+		if n.ResourceType != "test" || n.TestMetadata == nil {
+			continue
+		}
+		attached := n.AttachedNode
+		if attached == "" && len(n.DependsOn.Nodes) > 0 {
+			attached = n.DependsOn.Nodes[len(n.DependsOn.Nodes)-1]
+		}
+		an, ok := nodes[attached]
+		if !ok {
+			continue
+		}
+		t := s.findDbtTable(an)
+		if t == nil {
+			continue
+		}
+		columnName := n.ColumnName
+		if columnName == "" {
+			columnName = kwarg(n, "column_name")
+		}
+		err := s.addDbtTest(t, n, columnName, nodes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) addDbtTest(t *Table, n *DbtNode, columnName string, nodes map[string]*DbtNode) error {
+	var c *Column
+	if columnName != "" {
+		c = findColumnByNameFold(t, columnName)
+		if c == nil {
+			return nil
+		}
+		columnName = c.Name
+	}
+	cs := &Constraint{Name: n.Name}
+	switch n.TestMetadata.Name {
+	case "unique":
+		cs.Type = "UNIQUE"
+		cs.Def = fmt.Sprintf("UNIQUE (%s)", columnName)
+	case "not_null":
+		cs.Type = "CHECK"
+		cs.Def = fmt.Sprintf("CHECK (%s IS NOT NULL)", columnName)
+	case "accepted_values":
+		values := []string{}
+		if vs, ok := n.TestMetadata.Kwargs["values"].([]interface{}); ok {
+			for _, v := range vs {
+				if str, ok := v.(string); ok {
+					values = append(values, fmt.Sprintf("'%s'", strings.Replace(str, "'", "''", -1)))
+				} else {
+					values = append(values, fmt.Sprintf("%v", v))
+				}
+			}
+		}
+		cs.Type = "CHECK"
+		cs.Def = fmt.Sprintf("CHECK (%s IN (%s))", columnName, strings.Join(values, ", "))
+	case "relationships":
+		parent := s.findDbtRef(kwarg(n, "to"), nodes)
+		if parent == nil || c == nil {
+			return nil
+		}
+		pc := findColumnByNameFold(parent, kwarg(n, "field"))
+		if pc == nil {
+			return nil
+		}
+		cs.Type = "FOREIGN KEY"
+		cs.Def = fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", c.Name, parent.Name, pc.Name)
+		if s.findRelation(t, []*Column{c}, parent, []*Column{pc}) == nil {
+			r := &Relation{
+				Table:         t,
+				Columns:       []*Column{c},
+				ParentTable:   parent,
+				ParentColumns: []*Column{pc},
+				Def:           cs.Def,
+				IsAdditional:  true,
+			}
+			s.addRelation(r)
+		}
+	default:
+		cs.Type = "DBT TEST"
+		cs.Def = n.TestMetadata.Name
+		if columnName != "" {
+			cs.Def = fmt.Sprintf("%s (%s)", n.TestMetadata.Name, columnName)
+		}
+	}
+	for _, existing := range t.Constraints {
+		if existing.Type == cs.Type && existing.Def == cs.Def {
+			return nil
+		}
+	}
+	t.Constraints = append(t.Constraints, cs)
+	return nil
+}
+
+// findDbtTable find the table of the node by `schema.alias` or `alias` (`identifier` for sources)
+func (s *Schema) findDbtTable(n *DbtNode) *Table {
+	name := n.Alias
+	if n.ResourceType == "source" {
+		name = n.Identifier
+	}
+	if name == "" {
+		name = n.Name
+	}
+	for _, candidate := range []string{fmt.Sprintf("%s.%s", n.Schema, name), name} {
+		for _, t := range s.Tables {
+			if strings.EqualFold(t.Name, candidate) {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// findDbtRef find the table of ref('model') or source('source', 'table')
+func (s *Schema) findDbtRef(to string, nodes map[string]*DbtNode) *Table {
+	m := reDbtRef.FindStringSubmatch(to)
+	if m == nil {
+		return nil
+	}
+	args := []string{}
+	for _, a := range strings.Split(m[2], ",") {
+		args = append(args, strings.Trim(strings.TrimSpace(a), `'"`))
+	}
+	for _, n := range nodes {
+		switch {
+		case m[1] == "ref" && n.ResourceType != "source" && n.ResourceType != "test" && n.Name == args[len(args)-1]:
+			return s.findDbtTable(n)
+		case m[1] == "source" && n.ResourceType == "source" && len(args) == 2 && n.SourceName == args[0] && n.Name == args[1]:
+			return s.findDbtTable(n)
+		}
+	}
+	return nil
+}
+
+func kwarg(n *DbtNode, key string) string {
+	if v, ok := n.TestMetadata.Kwargs[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func findColumnByNameFold(t *Table, name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
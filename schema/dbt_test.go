@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDbtManifest(t *testing.T) {
+	s := newTestSchema()
+	err := s.LoadDbtManifest(filepath.Join(testdataDir(), "dbt_manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, _ := s.FindTableByName("users")
+	posts, _ := s.FindTableByName("posts")
+	tmp, _ := s.FindTableByName("tmp_posts")
+	if users.Comment != "Registered users" || tmp.Comment != "Posts loaded by the batch" || posts.Comment != "" {
+		t.Errorf("actual %v, %v, %v\nwant %v, %v, %v", users.Comment, tmp.Comment, posts.Comment, "Registered users", "Posts loaded by the batch", "")
+	}
+	if want := "Primary key of users"; users.Columns[0].Comment != want {
+		t.Errorf("actual %v\nwant %v", users.Columns[0].Comment, want)
+	}
+	if want := "Author of the post"; posts.Columns[0].Comment != want {
+		t.Errorf("actual %v\nwant %v", posts.Columns[0].Comment, want)
+	}
+
+	got := map[string]string{}
+	for _, tbl := range []*Table{users, posts} {
+		for _, c := range tbl.Constraints {
+			got[c.Name] = c.Type + ": " + c.Def
+		}
+	}
+	want := map[string]string{
+		"unique_users_id":                             "UNIQUE: UNIQUE (id)",
+		"accepted_values_users_id":                    "CHECK: CHECK (id IN (1, 2, 3))",
+		"not_null_posts_user_id":                      "CHECK: CHECK (user_id IS NOT NULL)",
+		"relationships_posts_user_id__id__ref_users_": "FOREIGN KEY: FOREIGN KEY (user_id) REFERENCES users (id)",
+	}
+	if len(got) != len(want) {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: actual %v\nwant %v", k, got[k], v)
+		}
+	}
+	if keys := users.ColumnKeys(users.Columns[0]); len(keys) != 1 || keys[0] != KeyUnique {
+		t.Errorf("actual %v\nwant %v", keys, []string{KeyUnique})
+	}
+	// the relation already detected is not duplicated
+	if len(s.Relations) != 1 {
+		t.Errorf("actual %v\nwant %v", len(s.Relations), 1)
+	}
+}
+
+func TestLoadDbtManifestAddRelation(t *testing.T) {
+	s := newTestSchema()
+	s.Relations = []*Relation{}
+	for _, tbl := range s.Tables {
+		for _, c := range tbl.Columns {
+			c.ParentRelations = nil
+			c.ChildRelations = nil
+		}
+	}
+	err := s.LoadDbtManifest(filepath.Join(testdataDir(), "dbt_manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Relations) != 1 {
+		t.Fatalf("actual %v\nwant %v", len(s.Relations), 1)
+	}
+	r := s.Relations[0]
+	if r.Table.Name != "posts" || r.ParentTable.Name != "users" || !r.IsAdditional {
+		t.Errorf("actual %v -> %v\nwant %v -> %v", r.Table.Name, r.ParentTable.Name, "posts", "users")
+	}
+	if want := "FOREIGN KEY (user_id) REFERENCES users (id)"; r.Def != want {
+		t.Errorf("actual %v\nwant %v", r.Def, want)
+	}
+}
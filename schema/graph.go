@@ -0,0 +1,161 @@
+package schema
+
+// relationSignature identifies relations between the same tables and columns
+type relationSignature struct {
+	table, parentTable     *Table
+	columns, parentColumns string
+}
+
+func signatureOf(r *Relation) relationSignature {
+	return relationSignature{table: r.Table, parentTable: r.ParentTable, columns: columnsKey(r.Columns), parentColumns: columnsKey(r.ParentColumns)}
+}
+
+// columnsKey return the key identifying the columns
+func columnsKey(columns []*Column) string {
+	k := make([]byte, 0, len(columns)*8)
+	for _, c := range columns {
+		k = append(k, c.Name...)
+		k = append(k, 0)
+	}
+	return string(k)
+}
+
+// LinkRelations remove duplicated relations (between the same tables and columns) from Relations,
+// and rebuild ParentRelations and ChildRelations of the columns from Relations.
+// Relations is the only source of the relations of the columns, so programs building the schema (e.g. drivers) set Relations and call it.
+func (s *Schema) LinkRelations() {
+	encountered := map[relationSignature]bool{}
+	relations := make([]*Relation, 0, len(s.Relations))
+	parents := map[*Column]int{}
+	children := map[*Column]int{}
+	n := 0
+	for _, r := range s.Relations {
+		sig := signatureOf(r)
+		if encountered[sig] {
+			s.duplicatedRelations = append(s.duplicatedRelations, r)
+			continue
+		}
+		encountered[sig] = true
+		relations = append(relations, r)
+		for _, c := range r.Columns {
+			parents[c]++
+		}
+		for _, c := range r.ParentColumns {
+			children[c]++
+		}
+		n += len(r.Columns) + len(r.ParentColumns)
+	}
+	s.Relations = relations
+
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			c.ParentRelations = nil
+			c.ChildRelations = nil
+		}
+	}
+	// relations of all columns share one array instead of growing a slice per column
+	links := make([]*Relation, n)
+	i := 0
+	for c, cnt := range parents {
+		c.ParentRelations = links[i : i : i+cnt]
+		i += cnt
+	}
+	for c, cnt := range children {
+		c.ChildRelations = links[i : i : i+cnt]
+		i += cnt
+	}
+	for _, r := range relations {
+		for _, c := range r.Columns {
+			c.ParentRelations = append(c.ParentRelations, r)
+		}
+		for _, c := range r.ParentColumns {
+			c.ChildRelations = append(c.ChildRelations, r)
+		}
+	}
+}
+
+// DuplicatedRelations return relations removed from Relations by LinkRelations as duplicates of others (e.g. two foreign keys on the same columns)
+func (s *Schema) DuplicatedRelations() []*Relation {
+	return s.duplicatedRelations
+}
+
+// addRelation add the relation to Relations and to ParentRelations and ChildRelations of its columns.
+// The caller checks that the relation is not a duplicate.
+func (s *Schema) addRelation(r *Relation) {
+	s.Relations = append(s.Relations, r)
+	for _, c := range r.Columns {
+		c.ParentRelations = append(c.ParentRelations, r)
+	}
+	for _, c := range r.ParentColumns {
+		c.ChildRelations = append(c.ChildRelations, r)
+	}
+}
+
+// RelationsOf return relations of the table (as the child or the parent table) through ParentRelations and ChildRelations of its columns, without duplicates
+func (s *Schema) RelationsOf(t *Table) []*Relation {
+	return t.relations()
+}
+
+// relations return relations of the table through ParentRelations and ChildRelations of its columns, without duplicates
+func (t *Table) relations() []*Relation {
+	relations := []*Relation{}
+	encountered := map[*Relation]bool{}
+	for _, c := range t.Columns {
+		for _, rs := range [][]*Relation{c.ParentRelations, c.ChildRelations} {
+			for _, r := range rs {
+				if !encountered[r] {
+					encountered[r] = true
+					relations = append(relations, r)
+				}
+			}
+		}
+	}
+	return relations
+}
+
+// RelatedTables return tables within distance hops from the table through relations. The table itself is not included.
+func (s *Schema) RelatedTables(t *Table, distance int) []*Table {
+	tables, _ := t.CollectTablesAndRelations(distance)
+	return tables
+}
+
+// CollectTablesAndRelations collect tables and relations within distance hops from the table.
+// The table itself is not included.
+func (s *Schema) CollectTablesAndRelations(t *Table, distance int) ([]*Table, []*Relation) {
+	return t.CollectTablesAndRelations(distance)
+}
+
+// CollectTablesAndRelations collect tables and relations within distance hops from the table.
+// The table itself is not included.
+func (t *Table) CollectTablesAndRelations(distance int) ([]*Table, []*Relation) {
+	return collectTablesAndRelations(t, distance, (*Table).relations)
+}
+
+// collectTablesAndRelations collect tables and relations within distance hops from the table in breadth-first order
+func collectTablesAndRelations(t *Table, distance int, relationsOf func(*Table) []*Relation) ([]*Table, []*Relation) {
+	encountered := map[*Table]bool{t: true}
+	tables := []*Table{}
+	relations := []*Relation{}
+	encounteredRelations := map[*Relation]bool{}
+	frontier := []*Table{t}
+	for i := 0; i < distance && len(frontier) > 0; i++ {
+		next := []*Table{}
+		for _, ft := range frontier {
+			for _, r := range relationsOf(ft) {
+				for _, rt := range []*Table{r.ParentTable, r.Table} {
+					if !encountered[rt] {
+						encountered[rt] = true
+						tables = append(tables, rt)
+						next = append(next, rt)
+					}
+				}
+				if !encounteredRelations[r] {
+					encounteredRelations[r] = true
+					relations = append(relations, r)
+				}
+			}
+		}
+		frontier = next
+	}
+	return tables, relations
+}
@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSchema_RelationsOf(t *testing.T) {
+	s := newTestSchema()
+	users, _ := s.FindTableByName("users")
+	posts, _ := s.FindTableByName("posts")
+	tmp, _ := s.FindTableByName("tmp_posts")
+
+	// the same relation detected twice (e.g. by the driver and by additional data)
+	dup := *s.Relations[0]
+	s.Relations = append(s.Relations, &dup)
+	s.LinkRelations()
+	if len(s.Relations) != 1 {
+		t.Errorf("actual %v\nwant %v", len(s.Relations), 1)
+	}
+	if got := s.DuplicatedRelations(); len(got) != 1 || got[0] != &dup {
+		t.Errorf("actual %v\nwant %v", got, []*Relation{&dup})
+	}
+
+	tests := []struct {
+		table *Table
+		want  int
+	}{
+		{users, 1},
+		{posts, 1},
+		{tmp, 0},
+	}
+	for _, tt := range tests {
+		got := s.RelationsOf(tt.table)
+		if len(got) != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.table.Name, len(got), tt.want)
+		}
+	}
+}
+
+func TestSchema_RelatedTables(t *testing.T) {
+	s := newTestSchema()
+	posts, _ := s.FindTableByName("posts")
+	pid := &Column{Name: "id"}
+	posts.Columns = append(posts.Columns, pid)
+	cid := &Column{Name: "post_id"}
+	comments := &Table{Name: "comments", Columns: []*Column{cid}}
+	s.Tables = append(s.Tables, comments)
+	s.Relations = append(s.Relations, &Relation{
+		Table:         comments,
+		Columns:       []*Column{cid},
+		ParentTable:   posts,
+		ParentColumns: []*Column{pid},
+	})
+	s.LinkRelations()
+
+	tests := []struct {
+		distance int
+		want     []string
+	}{
+		{0, []string{}},
+		{1, []string{"posts"}},
+		{2, []string{"posts", "users"}},
+		{3, []string{"posts", "users"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, rt := range s.RelatedTables(comments, tt.distance) {
+			got = append(got, rt.Name)
+		}
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) {
+			t.Errorf("distance %d: actual %v\nwant %v", tt.distance, got, tt.want)
+		}
+	}
+}
+
+func TestSchema_CollectTablesAndRelationsSameAsTable(t *testing.T) {
+	s := newTestSchema()
+	for _, table := range s.Tables {
+		for distance := 0; distance < 3; distance++ {
+			wt, wr := table.CollectTablesAndRelations(distance)
+			gt, gr := s.CollectTablesAndRelations(table, distance)
+			if fmt.Sprintf("%v%v", gt, gr) != fmt.Sprintf("%v%v", wt, wr) {
+				t.Errorf("%s, %d: actual %v %v\nwant %v %v", table.Name, distance, gt, gr, wt, wr)
+			}
+		}
+	}
+}
+
+func TestSchema_RelationsOfAfterChanges(t *testing.T) {
+	s := newTestSchema()
+	users, _ := s.FindTableByName("users")
+	if got := len(s.RelationsOf(users)); got != 1 {
+		t.Fatalf("actual %v\nwant %v", got, 1)
+	}
+	s.removeRelation(s.Relations[0])
+	if got := len(s.RelationsOf(users)); got != 0 {
+		t.Errorf("actual %v\nwant %v", got, 0)
+	}
+}
+
+func TestSchema_RelationsOfAfterSort(t *testing.T) {
+	uid := &Column{Name: "id"}
+	users := &Table{Name: "users", Columns: []*Column{uid}}
+	zc := &Column{Name: "user_id"}
+	zz := &Table{Name: "zz_posts", Columns: []*Column{zc}}
+	ac := &Column{Name: "user_id"}
+	aa := &Table{Name: "aa_posts", Columns: []*Column{ac}}
+	rz := &Relation{Table: zz, Columns: []*Column{zc}, ParentTable: users, ParentColumns: []*Column{uid}}
+	ra := &Relation{Table: aa, Columns: []*Column{ac}, ParentTable: users, ParentColumns: []*Column{uid}}
+	s := &Schema{Tables: []*Table{users, zz, aa}, Relations: []*Relation{rz, ra}}
+	s.LinkRelations()
+	if got := s.RelationsOf(users); len(got) != 2 || got[0] != rz {
+		t.Fatalf("actual %v\nwant %v", got, []*Relation{rz, ra})
+	}
+	// relations are sorted in place
+	if err := s.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.RelationsOf(users); len(got) != 2 || got[0] != ra {
+		t.Errorf("actual %v\nwant %v", got, []*Relation{ra, rz})
+	}
+}
@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// foldIdentifier return the identifier as the database folds unquoted identifiers.
+// PostgreSQL lower-cases and Oracle upper-cases them. Identifiers of other databases are not folded.
+func foldIdentifier(driver, name string) string {
+	switch driver {
+	case "postgres", "redshift":
+		return strings.ToLower(name)
+	case "oracle", "snowflake", "db2":
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// normalizeIdentifier return the name referred to by the (possibly schema-qualified) identifier.
+// Quoted parts ("Users", `Users` or [Users]) are unquoted as is, and unquoted parts are folded by the driver. It also returns whether any part is quoted.
+func normalizeIdentifier(driver, name string) (string, bool) {
+	parts := splitIdentifier(name)
+	quoted := false
+	for i, p := range parts {
+		if u, ok := unquoteIdentifier(p); ok {
+			parts[i] = u
+			quoted = true
+			continue
+		}
+		parts[i] = foldIdentifier(driver, p)
+	}
+	return strings.Join(parts, "."), quoted
+}
+
+// splitIdentifier split the schema-qualified identifier by dots outside quotes
+func splitIdentifier(name string) []string {
+	parts := []string{}
+	var close rune
+	start := 0
+	for i, r := range name {
+		switch {
+		case close != 0:
+			if r == close {
+				close = 0
+			}
+		case r == '"':
+			close = '"'
+		case r == '`':
+			close = '`'
+		case r == '[':
+			close = ']'
+		case r == '.':
+			parts = append(parts, name[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, name[start:])
+}
+
+// unquoteIdentifier return the unquoted identifier, and whether it is quoted. Doubled quotes in it are unescaped.
+func unquoteIdentifier(name string) (string, bool) {
+	if len(name) < 2 {
+		return name, false
+	}
+	switch {
+	case name[0] == '"' && name[len(name)-1] == '"':
+		return strings.Replace(name[1:len(name)-1], `""`, `"`, -1), true
+	case name[0] == '`' && name[len(name)-1] == '`':
+		return strings.Replace(name[1:len(name)-1], "``", "`", -1), true
+	case name[0] == '[' && name[len(name)-1] == ']':
+		return strings.Replace(name[1:len(name)-1], "]]", "]", -1), true
+	}
+	return name, false
+}
+
+// resolveIdentifier resolve the identifier to one of the indexed names.
+// The name is looked up as is, then normalized by the driver, and at last case-insensitively when it is not quoted and only one name matches.
+func resolveIdentifier(driver, name string, exists func(string) bool, byLower map[string][]string) (string, bool) {
+	if exists(name) {
+		return name, true
+	}
+	normalized, quoted := normalizeIdentifier(driver, name)
+	if exists(normalized) {
+		return normalized, true
+	}
+	if quoted {
+		return "", false
+	}
+	if names := byLower[strings.ToLower(name)]; len(names) == 1 {
+		return names[0], true
+	}
+	return "", false
+}
+
+// defaultSchemaName return the schema whose tables are named without schema (e.g. `public` of PostgreSQL). The database name is the schema of MySQL.
+func defaultSchemaName(driver, database string) string {
+	switch driver {
+	case "postgres", "redshift":
+		return "public"
+	case "mysql":
+		return database
+	case "sqlite", "sqlite3":
+		return "main"
+	}
+	return ""
+}
+
+// unqualifyIdentifier return the identifier without the schema when it is qualified with the default schema
+func unqualifyIdentifier(driver, defaultSchema, name string) (string, bool) {
+	parts := splitIdentifier(name)
+	if defaultSchema == "" || len(parts) != 2 {
+		return name, false
+	}
+	if s, _ := normalizeIdentifier(driver, parts[0]); s != defaultSchema {
+		return name, false
+	}
+	return parts[1], true
+}
+
+// qualifyIdentifier return the identifier qualified with the schema unless it is already qualified
+func qualifyIdentifier(schema, name string) string {
+	if schema == "" || len(splitIdentifier(name)) != 1 {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", schema, name)
+}
@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFoldIdentifier(t *testing.T) {
+	tests := []struct {
+		driver string
+		in     string
+		want   string
+	}{
+		{"postgres", "Users", "users"},
+		{"redshift", "USERS", "users"},
+		{"oracle", "users", "USERS"},
+		{"snowflake", "Users", "USERS"},
+		{"mysql", "Users", "Users"},
+		{"sqlite", "Users", "Users"},
+		{"", "Users", "Users"},
+	}
+	for _, tt := range tests {
+		got := foldIdentifier(tt.driver, tt.in)
+		if got != tt.want {
+			t.Errorf("%s %s: actual %v\nwant %v", tt.driver, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"users", []string{"users"}},
+		{"public.users", []string{"public", "users"}},
+		{`"my.schema"."Users"`, []string{`"my.schema"`, `"Users"`}},
+		{"`db`.`a.b`", []string{"`db`", "`a.b`"}},
+		{"[dbo].[a.b]", []string{"[dbo]", "[a.b]"}},
+		{"", []string{""}},
+	}
+	for _, tt := range tests {
+		got := splitIdentifier(tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: actual %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		driver     string
+		in         string
+		want       string
+		wantQuoted bool
+	}{
+		{"postgres", "Users", "users", false},
+		{"postgres", `"Users"`, "Users", true},
+		{"postgres", `Public."Users"`, "public.Users", true},
+		{"postgres", `"say ""hi"""`, `say "hi"`, true},
+		{"oracle", "hr.employees", "HR.EMPLOYEES", false},
+		{"oracle", `hr."Employees"`, "HR.Employees", true},
+		{"mysql", "`Users`", "Users", true},
+		{"mssql", "[dbo].[Users]", "dbo.Users", true},
+		{"mysql", "Users", "Users", false},
+	}
+	for _, tt := range tests {
+		got, quoted := normalizeIdentifier(tt.driver, tt.in)
+		if got != tt.want || quoted != tt.wantQuoted {
+			t.Errorf("%s %s: actual %v %v\nwant %v %v", tt.driver, tt.in, got, quoted, tt.want, tt.wantQuoted)
+		}
+	}
+}
+
+func TestResolveIdentifier(t *testing.T) {
+	names := []string{"users", "Posts", "public.users", "Logs", "LOGS"}
+	exists := func(n string) bool {
+		for _, name := range names {
+			if name == n {
+				return true
+			}
+		}
+		return false
+	}
+	byLower := map[string][]string{
+		"users":        {"users"},
+		"posts":        {"Posts"},
+		"public.users": {"public.users"},
+		"logs":         {"Logs", "LOGS"},
+	}
+	tests := []struct {
+		driver string
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"postgres", "users", "users", true},
+		{"postgres", "Users", "users", true},
+		{"postgres", `"users"`, "users", true},
+		{"postgres", `"Users"`, "", false},
+		{"postgres", "PUBLIC.USERS", "public.users", true},
+		{"postgres", `public."users"`, "public.users", true},
+		{"mysql", "posts", "Posts", true},
+		{"mysql", "`Posts`", "Posts", true},
+		{"mysql", "`posts`", "", false},
+		{"mysql", "logs", "", false},
+		{"mysql", "LOGS", "LOGS", true},
+		{"mysql", "comments", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := resolveIdentifier(tt.driver, tt.in, exists, byLower)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s %s: actual %v %v\nwant %v %v", tt.driver, tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestUnqualifyIdentifier(t *testing.T) {
+	tests := []struct {
+		driver        string
+		defaultSchema string
+		in            string
+		want          string
+		wantOK        bool
+	}{
+		{"postgres", "public", "public.users", "users", true},
+		{"postgres", "public", "PUBLIC.users", "users", true},
+		{"postgres", "public", `"Public".users`, "public.users", false},
+		{"postgres", "public", "billing.invoices", "billing.invoices", false},
+		{"postgres", "public", "users", "users", false},
+		{"mysql", "testdb", "testdb.users", "users", true},
+		{"mysql", "", "testdb.users", "testdb.users", false},
+	}
+	for _, tt := range tests {
+		got, ok := unqualifyIdentifier(tt.driver, tt.defaultSchema, tt.in)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("%s: actual %v %v\nwant %v %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestQualifyIdentifier(t *testing.T) {
+	tests := []struct {
+		schema string
+		in     string
+		want   string
+	}{
+		{"billing", "invoices", "billing.invoices"},
+		{"billing", "public.users", "public.users"},
+		{"billing", `"a.b"`, `billing."a.b"`},
+		{"", "invoices", "invoices"},
+	}
+	for _, tt := range tests {
+		if got := qualifyIdentifier(tt.schema, tt.in); got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
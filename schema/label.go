@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"regexp"
+)
+
+var reLabelAnnotation = regexp.MustCompile(`@label:([\w-]+)`)
+
+// Label is the struct for table and column label
+type Label struct {
+	Name        string   `json:"name" yaml:"name"`
+	Color       string   `json:"color,omitempty" yaml:"color"`
+	Description string   `json:"description,omitempty" yaml:"description"`
+	Tables      []string `json:"-" yaml:"tables"`
+}
+
+// FindLabelByName find label by label name
+func (s *Schema) FindLabelByName(name string) (*Label, bool) {
+	for _, l := range s.Labels {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// AssignLabels assign labels to tables.
+// A table gets a label when the table name matches one of the label's glob patterns,
+// or when the table comment has the annotation `@label:<name>`.
+func (s *Schema) AssignLabels(labels []Label) error {
+	for i := range labels {
+		l := labels[i]
+		// labels of columns from additional data get the color and the description of the config
+		if existing, ok := s.FindLabelByName(l.Name); ok {
+			existing.Color = l.Color
+			existing.Description = l.Description
+			existing.Tables = l.Tables
+			continue
+		}
+		s.Labels = append(s.Labels, &l)
+	}
+	for _, t := range s.Tables {
+		for _, l := range s.Labels {
+			match, err := matchPatterns(t.Name, l.Tables)
+			if err != nil {
+				return err
+			}
+			if match {
+				t.addLabel(l)
+			}
+		}
+		for _, m := range reLabelAnnotation.FindAllStringSubmatch(t.Comment, -1) {
+			l, ok := s.FindLabelByName(m[1])
+			if !ok {
+				l = &Label{Name: m[1]}
+				s.Labels = append(s.Labels, l)
+			}
+			t.addLabel(l)
+		}
+	}
+	return nil
+}
+
+// LabelColor return the color of the first label which has a color
+func (t *Table) LabelColor() string {
+	for _, l := range t.Labels {
+		if l.Color != "" {
+			return l.Color
+		}
+	}
+	return ""
+}
+
+func (t *Table) addLabel(l *Label) {
+	for _, tl := range t.Labels {
+		if tl == l {
+			return
+		}
+	}
+	t.Labels = append(t.Labels, l)
+}
+
+func (c *Column) addLabel(l *Label) {
+	for _, cl := range c.Labels {
+		if cl == l {
+			return
+		}
+	}
+	c.Labels = append(c.Labels, l)
+}
@@ -0,0 +1,46 @@
+package schema
+
+import "testing"
+
+func TestAssignLabels(t *testing.T) {
+	schema := newTestSchema()
+	posts, _ := schema.FindTableByName("posts")
+	posts.Comment = "posts comment @label:content"
+	err := schema.AssignLabels([]Label{
+		Label{
+			Name:   "user",
+			Color:  "#F6CFCF",
+			Tables: []string{"users", "*_posts"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		table string
+		want  []string
+	}{
+		{"users", []string{"user"}},
+		{"posts", []string{"content"}},
+		{"tmp_posts", []string{"user"}},
+	}
+	for _, tt := range tests {
+		table, _ := schema.FindTableByName(tt.table)
+		actual := []string{}
+		for _, l := range table.Labels {
+			actual = append(actual, l.Name)
+		}
+		if len(actual) != len(tt.want) || actual[0] != tt.want[0] {
+			t.Errorf("actual %v\nwant %v", actual, tt.want)
+		}
+	}
+	users, _ := schema.FindTableByName("users")
+	expected := "#F6CFCF"
+	actual := users.LabelColor()
+	if actual != expected {
+		t.Errorf("actual %v\nwant %v", actual, expected)
+	}
+	if len(schema.Labels) != 2 {
+		t.Errorf("actual %v\nwant %v", len(schema.Labels), 2)
+	}
+}
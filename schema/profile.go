@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SampleRows is the rows selected from the table as examples, with values of masked columns replaced
+type SampleRows struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// RowCount is the number of rows of the table
+type RowCount struct {
+	Count int64
+	// Estimated is whether Count is the estimate of the planner
+	Estimated bool
+}
+
+// String return the row count with thousands separators, prefixed with "~" when it is estimated
+func (r RowCount) String() string {
+	digits := fmt.Sprintf("%d", r.Count)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	groups := []string{}
+	for len(digits) > 3 {
+		groups = append([]string{digits[len(digits)-3:]}, groups...)
+		digits = digits[:len(digits)-3]
+	}
+	groups = append([]string{digits}, groups...)
+	s := sign + strings.Join(groups, ",")
+	if r.Estimated {
+		return "~" + s
+	}
+	return s
+}
+
+// ColumnStats is the statistics of the values of the column in the rows scanned
+type ColumnStats struct {
+	Rows     int64
+	Nulls    int64
+	Distinct *int64
+	Min      *string
+	Max      *string
+	// Percentiles is the nearest-rank percentiles of values of numeric and date/time columns
+	Percentiles []*Percentile
+}
+
+// Percentile is the value at the percentile of values of the column
+type Percentile struct {
+	P     int
+	Value string
+}
+
+// NullRatio return the ratio of NULL values formatted in percent. It is empty when no rows are scanned.
+func (s ColumnStats) NullRatio() string {
+	if s.Rows == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", float64(s.Nulls)*100/float64(s.Rows))
+}
+
+// EnumValue is the distinct value of the low-cardinality column and the number of rows with it
+type EnumValue struct {
+	Value string
+	Count int64
+}
+
+// Storage is the on-disk size of the table in bytes
+type Storage struct {
+	Table   int64
+	Indexes int64
+	// Toast is the size of TOAST (out-of-line values) of PostgreSQL. It is nil for databases without it.
+	Toast *int64
+}
+
+// Total return the total size of the table, indexes and TOAST
+func (s Storage) Total() int64 {
+	total := s.Table + s.Indexes
+	if s.Toast != nil {
+		total += *s.Toast
+	}
+	return total
+}
+
+// Freshness is the last written time of the table, the max value of the timestamp column
+type Freshness struct {
+	Column string
+	// LastWritten is nil when the table has no rows with the timestamp
+	LastWritten *string
+}
+
+// String return the last written time, or "-" when the table has no rows with the timestamp
+func (f Freshness) String() string {
+	if f.LastWritten == nil {
+		return "-"
+	}
+	return *f.LastWritten
+}
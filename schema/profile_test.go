@@ -0,0 +1,54 @@
+package schema
+
+import "testing"
+
+func TestRowCount_String(t *testing.T) {
+	tests := []struct {
+		in   RowCount
+		want string
+	}{
+		{RowCount{Count: 0}, "0"},
+		{RowCount{Count: 999}, "999"},
+		{RowCount{Count: 1000}, "1,000"},
+		{RowCount{Count: 1234567}, "1,234,567"},
+		{RowCount{Count: 1234567, Estimated: true}, "~1,234,567"},
+		{RowCount{Count: -1000}, "-1,000"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestColumnStats_NullRatio(t *testing.T) {
+	tests := []struct {
+		in   ColumnStats
+		want string
+	}{
+		{ColumnStats{Rows: 0, Nulls: 0}, ""},
+		{ColumnStats{Rows: 3, Nulls: 1}, "33.3%"},
+		{ColumnStats{Rows: 4, Nulls: 4}, "100.0%"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.NullRatio(); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestStorage_Total(t *testing.T) {
+	toast := int64(4)
+	tests := []struct {
+		in   Storage
+		want int64
+	}{
+		{Storage{Table: 1, Indexes: 2}, 3},
+		{Storage{Table: 1, Indexes: 2, Toast: &toast}, 7},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Total(); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
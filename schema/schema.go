@@ -1,3 +1,4 @@
+// Package schema is the model of the database schema (tables, columns, relations, ...) analyzed by tbls
 package schema
 
 import (
@@ -5,76 +6,131 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 )
 
+var reConstraintColumns = regexp.MustCompile(`\(([^)]+)\)`)
+
+// indexMu guards the indexes of tables and columns built on lookups and the changes by Add methods,
+// so that lookups and Add methods are safe for concurrent use (e.g. by workers analyzing or profiling tables)
+var indexMu sync.Mutex
+
+// VirtualTableType is the default type of virtual tables declared in additional data
+const VirtualTableType = "VIRTUAL TABLE"
+
+// Key types of columns
+const (
+	KeyPrimary = "PK"
+	KeyForeign = "FK"
+	KeyUnique  = "UK"
+	KeyIndex   = "IDX"
+)
+
 // Index is the struct for database index
 type Index struct {
-	Name string `json:"name"`
-	Def  string `json:"def"`
+	Name    string   `json:"name" yaml:"name"`
+	Def     string   `json:"def" yaml:"def"`
+	Columns []string `json:"columns" yaml:"columns"`
 }
 
 // Constraint is the struct for database constraint
 type Constraint struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Def  string `json:"def"`
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	Def  string `json:"def" yaml:"def"`
 }
 
 // Trigger is the struct for database trigger
 type Trigger struct {
-	Name string `json:"name"`
-	Def  string `json:"def"`
+	Name string `json:"name" yaml:"name"`
+	Def  string `json:"def" yaml:"def"`
 }
 
 // Column is the struct for table column
 type Column struct {
-	Name            string         `json:"name"`
-	Type            string         `json:"type"`
-	Nullable        bool           `json:"nullable"`
-	Default         sql.NullString `json:"default"`
-	Comment         string         `json:"comment"`
-	ParentRelations []*Relation    `json:"-"`
-	ChildRelations  []*Relation    `json:"-"`
+	Name        string       `json:"name" yaml:"name"`
+	Type        string       `json:"type" yaml:"type"`
+	Nullable    bool         `json:"nullable" yaml:"nullable"`
+	Default     *string      `json:"default" yaml:"default"`
+	Comment     string       `json:"comment" yaml:"comment"`
+	Charset     string       `json:"charset,omitempty" yaml:"charset,omitempty"`
+	Collation   string       `json:"collation,omitempty" yaml:"collation,omitempty"`
+	Sensitivity *Sensitivity `json:"sensitivity,omitempty" yaml:"sensitivity,omitempty"`
+	// Labels and Metadata are added from additional data
+	Labels          []*Label          `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	ParentRelations []*Relation       `json:"-" yaml:"-"`
+	ChildRelations  []*Relation       `json:"-" yaml:"-"`
+	Hidden          bool              `json:"-" yaml:"-"`
+	// Stats and EnumValues are collected from the data, so they are not a part of the schema JSON
+	Stats      *ColumnStats `json:"-" yaml:"-"`
+	EnumValues []*EnumValue `json:"-" yaml:"-"`
 }
 
 // Table is the struct for database table
 type Table struct {
-	Name        string        `json:"name"`
-	Type        string        `json:"type"`
-	Comment     string        `json:"comment"`
-	Columns     []*Column     `json:"columns"`
-	Indexes     []*Index      `json:"indexes"`
-	Constraints []*Constraint `json:"constraints"`
-	Triggers    []*Trigger    `json:"triggers"`
-	Def         string        `json:"def"`
+	Name        string        `json:"name" yaml:"name"`
+	Type        string        `json:"type" yaml:"type"`
+	Comment     string        `json:"comment" yaml:"comment"`
+	Columns     []*Column     `json:"columns" yaml:"columns"`
+	Indexes     []*Index      `json:"indexes" yaml:"indexes"`
+	Constraints []*Constraint `json:"constraints" yaml:"constraints"`
+	Triggers    []*Trigger    `json:"triggers" yaml:"triggers"`
+	Def         string        `json:"def" yaml:"def"`
+	Labels      []*Label      `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Collation   string        `json:"collation,omitempty" yaml:"collation,omitempty"`
+	// Virtual is true for the table declared in additional data, which does not exist in the database
+	Virtual bool `json:"virtual,omitempty" yaml:"virtual,omitempty"`
+	// SampleRows, RowCount, Storage and Freshness are collected from the data, so they are not a part of the schema JSON
+	SampleRows  *SampleRows `json:"-" yaml:"-"`
+	RowCount    *RowCount   `json:"-" yaml:"-"`
+	Storage     *Storage    `json:"-" yaml:"-"`
+	Freshness   *Freshness  `json:"-" yaml:"-"`
+	columnIndex *columnIndex
 }
 
-// Relation is the struct for table relation
+// Relation is the struct for table relation. Tables and columns are referred by their names in YAML
 type Relation struct {
-	Table         *Table    `json:"table"`
-	Columns       []*Column `json:"columns"`
-	ParentTable   *Table    `json:"parent_table"`
-	ParentColumns []*Column `json:"parent_columns"`
-	Def           string    `json:"def"`
-	IsAdditional  bool      `json:"is_additional"`
+	Table             *Table    `json:"table" yaml:"table"`
+	Columns           []*Column `json:"columns" yaml:"columns"`
+	ParentTable       *Table    `json:"parent_table" yaml:"parent_table"`
+	ParentColumns     []*Column `json:"parent_columns" yaml:"parent_columns"`
+	Def               string    `json:"def" yaml:"def"`
+	IsAdditional      bool      `json:"is_additional" yaml:"is_additional"`
+	Cardinality       string    `json:"cardinality,omitempty" yaml:"cardinality,omitempty"`
+	ParentCardinality string    `json:"parent_cardinality,omitempty" yaml:"parent_cardinality,omitempty"`
 }
 
 // Schema is the struct for database schema
 type Schema struct {
-	Name      string      `json:"name"`
-	Tables    []*Table    `json:"tables"`
-	Relations []*Relation `json:"relations"`
+	Name      string      `json:"name" yaml:"name"`
+	Tables    []*Table    `json:"tables" yaml:"tables"`
+	Relations []*Relation `json:"relations" yaml:"relations"`
+	Labels    []*Label    `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Driver    string      `json:"driver,omitempty" yaml:"driver,omitempty"`
+	Charset   string      `json:"charset,omitempty" yaml:"charset,omitempty"`
+	Collation string      `json:"collation,omitempty" yaml:"collation,omitempty"`
+
+	tableIndex          *tableIndex
+	duplicatedRelations []*Relation
 }
 
 // AdditionalData is the struct for table relations from yaml
 type AdditionalData struct {
-	Relations []AdditionalRelation `yaml:"relations"`
-	Comments  []AdditionalComment  `yaml:"comments"`
+	// DefaultSchema qualifies the unqualified table names in the file
+	DefaultSchema string               `yaml:"defaultSchema"`
+	Tables        []*Table             `yaml:"tables"`
+	Relations     []AdditionalRelation `yaml:"relations"`
+	Comments      []AdditionalComment  `yaml:"comments"`
 }
 
 // AdditionalRelation is the struct for table relation from yaml
@@ -84,77 +140,442 @@ type AdditionalRelation struct {
 	ParentTable   string   `yaml:"parentTable"`
 	ParentColumns []string `yaml:"parentColumns"`
 	Def           string   `yaml:"def"`
+	Cardinality   string   `yaml:"cardinality"`
+	Hidden        bool     `yaml:"hidden"`
 }
 
 // AdditionalComment is the struct for table relation from yaml
 type AdditionalComment struct {
-	Table          string            `yaml:"table"`
-	TableComment   string            `yaml:"tableComment"`
-	ColumnComments map[string]string `yaml:"columnComments"`
-}
-
-// MarshalJSON return custom JSON byte
-func (c Column) MarshalJSON() ([]byte, error) {
-	if c.Default.Valid {
-		return json.Marshal(&struct {
-			Name            string      `json:"name"`
-			Type            string      `json:"type"`
-			Nullable        bool        `json:"nullable"`
-			Default         string      `json:"default"`
-			Comment         string      `json:"comment"`
-			ParentRelations []*Relation `json:"-"`
-			ChildRelations  []*Relation `json:"-"`
-		}{
-			Name:            c.Name,
-			Type:            c.Type,
-			Nullable:        c.Nullable,
-			Default:         c.Default.String,
-			Comment:         c.Comment,
-			ParentRelations: c.ParentRelations,
-			ChildRelations:  c.ChildRelations,
-		})
+	Table          string                       `yaml:"table"`
+	TableComment   string                       `yaml:"tableComment"`
+	ColumnComments map[string]string            `yaml:"columnComments"`
+	ColumnLabels   map[string][]string          `yaml:"columnLabels"`
+	ColumnMetadata map[string]map[string]string `yaml:"columnMetadata"`
+}
+
+// NewDefault return the default value of the column scanned from the database. nil means no default
+func NewDefault(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
 	}
-	return json.Marshal(&struct {
-		Name            string      `json:"name"`
-		Type            string      `json:"type"`
-		Nullable        bool        `json:"nullable"`
-		Default         *string     `json:"default"`
-		Comment         string      `json:"comment"`
-		ParentRelations []*Relation `json:"-"`
-		ChildRelations  []*Relation `json:"-"`
-	}{
-		Name:            c.Name,
-		Type:            c.Type,
-		Nullable:        c.Nullable,
-		Default:         nil,
-		Comment:         c.Comment,
-		ParentRelations: c.ParentRelations,
-		ChildRelations:  c.ChildRelations,
-	})
+	d := v.String
+	return &d
+}
+
+// DefaultValue return the default value of the column, or empty string when it has no default
+func (c *Column) DefaultValue() string {
+	if c.Default == nil {
+		return ""
+	}
+	return *c.Default
+}
+
+// UnmarshalJSON parse the schema JSON (output of `tbls out -t json`).
+// Tables and columns of relations are resolved by their names.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	type named struct {
+		Name string `json:"name"`
+	}
+	v := struct {
+		Name      string   `json:"name"`
+		Tables    []*Table `json:"tables"`
+		Relations []struct {
+			Table             named   `json:"table"`
+			Columns           []named `json:"columns"`
+			ParentTable       named   `json:"parent_table"`
+			ParentColumns     []named `json:"parent_columns"`
+			Def               string  `json:"def"`
+			IsAdditional      bool    `json:"is_additional"`
+			Cardinality       string  `json:"cardinality,omitempty"`
+			ParentCardinality string  `json:"parent_cardinality,omitempty"`
+		} `json:"relations"`
+		Labels    []*Label `json:"labels,omitempty"`
+		Driver    string   `json:"driver,omitempty"`
+		Charset   string   `json:"charset,omitempty"`
+		Collation string   `json:"collation,omitempty"`
+	}{}
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.Name = v.Name
+	s.Tables = v.Tables
+	s.Labels = v.Labels
+	s.Driver = v.Driver
+	s.Charset = v.Charset
+	s.Collation = v.Collation
+	relations := []namedRelation{}
+	for _, rv := range v.Relations {
+		r := namedRelation{
+			Table:             rv.Table.Name,
+			ParentTable:       rv.ParentTable.Name,
+			Def:               rv.Def,
+			IsAdditional:      rv.IsAdditional,
+			Cardinality:       rv.Cardinality,
+			ParentCardinality: rv.ParentCardinality,
+		}
+		for _, cv := range rv.Columns {
+			r.Columns = append(r.Columns, cv.Name)
+		}
+		for _, cv := range rv.ParentColumns {
+			r.ParentColumns = append(r.ParentColumns, cv.Name)
+		}
+		relations = append(relations, r)
+	}
+	return s.resolveRelations(relations)
+}
+
+// namedRelation is the relation whose tables and columns are referred by their names
+type namedRelation struct {
+	Table             string   `yaml:"table"`
+	Columns           []string `yaml:"columns"`
+	ParentTable       string   `yaml:"parent_table"`
+	ParentColumns     []string `yaml:"parent_columns"`
+	Def               string   `yaml:"def"`
+	IsAdditional      bool     `yaml:"is_additional"`
+	Cardinality       string   `yaml:"cardinality,omitempty"`
+	ParentCardinality string   `yaml:"parent_cardinality,omitempty"`
 }
 
-// FindTableByName find table by table name
+// resolveRelations set relations of the schema resolving tables and columns by their names, and rebuild ParentRelations and ChildRelations of the columns
+func (s *Schema) resolveRelations(relations []namedRelation) error {
+	var err error
+	resolved := []*Relation{}
+	for _, rv := range relations {
+		r := &Relation{
+			Def:               rv.Def,
+			IsAdditional:      rv.IsAdditional,
+			Cardinality:       rv.Cardinality,
+			ParentCardinality: rv.ParentCardinality,
+		}
+		r.Table, err = s.FindTableByName(rv.Table)
+		if err != nil {
+			return err
+		}
+		r.ParentTable, err = s.FindTableByName(rv.ParentTable)
+		if err != nil {
+			return err
+		}
+		for _, name := range rv.Columns {
+			c, err := r.Table.FindColumnByName(name)
+			if err != nil {
+				return err
+			}
+			r.Columns = append(r.Columns, c)
+		}
+		for _, name := range rv.ParentColumns {
+			c, err := r.ParentTable.FindColumnByName(name)
+			if err != nil {
+				return err
+			}
+			r.ParentColumns = append(r.ParentColumns, c)
+		}
+		resolved = append(resolved, r)
+	}
+	s.Relations = resolved
+	s.LinkRelations()
+	return nil
+}
+
+// LogicalName return the logical name of the table from its comment
+func (t *Table) LogicalName(delimiter string) string {
+	return logicalName(t.Comment, delimiter)
+}
+
+// LogicalName return the logical name of the column from its comment
+func (c *Column) LogicalName(delimiter string) string {
+	return logicalName(c.Comment, delimiter)
+}
+
+// logicalName return the first line of the comment up to the delimiter
+func logicalName(comment string, delimiter string) string {
+	l := strings.SplitN(strings.Replace(comment, "\r\n", "\n", -1), "\n", 2)[0]
+	if delimiter != "" {
+		l = strings.SplitN(l, delimiter, 2)[0]
+	}
+	return strings.TrimSpace(l)
+}
+
+// FindTableByName find table by table name.
+// The name may be quoted ("Users") or differ in case from the table name as the database folds unquoted identifiers (e.g. PostgreSQL lower-cases them).
+// It may also be qualified with the default schema of the database (e.g. `public.users` for the table `users` of PostgreSQL).
 func (s *Schema) FindTableByName(name string) (*Table, error) {
-	for _, t := range s.Tables {
-		if t.Name == name {
+	if t, ok := s.findTable(name); ok {
+		return t, nil
+	}
+	if unqualified, ok := unqualifyIdentifier(s.Driver, defaultSchemaName(s.Driver, s.Name), name); ok {
+		if t, ok := s.findTable(unqualified); ok {
 			return t, nil
 		}
 	}
-	return nil, errors.WithStack(fmt.Errorf("not found table '%s'", name))
+	indexMu.Lock()
+	names := make([]string, len(s.Tables))
+	for i, t := range s.Tables {
+		names[i] = t.Name
+	}
+	indexMu.Unlock()
+	return nil, errors.WithStack(fmt.Errorf("not found table '%s'%s", name, didYouMean(name, names)))
 }
 
-// FindColumnByName find column by column name
+// findTable find table by table name with the index of Tables
+func (s *Schema) findTable(name string) (*Table, bool) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if !s.tableIndex.isFor(s.Tables) {
+		s.tableIndex = newTableIndex(s.Tables)
+	}
+	t, ok := s.tableIndex.find(s.Driver, name)
+	if !ok && t != nil {
+		// the table is renamed after indexing
+		s.tableIndex = newTableIndex(s.Tables)
+		t, ok = s.tableIndex.find(s.Driver, name)
+	}
+	return t, ok
+}
+
+// FindColumnByName find column by column name.
+// The name may be quoted ("Email") or differ in case from the column name when only one column matches case-insensitively.
 func (t *Table) FindColumnByName(name string) (*Column, error) {
-	for _, c := range t.Columns {
-		if c.Name == name {
-			return c, nil
+	if c, ok := t.findColumn(name); ok {
+		return c, nil
+	}
+	indexMu.Lock()
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+	}
+	indexMu.Unlock()
+	return nil, errors.WithStack(fmt.Errorf("not found column '%s.%s'%s", t.Name, name, didYouMean(name, names)))
+}
+
+// findColumn find column by column name with the index of Columns
+func (t *Table) findColumn(name string) (*Column, bool) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if !t.columnIndex.isFor(t.Columns) {
+		t.columnIndex = newColumnIndex(t.Columns)
+	}
+	c, ok := t.columnIndex.find(name)
+	if !ok && c != nil {
+		// the column is renamed after indexing
+		t.columnIndex = newColumnIndex(t.Columns)
+		c, ok = t.columnIndex.find(name)
+	}
+	return c, ok
+}
+
+// AddTable add the table to Tables. It is safe for concurrent use with other Add methods and lookups.
+func (s *Schema) AddTable(t *Table) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	s.Tables = append(s.Tables, t)
+}
+
+// AddRelation add the relation to Relations. It is safe for concurrent use with other Add methods and lookups.
+// ParentRelations and ChildRelations of the columns are built by LinkRelations after all relations are added.
+func (s *Schema) AddRelation(r *Relation) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	s.Relations = append(s.Relations, r)
+}
+
+// AddColumn add the column to Columns. It is safe for concurrent use with other Add methods and lookups.
+func (t *Table) AddColumn(c *Column) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	t.Columns = append(t.Columns, c)
+}
+
+// AddIndex add the index to Indexes. It is safe for concurrent use with other Add methods and lookups.
+func (t *Table) AddIndex(i *Index) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	t.Indexes = append(t.Indexes, i)
+}
+
+// AddConstraint add the constraint to Constraints. It is safe for concurrent use with other Add methods and lookups.
+func (t *Table) AddConstraint(c *Constraint) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	t.Constraints = append(t.Constraints, c)
+}
+
+// AddTrigger add the trigger to Triggers. It is safe for concurrent use with other Add methods and lookups.
+func (t *Table) AddTrigger(tr *Trigger) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	t.Triggers = append(t.Triggers, tr)
+}
+
+// tableIndex is the index of tables by name. It is rebuilt when Tables is replaced or resized, or a table found by the old name is renamed.
+type tableIndex struct {
+	tables  []*Table
+	byName  map[string]*Table
+	byLower map[string][]string
+}
+
+func newTableIndex(tables []*Table) *tableIndex {
+	i := &tableIndex{tables: tables, byName: make(map[string]*Table, len(tables)), byLower: map[string][]string{}}
+	for _, t := range tables {
+		if _, ok := i.byName[t.Name]; !ok {
+			i.byName[t.Name] = t
+			lower := strings.ToLower(t.Name)
+			i.byLower[lower] = append(i.byLower[lower], t.Name)
+		}
+	}
+	return i
+}
+
+// find return the table of the name. It returns the table and false when the table is renamed after indexing.
+func (i *tableIndex) find(driver, name string) (*Table, bool) {
+	key, ok := resolveIdentifier(driver, name, func(n string) bool {
+		_, ok := i.byName[n]
+		return ok
+	}, i.byLower)
+	if !ok {
+		return nil, false
+	}
+	t := i.byName[key]
+	return t, t.Name == key
+}
+
+// isFor return whether the index is built for the tables
+func (i *tableIndex) isFor(tables []*Table) bool {
+	return i != nil && len(i.tables) == len(tables) && (len(tables) == 0 || &i.tables[0] == &tables[0])
+}
+
+// columnIndex is the index of columns by name. It is rebuilt when Columns is replaced or resized, or a column found by the old name is renamed.
+type columnIndex struct {
+	columns []*Column
+	byName  map[string]*Column
+	byLower map[string][]string
+}
+
+func newColumnIndex(columns []*Column) *columnIndex {
+	i := &columnIndex{columns: columns, byName: make(map[string]*Column, len(columns)), byLower: map[string][]string{}}
+	for _, c := range columns {
+		if _, ok := i.byName[c.Name]; !ok {
+			i.byName[c.Name] = c
+			lower := strings.ToLower(c.Name)
+			i.byLower[lower] = append(i.byLower[lower], c.Name)
 		}
 	}
-	return nil, errors.WithStack(fmt.Errorf("not found column '%s.%s'", t.Name, name))
+	return i
+}
+
+// find return the column of the name. It returns the column and false when the column is renamed after indexing.
+// Columns do not know the driver, so unquoted names are not folded.
+func (i *columnIndex) find(name string) (*Column, bool) {
+	key, ok := resolveIdentifier("", name, func(n string) bool {
+		_, ok := i.byName[n]
+		return ok
+	}, i.byLower)
+	if !ok {
+		return nil, false
+	}
+	c := i.byName[key]
+	return c, c.Name == key
+}
+
+// isFor return whether the index is built for the columns
+func (i *columnIndex) isFor(columns []*Column) bool {
+	return i != nil && len(i.columns) == len(columns) && (len(columns) == 0 || &i.columns[0] == &columns[0])
+}
+
+// IsKeyColumn return whether the column is a part of the primary key, foreign keys or indexes of the table
+func (t *Table) IsKeyColumn(c *Column) bool {
+	if len(c.ParentRelations) > 0 || len(c.ChildRelations) > 0 {
+		return true
+	}
+	for _, i := range t.Indexes {
+		for _, name := range i.Columns {
+			if name == c.Name {
+				return true
+			}
+		}
+	}
+	return t.isConstraintColumn("PRIMARY KEY", c)
+}
+
+// ColumnKeys return the key types of the column: PK (primary key), FK (foreign key), UK (unique key) and IDX (indexed).
+// UK and IDX are omitted when already implied by a preceding key type.
+func (t *Table) ColumnKeys(c *Column) []string {
+	keys := []string{}
+	pk := t.isConstraintColumn("PRIMARY KEY", c)
+	if pk {
+		keys = append(keys, KeyPrimary)
+	}
+	if len(c.ParentRelations) > 0 {
+		keys = append(keys, KeyForeign)
+	}
+	if pk {
+		return keys
+	}
+	unique := t.isConstraintColumn("UNIQUE", c)
+	indexed := false
+	for _, i := range t.Indexes {
+		for _, name := range i.Columns {
+			if name != c.Name {
+				continue
+			}
+			indexed = true
+			if strings.Contains(strings.ToUpper(i.Def), "UNIQUE") {
+				unique = true
+			}
+		}
+	}
+	if unique {
+		keys = append(keys, KeyUnique)
+	} else if indexed {
+		keys = append(keys, KeyIndex)
+	}
+	return keys
+}
+
+func (t *Table) isConstraintColumn(typ string, c *Column) bool {
+	for _, cs := range t.Constraints {
+		if cs.Type != typ {
+			continue
+		}
+		m := reConstraintColumns.FindStringSubmatch(cs.Def)
+		if m == nil {
+			continue
+		}
+		for _, name := range strings.Split(m[1], ",") {
+			if strings.Trim(strings.TrimSpace(name), "`\"") == c.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ConstraintName return the name of the foreign key constraint of the relation. Empty for additional relations.
+func (r *Relation) ConstraintName() string {
+	if r.IsAdditional {
+		return ""
+	}
+	for _, c := range r.Table.Constraints {
+		if c.Type == "FOREIGN KEY" && c.Def == r.Def {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// SortOption is the option for sorting schema
+type SortOption struct {
+	// KeepColumnOrder keep columns in the original (DDL) order
+	KeepColumnOrder bool
 }
 
 // Sort schema tables, columns, relations, and constrains
 func (s *Schema) Sort() error {
+	return s.SortWithOption(SortOption{})
+}
+
+// SortWithOption sort schema tables, columns, relations, and constrains with option
+func (s *Schema) SortWithOption(opt SortOption) error {
 	for _, t := range s.Tables {
 		for _, c := range t.Columns {
 			sort.SliceStable(c.ParentRelations, func(i, j int) bool {
@@ -164,9 +585,11 @@ func (s *Schema) Sort() error {
 				return c.ChildRelations[i].Table.Name < c.ChildRelations[j].Table.Name
 			})
 		}
-		sort.SliceStable(t.Columns, func(i, j int) bool {
-			return t.Columns[i].Name < t.Columns[j].Name
-		})
+		if !opt.KeepColumnOrder {
+			sort.SliceStable(t.Columns, func(i, j int) bool {
+				return t.Columns[i].Name < t.Columns[j].Name
+			})
+		}
 		sort.SliceStable(t.Indexes, func(i, j int) bool {
 			return t.Indexes[i].Name < t.Indexes[j].Name
 		})
@@ -186,13 +609,125 @@ func (s *Schema) Sort() error {
 	return nil
 }
 
-// LoadAdditionalData load additional data (relations, comments) from yaml file
+// Filter filter tables by include/exclude glob patterns, and remove relations to filtered tables
+func (s *Schema) Filter(include []string, exclude []string) error {
+	tables := []*Table{}
+	for _, t := range s.Tables {
+		in, err := matchTable(t.Name, include, exclude)
+		if err != nil {
+			return err
+		}
+		if in {
+			tables = append(tables, t)
+		}
+	}
+	s.Tables = tables
+
+	relations := []*Relation{}
+	for _, r := range s.Relations {
+		if containsTable(tables, r.Table) && containsTable(tables, r.ParentTable) {
+			relations = append(relations, r)
+		}
+	}
+	s.Relations = relations
+	duplicated := []*Relation{}
+	for _, r := range s.duplicatedRelations {
+		if containsTable(tables, r.Table) && containsTable(tables, r.ParentTable) {
+			duplicated = append(duplicated, r)
+		}
+	}
+	s.duplicatedRelations = duplicated
+	s.LinkRelations()
+	return nil
+}
+
+// HideColumns mark columns matching glob patterns as hidden in documents and diagrams.
+// A pattern is matched against both the column name and the `table.column` name.
+func (s *Schema) HideColumns(patterns []string) error {
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			hidden, err := matchPatterns(c.Name, patterns)
+			if err != nil {
+				return err
+			}
+			if !hidden {
+				hidden, err = matchPatterns(fmt.Sprintf("%s.%s", t.Name, c.Name), patterns)
+				if err != nil {
+					return err
+				}
+			}
+			c.Hidden = hidden
+		}
+	}
+	return nil
+}
+
+func matchTable(name string, include []string, exclude []string) (bool, error) {
+	if len(include) > 0 {
+		in, err := matchPatterns(name, include)
+		if err != nil || !in {
+			return false, err
+		}
+	}
+	ex, err := matchPatterns(name, exclude)
+	if err != nil {
+		return false, err
+	}
+	return !ex, nil
+}
+
+func matchPatterns(name string, patterns []string) (bool, error) {
+	for _, p := range patterns {
+		match, err := path.Match(p, name)
+		if err != nil {
+			return false, errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid pattern '%s'", p))
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func containsTable(tables []*Table, t *Table) bool {
+	for _, tt := range tables {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAdditionalData load additional data (virtual tables, relations, comments) from yaml file.
+// If path is a directory, load all yaml files (*.yml, *.yaml) in the directory in name order.
 func (s *Schema) LoadAdditionalData(path string) error {
 	fullPath, err := filepath.Abs(path)
 	if err != nil {
 		return errors.Wrap(errors.WithStack(err), "failed to load additional data")
 	}
 
+	fi, err := os.Stat(fullPath)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to load additional data")
+	}
+	if fi.IsDir() {
+		files, err := ioutil.ReadDir(fullPath)
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), "failed to load additional data")
+		}
+		for _, f := range files {
+			ext := filepath.Ext(f.Name())
+			if f.IsDir() || (ext != ".yml" && ext != ".yaml") {
+				continue
+			}
+			err := s.LoadAdditionalData(filepath.Join(fullPath, f.Name()))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	buf, err := ioutil.ReadFile(fullPath)
 	if err != nil {
 		return errors.Wrap(errors.WithStack(err), "failed to load additional data")
@@ -200,25 +735,29 @@ func (s *Schema) LoadAdditionalData(path string) error {
 
 	err = s.AddAdditionalData(buf)
 	if err != nil {
-		return errors.Wrap(err, "failed to load additional data")
+		return errors.Wrap(err, fmt.Sprintf("failed to load additional data '%s'", path))
 	}
 
 	return nil
 }
 
-// AddAdditionalData add additional data (relations, comments) from yaml buffer
+// AddAdditionalData add additional data (virtual tables, relations, comments) from yaml buffer. Unknown keys are reported as errors.
 func (s *Schema) AddAdditionalData(buf []byte) error {
 	var data AdditionalData
-	err := yaml.Unmarshal(buf, &data)
+	err := yaml.UnmarshalStrict(buf, &data)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	err = addAdditionalRelations(s, data.Relations)
+	err = addAdditionalTables(s, data.DefaultSchema, data.Tables)
 	if err != nil {
 		return err
 	}
-	err = addAdditionalComments(s, data.Comments)
+	err = addAdditionalRelations(s, data.DefaultSchema, data.Relations)
+	if err != nil {
+		return err
+	}
+	err = addAdditionalComments(s, data.DefaultSchema, data.Comments)
 	if err != nil {
 		return err
 	}
@@ -226,63 +765,324 @@ func (s *Schema) AddAdditionalData(buf []byte) error {
 	return nil
 }
 
-func addAdditionalRelations(s *Schema, relations []AdditionalRelation) error {
-	for _, r := range relations {
-		relation := &Relation{
-			IsAdditional: true,
+// addAdditionalTables add the tables not in the database (external services, planned tables, ...) as virtual tables
+func addAdditionalTables(s *Schema, defaultSchema string, tables []*Table) error {
+	for _, t := range tables {
+		if t.Name == "" {
+			return errors.New("failed to add table: table name is required")
 		}
-		if r.Def != "" {
-			relation.Def = r.Def
-		} else {
-			relation.Def = "Additional Relation"
+		t.Name, _ = unqualifyIdentifier(s.Driver, defaultSchemaName(s.Driver, s.Name), qualifyIdentifier(defaultSchema, t.Name))
+		if _, err := s.FindTableByName(t.Name); err == nil {
+			return errors.WithStack(fmt.Errorf("failed to add table: table '%s' already exists", t.Name))
+		}
+		t.Virtual = true
+		if t.Type == "" {
+			t.Type = VirtualTableType
+		}
+		// analyzed tables have empty slices instead of nil
+		if t.Columns == nil {
+			t.Columns = []*Column{}
+		}
+		if t.Indexes == nil {
+			t.Indexes = []*Index{}
 		}
-		var err error
-		relation.Table, err = s.FindTableByName(r.Table)
+		if t.Constraints == nil {
+			t.Constraints = []*Constraint{}
+		}
+		if t.Triggers == nil {
+			t.Triggers = []*Trigger{}
+		}
+		s.Tables = append(s.Tables, t)
+	}
+	return nil
+}
+
+func addAdditionalRelations(s *Schema, defaultSchema string, relations []AdditionalRelation) error {
+	for _, r := range relations {
+		tables, isPattern, err := s.findTablesByPattern(r.Table)
 		if err != nil {
 			return errors.Wrap(err, "failed to add relation")
 		}
-		for _, c := range r.Columns {
-			column, err := relation.Table.FindColumnByName(c)
+		if !isPattern {
+			table, err := s.FindTableByName(qualifyIdentifier(defaultSchema, r.Table))
 			if err != nil {
 				return errors.Wrap(err, "failed to add relation")
 			}
-			relation.Columns = append(relation.Columns, column)
-			column.ParentRelations = append(column.ParentRelations, relation)
+			if err := addAdditionalRelation(s, defaultSchema, table, r, false); err != nil {
+				return err
+			}
+			continue
 		}
-		relation.ParentTable, err = s.FindTableByName(r.ParentTable)
+		var re *regexp.Regexp
+		if isRegexpPattern(r.Table) {
+			re = regexp.MustCompile(r.Table[1 : len(r.Table)-1])
+		}
+		for _, table := range tables {
+			if err := addAdditionalRelation(s, defaultSchema, table, expandAdditionalRelation(r, table.Name, re), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandAdditionalRelation expand `$1`... in the parent table, columns and def with the submatches of the table name
+func expandAdditionalRelation(r AdditionalRelation, table string, re *regexp.Regexp) AdditionalRelation {
+	if re == nil {
+		return r
+	}
+	m := re.FindStringSubmatchIndex(table)
+	expand := func(tmpl string) string {
+		return string(re.ExpandString([]byte{}, tmpl, table, m))
+	}
+	expanded := r
+	expanded.Table = table
+	expanded.ParentTable = expand(r.ParentTable)
+	expanded.Def = expand(r.Def)
+	expanded.Columns = []string{}
+	for _, c := range r.Columns {
+		expanded.Columns = append(expanded.Columns, expand(c))
+	}
+	expanded.ParentColumns = []string{}
+	for _, c := range r.ParentColumns {
+		expanded.ParentColumns = append(expanded.ParentColumns, expand(c))
+	}
+	return expanded
+}
+
+// addAdditionalRelation add, override or hide the relation of the table.
+// When the relation is expanded from a pattern, the tables without the columns or the parent table are skipped.
+func addAdditionalRelation(s *Schema, defaultSchema string, table *Table, r AdditionalRelation, expanded bool) error {
+	columns := []*Column{}
+	for _, c := range r.Columns {
+		column, err := table.FindColumnByName(c)
 		if err != nil {
+			if expanded {
+				return nil
+			}
 			return errors.Wrap(err, "failed to add relation")
 		}
-		for _, c := range r.ParentColumns {
-			column, err := relation.ParentTable.FindColumnByName(c)
-			if err != nil {
-				return errors.Wrap(err, "failed to add relation")
+		columns = append(columns, column)
+	}
+	parentTable, err := s.FindTableByName(qualifyIdentifier(defaultSchema, r.ParentTable))
+	if err != nil {
+		if expanded {
+			return nil
+		}
+		return errors.Wrap(err, "failed to add relation")
+	}
+	parentColumns := []*Column{}
+	for _, c := range r.ParentColumns {
+		column, err := parentTable.FindColumnByName(c)
+		if err != nil {
+			if expanded {
+				return nil
 			}
-			relation.ParentColumns = append(relation.ParentColumns, column)
-			column.ChildRelations = append(column.ChildRelations, relation)
+			return errors.Wrap(err, "failed to add relation")
+		}
+		parentColumns = append(parentColumns, column)
+	}
+	if expanded && table == parentTable && sameColumns(columns, parentColumns) {
+		return nil
+	}
+	cardinality, err := ParseCardinality(r.Cardinality)
+	if err != nil {
+		return errors.Wrap(err, "failed to add relation")
+	}
+
+	// override or hide the relation already detected
+	if existing := s.findRelation(table, columns, parentTable, parentColumns); existing != nil {
+		if r.Hidden {
+			s.removeRelation(existing)
+			return nil
+		}
+		if r.Def != "" {
+			existing.Def = r.Def
 		}
+		if cardinality != "" {
+			existing.Cardinality = cardinality
+		}
+		return nil
+	}
+	if r.Hidden {
+		if expanded {
+			return nil
+		}
+		return errors.WithStack(fmt.Errorf("failed to hide relation: relation %s(%s) -> %s(%s) not found", r.Table, strings.Join(r.Columns, ", "), r.ParentTable, strings.Join(r.ParentColumns, ", ")))
+	}
 
-		s.Relations = append(s.Relations, relation)
+	relation := &Relation{
+		Table:         table,
+		Columns:       columns,
+		ParentTable:   parentTable,
+		ParentColumns: parentColumns,
+		IsAdditional:  true,
+		Cardinality:   cardinality,
+	}
+	if r.Def != "" {
+		relation.Def = r.Def
+	} else {
+		relation.Def = "Additional Relation"
+	}
+	s.addRelation(relation)
+	return nil
+}
+
+// findRelation find the relation between the columns
+func (s *Schema) findRelation(table *Table, columns []*Column, parentTable *Table, parentColumns []*Column) *Relation {
+	for _, r := range s.Relations {
+		if r.Table == table && r.ParentTable == parentTable && sameColumns(r.Columns, columns) && sameColumns(r.ParentColumns, parentColumns) {
+			return r
+		}
 	}
 	return nil
 }
 
-func addAdditionalComments(s *Schema, comments []AdditionalComment) error {
+// removeRelation remove the relation from the schema and its columns
+func (s *Schema) removeRelation(relation *Relation) {
+	relations := []*Relation{}
+	for _, r := range s.Relations {
+		if r != relation {
+			relations = append(relations, r)
+		}
+	}
+	s.Relations = relations
+	sig := signatureOf(relation)
+	duplicated := []*Relation{}
+	for _, r := range s.duplicatedRelations {
+		if signatureOf(r) != sig {
+			duplicated = append(duplicated, r)
+		}
+	}
+	s.duplicatedRelations = duplicated
+	s.LinkRelations()
+}
+
+func sameColumns(a []*Column, b []*Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func addAdditionalComments(s *Schema, defaultSchema string, comments []AdditionalComment) error {
 	for _, c := range comments {
-		table, err := s.FindTableByName(c.Table)
+		tables, isPattern, err := s.findTablesByPattern(c.Table)
 		if err != nil {
 			return errors.Wrap(err, "failed to add table comment")
 		}
-		if c.TableComment != "" {
-			table.Comment = c.TableComment
-		}
-		for c, comment := range c.ColumnComments {
-			column, err := table.FindColumnByName(c)
+		if !isPattern {
+			table, err := s.FindTableByName(qualifyIdentifier(defaultSchema, c.Table))
 			if err != nil {
-				return errors.Wrap(err, "failed to add column comment")
+				return errors.Wrap(err, "failed to add table comment")
+			}
+			tables = []*Table{table}
+		}
+		for _, table := range tables {
+			if c.TableComment != "" {
+				table.Comment = c.TableComment
+			}
+			// tables matching the pattern do not always have the column
+			findColumn := func(name string, what string) (*Column, error) {
+				column, err := table.FindColumnByName(name)
+				if err != nil && !isPattern {
+					return nil, errors.Wrap(err, fmt.Sprintf("failed to add column %s", what))
+				}
+				return column, nil
+			}
+			for cn, comment := range c.ColumnComments {
+				column, err := findColumn(cn, "comment")
+				if err != nil {
+					return err
+				}
+				if column != nil {
+					column.Comment = comment
+				}
+			}
+			for cn, labels := range c.ColumnLabels {
+				column, err := findColumn(cn, "labels")
+				if err != nil {
+					return err
+				}
+				if column == nil {
+					continue
+				}
+				for _, name := range labels {
+					l, ok := s.FindLabelByName(name)
+					if !ok {
+						l = &Label{Name: name}
+						s.Labels = append(s.Labels, l)
+					}
+					column.addLabel(l)
+				}
+			}
+			for cn, metadata := range c.ColumnMetadata {
+				column, err := findColumn(cn, "metadata")
+				if err != nil {
+					return err
+				}
+				if column == nil {
+					continue
+				}
+				if column.Metadata == nil {
+					column.Metadata = map[string]string{}
+				}
+				for k, v := range metadata {
+					column.Metadata[k] = v
+				}
 			}
-			column.Comment = comment
 		}
 	}
 	return nil
 }
+
+// findTablesByPattern find tables by the glob pattern (`*`, `?`) or the regexp pattern enclosed in slashes (`/^audit_/`).
+// It also returns whether the name is a pattern. A pattern matching no tables is an error.
+func (s *Schema) findTablesByPattern(name string) ([]*Table, bool, error) {
+	var match func(string) (bool, error)
+	switch {
+	case isRegexpPattern(name):
+		re, err := regexp.Compile(name[1 : len(name)-1])
+		if err != nil {
+			return nil, true, errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid pattern '%s'", name))
+		}
+		match = func(n string) (bool, error) {
+			return re.MatchString(n), nil
+		}
+	case isGlobPattern(name):
+		match = func(n string) (bool, error) {
+			return matchPatterns(n, []string{name})
+		}
+	default:
+		return nil, false, nil
+	}
+	tables := []*Table{}
+	for _, t := range s.Tables {
+		ok, err := match(t.Name)
+		if err != nil {
+			return nil, true, err
+		}
+		if ok {
+			tables = append(tables, t)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, true, errors.WithStack(fmt.Errorf("not found table matching '%s'", name))
+	}
+	return tables, true, nil
+}
+
+// isRegexpPattern return whether the table name is a regexp pattern enclosed in slashes
+func isRegexpPattern(name string) bool {
+	return len(name) > 2 && strings.HasPrefix(name, "/") && strings.HasSuffix(name, "/")
+}
+
+// isGlobPattern return whether the table name is a glob pattern
+func isGlobPattern(name string) bool {
+	return strings.ContainsAny(name, "*?")
+}
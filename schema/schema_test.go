@@ -1,8 +1,13 @@
 package schema
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -28,6 +33,38 @@ func TestSchema_FindTableByName(t *testing.T) {
 	}
 }
 
+func TestSchema_FindTableByNameAfterChanges(t *testing.T) {
+	s := newTestSchema()
+	if _, err := s.FindTableByName("comments"); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+
+	// append
+	s.Tables = append(s.Tables, &Table{Name: "comments"})
+	if _, err := s.FindTableByName("comments"); err != nil {
+		t.Errorf("%s", err)
+	}
+
+	// replace
+	s.Tables = s.Tables[1:]
+	if _, err := s.FindTableByName("users"); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+
+	// rename
+	s.Tables[0].Name = "articles"
+	if _, err := s.FindTableByName("posts"); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+	table, err := s.FindTableByName("articles")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if table != s.Tables[0] {
+		t.Errorf("actual %v\nwant %v", table.Name, s.Tables[0].Name)
+	}
+}
+
 func TestTable_FindColumnByName(t *testing.T) {
 	table := Table{
 		Name: "testtable",
@@ -50,6 +87,227 @@ func TestTable_FindColumnByName(t *testing.T) {
 	}
 }
 
+func TestSchema_FindTableByNameConcurrently(t *testing.T) {
+	s := newTestSchema()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, n := range [][]string{{"users", "id"}, {"posts", "user_id"}, {"Users", "ID"}} {
+				table, err := s.FindTableByName(n[0])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := table.FindColumnByName(n[1]); err != nil {
+					t.Error(err)
+				}
+				s.RelationsOf(table)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSchema_FindTableByNameIdentifier(t *testing.T) {
+	s := &Schema{
+		Driver: "postgres",
+		Tables: []*Table{
+			&Table{Name: "users", Columns: []*Column{&Column{Name: "Email"}}},
+			&Table{Name: "public.Posts"},
+		},
+	}
+	for _, name := range []string{"users", "Users", `"users"`, "USERS"} {
+		if _, err := s.FindTableByName(name); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+	if _, err := s.FindTableByName(`"Users"`); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+	if _, err := s.FindTableByName(`public."Posts"`); err != nil {
+		t.Errorf("%s", err)
+	}
+	table, _ := s.FindTableByName("users")
+	for _, name := range []string{"Email", "email", `"Email"`} {
+		if _, err := table.FindColumnByName(name); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+	if _, err := table.FindColumnByName(`"email"`); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestSchema_UnmarshalJSON(t *testing.T) {
+	want := newTestSchema()
+	want.Tables[0].Columns[0].Default = NewDefault(sql.NullString{String: "nextval()", Valid: true})
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Schema{}
+	err = json.Unmarshal(b, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gb) != string(b) {
+		t.Errorf("actual %s\nwant %s", gb, b)
+	}
+	r := got.Relations[0]
+	if r.Table != got.Tables[1] || r.ParentTable != got.Tables[0] {
+		t.Errorf("actual %v, %v\nwant %v, %v", r.Table.Name, r.ParentTable.Name, "posts", "users")
+	}
+	if len(got.Tables[0].Columns[0].ChildRelations) != 1 || got.Tables[1].Columns[0].ParentRelations[0] != r {
+		t.Errorf("relations of columns are not resolved")
+	}
+
+	err = json.Unmarshal([]byte(`{"tables": [], "relations": [{"table": {"name": "posts"}}]}`), &Schema{})
+	if err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestTable_CollectTablesAndRelations(t *testing.T) {
+	schema := newTestSchema()
+	cid := &Column{
+		Name: "post_id",
+	}
+	comments := &Table{
+		Name:    "comments",
+		Columns: []*Column{cid},
+	}
+	posts, _ := schema.FindTableByName("posts")
+	pid := &Column{
+		Name: "id",
+	}
+	posts.Columns = append(posts.Columns, pid)
+	r := &Relation{
+		Table:         comments,
+		Columns:       []*Column{cid},
+		ParentTable:   posts,
+		ParentColumns: []*Column{pid},
+	}
+	cid.ParentRelations = []*Relation{r}
+	pid.ChildRelations = []*Relation{r}
+	schema.Tables = append(schema.Tables, comments)
+	schema.Relations = append(schema.Relations, r)
+
+	tests := []struct {
+		distance      int
+		tableCount    int
+		relationCount int
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{2, 2, 2},
+		{3, 2, 2},
+	}
+	for _, tt := range tests {
+		tables, relations := comments.CollectTablesAndRelations(tt.distance)
+		if len(tables) != tt.tableCount {
+			t.Errorf("actual %v\nwant %v", len(tables), tt.tableCount)
+		}
+		if len(relations) != tt.relationCount {
+			t.Errorf("actual %v\nwant %v", len(relations), tt.relationCount)
+		}
+	}
+}
+
+func TestRelation_ConstraintName(t *testing.T) {
+	s := newTestSchema()
+	posts, _ := s.FindTableByName("posts")
+	def := "FOREIGN KEY (user_id) REFERENCES users (id)"
+	posts.Constraints = []*Constraint{
+		&Constraint{Name: "posts_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"},
+		&Constraint{Name: "posts_user_id_fk", Type: "FOREIGN KEY", Def: def},
+	}
+	r := s.Relations[0]
+	tests := []struct {
+		def          string
+		isAdditional bool
+		want         string
+	}{
+		{def, false, "posts_user_id_fk"},
+		{"FOREIGN KEY (user_id) REFERENCES members (id)", false, ""},
+		{def, true, ""},
+	}
+	for _, tt := range tests {
+		r.Def = tt.def
+		r.IsAdditional = tt.isAdditional
+		if got := r.ConstraintName(); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestTable_IsKeyColumn(t *testing.T) {
+	s := newTestSchema()
+	users, _ := s.FindTableByName("users")
+	posts, _ := s.FindTableByName("posts")
+	title := &Column{Name: "title", Type: "text"}
+	body := &Column{Name: "body", Type: "text"}
+	code := &Column{Name: "code", Type: "text"}
+	posts.Columns = append(posts.Columns, title, body, code)
+	posts.Indexes = []*Index{&Index{Name: "posts_title_idx", Columns: []string{"title"}}}
+	posts.Constraints = []*Constraint{&Constraint{Name: "posts_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (`code`)"}}
+	tests := []struct {
+		table  *Table
+		column *Column
+		want   bool
+	}{
+		{users, users.Columns[0], true},
+		{posts, posts.Columns[0], true},
+		{posts, title, true},
+		{posts, body, false},
+		{posts, code, true},
+	}
+	for _, tt := range tests {
+		got := tt.table.IsKeyColumn(tt.column)
+		if got != tt.want {
+			t.Errorf("%s.%s: actual %v\nwant %v", tt.table.Name, tt.column.Name, got, tt.want)
+		}
+	}
+}
+
+func TestTable_ColumnKeys(t *testing.T) {
+	s := newTestSchema()
+	posts, _ := s.FindTableByName("posts")
+	title := &Column{Name: "title", Type: "text"}
+	slug := &Column{Name: "slug", Type: "text"}
+	body := &Column{Name: "body", Type: "text"}
+	code := &Column{Name: "code", Type: "text"}
+	posts.Columns = append(posts.Columns, title, slug, body, code)
+	posts.Indexes = []*Index{
+		&Index{Name: "posts_title_idx", Def: "CREATE INDEX posts_title_idx ON posts USING btree (title)", Columns: []string{"title"}},
+		&Index{Name: "posts_slug_key", Def: "CREATE UNIQUE INDEX posts_slug_key ON posts USING btree (slug)", Columns: []string{"slug"}},
+		&Index{Name: "posts_pkey", Def: "CREATE UNIQUE INDEX posts_pkey ON posts USING btree (code)", Columns: []string{"code"}},
+	}
+	posts.Constraints = []*Constraint{&Constraint{Name: "posts_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (code)"}}
+	userID, _ := posts.FindColumnByName("user_id")
+	tests := []struct {
+		column *Column
+		want   string
+	}{
+		{userID, "FK"},
+		{title, "IDX"},
+		{slug, "UK"},
+		{body, ""},
+		{code, "PK"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(posts.ColumnKeys(tt.column), ",")
+		if got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.column.Name, got, tt.want)
+		}
+	}
+}
+
 func TestSchema_Sort(t *testing.T) {
 	schema := Schema{
 		Name: "testschema",
@@ -87,6 +345,105 @@ func TestSchema_Sort(t *testing.T) {
 	}
 }
 
+var filterTests = []struct {
+	include       []string
+	exclude       []string
+	tableCount    int
+	relationCount int
+}{
+	{[]string{}, []string{}, 3, 1},
+	{[]string{"*"}, []string{"tmp_*"}, 2, 1},
+	{[]string{"users", "tmp_*"}, []string{}, 2, 0},
+	{[]string{}, []string{"users"}, 2, 0},
+}
+
+func TestSchema_Filter(t *testing.T) {
+	for _, tt := range filterTests {
+		schema := newTestSchema()
+		err := schema.Filter(tt.include, tt.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schema.Tables) != tt.tableCount {
+			t.Errorf("actual %v\nwant %v", len(schema.Tables), tt.tableCount)
+		}
+		if len(schema.Relations) != tt.relationCount {
+			t.Errorf("actual %v\nwant %v", len(schema.Relations), tt.relationCount)
+		}
+		for _, table := range schema.Tables {
+			for _, c := range table.Columns {
+				if len(c.ParentRelations)+len(c.ChildRelations) > tt.relationCount {
+					t.Errorf("column %s.%s has relations to filtered tables", table.Name, c.Name)
+				}
+			}
+		}
+	}
+}
+
+func TestSchema_HideColumns(t *testing.T) {
+	schema := newTestSchema()
+	err := schema.HideColumns([]string{"*_id", "users.secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, _ := schema.FindTableByName("posts")
+	userID, _ := posts.FindColumnByName("user_id")
+	if !userID.Hidden {
+		t.Errorf("actual %v\nwant %v", userID.Hidden, true)
+	}
+	users, _ := schema.FindTableByName("users")
+	id, _ := users.FindColumnByName("id")
+	if id.Hidden {
+		t.Errorf("actual %v\nwant %v", id.Hidden, false)
+	}
+}
+
+func TestSchema_SortWithOption(t *testing.T) {
+	schema := Schema{
+		Name: "testschema",
+		Tables: []*Table{
+			&Table{
+				Name: "b",
+			},
+			&Table{
+				Name: "a",
+				Columns: []*Column{
+					&Column{
+						Name: "id",
+					},
+					&Column{
+						Name: "created",
+					},
+				},
+				Indexes: []*Index{
+					&Index{
+						Name: "b_idx",
+					},
+					&Index{
+						Name: "a_idx",
+					},
+				},
+			},
+		},
+	}
+	_ = schema.SortWithOption(SortOption{KeepColumnOrder: true})
+	expected := "a"
+	actual := schema.Tables[0].Name
+	if actual != expected {
+		t.Errorf("actual %v\nwant %v", actual, expected)
+	}
+	expected2 := "id"
+	actual2 := schema.Tables[0].Columns[0].Name
+	if actual2 != expected2 {
+		t.Errorf("actual %v\nwant %v", actual2, expected2)
+	}
+	expected3 := "a_idx"
+	actual3 := schema.Tables[0].Indexes[0].Name
+	if actual3 != expected3 {
+		t.Errorf("actual %v\nwant %v", actual3, expected3)
+	}
+}
+
 func TestAddAditionalData(t *testing.T) {
 	schema := Schema{
 		Name: "testschema",
@@ -134,6 +491,11 @@ func TestAddAditionalData(t *testing.T) {
 	if actual != expected {
 		t.Errorf("actual %v\nwant %v", actual, expected)
 	}
+	expected3 := ZeroOrMore
+	actual3 := schema.Relations[0].Cardinality
+	if actual3 != expected3 {
+		t.Errorf("actual %v\nwant %v", actual3, expected3)
+	}
 	posts, _ := schema.FindTableByName("posts")
 	title, _ := posts.FindColumnByName("title")
 	expected2 := "post title"
@@ -143,6 +505,487 @@ func TestAddAditionalData(t *testing.T) {
 	}
 }
 
+func TestLoadAdditionalDataDir(t *testing.T) {
+	schema := newTestSchema()
+	schema.Relations = []*Relation{}
+	for _, t := range schema.Tables {
+		for _, c := range t.Columns {
+			c.ParentRelations = nil
+			c.ChildRelations = nil
+		}
+	}
+	posts, _ := schema.FindTableByName("posts")
+	posts.Columns = append(posts.Columns, &Column{Name: "title", Type: "text"})
+	err := schema.LoadAdditionalData(filepath.Join(testdataDir(), "schema_test_additional_data_dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 1
+	actual := len(schema.Relations)
+	if actual != expected {
+		t.Errorf("actual %v\nwant %v", actual, expected)
+	}
+	title, _ := posts.FindColumnByName("title")
+	expected2 := "post title"
+	actual2 := title.Comment
+	if actual2 != expected2 {
+		t.Errorf("actual %v\nwant %v", actual2, expected2)
+	}
+}
+
+func TestAddAditionalDataOverrideRelations(t *testing.T) {
+	schema := newTestSchema()
+	err := schema.AddAdditionalData([]byte(`relations:
+  -
+    table: posts
+    columns:
+      - user_id
+    parentTable: users
+    parentColumns:
+      - id
+    def: posts->users (deprecated)
+    cardinality: zero-or-one
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Relations) != 1 {
+		t.Fatalf("actual %v\nwant %v", len(schema.Relations), 1)
+	}
+	r := schema.Relations[0]
+	if r.Def != "posts->users (deprecated)" || r.Cardinality != ZeroOrOne || r.IsAdditional {
+		t.Errorf("actual %v %v %v\nwant %v %v %v", r.Def, r.Cardinality, r.IsAdditional, "posts->users (deprecated)", ZeroOrOne, false)
+	}
+
+	err = schema.AddAdditionalData([]byte(`relations:
+  -
+    table: posts
+    columns:
+      - user_id
+    parentTable: users
+    parentColumns:
+      - id
+    hidden: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Relations) != 0 {
+		t.Errorf("actual %v\nwant %v", len(schema.Relations), 0)
+	}
+	posts, _ := schema.FindTableByName("posts")
+	userID, _ := posts.FindColumnByName("user_id")
+	if len(userID.ParentRelations) != 0 {
+		t.Errorf("actual %v\nwant %v", len(userID.ParentRelations), 0)
+	}
+}
+
+func TestAddAditionalDataQualifiedNames(t *testing.T) {
+	schema := newTestSchema()
+	schema.Driver = "postgres"
+	schema.Relations = []*Relation{}
+	invoices := &Table{Name: "billing.invoices", Columns: []*Column{&Column{Name: "user_id"}}}
+	schema.Tables = append(schema.Tables, invoices)
+	err := schema.AddAdditionalData([]byte(`relations:
+  -
+    table: billing.invoices
+    columns:
+      - user_id
+    parentTable: public.users
+    parentColumns:
+      - id
+comments:
+  -
+    table: PUBLIC.posts
+    tableComment: posts of users
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Relations) != 1 || schema.Relations[0].Table != invoices {
+		t.Errorf("relation of billing.invoices is not added")
+	}
+	posts, _ := schema.FindTableByName("posts")
+	if want := "posts of users"; posts.Comment != want {
+		t.Errorf("actual %v\nwant %v", posts.Comment, want)
+	}
+
+	err = schema.AddAdditionalData([]byte(`defaultSchema: billing
+comments:
+  -
+    table: invoices
+    tableComment: invoices of users
+  -
+    table: public.users
+    tableComment: users
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "invoices of users"; invoices.Comment != want {
+		t.Errorf("actual %v\nwant %v", invoices.Comment, want)
+	}
+
+	if _, err := schema.FindTableByName("billing.users"); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestAddAditionalDataCommentPatterns(t *testing.T) {
+	schema := newTestSchema()
+	for _, t := range schema.Tables {
+		t.Columns = append(t.Columns, &Column{Name: "created_at"})
+	}
+	schema.Tables = append(schema.Tables, &Table{Name: "audit_logs", Columns: []*Column{&Column{Name: "id"}}})
+	err := schema.AddAdditionalData([]byte(`comments:
+  -
+    table: "*"
+    columnComments:
+      created_at: created time
+  -
+    table: /^audit_/
+    tableComment: audit table
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"users", "posts"} {
+		table, _ := schema.FindTableByName(name)
+		c, _ := table.FindColumnByName("created_at")
+		if want := "created time"; c.Comment != want {
+			t.Errorf("%s: actual %v\nwant %v", name, c.Comment, want)
+		}
+		if table.Comment == "audit table" {
+			t.Errorf("%s: actual %v", name, table.Comment)
+		}
+	}
+	audit, _ := schema.FindTableByName("audit_logs")
+	if want := "audit table"; audit.Comment != want {
+		t.Errorf("actual %v\nwant %v", audit.Comment, want)
+	}
+
+	tests := []string{"comments:\n  -\n    table: archived_*\n    tableComment: archived\n", "comments:\n  -\n    table: /(/\n    tableComment: invalid\n"}
+	for _, tt := range tests {
+		if err := schema.AddAdditionalData([]byte(tt)); err == nil {
+			t.Errorf("actual %v\nwant %v", err, "error")
+		}
+	}
+}
+
+func TestAddAditionalDataRelationPatterns(t *testing.T) {
+	schema := newTestSchema()
+	schema.Relations = []*Relation{}
+	for _, t := range schema.Tables {
+		for _, c := range t.Columns {
+			c.ParentRelations = nil
+			c.ChildRelations = nil
+		}
+	}
+	posts, _ := schema.FindTableByName("posts")
+	posts.Columns = append(posts.Columns, &Column{Name: "id"})
+	userHistories := &Table{Name: "user_histories", Columns: []*Column{&Column{Name: "source_id"}}}
+	postHistories := &Table{Name: "post_histories", Columns: []*Column{&Column{Name: "source_id"}}}
+	tagHistories := &Table{Name: "tag_histories", Columns: []*Column{&Column{Name: "source_id"}}}
+	schema.Tables = append(schema.Tables, userHistories, postHistories, tagHistories)
+	err := schema.AddAdditionalData([]byte(`relations:
+  -
+    table: /^(.+)_histories$/
+    columns:
+      - source_id
+    parentTable: ${1}s
+    parentColumns:
+      - id
+    def: history of ${1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Relations) != 2 {
+		t.Fatalf("actual %v\nwant %v", len(schema.Relations), 2)
+	}
+	for _, r := range schema.Relations {
+		if r.Table == tagHistories {
+			t.Errorf("relation of tag_histories without the parent table is added")
+		}
+		if want := fmt.Sprintf("%ss", strings.TrimSuffix(r.Table.Name, "_histories")); r.ParentTable.Name != want {
+			t.Errorf("actual %v\nwant %v", r.ParentTable.Name, want)
+		}
+		if want := fmt.Sprintf("history of %s", strings.TrimSuffix(r.Table.Name, "_histories")); r.Def != want {
+			t.Errorf("actual %v\nwant %v", r.Def, want)
+		}
+	}
+
+	err = schema.AddAdditionalData([]byte(`relations:
+  -
+    table: "*_histories"
+    columns:
+      - source_id
+    parentTable: users
+    parentColumns:
+      - id
+    hidden: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Relations) != 1 || schema.Relations[0].Table != postHistories {
+		t.Errorf("relation of user_histories is not hidden")
+	}
+}
+
+func TestAddAditionalDataTables(t *testing.T) {
+	schema := newTestSchema()
+	err := schema.AddAdditionalData([]byte(`tables:
+  -
+    name: payments
+    comment: payments in the external payment service
+    columns:
+      -
+        name: id
+        type: varchar(64)
+      -
+        name: user_id
+        type: int
+        nullable: true
+relations:
+  -
+    table: payments
+    columns:
+      - user_id
+    parentTable: users
+    parentColumns:
+      - id
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payments, err := schema.FindTableByName("payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !payments.Virtual || payments.Type != VirtualTableType || len(payments.Columns) != 2 {
+		t.Errorf("actual %v %v %v\nwant %v %v %v", payments.Virtual, payments.Type, len(payments.Columns), true, VirtualTableType, 2)
+	}
+	if payments.Columns[1].Default != nil || !payments.Columns[1].Nullable {
+		t.Errorf("columns of the virtual table are not loaded")
+	}
+	if len(schema.Relations) != 2 || schema.Relations[1].Table != payments {
+		t.Errorf("relation of the virtual table is not added")
+	}
+
+	err = schema.AddAdditionalData([]byte("tables:\n  -\n    name: users\n"))
+	if err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestAddAditionalDataColumnLabels(t *testing.T) {
+	schema := newTestSchema()
+	err := schema.AddAdditionalData([]byte(`comments:
+  -
+    table: users
+    columnLabels:
+      id:
+        - pii
+        - deprecated
+    columnMetadata:
+      id:
+        owner: billing
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = schema.AssignLabels([]Label{{Name: "pii", Color: "#ff0000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, _ := schema.FindTableByName("users")
+	id, _ := users.FindColumnByName("id")
+	if len(id.Labels) != 2 || id.Labels[0].Name != "pii" || id.Labels[0].Color != "#ff0000" || id.Labels[1].Name != "deprecated" {
+		t.Errorf("labels of the column are not added")
+	}
+	if len(schema.Labels) != 2 {
+		t.Errorf("actual %v\nwant %v", len(schema.Labels), 2)
+	}
+	if want := "billing"; id.Metadata["owner"] != want {
+		t.Errorf("actual %v\nwant %v", id.Metadata["owner"], want)
+	}
+	b, err := json.Marshal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"labels":[{"name":"pii","color":"#ff0000"},{"name":"deprecated"}],"metadata":{"owner":"billing"}`; !strings.Contains(string(b), want) {
+		t.Errorf("actual %s\nwant %s", b, want)
+	}
+
+	err = schema.AddAdditionalData([]byte("comments:\n  -\n    table: users\n    columnLabels:\n      email:\n        - pii\n"))
+	if err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+}
+
+func TestAddAditionalDataUnknownKey(t *testing.T) {
+	schema := newTestSchema()
+	err := schema.AddAdditionalData([]byte("relations:\n  -\n    table: posts\n    columns:\n      - user_id\n    parentTable: users\n    parentColums:\n      - id\n"))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	expected := "line 7: field parentColums not found"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("actual %v\nwant %v", err.Error(), expected)
+	}
+}
+
+func TestParseCardinality(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"zero-or-one", ZeroOrOne, false},
+		{"one-to-one", ExactlyOne, false},
+		{"one-to-many", ZeroOrMore, false},
+		{"one-or-more", OneOrMore, false},
+		{"many-to-many", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCardinality(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: actual %v\nwant error %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestSchema_DetectCardinalities(t *testing.T) {
+	tests := []struct {
+		nullable              bool
+		indexes               []*Index
+		constraints           []*Constraint
+		cardinality           string
+		wantCardinality       string
+		wantParentCardinality string
+	}{
+		{false, nil, nil, "", ZeroOrMore, ExactlyOne},
+		{true, nil, nil, "", ZeroOrMore, ZeroOrOne},
+		{false, []*Index{&Index{Name: "idx", Def: "CREATE INDEX idx ON posts (user_id)", Columns: []string{"user_id"}}}, nil, "", ZeroOrMore, ExactlyOne},
+		{false, []*Index{&Index{Name: "uniq", Def: "CREATE UNIQUE INDEX uniq ON posts (user_id)", Columns: []string{"user_id"}}}, nil, "", ZeroOrOne, ExactlyOne},
+		{false, []*Index{&Index{Name: "uniq", Def: "CREATE UNIQUE INDEX uniq ON posts (user_id, title)", Columns: []string{"user_id", "title"}}}, nil, "", ZeroOrMore, ExactlyOne},
+		{true, nil, []*Constraint{&Constraint{Name: "posts_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (user_id)"}}, "", ZeroOrOne, ZeroOrOne},
+		{false, nil, nil, OneOrMore, OneOrMore, ExactlyOne},
+	}
+	for i, tt := range tests {
+		s := newTestSchema()
+		posts, _ := s.FindTableByName("posts")
+		posts.Columns[0].Nullable = tt.nullable
+		posts.Indexes = tt.indexes
+		posts.Constraints = tt.constraints
+		s.Relations[0].Cardinality = tt.cardinality
+		s.DetectCardinalities()
+		if got := s.Relations[0].Cardinality; got != tt.wantCardinality {
+			t.Errorf("%d: actual %v\nwant %v", i, got, tt.wantCardinality)
+		}
+		if got := s.Relations[0].ParentCardinality; got != tt.wantParentCardinality {
+			t.Errorf("%d: actual %v\nwant %v", i, got, tt.wantParentCardinality)
+		}
+	}
+}
+
+func TestSchema_BuildConcurrently(t *testing.T) {
+	const n = 32
+	s := &Schema{Name: "testschema"}
+	users := &Table{Name: "users"}
+	users.AddColumn(&Column{Name: "id"})
+	s.AddTable(users)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// each worker adds its table and looks up the tables added by other workers
+			table := &Table{Name: fmt.Sprintf("posts%d", i)}
+			s.AddTable(table)
+			table.AddColumn(&Column{Name: "id"})
+			uid := &Column{Name: "user_id"}
+			table.AddColumn(uid)
+			table.AddIndex(&Index{Name: fmt.Sprintf("posts%d_user_id_idx", i), Columns: []string{"user_id"}})
+			table.AddConstraint(&Constraint{Name: fmt.Sprintf("posts%d_user_id_fk", i), Type: "FOREIGN KEY"})
+			table.AddTrigger(&Trigger{Name: fmt.Sprintf("posts%d_trigger", i)})
+			parent, err := s.FindTableByName("users")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			pid, err := parent.FindColumnByName("id")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.AddRelation(&Relation{Table: table, Columns: []*Column{uid}, ParentTable: parent, ParentColumns: []*Column{pid}})
+			_, _ = s.FindTableByName(fmt.Sprintf("posts%d", (i+1)%n))
+		}(i)
+	}
+	wg.Wait()
+	s.LinkRelations()
+
+	if len(s.Tables) != n+1 {
+		t.Errorf("actual %v\nwant %v", len(s.Tables), n+1)
+	}
+	if len(s.Relations) != n {
+		t.Errorf("actual %v\nwant %v", len(s.Relations), n)
+	}
+	for i := 0; i < n; i++ {
+		table, err := s.FindTableByName(fmt.Sprintf("posts%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(table.Columns) != 2 || len(table.Indexes) != 1 || len(table.Constraints) != 1 || len(table.Triggers) != 1 {
+			t.Errorf("%s: actual %v %v %v %v\nwant 2 1 1 1", table.Name, len(table.Columns), len(table.Indexes), len(table.Constraints), len(table.Triggers))
+		}
+	}
+	if got := len(users.Columns[0].ChildRelations); got != n {
+		t.Errorf("actual %v\nwant %v", got, n)
+	}
+}
+
+func newTestSchema() *Schema {
+	uid := &Column{
+		Name: "id",
+		Type: "serial",
+	}
+	puid := &Column{
+		Name: "user_id",
+		Type: "int",
+	}
+	users := &Table{
+		Name:    "users",
+		Columns: []*Column{uid},
+	}
+	posts := &Table{
+		Name:    "posts",
+		Columns: []*Column{puid},
+	}
+	tmp := &Table{
+		Name: "tmp_posts",
+	}
+	r := &Relation{
+		Table:         posts,
+		Columns:       []*Column{puid},
+		ParentTable:   users,
+		ParentColumns: []*Column{uid},
+	}
+	puid.ParentRelations = []*Relation{r}
+	uid.ChildRelations = []*Relation{r}
+	return &Schema{
+		Name:      "testschema",
+		Tables:    []*Table{users, posts, tmp},
+		Relations: []*Relation{r},
+	}
+}
+
 func testdataDir() string {
 	wd, _ := os.Getwd()
 	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(wd), "testdata"))
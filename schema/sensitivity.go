@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSensitivityLabel is the sensitivity label of columns detected by detectors without the label
+const DefaultSensitivityLabel = "PII"
+
+// DefaultSensitivityDetectors is the builtin detectors of sensitive columns
+var DefaultSensitivityDetectors = []SensitivityDetector{
+	{
+		Name:    "email",
+		Column:  `(?i)e_?mail`,
+		Comment: `(?i)e-?mail`,
+		Value:   `^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`,
+	},
+	{
+		Name:    "phone",
+		Column:  `(?i)(phone|(^|_)(tel|mobile|fax)(_|$))`,
+		Comment: `(?i)(phone|telephone)`,
+		Value:   `^\+?[0-9]{0,4}[ -]?\(?[0-9]{2,4}\)?[ -]?[0-9]{3,4}[ -]?[0-9]{3,4}$`,
+	},
+	{
+		Name:    "national_id",
+		Column:  `(?i)((^|_)ssn(_|$)|social_security|national_id|passport)`,
+		Comment: `(?i)(social security|national id|passport)`,
+		Value:   `^[0-9]{3}-[0-9]{2}-[0-9]{4}$`,
+	},
+}
+
+// SensitivityDetector is the struct for the detector of sensitive columns.
+// A column is detected when its name matches Column or its comment matches Comment,
+// or when all sampled non-NULL values match Value.
+type SensitivityDetector struct {
+	Name    string `yaml:"name"`
+	Label   string `yaml:"label,omitempty"`
+	Column  string `yaml:"column,omitempty"`
+	Comment string `yaml:"comment,omitempty"`
+	Value   string `yaml:"value,omitempty"`
+}
+
+// Sensitivity is the sensitivity label of the column and the detectors which detected it
+type Sensitivity struct {
+	Label     string   `json:"label" yaml:"label"`
+	Detectors []string `json:"detectors" yaml:"detectors"`
+}
+
+// String return the label and the detectors such as `PII (email)`
+func (s *Sensitivity) String() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", s.Label, strings.Join(s.Detectors, ", "))
+}
+
+// Validate check the patterns of the detector
+func (d SensitivityDetector) Validate() error {
+	if d.Name == "" {
+		return errors.New("sensitivity detector requires name")
+	}
+	if d.Column == "" && d.Comment == "" && d.Value == "" {
+		return errors.Errorf("sensitivity detector '%s' requires column, comment or value", d.Name)
+	}
+	for _, p := range []string{d.Column, d.Comment, d.Value} {
+		if _, err := regexp.Compile(p); err != nil {
+			return errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid pattern of sensitivity detector '%s'", d.Name))
+		}
+	}
+	return nil
+}
+
+// MatchValues return whether all values match Value of the detector. It is false when there are no values.
+func (d SensitivityDetector) MatchValues(values []string) (bool, error) {
+	if d.Value == "" || len(values) == 0 {
+		return false, nil
+	}
+	re, err := regexp.Compile(d.Value)
+	if err != nil {
+		return false, errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid pattern of sensitivity detector '%s'", d.Name))
+	}
+	for _, v := range values {
+		if !re.MatchString(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// TagSensitivity tag the column as sensitive with the label of the detector.
+// The label of the first detector is kept when the column is detected by multiple detectors.
+func (c *Column) TagSensitivity(d SensitivityDetector) {
+	label := d.Label
+	if label == "" {
+		label = DefaultSensitivityLabel
+	}
+	if c.Sensitivity == nil {
+		c.Sensitivity = &Sensitivity{Label: label, Detectors: []string{}}
+	}
+	for _, n := range c.Sensitivity.Detectors {
+		if n == d.Name {
+			return
+		}
+	}
+	c.Sensitivity.Detectors = append(c.Sensitivity.Detectors, d.Name)
+}
+
+// DetectSensitivity tag columns whose names or comments match the detectors
+func (s *Schema) DetectSensitivity(detectors []SensitivityDetector) error {
+	for _, d := range detectors {
+		var reColumn, reComment *regexp.Regexp
+		if d.Column != "" {
+			re, err := regexp.Compile(d.Column)
+			if err != nil {
+				return errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid pattern of sensitivity detector '%s'", d.Name))
+			}
+			reColumn = re
+		}
+		if d.Comment != "" {
+			re, err := regexp.Compile(d.Comment)
+			if err != nil {
+				return errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid pattern of sensitivity detector '%s'", d.Name))
+			}
+			reComment = re
+		}
+		for _, t := range s.Tables {
+			for _, c := range t.Columns {
+				if (reColumn != nil && reColumn.MatchString(c.Name)) || (reComment != nil && reComment.MatchString(c.Comment)) {
+					c.TagSensitivity(d)
+				}
+			}
+		}
+	}
+	return nil
+}
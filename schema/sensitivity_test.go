@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetectSensitivity(t *testing.T) {
+	s := newTestSchema()
+	users, _ := s.FindTableByName("users")
+	users.Columns = append(users.Columns,
+		&Column{Name: "username"},
+		&Column{Name: "email"},
+		&Column{Name: "contact", Comment: "Telephone number"},
+		&Column{Name: "hotel"},
+		&Column{Name: "ssn"},
+	)
+	detectors := []SensitivityDetector{}
+	detectors = append(detectors, DefaultSensitivityDetectors...)
+	detectors = append(detectors, SensitivityDetector{Name: "username", Label: "internal", Column: "^username$"})
+	if err := s.DetectSensitivity(detectors); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{"id", ""},
+		{"username", "internal (username)"},
+		{"email", "PII (email)"},
+		{"contact", "PII (phone)"},
+		{"hotel", ""},
+		{"ssn", "PII (national_id)"},
+	}
+	for _, tt := range tests {
+		c, err := users.FindColumnByName(tt.column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Sensitivity.String(); got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestTagSensitivity(t *testing.T) {
+	c := &Column{Name: "contact"}
+	c.TagSensitivity(SensitivityDetector{Name: "email", Label: "confidential"})
+	c.TagSensitivity(SensitivityDetector{Name: "phone"})
+	c.TagSensitivity(SensitivityDetector{Name: "email"})
+	want := "confidential (email, phone)"
+	if got := c.Sensitivity.String(); got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
+
+func TestSensitivityDetector_MatchValues(t *testing.T) {
+	detectors := map[string]SensitivityDetector{}
+	for _, d := range DefaultSensitivityDetectors {
+		detectors[d.Name] = d
+	}
+	tests := []struct {
+		detector string
+		values   []string
+		want     bool
+	}{
+		{"email", []string{"alice@example.com", "bob.smith+tbls@example.co.jp"}, true},
+		{"email", []string{"alice@example.com", "bob"}, false},
+		{"email", []string{}, false},
+		{"phone", []string{"+1 415-555-0100", "(03) 1234-5678", "09012345678"}, true},
+		{"phone", []string{"2020-01-01"}, false},
+		{"phone", []string{"1", "2"}, false},
+		{"national_id", []string{"078-05-1120"}, true},
+		{"national_id", []string{"078051120"}, false},
+	}
+	for _, tt := range tests {
+		got, err := detectors[tt.detector].MatchValues(tt.values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s %v: actual %v\nwant %v", tt.detector, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestSensitivityDetector_Validate(t *testing.T) {
+	tests := []struct {
+		detector SensitivityDetector
+		wantErr  bool
+	}{
+		{SensitivityDetector{Name: "card", Value: `^[0-9]{16}$`}, false},
+		{SensitivityDetector{Column: "card"}, true},
+		{SensitivityDetector{Name: "card"}, true},
+		{SensitivityDetector{Name: "card", Column: "("}, true},
+	}
+	for _, tt := range tests {
+		err := tt.detector.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: actual %v\nwant %v", fmt.Sprintf("%+v", tt.detector), err, tt.wantErr)
+		}
+	}
+}
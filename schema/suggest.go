@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the max number of names suggested for the unknown name
+const maxSuggestions = 3
+
+// didYouMean return the hint of the names closest to the unknown name, e.g. ` (did you mean 'users'?)`. Empty if no name is close enough.
+func didYouMean(name string, candidates []string) string {
+	s := suggest(name, candidates)
+	if len(s) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", strings.Join(s, "', '"))
+}
+
+// suggest return the names within the edit distance of a third of the name length (at least 2), closest first
+func suggest(name string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+	lower := strings.ToLower(name)
+	// qualified names (e.g. public.userz) are also compared to unqualified candidates without the schema
+	unqualified := lower
+	if i := strings.LastIndex(lower, "."); i >= 0 {
+		unqualified = lower[i+1:]
+	}
+	threshold := suggestThreshold(lower)
+	uthreshold := suggestThreshold(unqualified)
+	matches := []scored{}
+	for _, c := range candidates {
+		lc := strings.ToLower(c)
+		d := editDistance(lower, lc)
+		if d <= threshold {
+			matches = append(matches, scored{c, d})
+			continue
+		}
+		if unqualified != lower && !strings.Contains(lc, ".") {
+			if du := editDistance(unqualified, lc); du <= uthreshold {
+				matches = append(matches, scored{c, du})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+	names := []string{}
+	for i, m := range matches {
+		if i >= maxSuggestions {
+			break
+		}
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// suggestThreshold return the max edit distance of names suggested for the name
+func suggestThreshold(name string) int {
+	t := len([]rune(name)) / 3
+	if t < 2 {
+		t = 2
+	}
+	return t
+}
+
+// editDistance return the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
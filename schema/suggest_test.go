@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"users", "users", 0},
+		{"users", "user", 1},
+		{"usres", "users", 2},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"ユーザー", "ユーザ", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s, %s: actual %v\nwant %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	names := []string{"users", "user_options", "posts", "post_comments", "comments", "logs", "administrator.blogs"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"user", " (did you mean 'users'?)"},
+		{"USRES", " (did you mean 'users'?)"},
+		{"public.userz", " (did you mean 'users'?)"},
+		{"comment", " (did you mean 'comments'?)"},
+		{"log", " (did you mean 'logs'?)"},
+		{"administrator.blog", " (did you mean 'administrator.blogs', 'logs'?)"},
+		{"invoices", ""},
+	}
+	for _, tt := range tests {
+		if got := didYouMean(tt.name, names); got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindByNameSuggestion(t *testing.T) {
+	s := newTestSchema()
+	_, err := s.FindTableByName("usres")
+	if err == nil || !strings.Contains(err.Error(), "did you mean 'users'") {
+		t.Errorf("actual %v\nwant %v", err, "did you mean 'users'")
+	}
+	posts, _ := s.FindTableByName("posts")
+	_, err = posts.FindColumnByName("userid")
+	if err == nil || !strings.Contains(err.Error(), "did you mean 'user_id'") {
+		t.Errorf("actual %v\nwant %v", err, "did you mean 'user_id'")
+	}
+}
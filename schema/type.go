@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	reTypeArgs  = regexp.MustCompile(`\s*\(([^)]*)\)`)
+	reArgsComma = regexp.MustCompile(`\s*,\s*`)
+)
+
+// typeAliases is driver-specific spellings of types and their canonical forms
+var typeAliases = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"int8":                        "bigint",
+	"int2":                        "smallint",
+	"character varying":           "varchar",
+	"character":                   "char",
+	"bpchar":                      "char",
+	"bit varying":                 "varbit",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"time with time zone":         "timetz",
+	"double precision":            "double",
+	"float8":                      "double",
+	"float4":                      "real",
+	"bool":                        "boolean",
+	"decimal":                     "numeric",
+}
+
+// integerTypes is types whose arguments are display widths rather than a part of the type (MySQL `int(11)`)
+var integerTypes = map[string]bool{
+	"tinyint":   true,
+	"smallint":  true,
+	"mediumint": true,
+	"integer":   true,
+	"bigint":    true,
+}
+
+// NormalizeType return the canonical form of the driver-specific spelling of the column type.
+// e.g. `int(11)` -> `integer`, `character varying(255)` -> `varchar(255)`, `timestamp(3) without time zone` -> `timestamp(3)`
+func NormalizeType(t string) string {
+	n := strings.Join(strings.Fields(t), " ")
+	if n == "" {
+		return t
+	}
+	array := ""
+	for strings.HasSuffix(n, "[]") {
+		array += "[]"
+		n = strings.TrimSpace(strings.TrimSuffix(n, "[]"))
+	}
+	args := ""
+	if m := reTypeArgs.FindStringSubmatch(n); m != nil {
+		args = reArgsComma.ReplaceAllString(strings.TrimSpace(m[1]), ",")
+		n = strings.Join(strings.Fields(reTypeArgs.ReplaceAllString(n, " ")), " ")
+	}
+	n = strings.ToLower(n)
+	attrs := ""
+	for _, a := range []string{"zerofill", "unsigned"} {
+		if strings.HasSuffix(n, " "+a) {
+			n = strings.TrimSuffix(n, " "+a)
+			attrs = " " + a + attrs
+		}
+	}
+	if c, ok := typeAliases[n]; ok {
+		n = c
+	}
+	if args != "" && !integerTypes[n] {
+		n = n + "(" + args + ")"
+	}
+	return n + attrs + array
+}
+
+// NormalizeTypes replace types of all columns with their canonical forms, so that schemas of different databases (and environments) are compared consistently
+func (s *Schema) NormalizeTypes() {
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			c.Type = NormalizeType(c.Type)
+		}
+	}
+}
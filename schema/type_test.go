@@ -0,0 +1,49 @@
+package schema
+
+import "testing"
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"int(11)", "integer"},
+		{"int(10) unsigned", "integer unsigned"},
+		{"INTEGER", "integer"},
+		{"int4", "integer"},
+		{"bigint(20) unsigned zerofill", "bigint unsigned zerofill"},
+		{"character varying(255)", "varchar(255)"},
+		{"varchar(255)", "varchar(255)"},
+		{"character varying", "varchar"},
+		{"character(10)", "char(10)"},
+		{"timestamp without time zone", "timestamp"},
+		{"timestamp(3) without time zone", "timestamp(3)"},
+		{"timestamp with time zone", "timestamptz"},
+		{"double precision", "double"},
+		{"decimal(10, 2)", "numeric(10,2)"},
+		{"numeric(10,2)", "numeric(10,2)"},
+		{"tinyint(1)", "tinyint"},
+		{"character varying(64)[]", "varchar(64)[]"},
+		{"int4[][]", "integer[][]"},
+		{"enum('a','b')", "enum('a','b')"},
+		{"ENUM('Draft', 'In Review')", "enum('Draft','In Review')"},
+		{"datetime", "datetime"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := NormalizeType(tt.in)
+		if got != tt.want {
+			t.Errorf("%s: actual %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTypes(t *testing.T) {
+	s := newTestSchema()
+	s.Tables[0].Columns[0].Type = "INT(11)"
+	s.NormalizeTypes()
+	want := "integer"
+	if got := s.Tables[0].Columns[0].Type; got != want {
+		t.Errorf("actual %v\nwant %v", got, want)
+	}
+}
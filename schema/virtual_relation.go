@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultVirtualRelationDef is the def of relations detected from naming conventions
+const DefaultVirtualRelationDef = "Virtual Relation"
+
+var reDefaultVirtualRelationColumn = regexp.MustCompile(`^(.+)_id$`)
+
+// VirtualRelationRule is the struct for the rule to detect relations from column names
+type VirtualRelationRule struct {
+	Column       string `yaml:"column"`
+	ParentTable  string `yaml:"parentTable"`
+	ParentColumn string `yaml:"parentColumn"`
+	Def          string `yaml:"def"`
+}
+
+// DetectVirtualRelations detect relations from naming conventions for databases without foreign keys.
+// strategy "default" detects `<singular_table>_id` -> `<table>.id`.
+// Each rule matches column names by regexp and expands `$1`... in ParentTable and ParentColumn.
+func (s *Schema) DetectVirtualRelations(strategy string, rules []VirtualRelationRule) error {
+	switch strategy {
+	case "", "default":
+		for _, t := range s.Tables {
+			for _, c := range t.Columns {
+				if pt := s.ImpliedParentTable(c); pt != nil {
+					addVirtualRelation(s, t, c, pt, "id", DefaultVirtualRelationDef)
+				}
+			}
+		}
+	case "none":
+	default:
+		return errors.WithStack(fmt.Errorf("unsupported virtual relation strategy '%s'", strategy))
+	}
+
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Column)
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), fmt.Sprintf("invalid virtual relation rule '%s'", r.Column))
+		}
+		def := r.Def
+		if def == "" {
+			def = DefaultVirtualRelationDef
+		}
+		for _, t := range s.Tables {
+			for _, c := range t.Columns {
+				m := re.FindStringSubmatchIndex(c.Name)
+				if m == nil {
+					continue
+				}
+				ptName := string(re.ExpandString([]byte{}, r.ParentTable, c.Name, m))
+				pt, ok := s.findTable(ptName)
+				if !ok {
+					continue
+				}
+				pcName := "id"
+				if r.ParentColumn != "" {
+					pcName = string(re.ExpandString([]byte{}, r.ParentColumn, c.Name, m))
+				}
+				addVirtualRelation(s, t, c, pt, pcName, def)
+			}
+		}
+	}
+	return nil
+}
+
+// ImpliedParentTable return the parent table implied by the column name `<singular_table>_id` or `<table>_id`. nil if not found.
+func (s *Schema) ImpliedParentTable(c *Column) *Table {
+	m := reDefaultVirtualRelationColumn.FindStringSubmatch(c.Name)
+	if m == nil {
+		return nil
+	}
+	for _, name := range []string{m[1], pluralize(m[1])} {
+		if pt, ok := s.findTable(name); ok {
+			return pt
+		}
+	}
+	return nil
+}
+
+func addVirtualRelation(s *Schema, t *Table, c *Column, pt *Table, pcName string, def string) {
+	pc, ok := pt.findColumn(pcName)
+	if !ok || pc == c {
+		return
+	}
+	for _, r := range c.ParentRelations {
+		if r.ParentTable == pt {
+			return
+		}
+	}
+	relation := &Relation{
+		Table:         t,
+		Columns:       []*Column{c},
+		ParentTable:   pt,
+		ParentColumns: []*Column{pc},
+		Def:           def,
+		IsAdditional:  true,
+	}
+	s.addRelation(relation)
+}
+
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && !strings.HasSuffix(name, "ay") && !strings.HasSuffix(name, "ey") && !strings.HasSuffix(name, "oy"):
+		return fmt.Sprintf("%sies", strings.TrimSuffix(name, "y"))
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return fmt.Sprintf("%ses", name)
+	default:
+		return fmt.Sprintf("%ss", name)
+	}
+}
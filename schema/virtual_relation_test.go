@@ -0,0 +1,111 @@
+package schema
+
+import "testing"
+
+func TestDetectVirtualRelations(t *testing.T) {
+	schema := newVirtualRelationTestSchema()
+	err := schema.DetectVirtualRelations("default", []VirtualRelationRule{
+		VirtualRelationRule{
+			Column:       "^(.+)_code$",
+			ParentTable:  "${1}_masters",
+			ParentColumn: "code",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 3
+	actual := len(schema.Relations)
+	if actual != expected {
+		t.Fatalf("actual %v\nwant %v", actual, expected)
+	}
+	comments, _ := schema.FindTableByName("comments")
+	categoryCode, _ := comments.FindColumnByName("category_code")
+	if len(categoryCode.ParentRelations) != 1 {
+		t.Fatalf("actual %v\nwant %v", len(categoryCode.ParentRelations), 1)
+	}
+	expected2 := "category_masters"
+	actual2 := categoryCode.ParentRelations[0].ParentTable.Name
+	if actual2 != expected2 {
+		t.Errorf("actual %v\nwant %v", actual2, expected2)
+	}
+}
+
+func TestImpliedParentTable(t *testing.T) {
+	schema := newVirtualRelationTestSchema()
+	tests := []struct {
+		table  string
+		column string
+		want   string
+	}{
+		{"posts", "user_id", "users"},
+		{"comments", "post_id", "posts"},
+		{"comments", "unknown_id", ""},
+		{"comments", "category_code", ""},
+	}
+	for _, tt := range tests {
+		table, _ := schema.FindTableByName(tt.table)
+		column, _ := table.FindColumnByName(tt.column)
+		got := ""
+		if pt := schema.ImpliedParentTable(column); pt != nil {
+			got = pt.Name
+		}
+		if got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"user", "users"},
+		{"category", "categories"},
+		{"key", "keys"},
+		{"status", "statuses"},
+		{"box", "boxes"},
+	}
+	for _, tt := range tests {
+		if got := pluralize(tt.in); got != tt.want {
+			t.Errorf("actual %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func newVirtualRelationTestSchema() *Schema {
+	return &Schema{
+		Name: "testschema",
+		Tables: []*Table{
+			&Table{
+				Name: "users",
+				Columns: []*Column{
+					&Column{Name: "id"},
+				},
+			},
+			&Table{
+				Name: "posts",
+				Columns: []*Column{
+					&Column{Name: "id"},
+					&Column{Name: "user_id"},
+				},
+			},
+			&Table{
+				Name: "comments",
+				Columns: []*Column{
+					&Column{Name: "id"},
+					&Column{Name: "post_id"},
+					&Column{Name: "unknown_id"},
+					&Column{Name: "category_code"},
+				},
+			},
+			&Table{
+				Name: "category_masters",
+				Columns: []*Column{
+					&Column{Name: "code"},
+				},
+			},
+		},
+	}
+}
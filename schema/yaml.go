@@ -0,0 +1,52 @@
+package schema
+
+import "github.com/pkg/errors"
+
+// MarshalYAML return the YAML of the relation referring tables and columns by their names
+func (r Relation) MarshalYAML() (interface{}, error) {
+	v := namedRelation{
+		Columns:           []string{},
+		ParentColumns:     []string{},
+		Def:               r.Def,
+		IsAdditional:      r.IsAdditional,
+		Cardinality:       r.Cardinality,
+		ParentCardinality: r.ParentCardinality,
+	}
+	if r.Table != nil {
+		v.Table = r.Table.Name
+	}
+	if r.ParentTable != nil {
+		v.ParentTable = r.ParentTable.Name
+	}
+	for _, c := range r.Columns {
+		v.Columns = append(v.Columns, c.Name)
+	}
+	for _, c := range r.ParentColumns {
+		v.ParentColumns = append(v.ParentColumns, c.Name)
+	}
+	return v, nil
+}
+
+// UnmarshalYAML parse the schema YAML (output of `tbls out -t yaml`).
+// Tables and columns of relations are resolved by their names.
+func (s *Schema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v := struct {
+		Name      string          `yaml:"name"`
+		Tables    []*Table        `yaml:"tables"`
+		Relations []namedRelation `yaml:"relations"`
+		Labels    []*Label        `yaml:"labels,omitempty"`
+		Driver    string          `yaml:"driver,omitempty"`
+		Charset   string          `yaml:"charset,omitempty"`
+		Collation string          `yaml:"collation,omitempty"`
+	}{}
+	if err := unmarshal(&v); err != nil {
+		return errors.WithStack(err)
+	}
+	s.Name = v.Name
+	s.Tables = v.Tables
+	s.Labels = v.Labels
+	s.Driver = v.Driver
+	s.Charset = v.Charset
+	s.Collation = v.Collation
+	return s.resolveRelations(v.Relations)
+}
@@ -0,0 +1,149 @@
+package schema
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSchema_YAMLRoundTrip(t *testing.T) {
+	want := newRoundTripSchema()
+	b, err := yaml.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Schema{}
+	if err := yaml.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	assertSameSchema(t, got, want)
+}
+
+func TestSchema_JSONRoundTrip(t *testing.T) {
+	want := newRoundTripSchema()
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Schema{}
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	assertSameSchema(t, got, want)
+}
+
+func TestTable_YAMLRoundTrip(t *testing.T) {
+	want := newRoundTripSchema().Tables[0]
+	want.Columns = append(want.Columns, &Column{Name: "name", Type: "text", Nullable: true, Comment: "user name"})
+	b, err := yaml.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Table{}
+	if err := yaml.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	gb, _ := json.Marshal(got)
+	wb, _ := json.Marshal(want)
+	if string(gb) != string(wb) {
+		t.Errorf("actual %s\nwant %s", gb, wb)
+	}
+}
+
+func TestSchema_UnmarshalYAMLUnknownColumn(t *testing.T) {
+	in := `
+name: testschema
+tables:
+- name: users
+  columns:
+  - name: id
+relations:
+- table: users
+  columns: [account_id]
+  parent_table: users
+  parent_columns: [id]
+`
+	s := &Schema{}
+	if err := yaml.Unmarshal([]byte(in), s); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestColumn_YAMLDefault(t *testing.T) {
+	tests := []struct {
+		in   Column
+		want string
+	}{
+		{Column{Name: "a", Type: "int"}, "name: a\ntype: int\nnullable: false\ndefault: null\ncomment: \"\"\n"},
+		{Column{Name: "a", Type: "int", Default: NewDefault(sql.NullString{String: "", Valid: true})}, "name: a\ntype: int\nnullable: false\ndefault: \"\"\ncomment: \"\"\n"},
+	}
+	for _, tt := range tests {
+		b, err := yaml.Marshal(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("actual %v\nwant %v", string(b), tt.want)
+		}
+		c := Column{}
+		if err := yaml.Unmarshal(b, &c); err != nil {
+			t.Fatal(err)
+		}
+		if (c.Default == nil) != (tt.in.Default == nil) || c.DefaultValue() != tt.in.DefaultValue() {
+			t.Errorf("actual %v\nwant %v", c.Default, tt.in.Default)
+		}
+	}
+}
+
+func assertSameSchema(t *testing.T, got, want *Schema) {
+	t.Helper()
+	gb, _ := json.Marshal(got)
+	wb, _ := json.Marshal(want)
+	if string(gb) != string(wb) {
+		t.Errorf("actual %s\nwant %s", gb, wb)
+	}
+	for _, r := range got.Relations {
+		for _, c := range r.Columns {
+			if len(c.ParentRelations) != 1 || c.ParentRelations[0] != r {
+				t.Errorf("%s: parent relations are not rebuilt", c.Name)
+			}
+		}
+		for _, c := range r.ParentColumns {
+			if len(c.ChildRelations) != 1 || c.ChildRelations[0] != r {
+				t.Errorf("%s: child relations are not rebuilt", c.Name)
+			}
+		}
+		if r.Table != got.Tables[1] || r.ParentTable != got.Tables[0] {
+			t.Errorf("tables of the relation are not resolved: %s -> %s", r.Table.Name, r.ParentTable.Name)
+		}
+	}
+}
+
+func newRoundTripSchema() *Schema {
+	s := newTestSchema()
+	// analyzed schemas have empty slices instead of nil, which YAML can not tell apart
+	for _, t := range s.Tables {
+		if t.Columns == nil {
+			t.Columns = []*Column{}
+		}
+		t.Indexes = []*Index{}
+		t.Constraints = []*Constraint{}
+		t.Triggers = []*Trigger{}
+	}
+	s.Driver = "postgres"
+	s.Charset = "UTF8"
+	s.Labels = []*Label{{Name: "core", Color: "#ff0000"}}
+	s.Tables[0].Comment = "users\ntable"
+	s.Tables[0].Labels = []*Label{{Name: "core"}}
+	s.Tables[0].Columns[0].Default = NewDefault(sql.NullString{String: "nextval('users_id_seq'::regclass)", Valid: true})
+	s.Tables[1].Columns[0].Sensitivity = &Sensitivity{Label: DefaultSensitivityLabel, Detectors: []string{"email"}}
+	s.Tables[0].Indexes = []*Index{{Name: "users_pkey", Def: "CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)", Columns: []string{"id"}}}
+	s.Tables[0].Constraints = []*Constraint{{Name: "users_pkey", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}}
+	s.Tables[0].Triggers = []*Trigger{{Name: "update_users_updated", Def: "CREATE TRIGGER update_users_updated ..."}}
+	s.Relations[0].Def = "FOREIGN KEY (user_id) REFERENCES users(id)"
+	s.Relations[0].Cardinality = ZeroOrMore
+	s.Relations[0].ParentCardinality = ExactlyOne
+	return s
+}
@@ -0,0 +1,104 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type loggerKey struct{}
+
+// Logger writes debug logs (queries, connections) as text lines or JSON lines
+type Logger struct {
+	mu   sync.Mutex
+	w    io.Writer
+	json bool
+	now  func() time.Time
+}
+
+// NewLogger return a new Logger writing to w in the format (text or json)
+func NewLogger(w io.Writer, format string) (*Logger, error) {
+	switch format {
+	case "", "text":
+		return &Logger{w: w, now: time.Now}, nil
+	case "json":
+		return &Logger{w: w, json: true, now: time.Now}, nil
+	default:
+		return nil, fmt.Errorf("unsupported log format '%s' (text or json)", format)
+	}
+}
+
+// WithLogger return the context carrying the logger
+func WithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext return the logger of the context. It is nil when the context has no logger.
+func LoggerFromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerKey{}).(*Logger)
+	return l
+}
+
+// Debug write the debug log of the message with pairs of key and value. It writes nothing when the context has no logger.
+func Debug(ctx context.Context, msg string, kv ...interface{}) {
+	if l := LoggerFromContext(ctx); l != nil {
+		l.Log(msg, kv...)
+	}
+}
+
+// Log write the log of the message with pairs of key and value
+func (l *Logger) Log(msg string, kv ...interface{}) {
+	buf := new(bytes.Buffer)
+	ts := l.now().UTC().Format(time.RFC3339Nano)
+	if l.json {
+		buf.WriteString(`{"time":`)
+		writeJSON(buf, ts)
+		buf.WriteString(`,"msg":`)
+		writeJSON(buf, msg)
+		for i := 0; i+1 < len(kv); i += 2 {
+			buf.WriteString(",")
+			writeJSON(buf, fmt.Sprintf("%v", kv[i]))
+			buf.WriteString(":")
+			writeJSON(buf, logValue(kv[i+1]))
+		}
+		buf.WriteString("}\n")
+	} else {
+		fmt.Fprintf(buf, "%s %s", ts, msg)
+		for i := 0; i+1 < len(kv); i += 2 {
+			v := fmt.Sprintf("%v", logValue(kv[i+1]))
+			if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+				v = fmt.Sprintf("%q", v)
+			}
+			fmt.Fprintf(buf, " %v=%s", kv[i], v)
+		}
+		buf.WriteString("\n")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(buf.Bytes())
+}
+
+// logValue return the value to log. Durations are in milliseconds, and errors are messages.
+func logValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case time.Duration:
+		return float64(vv.Microseconds()) / 1000
+	case error:
+		return vv.Error()
+	default:
+		return v
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%v", v))
+	}
+	buf.Write(b)
+}
@@ -0,0 +1,36 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"text", "2018-06-01T00:00:00Z query query=\"SELECT 1\" duration_ms=1.5 rows=1 error=denied\n"},
+		{"json", `{"time":"2018-06-01T00:00:00Z","msg":"query","query":"SELECT 1","duration_ms":1.5,"rows":1,"error":"denied"}` + "\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		l, err := NewLogger(buf, tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.now = func() time.Time { return time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC) }
+		ctx := WithLogger(context.Background(), l)
+		Debug(ctx, "query", "query", "SELECT 1", "duration_ms", 1500*time.Microsecond, "rows", 1, "error", errors.New("denied"))
+		if buf.String() != tt.want {
+			t.Errorf("actual %v\nwant %v", buf.String(), tt.want)
+		}
+	}
+	if _, err := NewLogger(new(bytes.Buffer), "xml"); err == nil {
+		t.Errorf("actual %v\nwant %v", err, "error")
+	}
+	Debug(context.Background(), "query")
+}
@@ -0,0 +1,93 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type progressKey struct{}
+
+// Progress reports the progress of long phases (introspect, profile, er, ...) as lines with ETA.
+// A line is written at most once per interval, so short phases report nothing.
+type Progress struct {
+	mu       sync.Mutex
+	w        io.Writer
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewProgress return a new Progress writing to w at most once per interval
+func NewProgress(w io.Writer, interval time.Duration) *Progress {
+	return &Progress{w: w, interval: interval, now: time.Now}
+}
+
+// WithProgress return the context carrying the progress
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ProgressFromContext return the progress of the context. It is nil when the context has no progress.
+func ProgressFromContext(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
+
+// Bar is the progress of the phase of total steps. Methods of nil Bar do nothing.
+type Bar struct {
+	p        *Progress
+	name     string
+	total    int
+	done     int
+	start    time.Time
+	last     time.Time
+	reported bool
+}
+
+// StartBar start the progress of the phase of total steps. It return nil when the context has no progress.
+func StartBar(ctx context.Context, name string, total int) *Bar {
+	p := ProgressFromContext(ctx)
+	if p == nil || total <= 0 {
+		return nil
+	}
+	now := p.now()
+	return &Bar{p: p, name: name, total: total, start: now, last: now}
+}
+
+// Add add n done steps, and report the progress when the interval passed since the last report
+func (b *Bar) Add(n int) {
+	if b == nil {
+		return
+	}
+	b.p.mu.Lock()
+	defer b.p.mu.Unlock()
+	b.done += n
+	now := b.p.now()
+	if now.Sub(b.last) < b.p.interval || b.done >= b.total {
+		return
+	}
+	b.last = now
+	b.reported = true
+	elapsed := now.Sub(b.start)
+	eta := time.Duration(float64(elapsed) / float64(b.done) * float64(b.total-b.done))
+	if b.done == 0 {
+		fmt.Fprintf(b.p.w, "%s %d/%d (0%%)\n", b.name, b.done, b.total)
+		return
+	}
+	fmt.Fprintf(b.p.w, "%s %d/%d (%d%%) ETA %s\n", b.name, b.done, b.total, b.done*100/b.total, eta.Round(time.Second))
+}
+
+// Done end the progress. The completion is reported when the progress has been reported.
+func (b *Bar) Done() {
+	if b == nil {
+		return
+	}
+	b.p.mu.Lock()
+	defer b.p.mu.Unlock()
+	if !b.reported {
+		return
+	}
+	fmt.Fprintf(b.p.w, "%s %d/%d (%d%%) done in %s\n", b.name, b.done, b.total, b.done*100/b.total, b.p.now().Sub(b.start).Round(time.Second))
+}
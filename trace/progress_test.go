@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBar(t *testing.T) {
+	buf := new(bytes.Buffer)
+	p := NewProgress(buf, 10*time.Second)
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	ctx := WithProgress(context.Background(), p)
+
+	bar := StartBar(ctx, "er", 4)
+	now = now.Add(5 * time.Second)
+	bar.Add(1)
+	now = now.Add(5 * time.Second)
+	bar.Add(1)
+	now = now.Add(5 * time.Second)
+	bar.Add(1)
+	now = now.Add(5 * time.Second)
+	bar.Add(1)
+	bar.Done()
+	want := "er 2/4 (50%) ETA 10s\ner 4/4 (100%) done in 20s\n"
+	if buf.String() != want {
+		t.Errorf("actual %v\nwant %v", buf.String(), want)
+	}
+
+	buf.Reset()
+	short := StartBar(ctx, "introspect", 5)
+	short.Add(5)
+	short.Done()
+	if buf.String() != "" {
+		t.Errorf("actual %v\nwant %v", buf.String(), "")
+	}
+}
+
+func TestBarWithoutProgress(t *testing.T) {
+	bar := StartBar(context.Background(), "er", 3)
+	if bar != nil {
+		t.Errorf("actual %v\nwant %v", bar, nil)
+	}
+	bar.Add(1)
+	bar.Done()
+}
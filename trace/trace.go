@@ -0,0 +1,89 @@
+// Package trace records the time spent in each phase (connect, introspect, render, ...) of tbls, so that slow schemas can be reported with actionable data
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+type recorderKey struct{}
+
+// Phase is the total time spent in the phase, and how many times it ran
+type Phase struct {
+	Name     string
+	Duration time.Duration
+	Count    int
+}
+
+// Recorder records the time spent in each phase
+type Recorder struct {
+	mu     sync.Mutex
+	phases []*Phase
+}
+
+// New return a new Recorder
+func New() *Recorder {
+	return &Recorder{}
+}
+
+// WithRecorder return the context carrying the recorder
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// FromContext return the recorder of the context. It is nil when the context has no recorder.
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Start start the phase and return the function to end it. It records nothing when the context has no recorder.
+func Start(ctx context.Context, name string) func() {
+	r := FromContext(ctx)
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		r.Add(name, time.Since(start))
+	}
+}
+
+// Add add the time spent in the phase
+func (r *Recorder) Add(name string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.phases {
+		if p.Name == name {
+			p.Duration += d
+			p.Count++
+			return
+		}
+	}
+	r.phases = append(r.phases, &Phase{Name: name, Duration: d, Count: 1})
+}
+
+// Phases return recorded phases in the order they first started
+func (r *Recorder) Phases() []Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	phases := []Phase{}
+	for _, p := range r.phases {
+		phases = append(phases, *p)
+	}
+	return phases
+}
+
+// Write write the time spent in each phase and the total time
+func (r *Recorder) Write(wr io.Writer, total time.Duration) error {
+	w := tabwriter.NewWriter(wr, 0, 0, 2, ' ', 0)
+	for _, p := range r.Phases() {
+		fmt.Fprintf(w, "%s\t%s\t(%d)\n", p.Name, p.Duration.Round(time.Millisecond), p.Count)
+	}
+	fmt.Fprintf(w, "total\t%s\n", total.Round(time.Millisecond))
+	return w.Flush()
+}
@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	r := New()
+	ctx := WithRecorder(context.Background(), r)
+	for i := 0; i < 3; i++ {
+		end := Start(ctx, "render")
+		end()
+	}
+	Start(ctx, "connect")()
+	r.Add("connect", time.Second)
+
+	phases := r.Phases()
+	if len(phases) != 2 {
+		t.Fatalf("actual %v\nwant %v", len(phases), 2)
+	}
+	if phases[0].Name != "render" || phases[0].Count != 3 {
+		t.Errorf("actual %v, %v\nwant %v, %v", phases[0].Name, phases[0].Count, "render", 3)
+	}
+	if phases[1].Name != "connect" || phases[1].Count != 2 || phases[1].Duration < time.Second {
+		t.Errorf("actual %v, %v, %v\nwant %v, %v, %v", phases[1].Name, phases[1].Count, phases[1].Duration, "connect", 2, ">= 1s")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := r.Write(buf, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"render   0s  (3)\n", "connect  1s  (2)\n", "total    2s"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("actual %v\nwant %v", buf.String(), want)
+		}
+	}
+}
+
+func TestStartWithoutRecorder(t *testing.T) {
+	Start(context.Background(), "connect")()
+	if r := FromContext(context.Background()); r != nil {
+		t.Errorf("actual %v\nwant %v", r, nil)
+	}
+}